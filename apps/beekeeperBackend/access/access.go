@@ -0,0 +1,267 @@
+package access
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"beekeeper-api/auth"
+	"beekeeper-api/models"
+)
+
+// Permission is an action on a kind of resource, e.g. "hives:delete"
+type Permission string
+
+// Permissions checked by the API
+const (
+	HivesRead      Permission = "hives:read"
+	HivesWrite     Permission = "hives:write"
+	HivesDelete    Permission = "hives:delete"
+	LogsRead       Permission = "logs:read"
+	LogsWrite      Permission = "logs:write"
+	LogsDelete     Permission = "logs:delete"
+	TasksRead      Permission = "tasks:read"
+	TasksWrite     Permission = "tasks:write"
+	TasksDelete    Permission = "tasks:delete"
+	TeamManage     Permission = "team:manage"
+	UsersManage    Permission = "users:manage"
+	BackupsManage  Permission = "backups:manage"
+	WebhooksManage Permission = "webhooks:manage"
+)
+
+// rank orders the roles, most privileged first
+var rank = []string{models.RoleOwner, models.RoleEditor, models.RoleViewer, models.RoleInspector, models.RoleNone}
+
+// grants lists the permissions of each role. Inspectors can read like viewers
+// but are only ever invited to a single apiary for a limited time; RoleNone
+// grants nothing.
+var grants = map[string][]Permission{
+	models.RoleOwner: {
+		HivesRead, HivesWrite, HivesDelete,
+		LogsRead, LogsWrite, LogsDelete,
+		TasksRead, TasksWrite, TasksDelete,
+		TeamManage, UsersManage, BackupsManage, WebhooksManage,
+	},
+	models.RoleEditor: {
+		HivesRead, HivesWrite,
+		LogsRead, LogsWrite, LogsDelete,
+		TasksRead, TasksWrite, TasksDelete,
+	},
+	models.RoleViewer:    {HivesRead, LogsRead, TasksRead},
+	models.RoleInspector: {HivesRead, LogsRead, TasksRead},
+}
+
+// Can reports whether a role grants a permission.
+func Can(role string, perm Permission) bool {
+	return slices.Contains(grants[role], perm)
+}
+
+// RolesWith lists the roles granting a permission.
+func RolesWith(perm Permission) []string {
+	var roles []string
+	for role, perms := range grants {
+		if slices.Contains(perms, perm) {
+			roles = append(roles, role)
+		}
+	}
+	slices.Sort(roles)
+	return roles
+}
+
+// activeMemberships selects the team memberships that have not expired.
+func activeMemberships(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.TeamMember{}).Where("expires_at IS NULL OR expires_at > ?", time.Now())
+}
+
+// ApiaryRole returns the role a user has in an apiary. Global owners
+// administer every apiary. Elsewhere an unexpired membership adds to the
+// global role, the more privileged of the two applies, so inviting e.g. an
+// inspector locks nobody out. Members only apiaries ignore global roles other
+// than owner.
+func ApiaryRole(db *gorm.DB, user *models.User, apiaryID uint) (string, error) {
+	if user.Role == models.RoleOwner {
+		return models.RoleOwner, nil
+	}
+	var apiary models.Apiary
+	if err := db.Select("id", "members_only").Where("id = ?", apiaryID).Limit(1).Find(&apiary).Error; err != nil {
+		return "", err
+	}
+	role := user.Role
+	if apiary.MembersOnly {
+		role = models.RoleNone
+	}
+	var members []models.TeamMember
+	if err := activeMemberships(db).Where("apiary_id = ? AND user_id = ?", apiaryID, user.ID).Find(&members).Error; err != nil {
+		return "", err
+	}
+	for _, member := range members {
+		role = higher(role, member.Role)
+	}
+	return role, nil
+}
+
+// higher returns the more privileged of two roles.
+func higher(a, b string) string {
+	if slices.Index(rank, b) < slices.Index(rank, a) {
+		return b
+	}
+	return a
+}
+
+// HiveRole returns the role a user has for a hive: the apiary role for hives
+// in an apiary, the global role otherwise. Hives that do not exist yet are in
+// no apiary.
+func HiveRole(db *gorm.DB, user *models.User, hiveName int) (string, error) {
+	var hive models.Hive
+	if err := db.Where("hive_name = ?", hiveName).Limit(1).Find(&hive).Error; err != nil {
+		return "", err
+	}
+	if hive.ApiaryID == nil {
+		return user.Role, nil
+	}
+	return ApiaryRole(db, user, *hive.ApiaryID)
+}
+
+// Hives returns a subquery selecting the names of the hives a user holds a
+// permission for, following the same rules as HiveRole.
+func Hives(db *gorm.DB, userID uint, perm Permission) *gorm.DB {
+	roles := RolesWith(perm)
+	owner := db.Model(&models.User{}).Select("id").Where("role = ?", models.RoleOwner)
+	global := db.Model(&models.User{}).Select("id").Where("role IN ?", roles)
+	memberships := activeMemberships(db).Select("apiary_id").Where("user_id = ? AND role IN ?", userID, roles)
+	membersOnly := db.Model(&models.Apiary{}).Select("id").Where("members_only = ?", true)
+	return db.Model(&models.Hive{}).
+		Select("hive_name").
+		Where("? IN (?) OR apiary_id IN (?) OR ((apiary_id IS NULL OR apiary_id NOT IN (?)) AND ? IN (?))",
+			userID, owner, memberships, membersOnly, userID, global)
+}
+
+// Apiaries returns a subquery selecting the IDs of the apiaries a user holds
+// a permission in, following the same rules as ApiaryRole.
+func Apiaries(db *gorm.DB, userID uint, perm Permission) *gorm.DB {
+	roles := RolesWith(perm)
+	owner := db.Model(&models.User{}).Select("id").Where("role = ?", models.RoleOwner)
+	global := db.Model(&models.User{}).Select("id").Where("role IN ?", roles)
+	memberships := activeMemberships(db).Select("apiary_id").Where("user_id = ? AND role IN ?", userID, roles)
+	return db.Model(&models.Apiary{}).
+		Select("id").
+		Where("? IN (?) OR id IN (?) OR (members_only = ? AND ? IN (?))",
+			userID, owner, memberships, false, userID, global)
+}
+
+// ApiaryUsers returns a subquery selecting the IDs of the users holding a
+// permission in an apiary, following the same rules as ApiaryRole.
+func ApiaryUsers(db *gorm.DB, apiaryID uint, perm Permission) *gorm.DB {
+	roles := RolesWith(perm)
+	members := activeMemberships(db).Select("user_id").Where("apiary_id = ? AND role IN ?", apiaryID, roles)
+	global := db.Model(&models.User{}).Select("id").Where("role IN ?", roles)
+	open := db.Model(&models.Apiary{}).Select("id").Where("id = ? AND members_only = ?", apiaryID, false)
+	return db.Model(&models.User{}).
+		Select("id").
+		Where("role = ? OR id IN (?) OR (id IN (?) AND EXISTS (?))", models.RoleOwner, members, global, open)
+}
+
+// Users returns a subquery selecting the IDs of the users a user may see:
+// everyone for those who manage users, otherwise themselves and the members
+// of the apiaries they are a member of.
+func Users(db *gorm.DB, user *models.User) *gorm.DB {
+	if Can(user.Role, UsersManage) {
+		return db.Model(&models.User{}).Select("id")
+	}
+	shared := activeMemberships(db).Select("apiary_id").Where("user_id = ?", user.ID)
+	members := activeMemberships(db).Select("user_id").Where("apiary_id IN (?)", shared)
+	return db.Model(&models.User{}).Select("id").Where("id = ? OR id IN (?)", user.ID, members)
+}
+
+// Visible restricts a query on rows belonging to hives, e.g. logs by their
+// "hive_id", to the hives the current user holds a permission for. Anonymous
+// requests are not restricted.
+func Visible(c *gin.Context, db *gorm.DB, query *gorm.DB, perm Permission, column string) *gorm.DB {
+	user, ok := auth.CurrentUser(c)
+	if !ok {
+		return query
+	}
+	return query.Where(column+" IN (?)", Hives(db, user.ID, perm))
+}
+
+// Guard declares the permissions routes require.
+type Guard struct {
+	db *gorm.DB
+}
+
+// New creates a guard looking up roles in the database.
+func New(db *gorm.DB) *Guard {
+	return &Guard{db: db}
+}
+
+// Require returns a middleware letting a request through only if the current
+// user holds the permission for every target. Anonymous requests are let
+// through only while they may use the permission, see anonymous.
+func (g *Guard) Require(perm Permission, targets ...Target) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := auth.CurrentUser(c)
+		if !ok {
+			allowed, err := g.anonymous(perm)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+				return
+			}
+			if !allowed {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+				return
+			}
+			c.Next()
+			return
+		}
+		for _, target := range targets {
+			roles, err := target(c, g.db, user)
+			var missing NotFoundError
+			if errors.As(err, &missing) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": missing.Error()})
+				return
+			}
+			if errors.Is(err, ErrInvalidBody) {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+				return
+			}
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+				return
+			}
+			if roles != nil && !slices.ContainsFunc(roles, func(role string) bool { return Can(role, perm) }) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Permission denied: " + string(perm) + " required"})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// administration lists the permissions anonymous requests never hold, as
+// they expose other users' data such as the token hashes in backups, or send
+// every event elsewhere.
+var administration = []Permission{TeamManage, BackupsManage, WebhooksManage}
+
+// anonymous reports whether a request without a token may use a permission.
+// That is only the case while there are no users, as on an install that
+// predates them, where the first user has yet to be created. Once a user
+// exists, roles apply and everyone needs a token; otherwise a viewer could
+// drop theirs to delete hives. Guards without a database, over the
+// in-memory services, have no users.
+func (g *Guard) anonymous(perm Permission) (bool, error) {
+	if slices.Contains(administration, perm) {
+		return false, nil
+	}
+	if g.db == nil {
+		return true, nil
+	}
+	var users int64
+	if err := g.db.Model(&models.User{}).Count(&users).Error; err != nil {
+		return false, err
+	}
+	return users == 0, nil
+}
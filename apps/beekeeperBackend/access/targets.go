@@ -0,0 +1,187 @@
+package access
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+
+	"beekeeper-api/models"
+)
+
+// Target resolves the roles the current user has for what a request is
+// about. A target returning no roles at all, e.g. because the resource does
+// not exist, does not restrict the request and leaves it to the handler.
+type Target func(c *gin.Context, db *gorm.DB, user *models.User) ([]string, error)
+
+// ErrInvalidBody is returned by targets that cannot read what a request is
+// about from its body. Such requests are rejected, as the handler could bind
+// a value that was never checked.
+var ErrInvalidBody = errors.New("invalid request body")
+
+// NotFoundError is returned by targets when the entry in a path parameter
+// does not exist. The guard answers 404 with the message before any body is
+// decoded, as the handler would.
+type NotFoundError string
+
+func (e NotFoundError) Error() string { return string(e) }
+
+// Global checks the user's global role, for actions on the whole instance.
+func Global(c *gin.Context, db *gorm.DB, user *models.User) ([]string, error) {
+	return []string{user.Role}, nil
+}
+
+// Anywhere checks the global role and every unexpired membership, for lists
+// that are then narrowed down with Visible.
+func Anywhere(c *gin.Context, db *gorm.DB, user *models.User) ([]string, error) {
+	roles := []string{user.Role}
+	var memberships []string
+	if err := activeMemberships(db).Where("user_id = ?", user.ID).Pluck("role", &memberships).Error; err != nil {
+		return nil, err
+	}
+	return append(roles, memberships...), nil
+}
+
+// Self checks the global role, unless the user ID in the path parameter is
+// the user's own: everyone has full control over their own account.
+func Self(param string) Target {
+	return func(c *gin.Context, db *gorm.DB, user *models.User) ([]string, error) {
+		if c.Param(param) == strconv.FormatUint(uint64(user.ID), 10) {
+			return []string{models.RoleOwner}, nil
+		}
+		return []string{user.Role}, nil
+	}
+}
+
+// Hive checks the role for the hive named in a path parameter.
+func Hive(param string) Target {
+	return func(c *gin.Context, db *gorm.DB, user *models.User) ([]string, error) {
+		hiveName, err := strconv.Atoi(c.Param(param))
+		if err != nil {
+			return nil, nil
+		}
+		return hiveRoles(db, user, hiveName)
+	}
+}
+
+// Log checks the role for the hive of the log entry in a path parameter.
+func Log(param string) Target {
+	return entry(param, &models.Log{}, "Log not found")
+}
+
+// Task checks the role for the hive of the task in a path parameter.
+func Task(param string) Target {
+	return entry(param, &models.Task{}, "Task not found")
+}
+
+// Harvest checks the role for the hive of the harvest in a path parameter.
+func Harvest(param string) Target {
+	return entry(param, &models.Harvest{}, "Harvest not found")
+}
+
+// Incident checks the role for the hive of the incident in a path parameter.
+func Incident(param string) Target {
+	return entry(param, &models.Incident{}, "Incident not found")
+}
+
+func entry(param string, model any, missing NotFoundError) Target {
+	return func(c *gin.Context, db *gorm.DB, user *models.User) ([]string, error) {
+		var hiveIDs []int
+		if err := db.Model(model).Where("id = ?", c.Param(param)).Pluck("hive_id", &hiveIDs).Error; err != nil {
+			return nil, err
+		}
+		if len(hiveIDs) == 0 {
+			return nil, missing
+		}
+		return hiveRoles(db, user, hiveIDs[0])
+	}
+}
+
+// Apiary checks the role in the apiary in a path parameter.
+func Apiary(param string) Target {
+	return func(c *gin.Context, db *gorm.DB, user *models.User) ([]string, error) {
+		apiaryID, err := strconv.ParseUint(c.Param(param), 10, 64)
+		if err != nil {
+			return nil, nil
+		}
+		return apiaryRoles(db, user, uint(apiaryID))
+	}
+}
+
+// HiveInBody checks the role for the hive named in a field of the JSON body,
+// if it is set.
+func HiveInBody(field string) Target {
+	return func(c *gin.Context, db *gorm.DB, user *models.User) ([]string, error) {
+		hiveName, err := bodyField(c, field)
+		if err != nil || hiveName == nil || *hiveName == 0 {
+			return nil, err
+		}
+		return hiveRoles(db, user, int(*hiveName))
+	}
+}
+
+// ApiaryInBody checks the role in the apiary given in a field of the JSON
+// body, if it is set.
+func ApiaryInBody(field string) Target {
+	return func(c *gin.Context, db *gorm.DB, user *models.User) ([]string, error) {
+		apiaryID, err := bodyField(c, field)
+		if err != nil || apiaryID == nil || *apiaryID == 0 {
+			return nil, err
+		}
+		if *apiaryID < 0 {
+			return nil, ErrInvalidBody
+		}
+		return apiaryRoles(db, user, uint(*apiaryID))
+	}
+}
+
+func hiveRoles(db *gorm.DB, user *models.User, hiveName int) ([]string, error) {
+	role, err := HiveRole(db, user, hiveName)
+	if err != nil {
+		return nil, err
+	}
+	return []string{role}, nil
+}
+
+func apiaryRoles(db *gorm.DB, user *models.User, apiaryID uint) ([]string, error) {
+	role, err := ApiaryRole(db, user, apiaryID)
+	if err != nil {
+		return nil, err
+	}
+	return []string{role}, nil
+}
+
+// bodyField reads a numeric field of the JSON body and puts the body back for
+// the handler. The field is decoded by gin's JSON binding into a struct with
+// the same tag as the handler's input, so keys match case-insensitively and
+// the last of duplicate keys wins exactly as they do for the handler. Missing
+// and null fields read as nil; bodies the field cannot be decoded from are
+// ErrInvalidBody.
+func bodyField(c *gin.Context, field string) (*int64, error) {
+	if c.Request.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+
+	input := reflect.New(reflect.StructOf([]reflect.StructField{{
+		Name: "Value",
+		Type: reflect.TypeFor[*int64](),
+		Tag:  reflect.StructTag(`json:"` + field + `"`),
+	}}))
+	if binding.JSON.BindBody(body, input.Interface()) != nil {
+		return nil, ErrInvalidBody
+	}
+	return input.Elem().Field(0).Interface().(*int64), nil
+}
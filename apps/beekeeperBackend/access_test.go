@@ -0,0 +1,415 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"beekeeper-api/features/apiaries"
+	"beekeeper-api/features/dashboard"
+	"beekeeper-api/features/export"
+	"beekeeper-api/features/importer"
+	"beekeeper-api/features/tags"
+	"beekeeper-api/features/team"
+	"beekeeper-api/models"
+)
+
+// TestAnonymousAccess follows an install from before users existed through
+// its first user, after which requests without a token are rejected.
+func TestAnonymousAccess(t *testing.T) {
+	s := newTestServer(t)
+
+	s.do(http.MethodPost, "/api/hives", map[string]any{"hiveName": 1}, http.StatusCreated, nil)
+	s.do(http.MethodDelete, "/api/hives/1", nil, http.StatusNoContent, nil)
+	// Backups, teams and webhooks are never open, not even without users
+	s.do(http.MethodGet, "/api/admin/backups", nil, http.StatusUnauthorized, nil)
+	s.do(http.MethodPost, "/api/admin/backups", nil, http.StatusUnauthorized, nil)
+	s.do(http.MethodGet, "/api/webhooks", nil, http.StatusUnauthorized, nil)
+
+	var created struct {
+		User models.User `json:"user"`
+	}
+	s.do(http.MethodPost, "/api/users", map[string]any{"name": "Ana", "email": "ana@example.com"}, http.StatusCreated, &created)
+	if created.User.Role != models.RoleOwner {
+		t.Errorf("got role %q for the first user, want %q", created.User.Role, models.RoleOwner)
+	}
+
+	s.do(http.MethodPost, "/api/users", map[string]any{"name": "Eve", "email": "eve@example.com", "role": "owner"}, http.StatusUnauthorized, nil)
+	s.do(http.MethodGet, "/api/hives", nil, http.StatusUnauthorized, nil)
+	s.do(http.MethodPost, "/api/logs", map[string]any{"hiveID": 2, "content": "Inspected"}, http.StatusUnauthorized, nil)
+
+	s.signIn(models.RoleOwner)
+	s.do(http.MethodGet, "/api/admin/backups", nil, http.StatusOK, nil)
+	s.do(http.MethodPost, "/api/logs", map[string]any{"hiveID": 2, "content": "Inspected"}, http.StatusCreated, nil)
+}
+
+// TestFeatureAccess checks the routes beyond hives, logs and tasks with hive 1
+// in a members only apiary with a team and hive 2 outside of apiaries,
+// harvested once each. The global editor is no member of the team.
+func TestFeatureAccess(t *testing.T) {
+	s := newTestServer(t)
+	s.signIn(models.RoleOwner)
+	apiary := s.createMembersOnlyApiary()
+	s.do(http.MethodPost, "/api/hives", map[string]any{"hiveName": 1, "apiaryID": apiary}, http.StatusCreated, nil)
+	var harvested [2]models.Harvest
+	for i := range harvested {
+		s.do(http.MethodPost, "/api/harvests", map[string]any{"hiveID": i + 1, "weightKg": 10}, http.StatusCreated, &harvested[i])
+	}
+	member, _ := s.user(models.RoleNone)
+	if err := s.db.Create(&models.TeamMember{ApiaryID: apiary, UserID: member.ID, Role: models.RoleEditor}).Error; err != nil {
+		t.Fatal(err)
+	}
+	_, viewer := s.user(models.RoleViewer)
+	_, editor := s.user(models.RoleEditor)
+
+	requests := []struct {
+		name, method, path string
+		body               any
+		token              string
+		status             int
+	}{
+		{"anonymous harvest", http.MethodPost, "/api/harvests", map[string]any{"hiveID": 2, "weightKg": 5}, "", http.StatusUnauthorized},
+		{"anonymous incidents", http.MethodGet, "/api/incidents", nil, "", http.StatusUnauthorized},
+		{"anonymous import", http.MethodPost, "/api/import", map[string]any{}, "", http.StatusUnauthorized},
+		{"viewer harvest", http.MethodPost, "/api/harvests", map[string]any{"hiveID": 2, "weightKg": 5}, viewer, http.StatusForbidden},
+		{"viewer deletes harvest", http.MethodDelete, "/api/harvests/2", nil, viewer, http.StatusForbidden},
+		{"viewer apiary", http.MethodPost, "/api/apiaries", map[string]any{"name": "Meadow", "latitude": 48, "longitude": 11}, viewer, http.StatusForbidden},
+		{"viewer incident", http.MethodPost, "/api/incidents", map[string]any{"hiveID": 2, "disease": "afb"}, viewer, http.StatusForbidden},
+		{"viewer import", http.MethodPost, "/api/import", map[string]any{}, viewer, http.StatusForbidden},
+		{"editor webhooks", http.MethodGet, "/api/webhooks", nil, editor, http.StatusForbidden},
+		{"editor harvest of another team", http.MethodGet, "/api/harvests/1", nil, editor, http.StatusForbidden},
+		{"editor updates apiary of another team", http.MethodPatch, "/api/apiaries/1", map[string]any{"name": "Meadow"}, editor, http.StatusForbidden},
+		{"editor moves into another team", http.MethodPost, "/api/equipment-moves", map[string]any{"fromHiveID": 2, "toHiveID": 1, "equipment": "super"}, editor, http.StatusForbidden},
+		{"editor moves to storage", http.MethodPost, "/api/equipment-moves", map[string]any{"fromHiveID": 2, "equipment": "super"}, editor, http.StatusCreated},
+	}
+	for _, r := range requests {
+		t.Run(r.name, func(t *testing.T) {
+			if rec := s.request(r.method, r.path, r.body, r.token); rec.Code != r.status {
+				t.Errorf("got status %d, want %d: %s", rec.Code, r.status, rec.Body)
+			}
+		})
+	}
+
+	// Lists only show what the editor can read
+	s.token = editor
+	var listed []models.Harvest
+	s.do(http.MethodGet, "/api/harvests", nil, http.StatusOK, &listed)
+	if len(listed) != 1 || listed[0].ID != harvested[1].ID {
+		t.Errorf("got harvests %+v, want only the one of hive 2", listed)
+	}
+
+	// Imports report the rows of hives the editor cannot write
+	export := map[string]any{
+		"logs": []map[string]any{
+			{"hive_id": 2, "content": "Inspected"},
+			{"hive_id": 1, "content": "Inspected"},
+		},
+	}
+	var report importer.Report
+	s.do(http.MethodPost, "/api/import", export, http.StatusUnprocessableEntity, &report)
+	want := []importer.RowError{{Entity: "log", Row: 2, Message: "no permission for this hive"}}
+	if !slices.Equal(report.Errors, want) {
+		t.Errorf("got errors %+v, want %+v", report.Errors, want)
+	}
+}
+
+// TestReadAccess checks the reports, exports and overviews across all hives
+// with hive 1 in a members only apiary with a team and hive 2 outside of
+// apiaries. The global viewer is no member of the team and only sees hive 2.
+func TestReadAccess(t *testing.T) {
+	s := newTestServer(t)
+	s.signIn(models.RoleOwner)
+	apiary := s.createMembersOnlyApiary()
+	s.do(http.MethodPost, "/api/hives", map[string]any{"hiveName": 1, "apiaryID": apiary}, http.StatusCreated, nil)
+	s.do(http.MethodPost, "/api/logs", map[string]any{"hiveID": 1, "content": "Chalkbrood #team-only"}, http.StatusCreated, nil)
+	s.do(http.MethodPost, "/api/tasks", map[string]any{"hiveID": 1, "content": "Burn frames"}, http.StatusCreated, nil)
+	s.do(http.MethodPost, "/api/logs", map[string]any{"hiveID": 2, "content": "Calm #public"}, http.StatusCreated, nil)
+	s.do(http.MethodPost, "/api/tasks", map[string]any{"hiveID": 2, "content": "Add a super"}, http.StatusCreated, nil)
+	member, _ := s.user(models.RoleNone)
+	if err := s.db.Create(&models.TeamMember{ApiaryID: apiary, UserID: member.ID, Role: models.RoleEditor}).Error; err != nil {
+		t.Fatal(err)
+	}
+	owner := s.token
+	_, viewer := s.user(models.RoleViewer)
+
+	paths := []string{
+		"/api/export",
+		"/api/hives/2/report.pdf",
+		"/api/reports/apiary.pdf",
+		"/api/dashboard",
+		"/api/tags",
+		"/api/apiaries",
+		"/api/apiaries/nearby?lat=48.4&lon=11.7",
+		"/api/apiaries.geojson",
+		"/api/events",
+	}
+	for _, path := range paths {
+		t.Run("anonymous "+path, func(t *testing.T) {
+			if rec := s.request(http.MethodGet, path, nil, ""); rec.Code != http.StatusUnauthorized {
+				t.Errorf("got status %d, want %d: %s", rec.Code, http.StatusUnauthorized, rec.Body)
+			}
+		})
+	}
+
+	s.token = viewer
+	t.Run("export", func(t *testing.T) {
+		var document export.Document
+		s.do(http.MethodGet, "/api/export", nil, http.StatusOK, &document)
+		if len(document.Hives) != 1 || document.Hives[0].HiveName != 2 {
+			t.Errorf("got hives %+v, want only hive 2", document.Hives)
+		}
+		if len(document.Logs) != 1 || document.Logs[0].HiveID != 2 || len(document.Tasks) != 1 || document.Tasks[0].HiveID != 2 {
+			t.Errorf("got logs %+v and tasks %+v, want only those of hive 2", document.Logs, document.Tasks)
+		}
+	})
+	t.Run("hive report", func(t *testing.T) {
+		s.do(http.MethodGet, "/api/hives/1/report.pdf", nil, http.StatusForbidden, nil)
+		s.do(http.MethodGet, "/api/hives/2/report.pdf", nil, http.StatusOK, nil)
+	})
+	t.Run("apiary report", func(t *testing.T) {
+		// Every hive gets a page after the overview
+		pages := func(path, token string) int {
+			rec := s.request(http.MethodGet, path, nil, token)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+			}
+			return strings.Count(rec.Body.String(), "/Type /Page\n")
+		}
+		all := pages("/api/reports/apiary.pdf", owner)
+		if got := pages("/api/reports/apiary.pdf", viewer); got != all-1 {
+			t.Errorf("got %d pages, want %d for one hive less than the owner's", got, all-1)
+		}
+		// Only hive 1 is in the apiary, and the viewer cannot read it
+		path := fmt.Sprintf("/api/reports/apiary.pdf?apiary_id=%d", apiary)
+		if got := pages(path, owner); got != all-1 {
+			t.Errorf("got %d pages for the apiary, want %d for hive 1 alone", got, all-1)
+		}
+		if got := pages(path, viewer); got != all-2 {
+			t.Errorf("got %d pages for the apiary, want %d for no hive", got, all-2)
+		}
+		s.do(http.MethodGet, "/api/reports/apiary.pdf?apiary_id=orchard", nil, http.StatusBadRequest, nil)
+	})
+	t.Run("dashboard", func(t *testing.T) {
+		var board dashboard.Dashboard
+		s.do(http.MethodGet, "/api/dashboard", nil, http.StatusOK, &board)
+		if len(board.Hives) != 1 || board.Hives[0].HiveName != 2 {
+			t.Errorf("got %+v, want only hive 2", board.Hives)
+		}
+	})
+	t.Run("tags", func(t *testing.T) {
+		var usages []tags.TagUsage
+		s.do(http.MethodGet, "/api/tags", nil, http.StatusOK, &usages)
+		if len(usages) != 1 || usages[0].Name != "public" || usages[0].Logs != 1 {
+			t.Errorf("got %+v, want only the tag of hive 2", usages)
+		}
+	})
+	t.Run("apiaries", func(t *testing.T) {
+		var listed, nearby []models.Apiary
+		s.do(http.MethodGet, "/api/apiaries", nil, http.StatusOK, &listed)
+		s.do(http.MethodGet, "/api/apiaries/nearby?lat=48.4&lon=11.7", nil, http.StatusOK, &nearby)
+		var collection apiaries.FeatureCollection
+		s.do(http.MethodGet, "/api/apiaries.geojson", nil, http.StatusOK, &collection)
+		if len(listed) != 0 || len(nearby) != 0 || len(collection.Features) != 0 {
+			t.Errorf("got apiaries %+v, nearby %+v and features %+v, want none", listed, nearby, collection.Features)
+		}
+	})
+	t.Run("events", func(t *testing.T) {
+		received := s.replay("/api/events", viewer, "0")
+		var hives []int
+		for _, event := range received {
+			hives = append(hives, event.HiveID)
+		}
+		if !slices.Equal(hives, []int{2, 2}) {
+			t.Errorf("got events of hives %v, want the log and task of hive 2", hives)
+		}
+		if all := s.replay("/api/events", owner, "0"); len(all) != 5 {
+			t.Errorf("got %d events for the owner, want 5", len(all))
+		}
+	})
+}
+
+// TestBodyTargets checks that the hive or apiary a body names is checked the
+// way the handler binds it, with keys in any case and the last of duplicate
+// keys winning. Hive 1 is in a members only apiary with a team, hive 2
+// outside of apiaries; the global viewer and editor are no members of the
+// team.
+func TestBodyTargets(t *testing.T) {
+	s := newTestServer(t)
+	s.signIn(models.RoleOwner)
+	apiary := s.createMembersOnlyApiary()
+	s.do(http.MethodPost, "/api/hives", map[string]any{"hiveName": 1, "apiaryID": apiary}, http.StatusCreated, nil)
+	var logged models.Log
+	s.do(http.MethodPost, "/api/logs", map[string]any{"hiveID": 2, "content": "Inspected"}, http.StatusCreated, &logged)
+	member, memberToken := s.user(models.RoleNone)
+	if err := s.db.Create(&models.TeamMember{ApiaryID: apiary, UserID: member.ID, Role: models.RoleEditor}).Error; err != nil {
+		t.Fatal(err)
+	}
+	_, viewer := s.user(models.RoleViewer)
+	_, editor := s.user(models.RoleEditor)
+
+	requests := []struct {
+		name, method, path, body string
+		token                    string
+		status                   int
+	}{
+		{"viewer log in other case", http.MethodPost, "/api/logs", `{"HiveID":1,"content":"Inspected"}`, viewer, http.StatusForbidden},
+		{"viewer task in other case", http.MethodPost, "/api/tasks", `{"HiveID":1,"content":"Feed"}`, viewer, http.StatusForbidden},
+		{"editor log in upper case", http.MethodPost, "/api/logs", `{"HIVEID":1,"content":"Inspected"}`, editor, http.StatusForbidden},
+		{"editor log with duplicate keys", http.MethodPost, "/api/logs", `{"hiveID":2,"HIVEID":1,"content":"Inspected"}`, editor, http.StatusForbidden},
+		{"editor task with duplicate keys", http.MethodPost, "/api/tasks", `{"hiveID":2,"hiveid":1,"content":"Feed"}`, editor, http.StatusForbidden},
+		{"editor moves log in other case", http.MethodPut, fmt.Sprintf("/api/logs/%d", logged.ID), `{"HiveId":1,"content":"Inspected"}`, editor, http.StatusForbidden},
+		{"editor hive into apiary in other case", http.MethodPost, "/api/hives", fmt.Sprintf(`{"hiveName":3,"ApiaryID":%d}`, apiary), editor, http.StatusForbidden},
+		{"editor harvest in other case", http.MethodPost, "/api/harvests", `{"HiveID":1,"weightKg":5}`, editor, http.StatusForbidden},
+		{"editor incident in other case", http.MethodPost, "/api/incidents", `{"HIVEID":1,"disease":"afb"}`, editor, http.StatusForbidden},
+		{"editor moves from another team", http.MethodPost, "/api/equipment-moves", `{"FromHiveID":1,"toHiveID":2,"equipment":"super"}`, editor, http.StatusForbidden},
+		{"hive as a string", http.MethodPost, "/api/logs", `{"hiveID":"1","content":"Inspected"}`, editor, http.StatusBadRequest},
+		{"hive as a fraction", http.MethodPost, "/api/logs", `{"hiveID":1.5,"content":"Inspected"}`, editor, http.StatusBadRequest},
+		{"missing log before its body", http.MethodPut, "/api/logs/999", `{"hiveID":"1"}`, editor, http.StatusNotFound},
+		{"missing task before its body", http.MethodPut, "/api/tasks/999", `{"hiveID":"1"}`, editor, http.StatusNotFound},
+		{"body not an object", http.MethodPost, "/api/logs", `[1]`, editor, http.StatusBadRequest},
+		{"member log in other case", http.MethodPost, "/api/logs", `{"HiveID":1,"content":"Inspected"}`, memberToken, http.StatusCreated},
+	}
+	for _, r := range requests {
+		t.Run(r.name, func(t *testing.T) {
+			if rec := s.request(r.method, r.path, r.body, r.token); rec.Code != r.status {
+				t.Errorf("got status %d, want %d: %s", rec.Code, r.status, rec.Body)
+			}
+		})
+	}
+
+	// The last of duplicate keys is the hive both checked and written to
+	rec := s.request(http.MethodPost, "/api/logs", `{"hiveID":1,"HiveID":2,"content":"Inspected"}`, editor)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+	if entry := decode[models.Log](t, rec.Body.Bytes()); entry.HiveID != 2 {
+		t.Errorf("got log of hive %d, want hive 2", entry.HiveID)
+	}
+}
+
+// TestUserVisibility checks that users other than owners only see
+// themselves and the members of their apiaries
+func TestUserVisibility(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.signIn(models.RoleOwner)
+	apiary := s.createApiary()
+	member, memberToken := s.user(models.RoleNone)
+	colleague, _ := s.user(models.RoleViewer)
+	for _, user := range []models.User{member, colleague} {
+		if err := s.db.Create(&models.TeamMember{ApiaryID: apiary, UserID: user.ID, Role: models.RoleEditor}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	editor, editorToken := s.user(models.RoleEditor)
+
+	ids := func(token string) []uint {
+		s.token = token
+		var users []models.User
+		s.do(http.MethodGet, "/api/users", nil, http.StatusOK, &users)
+		var ids []uint
+		for _, user := range users {
+			ids = append(ids, user.ID)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+	owners := ids(s.token)
+	if want := []uint{owner.ID, member.ID, colleague.ID, editor.ID}; !slices.Equal(owners, want) {
+		t.Errorf("got users %v for the owner, want %v", owners, want)
+	}
+	if got, want := ids(memberToken), []uint{member.ID, colleague.ID}; !slices.Equal(got, want) {
+		t.Errorf("got users %v for the member, want %v", got, want)
+	}
+	if got, want := ids(editorToken), []uint{editor.ID}; !slices.Equal(got, want) {
+		t.Errorf("got users %v for the editor, want %v", got, want)
+	}
+
+	s.do(http.MethodGet, fmt.Sprintf("/api/users/%d", member.ID), nil, http.StatusNotFound, nil)
+	s.do(http.MethodGet, fmt.Sprintf("/api/users/%d", editor.ID), nil, http.StatusOK, nil)
+}
+
+// TestTeamAccess invites an inspector to an apiary, which must not lock out
+// the global roles, until an owner makes the apiary members only
+func TestTeamAccess(t *testing.T) {
+	s := newTestServer(t)
+	s.signIn(models.RoleOwner)
+	owner := s.token
+	var apiary models.Apiary
+	s.do(http.MethodPost, "/api/apiaries", map[string]any{"name": "Orchard", "latitude": 48.4, "longitude": 11.7}, http.StatusCreated, &apiary)
+	s.do(http.MethodPost, "/api/hives", map[string]any{"hiveName": 1, "apiaryID": apiary.ID}, http.StatusCreated, nil)
+	s.do(http.MethodPost, "/api/hives", map[string]any{"hiveName": 2}, http.StatusCreated, nil)
+
+	var invitation team.CreateInvitationResponse
+	s.do(http.MethodPost, fmt.Sprintf("/api/apiaries/%d/invitations", apiary.ID), map[string]any{
+		"email": "vet@example.com", "role": models.RoleInspector, "accessExpiresAt": time.Now().Add(7 * 24 * time.Hour),
+	}, http.StatusCreated, &invitation)
+	s.token = ""
+	var accepted team.AcceptInvitationResponse
+	s.do(http.MethodPost, "/api/invitations/accept", map[string]any{"token": invitation.Token, "name": "Vet"}, http.StatusOK, &accepted)
+	inspector := accepted.Token
+	_, viewer := s.user(models.RoleViewer)
+	_, editor := s.user(models.RoleEditor)
+
+	requests := []struct {
+		name, method, path string
+		body               any
+		token              string
+		status             int
+	}{
+		{"editor logs", http.MethodPost, "/api/logs", map[string]any{"hiveID": 1, "content": "Inspected"}, editor, http.StatusCreated},
+		{"editor updates apiary", http.MethodPatch, fmt.Sprintf("/api/apiaries/%d", apiary.ID), map[string]any{"notes": "Gate code 1234"}, editor, http.StatusOK},
+		{"viewer reads", http.MethodGet, "/api/hives/1", nil, viewer, http.StatusOK},
+		{"viewer logs", http.MethodPost, "/api/logs", map[string]any{"hiveID": 1, "content": "Inspected"}, viewer, http.StatusForbidden},
+		{"inspector reads", http.MethodGet, "/api/hives/1", nil, inspector, http.StatusOK},
+		{"inspector reads outside the apiary", http.MethodGet, "/api/hives/2", nil, inspector, http.StatusForbidden},
+		{"inspector logs", http.MethodPost, "/api/logs", map[string]any{"hiveID": 1, "content": "Inspected"}, inspector, http.StatusForbidden},
+		{"editor makes apiary members only", http.MethodPatch, fmt.Sprintf("/api/apiaries/%d", apiary.ID), map[string]any{"membersOnly": true}, editor, http.StatusForbidden},
+		{"editor creates members only apiary", http.MethodPost, "/api/apiaries", map[string]any{"name": "Meadow", "latitude": 48, "longitude": 11, "membersOnly": true}, editor, http.StatusForbidden},
+	}
+	for _, r := range requests {
+		t.Run(r.name, func(t *testing.T) {
+			if rec := s.request(r.method, r.path, r.body, r.token); rec.Code != r.status {
+				t.Errorf("got status %d, want %d: %s", rec.Code, r.status, rec.Body)
+			}
+		})
+	}
+
+	// listHives returns the names of the hives a user sees
+	listHives := func(token string) []int {
+		t.Helper()
+		s.token = token
+		var names []int
+		var hives []models.Hive
+		s.do(http.MethodGet, "/api/hives", nil, http.StatusOK, &hives)
+		for _, hive := range hives {
+			names = append(names, hive.HiveName)
+		}
+		return names
+	}
+	if names := listHives(editor); !slices.Equal(names, []int{1, 2}) {
+		t.Errorf("got hives %v for the editor, want 1 and 2", names)
+	}
+	if names := listHives(inspector); !slices.Equal(names, []int{1}) {
+		t.Errorf("got hives %v for the inspector, want 1", names)
+	}
+
+	// Members only, the apiary is closed to everyone but its team and owners
+	s.token = owner
+	var updated models.Apiary
+	s.do(http.MethodPatch, fmt.Sprintf("/api/apiaries/%d", apiary.ID), map[string]any{"membersOnly": true}, http.StatusOK, &updated)
+	if !updated.MembersOnly {
+		t.Errorf("got %+v, want it members only", updated)
+	}
+	if rec := s.request(http.MethodPost, "/api/logs", map[string]any{"hiveID": 1, "content": "Inspected"}, editor); rec.Code != http.StatusForbidden {
+		t.Errorf("got status %d for the editor in a members only apiary, want 403: %s", rec.Code, rec.Body)
+	}
+	if names := listHives(editor); !slices.Equal(names, []int{2}) {
+		t.Errorf("got hives %v for the editor, want only 2", names)
+	}
+	if rec := s.request(http.MethodGet, "/api/hives/1", nil, inspector); rec.Code != http.StatusOK {
+		t.Errorf("got status %d for the inspector in a members only apiary, want 200: %s", rec.Code, rec.Body)
+	}
+}
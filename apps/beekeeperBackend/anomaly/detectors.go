@@ -0,0 +1,130 @@
+package anomaly
+
+import (
+	"fmt"
+	"time"
+
+	"beekeeper-api/models"
+)
+
+// Detector inspects the recent history of one metric of a hive and reports an
+// anomaly when its rule matches the newest reading.
+type Detector interface {
+	// Name identifies the detector on the alerts it raises
+	Name() string
+	// Metric is the telemetry metric the detector evaluates
+	Metric() string
+	// Window is how far back Check needs to see
+	Window() time.Duration
+	// Check receives the readings within Window before current, oldest first,
+	// with their times in the hive's time zone
+	Check(history []models.Telemetry, current models.Telemetry) (string, bool)
+}
+
+// StepDrop detects a sudden weight loss, typically a swarm leaving the hive.
+type StepDrop struct {
+	Drop float64
+	Span time.Duration
+}
+
+func (d StepDrop) Name() string          { return "step_drop" }
+func (d StepDrop) Metric() string        { return "weight" }
+func (d StepDrop) Window() time.Duration { return d.Span }
+
+func (d StepDrop) Check(history []models.Telemetry, current models.Telemetry) (string, bool) {
+	if len(history) == 0 {
+		return "", false
+	}
+	peak := history[0].Value
+	for _, reading := range history[1:] {
+		peak = max(peak, reading.Value)
+	}
+	if drop := peak - current.Value; drop >= d.Drop {
+		return fmt.Sprintf("Weight dropped %.1f kg within %s, possible swarm", drop, d.Span), true
+	}
+	return "", false
+}
+
+// SustainedDecline detects a steady weight loss during the day, which can
+// indicate the colony is being robbed. The day lasts from DayStart to DayEnd
+// o'clock in the hive's time zone.
+type SustainedDecline struct {
+	Loss     float64
+	Span     time.Duration
+	DayStart int
+	DayEnd   int
+}
+
+// declineTolerance is how much a single reading may rise (scale noise, a bee
+// landing) without interrupting an otherwise steady decline.
+const declineTolerance = 0.1
+
+func (d SustainedDecline) Name() string          { return "sustained_decline" }
+func (d SustainedDecline) Metric() string        { return "weight" }
+func (d SustainedDecline) Window() time.Duration { return d.Span }
+
+func (d SustainedDecline) Check(history []models.Telemetry, current models.Telemetry) (string, bool) {
+	if len(history) < 2 {
+		return "", false
+	}
+	first := history[0]
+	if current.RecordedAt.Sub(first.RecordedAt) < d.Span*9/10 {
+		return "", false // not enough history to call it sustained
+	}
+	if !d.daytime(first.RecordedAt) || !d.daytime(current.RecordedAt) {
+		return "", false
+	}
+
+	readings := append(history[:len(history):len(history)], current)
+	largestStep := 0.0
+	for i := 1; i < len(readings); i++ {
+		change := readings[i].Value - readings[i-1].Value
+		if change > declineTolerance {
+			return "", false
+		}
+		largestStep = max(largestStep, -change)
+	}
+
+	// A loss dominated by a single step is a swarm or a harvest, not robbing
+	loss := first.Value - current.Value
+	if loss < d.Loss || largestStep > loss/2 {
+		return "", false
+	}
+	return fmt.Sprintf("Weight fell steadily by %.1f kg over %s during the day, possible robbing", loss, d.Span), true
+}
+
+func (d SustainedDecline) daytime(t time.Time) bool {
+	hour := t.Hour()
+	return hour >= d.DayStart && hour < d.DayEnd
+}
+
+// BroodTemperature detects brood nest temperatures outside the healthy range,
+// averaged over a window so a single opened lid does not raise an alert.
+type BroodTemperature struct {
+	Min  float64
+	Max  float64
+	Span time.Duration
+}
+
+func (d BroodTemperature) Name() string          { return "brood_temperature" }
+func (d BroodTemperature) Metric() string        { return "temperature" }
+func (d BroodTemperature) Window() time.Duration { return d.Span }
+
+func (d BroodTemperature) Check(history []models.Telemetry, current models.Telemetry) (string, bool) {
+	if len(history) == 0 {
+		return "", false
+	}
+	sum := current.Value
+	for _, reading := range history {
+		sum += reading.Value
+	}
+	mean := sum / float64(len(history)+1)
+
+	switch {
+	case mean < d.Min:
+		return fmt.Sprintf("Brood temperature averaged %.1f °C over %s, below %.1f °C", mean, d.Span, d.Min), true
+	case mean > d.Max:
+		return fmt.Sprintf("Brood temperature averaged %.1f °C over %s, above %.1f °C", mean, d.Span, d.Max), true
+	}
+	return "", false
+}
@@ -0,0 +1,164 @@
+package anomaly
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"beekeeper-api/config"
+	"beekeeper-api/events"
+	"beekeeper-api/models"
+)
+
+// Engine evaluates incoming telemetry against the configured detectors. Every
+// anomaly it finds is recorded as an alert together with a high-priority task
+// and an automatic log entry on the affected hive.
+type Engine struct {
+	db        *gorm.DB
+	bus       *events.Bus
+	detectors []Detector
+	cooldown  time.Duration
+	location  *time.Location
+}
+
+// NewEngine creates an engine with the detectors enabled in the configuration.
+func NewEngine(cfg *config.Config, db *gorm.DB, bus *events.Bus) *Engine {
+	location, err := time.LoadLocation(cfg.AnomalyTimezone)
+	if err != nil {
+		log.Printf("Unknown ANOMALY_TIMEZONE %q, using the server's time zone: %v", cfg.AnomalyTimezone, err)
+		location = time.Local
+	}
+	e := &Engine{db: db, bus: bus, cooldown: cfg.AlertCooldown, location: location}
+	if cfg.SwarmDropKg > 0 {
+		e.detectors = append(e.detectors, StepDrop{Drop: cfg.SwarmDropKg, Span: cfg.SwarmWindow})
+	}
+	if cfg.RobbingLossKg > 0 {
+		e.detectors = append(e.detectors, SustainedDecline{Loss: cfg.RobbingLossKg, Span: cfg.RobbingWindow, DayStart: cfg.RobbingDayStart, DayEnd: cfg.RobbingDayEnd})
+	}
+	if cfg.BroodTempMin > 0 || cfg.BroodTempMax > 0 {
+		e.detectors = append(e.detectors, BroodTemperature{Min: cfg.BroodTempMin, Max: cfg.BroodTempMax, Span: cfg.BroodTempWindow})
+	}
+	return e
+}
+
+// Process runs every detector for the reading's metric. The reading must
+// already be stored.
+func (e *Engine) Process(reading models.Telemetry) {
+	location, err := e.locate(reading.HiveID)
+	if err != nil {
+		log.Printf("Anomaly detection for hive %d failed: %v", reading.HiveID, err)
+		return
+	}
+	// Detectors see local times; queries keep the stored ones, as SQLite
+	// compares times as text
+	local := reading
+	local.RecordedAt = reading.RecordedAt.In(location)
+
+	for _, detector := range e.detectors {
+		if detector.Metric() != reading.Metric {
+			continue
+		}
+
+		var history []models.Telemetry
+		err := e.db.
+			Where("hive_id = ? AND metric = ? AND id <> ?", reading.HiveID, reading.Metric, reading.ID).
+			Where("recorded_at >= ? AND recorded_at <= ?", reading.RecordedAt.Add(-detector.Window()), reading.RecordedAt).
+			Order("recorded_at asc").
+			Find(&history).Error
+		if err != nil {
+			log.Printf("Anomaly detection for hive %d failed: %v", reading.HiveID, err)
+			continue
+		}
+
+		for i := range history {
+			history[i].RecordedAt = history[i].RecordedAt.In(location)
+		}
+
+		message, triggered := detector.Check(history, local)
+		if !triggered {
+			continue
+		}
+		if err := e.raise(detector, reading, message); err != nil {
+			log.Printf("Failed to raise %s alert for hive %d: %v", detector.Name(), reading.HiveID, err)
+		}
+	}
+}
+
+// locate returns the time zone of a hive: that of its apiary, if set, or the
+// configured one.
+func (e *Engine) locate(hiveID int) (*time.Location, error) {
+	var zones []string
+	err := e.db.Model(&models.Hive{}).
+		Joins("JOIN apiaries ON apiaries.id = hives.apiary_id").
+		Where("hives.hive_name = ?", hiveID).
+		Pluck("apiaries.timezone", &zones).Error
+	if err != nil || len(zones) == 0 || zones[0] == "" {
+		return e.location, err
+	}
+	return time.LoadLocation(zones[0])
+}
+
+// raise records the alert unless the same detector already fired for the hive
+// within the cooldown period of the reading. The cooldown is measured in
+// reading time, so readings replayed after an outage are judged like live ones.
+func (e *Engine) raise(detector Detector, reading models.Telemetry, message string) error {
+	var (
+		task     models.Task
+		logEntry models.Log
+		alert    models.Alert
+		raised   bool
+	)
+	err := e.db.Transaction(func(tx *gorm.DB) error {
+		var recent int64
+		err := tx.Model(&models.Alert{}).
+			Where("hive_id = ? AND detector = ? AND recorded_at > ? AND recorded_at < ?",
+				reading.HiveID, detector.Name(), reading.RecordedAt.Add(-e.cooldown), reading.RecordedAt.Add(e.cooldown)).
+			Count(&recent).Error
+		if err != nil || recent > 0 {
+			return err
+		}
+
+		task = models.Task{
+			HiveID:   reading.HiveID,
+			Content:  "Inspect hive: " + message,
+			Priority: models.PriorityHigh,
+		}
+		if err := tx.Create(&task).Error; err != nil {
+			return err
+		}
+
+		logEntry = models.Log{
+			HiveID:  reading.HiveID,
+			Content: "Automatic alert: " + message,
+		}
+		if err := tx.Create(&logEntry).Error; err != nil {
+			return err
+		}
+
+		alert = models.Alert{
+			HiveID:     reading.HiveID,
+			Detector:   detector.Name(),
+			Message:    message,
+			Value:      reading.Value,
+			TaskID:     &task.ID,
+			LogID:      &logEntry.ID,
+			RecordedAt: reading.RecordedAt,
+		}
+		if err := tx.Create(&alert).Error; err != nil {
+			return err
+		}
+
+		raised = true
+		return nil
+	})
+	if err != nil || !raised {
+		return err
+	}
+
+	log.Printf("Alert for hive %d: %s", reading.HiveID, message)
+	e.bus.Publish(events.TaskCreated, task.HiveID, task)
+	e.bus.Publish(events.LogCreated, logEntry.HiveID, logEntry)
+	e.bus.Publish(events.AlertRaised, alert.HiveID, alert)
+	return nil
+}
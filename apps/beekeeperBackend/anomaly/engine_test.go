@@ -0,0 +1,165 @@
+package anomaly
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"beekeeper-api/config"
+	"beekeeper-api/database"
+	"beekeeper-api/events"
+	"beekeeper-api/migrate"
+	"beekeeper-api/models"
+)
+
+var databases atomic.Int64
+
+// openDB creates a migrated in-memory database
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	cfg := config.New()
+	cfg.DBDriver = "sqlite"
+	cfg.DatabaseURL = fmt.Sprintf("file:anomaly%d?mode=memory&cache=shared", databases.Add(1))
+	cfg.DBMaxOpenConns = 1
+	cfg.DBMaxIdleConns = 1
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db.Logger = logger.Discard
+
+	migrator, err := migrate.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// detecting returns a configuration with every detector disabled, for the
+// test to enable the one it is about
+func detecting() *config.Config {
+	cfg := config.New()
+	cfg.SwarmDropKg = 0
+	cfg.RobbingLossKg = 0
+	cfg.BroodTempMin = 0
+	cfg.BroodTempMax = 0
+	return cfg
+}
+
+// record stores a weight reading and processes it like the MQTT bridge does
+func record(t *testing.T, db *gorm.DB, engine *Engine, hiveID int, value float64, at time.Time) {
+	t.Helper()
+	reading := models.Telemetry{HiveID: hiveID, Metric: "weight", Value: value, RecordedAt: at}
+	if err := db.Create(&reading).Error; err != nil {
+		t.Fatal(err)
+	}
+	engine.Process(reading)
+}
+
+func alerts(t *testing.T, db *gorm.DB, hiveID int) []models.Alert {
+	t.Helper()
+	var alerts []models.Alert
+	if err := db.Where("hive_id = ?", hiveID).Order("recorded_at").Find(&alerts).Error; err != nil {
+		t.Fatal(err)
+	}
+	return alerts
+}
+
+// TestEngineCooldown replays a day of readings at once, as after an outage of
+// the bridge: the cooldown has to follow the readings' times.
+func TestEngineCooldown(t *testing.T) {
+	db := openDB(t)
+	if err := db.Create(&models.Hive{HiveName: 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+	cfg := detecting()
+	cfg.SwarmDropKg = 1.5
+	cfg.SwarmWindow = 15 * time.Minute
+	cfg.AlertCooldown = 6 * time.Hour
+	engine := NewEngine(cfg, db, events.NewBus(10))
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	swarm := func(at time.Duration) {
+		record(t, db, engine, 1, 40, start.Add(at))
+		record(t, db, engine, 1, 38, start.Add(at+5*time.Minute))
+	}
+	swarm(0)
+	swarm(time.Hour)      // within the cooldown
+	swarm(24 * time.Hour) // a day later
+	swarm(23 * time.Hour) // arriving late, within the cooldown before
+
+	got := alerts(t, db, 1)
+	want := []time.Time{start.Add(5 * time.Minute), start.Add(24*time.Hour + 5*time.Minute)}
+	if len(got) != len(want) {
+		t.Fatalf("got %d alerts, want %d: %+v", len(got), len(want), got)
+	}
+	for i, alert := range got {
+		if !alert.RecordedAt.Equal(want[i]) {
+			t.Errorf("got alert %d for the reading at %v, want %v", i, alert.RecordedAt, want[i])
+		}
+	}
+}
+
+// TestSustainedDeclineTimezone feeds the same three hour steady loss to hives
+// in different time zones. Only where that is daytime is it robbing.
+func TestSustainedDeclineTimezone(t *testing.T) {
+	db := openDB(t)
+	cfg := detecting()
+	cfg.RobbingLossKg = 1.0
+	cfg.RobbingWindow = 3 * time.Hour
+	cfg.RobbingDayStart = 8
+	cfg.RobbingDayEnd = 20
+	cfg.AnomalyTimezone = "Asia/Tokyo"
+	engine := NewEngine(cfg, db, events.NewBus(10))
+
+	tests := []struct {
+		name     string
+		timezone *string // nil for a hive outside apiaries
+		from     int     // hour in UTC the loss starts
+		robbed   bool
+	}{
+		{"apiary in New Zealand", ptr("Pacific/Auckland"), 0, true},
+		{"apiary in England", ptr("Europe/London"), 0, false},
+		{"apiary without a time zone", ptr(""), 0, true},
+		{"outside apiaries", nil, 0, true},
+		{"night in the configured time zone", nil, 12, false},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hive := models.Hive{HiveName: i + 1}
+			if tt.timezone != nil {
+				apiary := models.Apiary{Name: tt.name, Timezone: *tt.timezone}
+				if err := db.Create(&apiary).Error; err != nil {
+					t.Fatal(err)
+				}
+				hive.ApiaryID = &apiary.ID
+			}
+			if err := db.Create(&hive).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			start := time.Date(2024, 5, 1, tt.from, 0, 0, 0, time.UTC)
+			for step := range 7 {
+				record(t, db, engine, hive.HiveName, 40-0.25*float64(step), start.Add(time.Duration(step)*30*time.Minute))
+			}
+
+			if got := len(alerts(t, db, hive.HiveName)) > 0; got != tt.robbed {
+				t.Errorf("got robbing %v, want %v", got, tt.robbed)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string { return &s }
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"beekeeper-api/features/apiaries"
+	"beekeeper-api/models"
+)
+
+func TestApiaries(t *testing.T) {
+	// createApiaries adds Orchard, Meadow 5.6 km north of it, Field 7.4 km
+	// east of it and Capital in Berlin
+	createApiaries := func(s *testServer) {
+		for _, apiary := range []map[string]any{
+			{"name": "Orchard", "latitude": 48.4, "longitude": 11.7},
+			{"name": "Meadow", "latitude": 48.45, "longitude": 11.7},
+			{"name": "Field", "latitude": 48.4, "longitude": 11.8},
+			{"name": "Capital", "latitude": 52.52, "longitude": 13.405},
+		} {
+			s.do(http.MethodPost, "/api/apiaries", apiary, http.StatusCreated, nil)
+		}
+	}
+	// nearby returns the names and distances of the apiaries found
+	nearby := func(t *testing.T, body []byte) ([]string, []float64) {
+		t.Helper()
+		var names []string
+		var distances []float64
+		for _, apiary := range decode[[]apiaries.NearbyApiary](t, body) {
+			names = append(names, apiary.Name)
+			distances = append(distances, apiary.DistanceKm)
+		}
+		return names, distances
+	}
+	boundary := [][2]float64{{11.7, 48.4}, {11.71, 48.4}, {11.71, 48.41}}
+
+	runCases(t, []apiCase{
+		{
+			name:   "nearby within the radius",
+			setup:  createApiaries,
+			method: http.MethodGet, path: "/api/apiaries/nearby?lat=48.4&lon=11.7&radius_km=6",
+			status: http.StatusOK,
+			dbOnly: true,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				names, distances := nearby(t, body)
+				if !reflect.DeepEqual(names, []string{"Orchard", "Meadow"}) || !reflect.DeepEqual(distances, []float64{0, 5.56}) {
+					t.Errorf("got %v at %v km, want Orchard and Meadow at 0 and 5.56 km", names, distances)
+				}
+			},
+		},
+		{
+			name:   "nearby within 10 km by default",
+			setup:  createApiaries,
+			method: http.MethodGet, path: "/api/apiaries/nearby?lat=48.4&lon=11.7",
+			status: http.StatusOK,
+			dbOnly: true,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				if names, distances := nearby(t, body); !reflect.DeepEqual(names, []string{"Orchard", "Meadow", "Field"}) {
+					t.Errorf("got %v at %v km, want Orchard, Meadow and Field", names, distances)
+				}
+			},
+		},
+		{
+			name:   "nearby from afar",
+			setup:  createApiaries,
+			method: http.MethodGet, path: "/api/apiaries/nearby?lat=52.5&lon=13.4&radius_km=600",
+			status: http.StatusOK,
+			dbOnly: true,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				if names, _ := nearby(t, body); !reflect.DeepEqual(names, []string{"Capital", "Meadow", "Field", "Orchard"}) {
+					t.Errorf("got %v, want all apiaries, closest first", names)
+				}
+			},
+		},
+		{
+			name:   "nearby with an invalid radius",
+			method: http.MethodGet, path: "/api/apiaries/nearby?lat=48.4&lon=11.7&radius_km=0",
+			status: http.StatusBadRequest,
+			dbOnly: true,
+			check:  hasError("radius_km must be a positive number"),
+		},
+		{
+			name: "geojson",
+			setup: func(s *testServer) {
+				var apiary models.Apiary
+				s.do(http.MethodPost, "/api/apiaries", map[string]any{"name": "Orchard", "latitude": 48.4, "longitude": 11.7, "boundary": boundary}, http.StatusCreated, &apiary)
+				s.do(http.MethodPost, "/api/hives", map[string]any{"hiveName": 1, "apiaryID": apiary.ID}, http.StatusCreated, nil)
+			},
+			method: http.MethodGet, path: "/api/apiaries.geojson",
+			status: http.StatusOK,
+			dbOnly: true,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				collection := decode[struct {
+					Type     string `json:"type"`
+					Features []struct {
+						ID       string `json:"id"`
+						Geometry struct {
+							Type        string `json:"type"`
+							Coordinates any    `json:"coordinates"`
+						} `json:"geometry"`
+						Properties map[string]any `json:"properties"`
+					} `json:"features"`
+				}](t, body)
+				if collection.Type != "FeatureCollection" || len(collection.Features) != 2 {
+					t.Fatalf("got %+v, want a point and a boundary", collection)
+				}
+				point, ring := collection.Features[0], collection.Features[1]
+				// GeoJSON positions are longitude first
+				if point.ID != "apiary-1" || point.Geometry.Type != "Point" || !reflect.DeepEqual(point.Geometry.Coordinates, []any{11.7, 48.4}) {
+					t.Errorf("got point %+v, want [11.7, 48.4]", point)
+				}
+				if point.Properties["hive_count"] != 1.0 || !reflect.DeepEqual(point.Properties["hives"], []any{1.0}) {
+					t.Errorf("got properties %v, want hive 1", point.Properties)
+				}
+				want := []any{[]any{
+					[]any{11.7, 48.4}, []any{11.71, 48.4}, []any{11.71, 48.41}, []any{11.7, 48.4},
+				}}
+				if ring.ID != "apiary-1-boundary" || ring.Geometry.Type != "Polygon" || !reflect.DeepEqual(ring.Geometry.Coordinates, want) {
+					t.Errorf("got boundary %+v, want the closed ring %v", ring, want)
+				}
+			},
+		},
+		{
+			name:   "boundary with repeated points",
+			method: http.MethodPost, path: "/api/apiaries",
+			body:   map[string]any{"name": "Orchard", "latitude": 48.4, "longitude": 11.7, "boundary": [][2]float64{{11.7, 48.4}, {11.71, 48.4}, {11.7, 48.4}, {11.71, 48.4}}},
+			status: http.StatusBadRequest,
+			dbOnly: true,
+			check:  hasError("boundary needs at least three distinct points"),
+		},
+		{
+			name:   "boundary on a line",
+			method: http.MethodPost, path: "/api/apiaries",
+			body:   map[string]any{"name": "Orchard", "latitude": 48.4, "longitude": 11.7, "boundary": [][2]float64{{11.7, 48.4}, {11.71, 48.4}, {11.72, 48.4}}},
+			status: http.StatusBadRequest,
+			dbOnly: true,
+			check:  hasError("boundary must enclose an area"),
+		},
+		{
+			name: "delete with hives",
+			setup: func(s *testServer) {
+				apiary := s.createApiary()
+				s.do(http.MethodPost, "/api/hives", map[string]any{"hiveName": 1, "apiaryID": apiary}, http.StatusCreated, nil)
+			},
+			method: http.MethodDelete, path: "/api/apiaries/1",
+			status: http.StatusConflict,
+			dbOnly: true,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				hasError("Apiary still has hives, move them to another apiary or out of apiaries first")(t, s, body)
+				var hive models.Hive
+				s.do(http.MethodGet, "/api/hives/1", nil, http.StatusOK, &hive)
+				if hive.ApiaryID == nil || *hive.ApiaryID != 1 {
+					t.Errorf("got hive in apiary %v, want 1", hive.ApiaryID)
+				}
+			},
+		},
+		{
+			name: "delete without hives",
+			setup: func(s *testServer) {
+				s.signIn(models.RoleOwner)
+				apiary := s.createApiary()
+				member, _ := s.user(models.RoleNone)
+				if err := s.db.Create(&models.TeamMember{ApiaryID: apiary, UserID: member.ID, Role: models.RoleEditor}).Error; err != nil {
+					s.t.Fatal(err)
+				}
+			},
+			method: http.MethodDelete, path: "/api/apiaries/1",
+			status: http.StatusNoContent,
+			dbOnly: true,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				var members int64
+				if err := s.db.Model(&models.TeamMember{}).Count(&members).Error; err != nil {
+					t.Fatal(err)
+				}
+				if members != 0 {
+					t.Errorf("got %d members left, want the team deleted with the apiary", members)
+				}
+			},
+		},
+	})
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"beekeeper-api/models"
+)
+
+const userKey = "auth.user"
+
+// Middleware identifies the user behind a request from its
+// "Authorization: Bearer <token>" header, holding an API token or, if an OIDC
+// issuer is configured, one of its JWTs. Requests with an unknown or invalid
+// token are rejected. Requests without a token are passed on anonymously,
+// unless required is set; then only the public routes, given as
+// "METHOD /full/path", can be used without a token.
+func Middleware(db *gorm.DB, provider *OIDC, required bool, public ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			if required && !slices.Contains(public, c.Request.Method+" "+c.FullPath()) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+				return
+			}
+			c.Next()
+			return
+		}
+		scheme, token, ok := strings.Cut(header, " ")
+		token = strings.TrimSpace(token)
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header"})
+			return
+		}
+
+		// API tokens are hex, JWTs have three dot-separated parts
+		if provider != nil && strings.Count(token, ".") == 2 {
+			user, err := provider.VerifyBearer(c.Request.Context(), token)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid bearer token"})
+				return
+			}
+			c.Set(userKey, user)
+			c.Next()
+			return
+		}
+
+		var apiToken models.APIToken
+		if err := db.Preload("User").First(&apiToken, "token_hash = ?", HashToken(token)).Error; err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API token"})
+			return
+		}
+		db.Model(&apiToken).Update("last_used_at", time.Now())
+
+		c.Set(userKey, &apiToken.User)
+		c.Next()
+	}
+}
+
+// CurrentUser returns the authenticated user of the request, if any.
+func CurrentUser(c *gin.Context) (*models.User, bool) {
+	value, ok := c.Get(userKey)
+	if !ok {
+		return nil, false
+	}
+	user, ok := value.(*models.User)
+	return user, ok
+}
+
+// NewToken generates a random secret token and the hash stored in its place.
+func NewToken() (token, hash string, err error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the stored form of a secret token.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
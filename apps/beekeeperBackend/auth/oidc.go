@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+
+	"beekeeper-api/config"
+	"beekeeper-api/models"
+)
+
+// roleRank orders the global roles an issuer can grant, most privileged first
+var roleRank = []string{models.RoleOwner, models.RoleEditor, models.RoleViewer, models.RoleNone}
+
+// OIDC signs users in with an OpenID Connect issuer such as Keycloak. It
+// validates the issuer's JWTs against its published keys and maps their
+// claims to local users.
+type OIDC struct {
+	// OAuth2 is the client configuration for the authorization code flow
+	OAuth2 oauth2.Config
+	// PostLoginURL is where the browser is sent after logging in, if set
+	PostLoginURL string
+
+	db          *gorm.DB
+	idTokens    *oidc.IDTokenVerifier
+	bearers     *oidc.IDTokenVerifier
+	roleClaim   string
+	defaultRole string
+}
+
+// claims are the standard claims used to find or create the local user
+type claims struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// NewOIDC discovers the configured issuer. It returns nil if no issuer is
+// configured.
+func NewOIDC(ctx context.Context, cfg *config.Config, db *gorm.DB) (*OIDC, error) {
+	if cfg.OIDCIssuer == "" {
+		return nil, nil
+	}
+	if cfg.OIDCClientID == "" {
+		return nil, errors.New("OIDC_CLIENT_ID is required with OIDC_ISSUER")
+	}
+	if !slices.Contains(roleRank, cfg.OIDCDefaultRole) {
+		return nil, fmt.Errorf("unknown OIDC default role %q", cfg.OIDCDefaultRole)
+	}
+
+	provider, err := oidc.NewProvider(ctx, cfg.OIDCIssuer)
+	if err != nil {
+		return nil, err
+	}
+	audience := cfg.OIDCAudience
+	if audience == "" {
+		audience = cfg.OIDCClientID
+	}
+	return &OIDC{
+		OAuth2: oauth2.Config{
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			Endpoint:     provider.Endpoint(),
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       cfg.OIDCScopes,
+		},
+		PostLoginURL: cfg.OIDCPostLoginURL,
+		db:           db,
+		idTokens:     provider.Verifier(&oidc.Config{ClientID: cfg.OIDCClientID}),
+		bearers:      provider.Verifier(&oidc.Config{ClientID: audience}),
+		roleClaim:    cfg.OIDCRoleClaim,
+		defaultRole:  cfg.OIDCDefaultRole,
+	}, nil
+}
+
+// VerifyBearer validates a JWT sent as bearer token, e.g. the access token a
+// mobile client got from the issuer, and returns its local user.
+func (o *OIDC) VerifyBearer(ctx context.Context, raw string) (*models.User, error) {
+	token, err := o.bearers.Verify(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	return o.user(token)
+}
+
+// VerifyIDToken validates the ID token returned at the end of a login and
+// returns its local user.
+func (o *OIDC) VerifyIDToken(ctx context.Context, raw, nonce string) (*models.User, error) {
+	token, err := o.idTokens.Verify(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	if token.Nonce != nonce {
+		return nil, errors.New("nonce does not match")
+	}
+	return o.user(token)
+}
+
+// user finds the local user of a token by its subject, then by its verified
+// email, linking the two if that account has no subject yet and is the only
+// one using the email. Unknown users are created with the default role.
+// If a role claim is configured, the user's global role follows it on every
+// login.
+func (o *OIDC) user(token *oidc.IDToken) (*models.User, error) {
+	var c claims
+	if err := token.Claims(&c); err != nil {
+		return nil, err
+	}
+	var raw map[string]any
+	if err := token.Claims(&raw); err != nil {
+		return nil, err
+	}
+	role := o.defaultRole
+	if o.roleClaim != "" {
+		role = o.roleFrom(raw)
+	}
+
+	var user models.User
+	err := o.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("oidc_subject = ?", token.Subject).Limit(1).Find(&user).Error; err != nil {
+			return err
+		}
+		// Only an email the issuer vouches for links to an existing account;
+		// without the claim anyone able to set that email could take it over
+		if user.ID == 0 && c.Email != "" && c.EmailVerified {
+			var matches []models.User
+			if err := tx.Where("LOWER(email) = ?", strings.ToLower(c.Email)).Limit(2).Find(&matches).Error; err != nil {
+				return err
+			}
+			switch {
+			case len(matches) > 1:
+				return errors.New("several accounts use the email of the token")
+			case len(matches) == 1 && matches[0].OIDCSubject != nil:
+				// Linked to another login already, which is not replaced
+				return errors.New("the account of the token's email is linked to another login")
+			case len(matches) == 1:
+				user = matches[0]
+			}
+		}
+
+		if user.ID == 0 {
+			if c.Email == "" {
+				return errors.New("token has no email claim")
+			}
+			_, hash, err := NewToken()
+			if err != nil {
+				return err
+			}
+			user = models.User{
+				Name:              displayName(c),
+				Email:             strings.ToLower(c.Email),
+				Role:              role,
+				OIDCSubject:       &token.Subject,
+				CalendarTokenHash: hash,
+			}
+			return tx.Create(&user).Error
+		}
+
+		updates := map[string]any{}
+		if user.OIDCSubject == nil {
+			updates["oidc_subject"] = token.Subject
+		}
+		if o.roleClaim != "" && user.Role != role {
+			updates["role"] = role
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&user).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// roleFrom returns the most privileged role named in the role claim, a dotted
+// path such as "realm_access.roles", or the default role if none is.
+func (o *OIDC) roleFrom(raw map[string]any) string {
+	var value any = raw
+	for _, key := range strings.Split(o.roleClaim, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return o.defaultRole
+		}
+		value = object[key]
+	}
+
+	var names []string
+	switch v := value.(type) {
+	case string:
+		names = strings.Fields(v)
+	case []any:
+		for _, item := range v {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	for _, role := range roleRank {
+		if slices.Contains(names, role) {
+			return role
+		}
+	}
+	return o.defaultRole
+}
+
+func displayName(c claims) string {
+	switch {
+	case c.Name != "":
+		return c.Name
+	case c.PreferredUsername != "":
+		return c.PreferredUsername
+	}
+	return c.Email
+}
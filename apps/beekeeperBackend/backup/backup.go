@@ -0,0 +1,200 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"beekeeper-api/config"
+)
+
+// ErrUnsupported is returned for databases other than SQLite, which have
+// their own backup tools
+var ErrUnsupported = errors.New("backups are only supported for SQLite")
+
+// ErrNotFound is returned for unknown backup names
+var ErrNotFound = errors.New("backup not found")
+
+// namePattern matches the files written by Create, so nothing else in the
+// directory is listed, served or pruned
+var namePattern = regexp.MustCompile(`^beekeeper-(\d{8}-\d{6})(?:-(\d+))?\.db$`)
+
+// Backup is a snapshot of the database
+type Backup struct {
+	Name      string    `json:"name" example:"beekeeper-20240115-020000.db"`
+	Size      int64     `json:"size" example:"1048576"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Manager writes consistent snapshots of a live SQLite database with
+// VACUUM INTO, on demand and on a schedule, and prunes old ones.
+type Manager struct {
+	db        *gorm.DB
+	dir       string
+	interval  time.Duration
+	retention int
+	now       func() time.Time
+
+	mu sync.Mutex
+}
+
+// NewManager creates a backup manager from the configuration
+func NewManager(cfg *config.Config, db *gorm.DB) *Manager {
+	return &Manager{
+		db:        db,
+		dir:       cfg.BackupDir,
+		interval:  cfg.BackupInterval,
+		retention: cfg.BackupRetention,
+		now:       time.Now,
+	}
+}
+
+// Start takes a snapshot in the background every configured interval. It
+// does nothing if the interval is 0 or the database is not SQLite.
+func (m *Manager) Start() {
+	if m.interval <= 0 || !m.supported() {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if backup, err := m.Create(); err != nil {
+				log.Printf("backup: scheduled snapshot failed: %v", err)
+			} else {
+				log.Printf("backup: wrote %s", backup.Name)
+			}
+		}
+	}()
+}
+
+// Create writes a snapshot and prunes the oldest ones beyond the retention.
+// The database stays available while the snapshot is written.
+func (m *Manager) Create() (Backup, error) {
+	if !m.supported() {
+		return Backup{}, ErrUnsupported
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := os.MkdirAll(m.dir, 0o750); err != nil {
+		return Backup{}, err
+	}
+	stamp := m.now().UTC().Format("20060102-150405")
+	name := "beekeeper-" + stamp + ".db"
+	// Snapshots within the same second are numbered after the newest one
+	if taken, _ := filepath.Glob(filepath.Join(m.dir, "beekeeper-"+stamp+"*.db")); len(taken) > 0 {
+		last := 1
+		for _, path := range taken {
+			if match := namePattern.FindStringSubmatch(filepath.Base(path)); match != nil {
+				counter, _ := strconv.Atoi(match[2])
+				last = max(last, counter)
+			}
+		}
+		name = fmt.Sprintf("beekeeper-%s-%d.db", stamp, last+1)
+	}
+
+	// Written under a temporary name, so a half written snapshot is never
+	// listed or served
+	path := filepath.Join(m.dir, name)
+	partial := path + ".partial"
+	os.Remove(partial)
+	if err := m.db.Exec("VACUUM INTO ?", partial).Error; err != nil {
+		os.Remove(partial)
+		return Backup{}, err
+	}
+	if err := os.Rename(partial, path); err != nil {
+		return Backup{}, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Backup{}, err
+	}
+	if err := m.prune(); err != nil {
+		log.Printf("backup: pruning old snapshots failed: %v", err)
+	}
+	return Backup{Name: name, Size: info.Size(), CreatedAt: info.ModTime()}, nil
+}
+
+// List returns the snapshots, newest first
+func (m *Manager) List() ([]Backup, error) {
+	if !m.supported() {
+		return nil, ErrUnsupported
+	}
+	entries, err := os.ReadDir(m.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Backup{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []Backup{}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !namePattern.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, Backup{Name: entry.Name(), Size: info.Size(), CreatedAt: info.ModTime()})
+	}
+	sort.Slice(backups, func(i, j int) bool { return order(backups[i].Name) > order(backups[j].Name) })
+	return backups, nil
+}
+
+// Path returns the file of a snapshot
+func (m *Manager) Path(name string) (string, error) {
+	if !m.supported() {
+		return "", ErrUnsupported
+	}
+	if !namePattern.MatchString(name) || !m.exists(name) {
+		return "", ErrNotFound
+	}
+	return filepath.Join(m.dir, name), nil
+}
+
+// prune deletes the oldest snapshots beyond the retention, 0 keeps all
+func (m *Manager) prune() error {
+	if m.retention <= 0 {
+		return nil
+	}
+	backups, err := m.List()
+	if err != nil || len(backups) <= m.retention {
+		return err
+	}
+	for _, backup := range backups[m.retention:] {
+		if err := os.Remove(filepath.Join(m.dir, backup.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// order is a key that sorts snapshot names by creation time, including
+// several taken within the same second
+func order(name string) string {
+	match := namePattern.FindStringSubmatch(name)
+	counter, _ := strconv.Atoi(match[2])
+	return fmt.Sprintf("%s-%06d", match[1], max(counter, 1))
+}
+
+func (m *Manager) exists(name string) bool {
+	_, err := os.Stat(filepath.Join(m.dir, name))
+	return err == nil
+}
+
+func (m *Manager) supported() bool {
+	return m.db.Dialector.Name() == "sqlite"
+}
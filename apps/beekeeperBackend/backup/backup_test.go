@@ -0,0 +1,316 @@
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"beekeeper-api/config"
+	"beekeeper-api/database"
+	"beekeeper-api/migrate"
+	"beekeeper-api/models"
+)
+
+// The tests run on SQLite files with the default pragmas, using whichever
+// driver the build tags select.
+
+// testConfig configures a SQLite file and a backup directory in a temporary
+// directory
+func testConfig(t *testing.T) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := config.New()
+	cfg.DBDriver = "sqlite"
+	cfg.DatabaseURL = ""
+	cfg.DBFile = filepath.Join(dir, "beekeeper.db")
+	cfg.BackupDir = filepath.Join(dir, "backups")
+	cfg.BackupRetention = 0
+	return cfg
+}
+
+// open connects to the configured database and migrates it. The connection
+// is closed at the end of the test.
+func open(t *testing.T, cfg *config.Config) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { closeDB(t, db) })
+	db.Logger = logger.Discard
+	migrator, err := migrate.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func closeDB(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.Close()
+}
+
+// logs returns the contents of the logs in a SQLite file
+func logs(t *testing.T, path string) []string {
+	t.Helper()
+	db, err := database.OpenReadOnly(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeDB(t, db)
+	var contents []string
+	if err := db.Model(&models.Log{}).Order("id").Pluck("content", &contents).Error; err != nil {
+		t.Fatal(err)
+	}
+	return contents
+}
+
+// writeLog writes a log for hive 1, creating the hive if needed
+func writeLog(t *testing.T, db *gorm.DB, content string) {
+	t.Helper()
+	if err := db.FirstOrCreate(&models.Hive{}, models.Hive{HiveName: 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.Log{HiveID: 1, Content: content}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func names(backups []Backup) []string {
+	var names []string
+	for _, backup := range backups {
+		names = append(names, backup.Name)
+	}
+	return names
+}
+
+func TestCreateAndList(t *testing.T) {
+	cfg := testConfig(t)
+	db := open(t, cfg)
+	writeLog(t, db, "Inspected")
+
+	m := NewManager(cfg, db)
+	now := time.Date(2024, 1, 15, 2, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	var created []string
+	for range 3 {
+		backup, err := m.Create()
+		if err != nil {
+			t.Fatal(err)
+		}
+		created = append(created, backup.Name)
+	}
+	now = now.Add(time.Hour)
+	backup, err := m.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	created = append(created, backup.Name)
+	// Files not written by Create are ignored
+	if err := os.WriteFile(filepath.Join(cfg.BackupDir, "notes.txt"), []byte("keep"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"beekeeper-20240115-020000.db",
+		"beekeeper-20240115-020000-2.db",
+		"beekeeper-20240115-020000-3.db",
+		"beekeeper-20240115-030000.db",
+	}
+	if !slices.Equal(created, want) {
+		t.Errorf("created %v, want %v", created, want)
+	}
+	listed, err := m.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Reverse(want)
+	if got := names(listed); !slices.Equal(got, want) {
+		t.Errorf("listed %v, want %v", got, want)
+	}
+
+	path, err := m.Path(created[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := logs(t, path); !slices.Equal(got, []string{"Inspected"}) {
+		t.Errorf("got logs %v in the snapshot", got)
+	}
+	for _, name := range []string{"notes.txt", "beekeeper-20240101-000000.db", "../beekeeper.db"} {
+		if _, err := m.Path(name); !errors.Is(err, ErrNotFound) {
+			t.Errorf("got %v for %s, want ErrNotFound", err, name)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.BackupRetention = 2
+	db := open(t, cfg)
+
+	m := NewManager(cfg, db)
+	now := time.Date(2024, 1, 15, 2, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	for range 4 {
+		if _, err := m.Create(); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(24 * time.Hour)
+	}
+
+	listed, err := m.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"beekeeper-20240118-020000.db", "beekeeper-20240117-020000.db"}
+	if got := names(listed); !slices.Equal(got, want) {
+		t.Errorf("kept %v, want the newest %v", got, want)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	cfg := testConfig(t)
+	db := open(t, cfg)
+	writeLog(t, db, "Before the snapshot")
+	backup, err := NewManager(cfg, db).Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeLog(t, db, "After the snapshot")
+	closeDB(t, db)
+
+	restored, err := Restore(cfg, backup.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest, err := migrate.Load("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Version != latest[len(latest)-1].Version {
+		t.Errorf("got version %d, want %d", restored.Version, latest[len(latest)-1].Version)
+	}
+	if restored.Source != filepath.Join(cfg.BackupDir, backup.Name) {
+		t.Errorf("got source %s", restored.Source)
+	}
+	if got := logs(t, cfg.DBFile); !slices.Equal(got, []string{"Before the snapshot"}) {
+		t.Errorf("got logs %v after restoring", got)
+	}
+	if got := logs(t, restored.Previous); !slices.Equal(got, []string{"Before the snapshot", "After the snapshot"}) {
+		t.Errorf("got logs %v in the replaced database", got)
+	}
+	if _, err := os.Stat(cfg.DBFile + ".restoring"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("staged copy left behind: %v", err)
+	}
+}
+
+// TestRestoreLegacy restores a backup taken before migrations existed, which
+// is left to be migrated at the next start
+func TestRestoreLegacy(t *testing.T) {
+	cfg := testConfig(t)
+	source := filepath.Join(t.TempDir(), "old.db")
+	legacy, err := database.Open(&config.Config{DBDriver: "sqlite", DBFile: source})
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := os.ReadFile(filepath.Join("..", "migrate", "testdata", "baseline.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(script), "\n") {
+		if line == "" || strings.HasPrefix(line, "--") {
+			continue
+		}
+		if err := legacy.Exec(line).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	closeDB(t, legacy)
+	original, err := os.ReadFile(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := Restore(cfg, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Version != 0 || restored.Previous != "" {
+		t.Errorf("got %+v, want version 0 and nothing replaced", restored)
+	}
+	// Checking the backup did not change it
+	copied, err := os.ReadFile(cfg.DBFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(copied, original) {
+		t.Error("restored database differs from the backup")
+	}
+	if got := logs(t, cfg.DBFile); len(got) != 2 {
+		t.Errorf("got logs %v, want the 2 of the backup", got)
+	}
+}
+
+func TestRestoreRejected(t *testing.T) {
+	cfg := testConfig(t)
+	db := open(t, cfg)
+	writeLog(t, db, "Current")
+	m := NewManager(cfg, db)
+	backup, err := m.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := m.Path(backup.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeDB(t, db)
+
+	// A backup of a newer version
+	newer, err := database.Open(&config.Config{DBDriver: "sqlite", DBFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := newer.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (9999, 'from_the_future', ?)", time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+	closeDB(t, newer)
+	if _, err := Restore(cfg, backup.Name); !errors.Is(err, migrate.ErrSchemaTooNew) {
+		t.Errorf("got %v for a newer backup, want ErrSchemaTooNew", err)
+	}
+
+	garbage := filepath.Join(t.TempDir(), "garbage.db")
+	if err := os.WriteFile(garbage, bytes.Repeat([]byte("not a database "), 512), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Restore(cfg, garbage); err == nil {
+		t.Error("restored a file that is no database")
+	}
+	if _, err := Restore(cfg, "beekeeper-20240101-000000.db"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got %v for a missing backup, want ErrNotExist", err)
+	}
+
+	// The current database was kept every time
+	if got := logs(t, cfg.DBFile); !slices.Equal(got, []string{"Current"}) {
+		t.Errorf("got logs %v, want the current database untouched", got)
+	}
+	if _, err := os.Stat(cfg.DBFile + ".restoring"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("staged copy left behind: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"beekeeper-api/config"
+	"beekeeper-api/database"
+	"beekeeper-api/migrate"
+)
+
+// Restored describes a completed restore
+type Restored struct {
+	// Source is the backup file that was restored
+	Source string
+	// Version is the schema version of the backup; older versions are
+	// migrated at the next start
+	Version int64
+	// Previous is where the replaced database was moved, empty if there was
+	// none
+	Previous string
+}
+
+// Restore replaces the configured SQLite database with a backup, given as a
+// path or as the name of a snapshot in the backup directory. The backup is
+// copied next to the database and checked before anything is replaced: it
+// must pass SQLite's integrity check and must not have a newer schema than
+// this version supports. The replaced database is kept next to it. The
+// server must not be running.
+func Restore(cfg *config.Config, source string) (Restored, error) {
+	if cfg.DBDriver != "sqlite" {
+		return Restored{}, ErrUnsupported
+	}
+	if _, err := os.Stat(source); err != nil {
+		inDir := filepath.Join(cfg.BackupDir, source)
+		if !namePattern.MatchString(source) {
+			return Restored{}, err
+		}
+		if _, err := os.Stat(inDir); err != nil {
+			return Restored{}, err
+		}
+		source = inDir
+	}
+	target := database.SQLiteFile(cfg)
+	restored := Restored{Source: source}
+
+	staged := target + ".restoring"
+	if err := copyFile(source, staged); err != nil {
+		return restored, err
+	}
+	version, err := check(staged)
+	if err != nil {
+		os.Remove(staged)
+		return restored, err
+	}
+	restored.Version = version
+
+	// Keep the current database, with its WAL files so nothing is lost
+	if _, err := os.Stat(target); err == nil {
+		restored.Previous = fmt.Sprintf("%s.pre-restore-%s", target, time.Now().UTC().Format("20060102-150405"))
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			err := os.Rename(target+suffix, restored.Previous+suffix)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				os.Remove(staged)
+				return restored, err
+			}
+		}
+	}
+	return restored, os.Rename(staged, target)
+}
+
+// check opens a staged backup read-only and returns its schema version. A
+// backup from before migrations existed is at version 0 and is adopted at the
+// next start, not here: checking must not change it.
+func check(path string) (int64, error) {
+	db, err := database.OpenReadOnly(path)
+	if err != nil {
+		return 0, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+	defer sqlDB.Close()
+
+	var problems []string
+	if err := db.Raw("PRAGMA integrity_check").Scan(&problems).Error; err != nil {
+		return 0, fmt.Errorf("backup is not a readable SQLite database: %w", err)
+	}
+	if len(problems) != 1 || problems[0] != "ok" {
+		return 0, fmt.Errorf("backup failed the integrity check: %v", problems)
+	}
+	if !db.Migrator().HasTable("hives") {
+		return 0, errors.New("backup is not a beekeeper database")
+	}
+
+	migrator, err := migrate.New(db)
+	if err != nil {
+		return 0, err
+	}
+	if err := migrator.Check(); err != nil {
+		return 0, err
+	}
+	return migrator.Version()
+}
+
+func copyFile(source, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(target)
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		os.Remove(target)
+		return err
+	}
+	return out.Close()
+}
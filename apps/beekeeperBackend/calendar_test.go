@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"beekeeper-api/auth"
+	"beekeeper-api/features/calendar"
+	"beekeeper-api/models"
+)
+
+// ics wraps iCalendar components into a calendar
+func ics(components ...string) string {
+	return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" + strings.Join(components, "") + "END:VCALENDAR\r\n"
+}
+
+// todo is a VTODO with a UID and summary
+func todo(uid, summary string) string {
+	return fmt.Sprintf("BEGIN:VTODO\r\nUID:%s\r\nSUMMARY:%s\r\nEND:VTODO\r\n", uid, summary)
+}
+
+// calendarToken issues a new calendar token for a user
+func (s *testServer) calendarToken(user models.User) string {
+	s.t.Helper()
+	token, hash, err := auth.NewToken()
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if err := s.db.Model(&user).Update("calendar_token_hash", hash).Error; err != nil {
+		s.t.Fatal(err)
+	}
+	return token
+}
+
+// newCalendarServer has hive 1 in a members only apiary with an inspector,
+// hive 2 in an apiary without members and hive 3 outside of apiaries, with a
+// due task each
+func newCalendarServer(t *testing.T) (*testServer, models.User) {
+	s := newTestServer(t)
+	s.signIn(models.RoleOwner)
+	invited, other := s.createMembersOnlyApiary(), s.createApiary()
+	s.do(http.MethodPost, "/api/hives", map[string]any{"hiveName": 1, "apiaryID": invited}, http.StatusCreated, nil)
+	s.do(http.MethodPost, "/api/hives", map[string]any{"hiveName": 2, "apiaryID": other}, http.StatusCreated, nil)
+	due := time.Now().Add(24 * time.Hour)
+	for hive := 1; hive <= 3; hive++ {
+		s.do(http.MethodPost, "/api/tasks", map[string]any{"hiveID": hive, "content": "Feed", "dueAt": due}, http.StatusCreated, nil)
+	}
+
+	inspector, _ := s.user(models.RoleNone)
+	expires := time.Now().Add(7 * 24 * time.Hour)
+	if err := s.db.Create(&models.TeamMember{ApiaryID: invited, UserID: inspector.ID, Role: models.RoleInspector, ExpiresAt: &expires}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return s, inspector
+}
+
+func TestCalendarFeed(t *testing.T) {
+	s, inspector := newCalendarServer(t)
+
+	rec := s.request(http.MethodGet, "/api/tasks/calendar.ics?token="+s.calendarToken(inspector), nil, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+	}
+	feed := rec.Body.String()
+	if !strings.Contains(feed, "SUMMARY:Hive 1: Feed") {
+		t.Errorf("feed is missing the task of the invited apiary:\n%s", feed)
+	}
+	for _, hive := range []string{"Hive 2", "Hive 3"} {
+		if strings.Contains(feed, "SUMMARY:"+hive) {
+			t.Errorf("feed has a task of %s the inspector was not invited to", hive)
+		}
+	}
+
+	rec = s.request(http.MethodGet, "/api/tasks/calendar.ics?token=unknown", nil, "")
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("got status %d for an unknown token, want 401", rec.Code)
+	}
+}
+
+func TestCalendarImport(t *testing.T) {
+	s, _ := newCalendarServer(t)
+	_, viewer := s.user(models.RoleViewer)
+	_, editor := s.user(models.RoleEditor)
+
+	body := ics(todo("new@example.com", "Hive 3: Add a super"))
+	if rec := s.request(http.MethodPost, "/api/tasks/calendar.ics", body, viewer); rec.Code != http.StatusForbidden {
+		t.Errorf("got status %d for a viewer, want 403", rec.Code)
+	}
+	if rec := s.request(http.MethodPost, "/api/tasks/calendar.ics", body, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("got status %d without a token, want 401", rec.Code)
+	}
+
+	// The editor is no member of the apiary of hive 1, which is members only
+	body = ics(
+		todo("new@example.com", "Hive 3: Add a super"),
+		todo("other@example.com", "Hive 1: Add a super"),
+		todo("task-1@beekeeper", "Hive 3: Feed"),
+	)
+	rec := s.request(http.MethodPost, "/api/tasks/calendar.ics", body, editor)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+	}
+	result := decode[calendar.ImportResult](t, rec.Body.Bytes())
+	if result.Created != 1 || result.Updated != 0 || len(result.Skipped) != 2 {
+		t.Errorf("got %+v, want 1 created and 2 skipped", result)
+	}
+
+	var task models.Task
+	s.do(http.MethodGet, "/api/tasks/1", nil, http.StatusOK, &task)
+	if task.HiveID != 1 {
+		t.Errorf("task 1 was moved to hive %d", task.HiveID)
+	}
+}
+
+// TestCalendarReimport imports entries of the feed back, changing only what
+// they carry, and tags hashtags
+func TestCalendarReimport(t *testing.T) {
+	s, _ := newCalendarServer(t)
+	var before models.Task
+	s.do(http.MethodPut, "/api/tasks/3", map[string]any{"completed": true}, http.StatusOK, &before)
+	if before.DueAt == nil || before.CompletedAt == nil {
+		t.Fatalf("got task %+v, want it due and completed", before)
+	}
+
+	importICS := func(body string) calendar.ImportResult {
+		t.Helper()
+		rec := s.request(http.MethodPost, "/api/tasks/calendar.ics", body, s.token)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+		}
+		return decode[calendar.ImportResult](t, rec.Body.Bytes())
+	}
+	get := func() models.Task {
+		t.Helper()
+		var task models.Task
+		s.do(http.MethodGet, "/api/tasks/3", nil, http.StatusOK, &task)
+		return task
+	}
+
+	// Neither due date nor status: both stay
+	importICS(ics(todo("task-3@beekeeper", "Hive 3: Feed #syrup")))
+	task := get()
+	if task.Content != "Feed #syrup" || !slices.Equal(tagNames(task.Tags), []string{"syrup"}) {
+		t.Errorf("got %q tagged %v, want the new content and its hashtag", task.Content, tagNames(task.Tags))
+	}
+	if task.DueAt == nil || !task.DueAt.Equal(*before.DueAt) {
+		t.Errorf("got due date %v, want %v kept", task.DueAt, before.DueAt)
+	}
+	if task.CompletedAt == nil || !task.CompletedAt.Equal(*before.CompletedAt) {
+		t.Errorf("got completion %v, want %v kept", task.CompletedAt, before.CompletedAt)
+	}
+
+	// Done again without a time keeps the original completion time
+	importICS(ics("BEGIN:VTODO\r\nUID:task-3@beekeeper\r\nSUMMARY:Hive 3: Feed\r\nSTATUS:COMPLETED\r\nEND:VTODO\r\n"))
+	if task := get(); task.CompletedAt == nil || !task.CompletedAt.Equal(*before.CompletedAt) {
+		t.Errorf("got completion %v, want %v kept", task.CompletedAt, before.CompletedAt)
+	}
+
+	// A status reopens the task, an event moves its due date
+	importICS(ics("BEGIN:VTODO\r\nUID:task-3@beekeeper\r\nSUMMARY:Hive 3: Feed\r\nSTATUS:NEEDS-ACTION\r\nEND:VTODO\r\n"))
+	if task := get(); task.CompletedAt != nil {
+		t.Errorf("got completion %v, want the task reopened", task.CompletedAt)
+	}
+	importICS(ics("BEGIN:VEVENT\r\nUID:task-3-event@beekeeper\r\nSUMMARY:Hive 3: Feed\r\nDTSTART;VALUE=DATE:20300105\r\nSTATUS:CONFIRMED\r\nEND:VEVENT\r\n"))
+	task = get()
+	if task.DueAt == nil || task.DueAt.Format("2006-01-02") != "2030-01-05" || task.CompletedAt != nil {
+		t.Errorf("got due date %v and completion %v, want 2030-01-05 and open", task.DueAt, task.CompletedAt)
+	}
+
+	// New tasks are tagged too
+	if result := importICS(ics(todo("new@example.com", "Hive 3: Check #queen-cells"))); result.Created != 1 {
+		t.Fatalf("got %+v, want 1 created", result)
+	}
+	var tasks []models.Task
+	s.do(http.MethodGet, "/api/tasks?tag=queen-cells", nil, http.StatusOK, &tasks)
+	if len(tasks) != 1 || tasks[0].Content != "Check #queen-cells" {
+		t.Errorf("got tasks %+v tagged queen-cells, want the new one", tasks)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"beekeeper-api/backup"
+	"beekeeper-api/config"
+	"beekeeper-api/database"
+	"beekeeper-api/migrate"
+)
+
+const usage = `Usage: beekeeper-api [command]
+
+Without a command, the API server is started.
+
+Commands:
+  migrate up          Apply all pending schema migrations
+  migrate down [n]    Revert the last n applied migrations (default 1)
+  migrate status      List migrations and whether they are applied
+  repair [--recreate-hives|--delete]
+                      List logs and tasks of missing hives, and fix them by
+                      creating the hives or deleting the rows
+  restore <backup>    Replace the SQLite database with a backup, given as a
+                      file or the name of a snapshot in BACKUP_DIR. Stop the
+                      server first.
+`
+
+// runCommand runs a maintenance command instead of the server and returns
+// the exit code
+func runCommand(cfg *config.Config, args []string) int {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(cfg, args[1:])
+	case "repair":
+		return runRepair(cfg, args[1:])
+	case "restore":
+		return runRestore(cfg, args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", args[0], usage)
+	return 2
+}
+
+func runMigrate(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	db, err := database.Open(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		return 1
+	}
+	migrator, err := migrate.New(db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load migrations: %v\n", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, migration := range applied {
+			fmt.Printf("Applied %04d %s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to migrate database: %v\n", err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("Database is up to date")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				fmt.Fprintf(os.Stderr, "Invalid number of migrations %q\n", args[1])
+				return 2
+			}
+		}
+		reverted, err := migrator.Down(steps)
+		for _, migration := range reverted {
+			fmt.Printf("Reverted %04d %s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to migrate database: %v\n", err)
+			return 1
+		}
+		if len(reverted) == 0 {
+			fmt.Println("No migrations to revert")
+		}
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read migrations: %v\n", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, applied)
+		}
+		w.Flush()
+		if err := migrator.Check(); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown migrate command %q\n\n%s", args[0], usage)
+		return 2
+	}
+	return 0
+}
+
+func runRepair(cfg *config.Config, args []string) int {
+	fix, deleteRows := false, false
+	for _, arg := range args {
+		switch arg {
+		case "--recreate-hives":
+			fix = true
+		case "--delete":
+			fix, deleteRows = true, true
+		default:
+			fmt.Fprint(os.Stderr, usage)
+			return 2
+		}
+	}
+	if len(args) > 1 {
+		fmt.Fprintln(os.Stderr, "Use either --recreate-hives or --delete")
+		return 2
+	}
+	db, err := database.Open(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		return 1
+	}
+
+	var orphans []database.Orphans
+	if fix {
+		orphans, err = database.RepairOrphans(db, deleteRows)
+	} else {
+		orphans, err = database.FindOrphans(db)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to repair database: %v\n", err)
+		return 1
+	}
+
+	found := false
+	for _, o := range orphans {
+		if o.Rows == 0 {
+			continue
+		}
+		found = true
+		action := "found"
+		switch {
+		case fix && deleteRows:
+			action = "deleted"
+		case fix:
+			action = "kept by recreating their hives"
+		}
+		fmt.Printf("%s: %d rows of missing hives %v %s\n", o.Table, o.Rows, o.HiveIDs, action)
+	}
+	switch {
+	case !found:
+		fmt.Println("No logs or tasks of missing hives")
+	case !fix:
+		fmt.Println("Run with --recreate-hives to keep them or --delete to remove them")
+	}
+	return 0
+}
+
+func runRestore(cfg *config.Config, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	restored, err := backup.Restore(cfg, args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to restore backup: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Restored %s (schema version %04d)\n", restored.Source, restored.Version)
+	if restored.Previous != "" {
+		fmt.Printf("The replaced database was moved to %s\n", restored.Previous)
+	}
+	return 0
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm/logger"
+
+	"beekeeper-api/config"
+	"beekeeper-api/database"
+	"beekeeper-api/migrate"
+	"beekeeper-api/models"
+)
+
+// capture runs a command and returns its exit code and what it printed
+func capture(t *testing.T, run func() int) (int, string) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	code := run()
+	os.Stdout = stdout
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code, string(out)
+}
+
+// TestRepairCommand runs repair on a SQLite file with logs and tasks of the
+// missing hive 9, written while foreign keys were not enforced
+func TestRepairCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		// hives and logs are left afterwards
+		hives, logs int64
+		output      string
+	}{
+		{"list", nil, 1, 2, "logs: 1 rows of missing hives [9] found"},
+		{"recreate hives", []string{"--recreate-hives"}, 2, 2, "logs: 1 rows of missing hives [9] kept by recreating their hives"},
+		{"delete", []string{"--delete"}, 1, 1, "tasks: 1 rows of missing hives [9] deleted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.DBDriver = "sqlite"
+			cfg.DatabaseURL = ""
+			cfg.DBFile = filepath.Join(t.TempDir(), "beekeeper.db")
+			cfg.SQLiteForeignKeys = false
+			db, err := database.Open(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			db.Logger = logger.Discard
+			migrator, err := migrate.New(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := migrator.Up(); err != nil {
+				t.Fatal(err)
+			}
+			rows := []any{
+				&models.Hive{HiveName: 1},
+				&models.Log{HiveID: 1, Content: "Inspected"},
+				&models.Log{HiveID: 9, Content: "Inspected"},
+				&models.Task{HiveID: 9, Content: "Feed"},
+			}
+			for _, row := range rows {
+				if err := db.Create(row).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+			sqlDB, err := db.DB()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sqlDB.Close()
+
+			code, out := capture(t, func() int { return runRepair(cfg, tt.args) })
+			if code != 0 {
+				t.Fatalf("got exit code %d: %s", code, out)
+			}
+			if !strings.Contains(out, tt.output) {
+				t.Errorf("got output %q, want it to contain %q", out, tt.output)
+			}
+			var hives, logs int64
+			if err := db.Model(&models.Hive{}).Count(&hives).Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := db.Model(&models.Log{}).Count(&logs).Error; err != nil {
+				t.Fatal(err)
+			}
+			if hives != tt.hives || logs != tt.logs {
+				t.Errorf("got %d hives and %d logs, want %d and %d", hives, logs, tt.hives, tt.logs)
+			}
+
+			// Repaired databases have nothing left to report
+			if len(tt.args) > 0 {
+				if _, out := capture(t, func() int { return runRepair(cfg, nil) }); !strings.Contains(out, "No logs or tasks of missing hives") {
+					t.Errorf("got output %q after repairing", out)
+				}
+			}
+		})
+	}
+
+	t.Run("both fixes", func(t *testing.T) {
+		if code := runRepair(config.New(), []string{"--recreate-hives", "--delete"}); code != 2 {
+			t.Errorf("got exit code %d, want 2", code)
+		}
+	})
+}
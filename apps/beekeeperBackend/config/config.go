@@ -2,12 +2,101 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds the application's configuration
 type Config struct {
 	Port   string
 	DBFile string
+
+	// Database backend: "sqlite" (DBFile unless DatabaseURL is set),
+	// "postgres" or "mysql" (DatabaseURL required), and its connection pool
+	DBDriver          string
+	DatabaseURL       string
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	// Apply pending schema migrations at startup, otherwise refuse to start
+	// until `migrate up` was run
+	DBAutoMigrate bool
+
+	// Pragmas set on every SQLite connection, empty values keep SQLite's
+	// default, and the check run at startup: "quick", "full" or "off"
+	SQLiteForeignKeys    bool
+	SQLiteJournalMode    string
+	SQLiteBusyTimeout    time.Duration
+	SQLiteSynchronous    string
+	SQLiteIntegrityCheck string
+
+	// SQLite backups in BackupDir, taken every BackupInterval (0 disables
+	// them) and pruned to the newest BackupRetention
+	BackupDir       string
+	BackupInterval  time.Duration
+	BackupRetention int
+
+	// Reject requests without an API token, except public routes
+	AuthRequired bool
+
+	// MQTT telemetry ingestion, disabled when MQTTBrokerURL is empty
+	MQTTBrokerURL       string
+	MQTTTopics          []string
+	MQTTClientID        string
+	MQTTUsername        string
+	MQTTPassword        string
+	MQTTMaxReconnectGap time.Duration
+
+	// Telemetry anomaly detection, a zero threshold disables that detector
+	SwarmDropKg     float64
+	SwarmWindow     time.Duration
+	RobbingLossKg   float64
+	RobbingWindow   time.Duration
+	RobbingDayStart int
+	RobbingDayEnd   int
+	BroodTempMin    float64
+	BroodTempMax    float64
+	BroodTempWindow time.Duration
+	AlertCooldown   time.Duration
+	// Time zone of hives outside apiaries with a time zone, "Local" for the
+	// server's
+	AnomalyTimezone string
+
+	// Number of recent events kept for clients resuming the event stream
+	EventBufferSize int
+
+	// Outbound webhook delivery
+	WebhookMaxAttempts int
+	WebhookRetryBase   time.Duration
+	WebhookTimeout     time.Duration
+
+	// Task reminders, sent ReminderLeadTime before a task is due
+	ReminderLeadTime time.Duration
+	ReminderInterval time.Duration
+	SMTPHost         string
+	SMTPPort         int
+	SMTPUsername     string
+	SMTPPassword     string
+	SMTPFrom         string
+	NtfyBaseURL      string
+
+	// Weather lookups for new log entries: "none", "stub" or "open-meteo"
+	WeatherProvider string
+	WeatherBaseURL  string
+	WeatherTimeout  time.Duration
+
+	// OpenID Connect login, disabled when OIDCIssuer is empty. Bearer JWTs
+	// must be issued for OIDCAudience, which defaults to the client ID.
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
+	OIDCScopes       []string
+	OIDCAudience     string
+	OIDCRoleClaim    string
+	OIDCDefaultRole  string
+	OIDCPostLoginURL string
 }
 
 // New creates a new Config instance from environment variables
@@ -15,6 +104,73 @@ func New() *Config {
 	return &Config{
 		Port:   getEnv("PORT", "8000"),
 		DBFile: getEnv("DB_FILE", "beekeeper.db"),
+
+		DBDriver:          getEnv("DB_DRIVER", "sqlite"),
+		DatabaseURL:       getEnv("DATABASE_URL", ""),
+		DBMaxOpenConns:    getEnvInt("DB_MAX_OPEN_CONNS", 10),
+		DBMaxIdleConns:    getEnvInt("DB_MAX_IDLE_CONNS", 5),
+		DBConnMaxLifetime: getEnvDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		DBAutoMigrate:     getEnvBool("DB_AUTO_MIGRATE", true),
+
+		SQLiteForeignKeys:    getEnvBool("SQLITE_FOREIGN_KEYS", true),
+		SQLiteJournalMode:    getEnv("SQLITE_JOURNAL_MODE", "WAL"),
+		SQLiteBusyTimeout:    getEnvDuration("SQLITE_BUSY_TIMEOUT", 5*time.Second),
+		SQLiteSynchronous:    getEnv("SQLITE_SYNCHRONOUS", "NORMAL"),
+		SQLiteIntegrityCheck: getEnv("SQLITE_INTEGRITY_CHECK", "quick"),
+
+		BackupDir:       getEnv("BACKUP_DIR", "backups"),
+		BackupInterval:  getEnvDuration("BACKUP_INTERVAL", 24*time.Hour),
+		BackupRetention: getEnvInt("BACKUP_RETENTION", 7),
+
+		AuthRequired: getEnvBool("AUTH_REQUIRED", false),
+
+		MQTTBrokerURL:       getEnv("MQTT_BROKER_URL", ""),
+		MQTTTopics:          getEnvList("MQTT_TOPICS", "apiary/+/hive/+/weight"),
+		MQTTClientID:        getEnv("MQTT_CLIENT_ID", "beekeeper-api"),
+		MQTTUsername:        getEnv("MQTT_USERNAME", ""),
+		MQTTPassword:        getEnv("MQTT_PASSWORD", ""),
+		MQTTMaxReconnectGap: getEnvDuration("MQTT_MAX_RECONNECT_INTERVAL", 2*time.Minute),
+
+		SwarmDropKg:     getEnvFloat("ANOMALY_SWARM_DROP_KG", 1.5),
+		SwarmWindow:     getEnvDuration("ANOMALY_SWARM_WINDOW", 15*time.Minute),
+		RobbingLossKg:   getEnvFloat("ANOMALY_ROBBING_LOSS_KG", 1.0),
+		RobbingWindow:   getEnvDuration("ANOMALY_ROBBING_WINDOW", 3*time.Hour),
+		RobbingDayStart: getEnvInt("ANOMALY_ROBBING_DAY_START", 8),
+		RobbingDayEnd:   getEnvInt("ANOMALY_ROBBING_DAY_END", 20),
+		BroodTempMin:    getEnvFloat("ANOMALY_BROOD_TEMP_MIN", 32),
+		BroodTempMax:    getEnvFloat("ANOMALY_BROOD_TEMP_MAX", 36.5),
+		BroodTempWindow: getEnvDuration("ANOMALY_BROOD_TEMP_WINDOW", time.Hour),
+		AlertCooldown:   getEnvDuration("ANOMALY_ALERT_COOLDOWN", 6*time.Hour),
+		AnomalyTimezone: getEnv("ANOMALY_TIMEZONE", "Local"),
+
+		EventBufferSize: getEnvInt("EVENT_BUFFER_SIZE", 1000),
+
+		WebhookMaxAttempts: getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookRetryBase:   getEnvDuration("WEBHOOK_RETRY_BASE", 30*time.Second),
+		WebhookTimeout:     getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+
+		ReminderLeadTime: getEnvDuration("REMINDER_LEAD_TIME", 24*time.Hour),
+		ReminderInterval: getEnvDuration("REMINDER_INTERVAL", time.Minute),
+		SMTPHost:         getEnv("SMTP_HOST", ""),
+		SMTPPort:         getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:     getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:     getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:         getEnv("SMTP_FROM", "beekeeper@localhost"),
+		NtfyBaseURL:      getEnv("NTFY_BASE_URL", "https://ntfy.sh"),
+
+		WeatherProvider: getEnv("WEATHER_PROVIDER", "none"),
+		WeatherBaseURL:  getEnv("WEATHER_BASE_URL", "https://api.open-meteo.com"),
+		WeatherTimeout:  getEnvDuration("WEATHER_TIMEOUT", 10*time.Second),
+
+		OIDCIssuer:       getEnv("OIDC_ISSUER", ""),
+		OIDCClientID:     getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:  getEnv("OIDC_REDIRECT_URL", "http://localhost:8000/api/auth/callback"),
+		OIDCScopes:       getEnvList("OIDC_SCOPES", "openid,profile,email"),
+		OIDCAudience:     getEnv("OIDC_AUDIENCE", ""),
+		OIDCRoleClaim:    getEnv("OIDC_ROLE_CLAIM", ""),
+		OIDCDefaultRole:  getEnv("OIDC_DEFAULT_ROLE", "viewer"),
+		OIDCPostLoginURL: getEnv("OIDC_POST_LOGIN_URL", ""),
 	}
 }
 
@@ -26,3 +182,53 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// Helper function to get a comma-separated environment variable as a list
+func getEnvList(key, fallback string) []string {
+	var list []string
+	for _, item := range strings.Split(getEnv(key, fallback), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Helper function to get a duration environment variable (e.g. "30s", "5m")
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value, ok := os.LookupEnv(key); ok {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
+
+// Helper function to get an integer environment variable
+func getEnvInt(key string, fallback int) int {
+	if value, ok := os.LookupEnv(key); ok {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return fallback
+}
+
+// Helper function to get a boolean environment variable ("true", "1", ...)
+func getEnvBool(key string, fallback bool) bool {
+	if value, ok := os.LookupEnv(key); ok {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return fallback
+}
+
+// Helper function to get a numeric environment variable
+func getEnvFloat(key string, fallback float64) float64 {
+	if value, ok := os.LookupEnv(key); ok {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return fallback
+}
@@ -1,28 +1,188 @@
 package database
 
 import (
+	"fmt"
 	"log"
+	"net/url"
+	"strconv"
+	"strings"
 
-	"gorm.io/driver/sqlite"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"beekeeper-api/config"
-	"beekeeper-api/models"
+	"beekeeper-api/migrate"
 )
 
-// Init initializes and returns a GORM database instance
+// Init initializes and returns a GORM database instance with an up-to-date
+// schema
 func Init(cfg *config.Config) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(cfg.DBFile), &gorm.Config{})
+	db, err := Open(cfg)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
+	if db.Dialector.Name() == "sqlite" {
+		log.Printf("Using SQLite driver %s", SQLiteDriver)
+	}
+
+	// Refuse to run on a damaged SQLite file
+	dangling, err := CheckIntegrity(db, cfg.SQLiteIntegrityCheck)
+	if err != nil {
+		log.Fatalf("Database failed the integrity check: %v", err)
+	}
+	if dangling > 0 {
+		log.Printf("Found %d rows referencing missing rows, run `repair` to fix logs and tasks of deleted hives", dangling)
+	}
 
-	// Auto-migrate the schema
-	err = db.AutoMigrate(&models.Hive{}, &models.Log{}, &models.Task{})
+	// Refuse to run against a schema from a newer version, then apply
+	// pending migrations
+	migrator, err := migrate.New(db)
 	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	if err := migrator.Check(); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
+	if !cfg.DBAutoMigrate {
+		pending, err := migrator.Pending()
+		if err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+		if len(pending) > 0 {
+			log.Fatalf("Database has %d pending migrations, run `migrate up` first", len(pending))
+		}
+		return db
+	}
+	applied, err := migrator.Up()
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+	for _, migration := range applied {
+		log.Printf("Applied migration %04d %s", migration.Version, migration.Name)
+	}
 
 	return db
 }
 
+// Open connects to the configured database without touching its schema
+func Open(cfg *config.Config) (*gorm.DB, error) {
+	dialector, err := Dialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Connection pool
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+
+	return db, nil
+}
+
+// OpenReadOnly opens a SQLite file read-only and without the configured
+// pragmas, e.g. to inspect a backup, so it is not changed in any way
+func OpenReadOnly(path string) (*gorm.DB, error) {
+	dsn := "file:" + (&url.URL{Path: path}).EscapedPath() + "?mode=ro"
+	return gorm.Open(openSQLite(dsn, nil), &gorm.Config{})
+}
+
+// Dialector returns the GORM dialector for the configured driver. SQLite
+// uses DATABASE_URL if set and DB_FILE otherwise; PostgreSQL and MySQL need
+// DATABASE_URL.
+func Dialector(cfg *config.Config) (gorm.Dialector, error) {
+	switch cfg.DBDriver {
+	case "sqlite":
+		dsn := cfg.DatabaseURL
+		if dsn == "" {
+			dsn = cfg.DBFile
+		}
+		return openSQLite(dsn, sqlitePragmas(cfg)), nil
+	case "postgres":
+		if cfg.DatabaseURL == "" {
+			return nil, fmt.Errorf("DATABASE_URL is required for %s", cfg.DBDriver)
+		}
+		return postgres.Open(cfg.DatabaseURL), nil
+	case "mysql":
+		if cfg.DatabaseURL == "" {
+			return nil, fmt.Errorf("DATABASE_URL is required for %s", cfg.DBDriver)
+		}
+		// Timestamps have to be scanned into time.Time
+		dsn, err := mysqldriver.ParseDSN(cfg.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		dsn.ParseTime = true
+		return mysql.New(mysql.Config{DSNConfig: dsn}), nil
+	}
+	return nil, fmt.Errorf("unknown DB_DRIVER %q, use sqlite, postgres or mysql", cfg.DBDriver)
+}
+
+// pragma is a SQLite setting applied to every new connection
+type pragma struct {
+	name, value string
+}
+
+// sqlitePragmas lists the configured connection pragmas. SQLite only enforces
+// foreign keys, and with them ON DELETE CASCADE, on connections that enable
+// them.
+func sqlitePragmas(cfg *config.Config) []pragma {
+	var pragmas []pragma
+	if cfg.SQLiteBusyTimeout > 0 {
+		pragmas = append(pragmas, pragma{"busy_timeout", strconv.FormatInt(cfg.SQLiteBusyTimeout.Milliseconds(), 10)})
+	}
+	if cfg.SQLiteForeignKeys {
+		pragmas = append(pragmas, pragma{"foreign_keys", "1"})
+	} else {
+		pragmas = append(pragmas, pragma{"foreign_keys", "0"})
+	}
+	if cfg.SQLiteJournalMode != "" {
+		pragmas = append(pragmas, pragma{"journal_mode", cfg.SQLiteJournalMode})
+	}
+	if cfg.SQLiteSynchronous != "" {
+		pragmas = append(pragmas, pragma{"synchronous", cfg.SQLiteSynchronous})
+	}
+	return pragmas
+}
+
+// withParams adds query parameters to a DSN
+func withParams(dsn string, params url.Values) string {
+	if len(params) == 0 {
+		return dsn
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&" + params.Encode()
+	}
+	return dsn + "?" + params.Encode()
+}
+
+// SQLiteFile returns the path of the SQLite database file, without the
+// file: prefix and query parameters a DATABASE_URL may have
+func SQLiteFile(cfg *config.Config) string {
+	dsn := cfg.DatabaseURL
+	if dsn == "" {
+		dsn = cfg.DBFile
+	}
+	dsn, _, _ = strings.Cut(strings.TrimPrefix(dsn, "file:"), "?")
+	return dsn
+}
+
+// ForUpdate locks the rows a query reads until the surrounding transaction
+// ends. SQLite has no row locks and serializes writing transactions anyway,
+// so nothing is added there.
+func ForUpdate(db *gorm.DB) *gorm.DB {
+	if db.Dialector.Name() == "sqlite" {
+		return db
+	}
+	return db.Clauses(clause.Locking{Strength: "UPDATE"})
+}
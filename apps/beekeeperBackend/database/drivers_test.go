@@ -0,0 +1,223 @@
+package database
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"beekeeper-api/config"
+	"beekeeper-api/models"
+)
+
+// The tests in this file that need a PostgreSQL or MySQL server run against
+// the one of TEST_DB_DRIVER and TEST_DATABASE_URL, like the API tests:
+//
+//	make test-postgres
+//	make test-mysql
+
+func TestMySQLParseTime(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		// params are connection parameters that must be kept
+		params map[string]string
+		ok     bool
+	}{
+		{"plain", "beekeeper:secret@tcp(localhost:3306)/beekeeper", nil, true},
+		{"turned off", "beekeeper:secret@tcp(localhost:3306)/beekeeper?parseTime=false", nil, true},
+		{"other parameters", "beekeeper:secret@tcp(db:3306)/beekeeper?charset=utf8mb4&parseTime=0&tls=skip-verify", map[string]string{"charset": "utf8mb4"}, true},
+		{"invalid", "beekeeper:secret@localhost", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.New()
+			cfg.DBDriver = "mysql"
+			cfg.DatabaseURL = tt.url
+			dialector, err := Dialector(cfg)
+			if (err == nil) != tt.ok {
+				t.Fatalf("got error %v, want ok=%v", err, tt.ok)
+			}
+			if !tt.ok {
+				return
+			}
+			// The DSN the driver connects with has to ask for time.Time
+			dsn, err := mysqldriver.ParseDSN(dialector.(*mysql.Dialector).DSN)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !dsn.ParseTime {
+				t.Errorf("got parseTime off in %s", dialector.(*mysql.Dialector).DSN)
+			}
+			for name, value := range tt.params {
+				if dsn.Params[name] != value {
+					t.Errorf("got %s=%q, want %q", name, dsn.Params[name], value)
+				}
+			}
+		})
+	}
+}
+
+// TestForUpdateSQL checks the statements ForUpdate makes for every driver,
+// without connecting to a server
+func TestForUpdateSQL(t *testing.T) {
+	cfg := config.New()
+	cfg.DBDriver = "sqlite"
+	cfg.DatabaseURL = "file:forupdate?mode=memory&cache=shared"
+	sqlite, err := Dialector(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		dialector gorm.Dialector
+		locks     bool
+	}{
+		{"sqlite", sqlite, false},
+		{"postgres", postgres.New(postgres.Config{DSN: "postgres://beekeeper@localhost/beekeeper"}), true},
+		{"mysql", mysql.New(mysql.Config{DSN: "beekeeper@tcp(localhost:3306)/beekeeper", SkipInitializeWithVersion: true}), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := gorm.Open(tt.dialector, &gorm.Config{DryRun: true, DisableAutomaticPing: true, Logger: logger.Discard})
+			if err != nil {
+				t.Fatal(err)
+			}
+			stmt := ForUpdate(db).First(&models.Hive{}, "hive_name = ?", 1).Statement
+			sql := stmt.SQL.String()
+			if strings.HasSuffix(sql, "FOR UPDATE") != tt.locks {
+				t.Errorf("got %s, want locking=%v", sql, tt.locks)
+			}
+		})
+	}
+}
+
+// serverDB connects to the PostgreSQL or MySQL server of TEST_DB_DRIVER and
+// TEST_DATABASE_URL. The test is skipped, saying so, with SQLite.
+func serverDB(t *testing.T, drivers ...string) *gorm.DB {
+	t.Helper()
+	cfg := config.New()
+	cfg.DBDriver = os.Getenv("TEST_DB_DRIVER")
+	cfg.DatabaseURL = os.Getenv("TEST_DATABASE_URL")
+	if cfg.DBDriver == "" || cfg.DBDriver == "sqlite" {
+		t.Skipf("needs a %s server, run make test-%s", strings.Join(drivers, " or "), drivers[0])
+	}
+	if cfg.DatabaseURL == "" {
+		t.Fatalf("TEST_DATABASE_URL is required for TEST_DB_DRIVER=%s", cfg.DBDriver)
+	}
+	if !strings.Contains(strings.Join(drivers, " "), cfg.DBDriver) {
+		t.Skipf("only applies to %s, not TEST_DB_DRIVER=%s", strings.Join(drivers, " or "), cfg.DBDriver)
+	}
+	t.Logf("Testing against %s", cfg.DBDriver)
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db.Logger = logger.Discard
+	return db
+}
+
+// counter is a row the locking tests update concurrently
+type counter struct {
+	ID    uint
+	Value int
+}
+
+func (counter) TableName() string { return "for_update_counters" }
+
+func TestForUpdateLocks(t *testing.T) {
+	db := serverDB(t, "postgres", "mysql")
+	if err := db.Migrator().DropTable(&counter{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&counter{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Migrator().DropTable(&counter{}) })
+	if err := db.Create(&counter{ID: 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// A second transaction reading the row for update waits for the first
+	tx := db.Begin()
+	var locked counter
+	if err := ForUpdate(tx).First(&locked, 1).Error; err != nil {
+		t.Fatal(err)
+	}
+	read := make(chan error, 1)
+	go func() {
+		read <- db.Transaction(func(other *gorm.DB) error {
+			var c counter
+			return ForUpdate(other).First(&c, 1).Error
+		})
+	}()
+	select {
+	case err := <-read:
+		t.Errorf("read a locked row (error %v)", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+	if err := tx.Commit().Error; err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-read:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the row stayed locked after the commit")
+	}
+
+	// Increments read under the lock are never lost
+	const increments = 20
+	var wg sync.WaitGroup
+	for range increments {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := db.Transaction(func(tx *gorm.DB) error {
+				var c counter
+				if err := ForUpdate(tx).First(&c, 1).Error; err != nil {
+					return err
+				}
+				return tx.Model(&c).Update("value", c.Value+1).Error
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	var total counter
+	if err := db.First(&total, 1).Error; err != nil {
+		t.Fatal(err)
+	}
+	if total.Value != increments {
+		t.Errorf("got %d after %d increments", total.Value, increments)
+	}
+}
+
+func TestMySQLTimestamps(t *testing.T) {
+	db := serverDB(t, "mysql")
+	// Without parseTime the driver returns timestamps as bytes
+	var now time.Time
+	if err := db.Raw("SELECT CURRENT_TIMESTAMP").Scan(&now).Error; err != nil {
+		t.Fatal(err)
+	}
+	if now.IsZero() || time.Since(now).Abs() > 24*time.Hour {
+		t.Errorf("got %v as the current time", now)
+	}
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"beekeeper-api/models"
+)
+
+// CheckIntegrity runs SQLite's quick or full integrity check and fails if the
+// file is damaged. It also counts the rows whose foreign keys point nowhere,
+// e.g. logs of deleted hives left over from when foreign keys were not
+// enforced. Other databases are not checked.
+func CheckIntegrity(db *gorm.DB, mode string) (dangling int, err error) {
+	if db.Dialector.Name() != "sqlite" {
+		return 0, nil
+	}
+	var check string
+	switch mode {
+	case "off", "":
+		return 0, nil
+	case "quick":
+		check = "PRAGMA quick_check"
+	case "full":
+		check = "PRAGMA integrity_check"
+	default:
+		return 0, fmt.Errorf("unknown integrity check %q, use quick, full or off", mode)
+	}
+
+	var problems []string
+	if err := db.Raw(check).Scan(&problems).Error; err != nil {
+		return 0, err
+	}
+	if len(problems) != 1 || problems[0] != "ok" {
+		return 0, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+
+	rows, err := db.Raw("PRAGMA foreign_key_check").Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		dangling++
+	}
+	return dangling, rows.Err()
+}
+
+// Orphans are the rows of a table whose hive does not exist
+type Orphans struct {
+	Table   string
+	Rows    int64
+	HiveIDs []int
+}
+
+// orphanTables are the tables repaired by RepairOrphans, with the tables
+// depending on them and their foreign key
+var orphanTables = []struct {
+	table      string
+	dependents map[string]string
+}{
+	{"logs", map[string]string{"log_weathers": "log_id", "log_tags": "log_id"}},
+	{"tasks", map[string]string{"task_tags": "task_id", "task_reminders": "task_id"}},
+}
+
+// FindOrphans reports logs and tasks whose hive is missing
+func FindOrphans(db *gorm.DB) ([]Orphans, error) {
+	var found []Orphans
+	for _, t := range orphanTables {
+		orphans := Orphans{Table: t.table}
+		missing := "hive_id NOT IN (SELECT hive_name FROM hives)"
+		if err := db.Table(t.table).Where(missing).Count(&orphans.Rows).Error; err != nil {
+			return nil, err
+		}
+		if err := db.Table(t.table).Where(missing).Distinct().Order("hive_id").Pluck("hive_id", &orphans.HiveIDs).Error; err != nil {
+			return nil, err
+		}
+		found = append(found, orphans)
+	}
+	return found, nil
+}
+
+// RepairOrphans fixes the logs and tasks whose hive is missing, either by
+// creating the missing hives, which keeps their history, or by deleting them
+// along with their tags, weather and reminders. It returns what was fixed.
+func RepairOrphans(db *gorm.DB, deleteRows bool) ([]Orphans, error) {
+	var fixed []Orphans
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if fixed, err = FindOrphans(tx); err != nil {
+			return err
+		}
+
+		if !deleteRows {
+			created := map[int]bool{}
+			for _, orphans := range fixed {
+				for _, hiveID := range orphans.HiveIDs {
+					if created[hiveID] {
+						continue
+					}
+					if err := tx.Create(&models.Hive{HiveName: hiveID}).Error; err != nil {
+						return err
+					}
+					created[hiveID] = true
+				}
+			}
+			return nil
+		}
+
+		for _, t := range orphanTables {
+			orphanIDs := "SELECT id FROM " + t.table + " WHERE hive_id NOT IN (SELECT hive_name FROM hives)"
+			for dependent, column := range t.dependents {
+				if err := tx.Exec("DELETE FROM " + dependent + " WHERE " + column + " IN (" + orphanIDs + ")").Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Exec("DELETE FROM " + t.table + " WHERE hive_id NOT IN (SELECT hive_name FROM hives)").Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return fixed, err
+}
@@ -0,0 +1,204 @@
+package database
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"beekeeper-api/config"
+	"beekeeper-api/models"
+)
+
+// seedOrphans writes, without enforcing foreign keys, a log and a task of
+// hive 1 and logs and tasks of the missing hives 8 and 9, with weather, tags
+// and a reminder
+func seedOrphans(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := openFile(t, func(cfg *config.Config) { cfg.SQLiteForeignKeys = false })
+	user := models.User{Name: "Ana", Email: "ana@example.com", Role: models.RoleOwner, CalendarTokenHash: "hash"}
+	tag := models.Tag{Name: "queenless"}
+	rows := []any{
+		&models.Hive{HiveName: 1},
+		&user,
+		&tag,
+		&models.Log{HiveID: 1, Content: "Kept"},
+		&models.Log{HiveID: 8, Content: "Orphan"},
+		&models.Log{HiveID: 9, Content: "Orphan"},
+		&models.Task{HiveID: 1, Content: "Kept"},
+		&models.Task{HiveID: 9, Content: "Orphan"},
+		&models.LogWeather{LogID: 2, Provider: "open-meteo"},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	statements := []string{
+		"INSERT INTO log_tags (log_id, tag_id) VALUES (1, 1), (2, 1)",
+		"INSERT INTO task_tags (task_id, tag_id) VALUES (2, 1)",
+		"INSERT INTO task_reminders (task_id, user_id, kind, sent_at) VALUES (2, 1, 'overdue', CURRENT_TIMESTAMP)",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+// count returns the number of rows in each table
+func count(t *testing.T, db *gorm.DB, tables ...string) []int64 {
+	t.Helper()
+	counts := make([]int64, len(tables))
+	for i, table := range tables {
+		if err := db.Table(table).Count(&counts[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return counts
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	for _, mode := range []string{"quick", "full"} {
+		t.Run(mode, func(t *testing.T) {
+			dangling, err := CheckIntegrity(openFile(t, nil), mode)
+			if err != nil || dangling != 0 {
+				t.Errorf("got %d, %v for a new database", dangling, err)
+			}
+
+			// The log and task of hive 9 and the log of hive 8
+			dangling, err = CheckIntegrity(seedOrphans(t), mode)
+			if err != nil || dangling != 3 {
+				t.Errorf("got %d, %v, want 3 rows referencing missing hives", dangling, err)
+			}
+		})
+	}
+	t.Run("off", func(t *testing.T) {
+		if dangling, err := CheckIntegrity(seedOrphans(t), "off"); err != nil || dangling != 0 {
+			t.Errorf("got %d, %v, want nothing checked", dangling, err)
+		}
+	})
+	t.Run("unknown mode", func(t *testing.T) {
+		if _, err := CheckIntegrity(openFile(t, nil), "thorough"); err == nil {
+			t.Error("got no error for an unknown mode")
+		}
+	})
+	t.Run("damaged", func(t *testing.T) {
+		var cfg *config.Config
+		db := openFile(t, func(c *config.Config) {
+			c.SQLiteJournalMode = "DELETE"
+			cfg = c
+		})
+		if err := db.Create(&models.Hive{HiveName: 1}).Error; err != nil {
+			t.Fatal(err)
+		}
+		logs := make([]models.Log, 500)
+		for i := range logs {
+			logs[i] = models.Log{HiveID: 1, Content: "Inspected, all frames checked and brood looks healthy"}
+		}
+		if err := db.CreateInBatches(logs, 100).Error; err != nil {
+			t.Fatal(err)
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sqlDB.Close()
+
+		// Overwrite the last pages, which hold logs
+		info, err := os.Stat(cfg.DBFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		garbage := make([]byte, 2*4096)
+		for i := range garbage {
+			garbage[i] = 0x5a
+		}
+		file, err := os.OpenFile(cfg.DBFile, os.O_WRONLY, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = file.WriteAt(garbage, info.Size()-int64(len(garbage)))
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		damaged, err := Open(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sqlDB, err := damaged.DB(); err == nil {
+			defer sqlDB.Close()
+		}
+		if _, err := CheckIntegrity(damaged, "full"); err == nil {
+			t.Error("got no error for a damaged file")
+		}
+	})
+}
+
+func TestFindOrphans(t *testing.T) {
+	orphans, err := FindOrphans(seedOrphans(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Orphans{
+		{Table: "logs", Rows: 2, HiveIDs: []int{8, 9}},
+		{Table: "tasks", Rows: 1, HiveIDs: []int{9}},
+	}
+	if !slices.EqualFunc(orphans, want, func(a, b Orphans) bool {
+		return a.Table == b.Table && a.Rows == b.Rows && slices.Equal(a.HiveIDs, b.HiveIDs)
+	}) {
+		t.Errorf("got %+v, want %+v", orphans, want)
+	}
+}
+
+func TestRepairOrphans(t *testing.T) {
+	tables := []string{"hives", "logs", "tasks", "log_weathers", "log_tags", "task_tags", "task_reminders"}
+	tests := []struct {
+		name       string
+		deleteRows bool
+		want       []int64
+	}{
+		{"recreate hives", false, []int64{3, 3, 2, 1, 2, 1, 1}},
+		{"delete", true, []int64{1, 1, 1, 0, 1, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := seedOrphans(t)
+			fixed, err := RepairOrphans(db, tt.deleteRows)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(fixed) != 2 || fixed[0].Rows != 2 || fixed[1].Rows != 1 {
+				t.Errorf("got fixed %+v, want 2 logs and 1 task", fixed)
+			}
+			if got := count(t, db, tables...); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v rows in %v, want %v", got, tables, tt.want)
+			}
+
+			// Nothing is left to repair, and the rows of hive 1 are untouched
+			orphans, err := FindOrphans(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, o := range orphans {
+				if o.Rows != 0 {
+					t.Errorf("got %d orphaned %s after repairing", o.Rows, o.Table)
+				}
+			}
+			if dangling, err := CheckIntegrity(db, "quick"); err != nil || dangling != 0 {
+				t.Errorf("got %d, %v from the integrity check after repairing", dangling, err)
+			}
+			var kept []string
+			if err := db.Model(&models.Log{}).Where("hive_id = 1").Pluck("content", &kept).Error; err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(kept, []string{"Kept"}) {
+				t.Errorf("got logs %v of hive 1", kept)
+			}
+		})
+	}
+}
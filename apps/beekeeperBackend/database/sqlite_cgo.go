@@ -0,0 +1,23 @@
+//go:build cgo && !sqlite_purego
+
+package database
+
+import (
+	"net/url"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// SQLiteDriver names the SQLite driver compiled in
+const SQLiteDriver = "mattn/go-sqlite3"
+
+// openSQLite uses the C SQLite library through CGO. The driver sets pragmas
+// given as _<name> DSN parameters on every connection.
+func openSQLite(dsn string, pragmas []pragma) gorm.Dialector {
+	params := url.Values{}
+	for _, p := range pragmas {
+		params.Set("_"+p.name, p.value)
+	}
+	return sqlite.Open(withParams(dsn, params))
+}
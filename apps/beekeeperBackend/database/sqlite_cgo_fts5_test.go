@@ -0,0 +1,8 @@
+//go:build cgo && !sqlite_purego && sqlite_fts5
+
+package database
+
+const wantDriver = "mattn/go-sqlite3"
+
+// withFTS5 is whether the driver was built with full-text search
+const withFTS5 = true
@@ -0,0 +1,9 @@
+//go:build cgo && !sqlite_purego && !sqlite_fts5
+
+package database
+
+const wantDriver = "mattn/go-sqlite3"
+
+// withFTS5 is whether the driver was built with full-text search, which
+// mattn/go-sqlite3 only is with the sqlite_fts5 tag
+const withFTS5 = false
@@ -0,0 +1,26 @@
+//go:build !cgo || sqlite_purego
+
+package database
+
+import (
+	"net/url"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// SQLiteDriver names the SQLite driver compiled in
+const SQLiteDriver = "modernc.org/sqlite"
+
+// openSQLite uses SQLite translated to Go, so the binary builds without a C
+// toolchain and cross-compiles, e.g. for a Raspberry Pi. It is picked with
+// the sqlite_purego build tag or automatically when CGO is disabled. The
+// driver sets pragmas given as _pragma=<name>(<value>) DSN parameters on every
+// connection.
+func openSQLite(dsn string, pragmas []pragma) gorm.Dialector {
+	params := url.Values{}
+	for _, p := range pragmas {
+		params.Add("_pragma", p.name+"("+p.value+")")
+	}
+	return sqlite.Open(withParams(dsn, params))
+}
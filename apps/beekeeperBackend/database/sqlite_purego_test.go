@@ -0,0 +1,9 @@
+//go:build !cgo || sqlite_purego
+
+package database
+
+const wantDriver = "modernc.org/sqlite"
+
+// withFTS5 is whether the driver was built with full-text search, which
+// modernc.org/sqlite always is
+const withFTS5 = true
@@ -0,0 +1,175 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"beekeeper-api/config"
+	"beekeeper-api/migrate"
+	"beekeeper-api/models"
+)
+
+// The SQLite tests run against whichever driver the build tags select, so
+// run them both ways:
+//
+//	go test -tags sqlite_fts5 ./database
+//	go test -tags sqlite_purego ./database
+
+// openFile creates a migrated SQLite database file with the configured
+// pragmas
+func openFile(t *testing.T, configure func(cfg *config.Config)) *gorm.DB {
+	t.Helper()
+	cfg := config.New()
+	cfg.DBDriver = "sqlite"
+	cfg.DBFile = filepath.Join(t.TempDir(), "beekeeper.db")
+	if configure != nil {
+		configure(cfg)
+	}
+	db, err := Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db.Logger = logger.Discard
+
+	migrator, err := migrate.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// pragmaOnEveryConnection reads a pragma on several connections at once, as
+// the driver has to set it on each of them
+func pragmaOnEveryConnection(t *testing.T, db *gorm.DB, name string) []string {
+	t.Helper()
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values []string
+	for range 3 {
+		conn, err := sqlDB.Conn(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		var value string
+		if err := conn.QueryRowContext(context.Background(), "PRAGMA "+name).Scan(&value); err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, strings.ToLower(value))
+	}
+	return values
+}
+
+func TestSQLiteDriver(t *testing.T) {
+	t.Logf("SQLite driver in use: %s", SQLiteDriver)
+	if SQLiteDriver != wantDriver {
+		t.Errorf("got driver %s, want %s for these build tags", SQLiteDriver, wantDriver)
+	}
+}
+
+func TestSQLiteJournalMode(t *testing.T) {
+	for _, mode := range []string{"WAL", "DELETE"} {
+		t.Run(mode, func(t *testing.T) {
+			db := openFile(t, func(cfg *config.Config) { cfg.SQLiteJournalMode = mode })
+			for _, got := range pragmaOnEveryConnection(t, db, "journal_mode") {
+				if got != strings.ToLower(mode) {
+					t.Errorf("got journal mode %s, want %s", got, strings.ToLower(mode))
+				}
+			}
+		})
+	}
+}
+
+func TestSQLiteForeignKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		enforce bool
+		// logs is how many logs are left after deleting their hive
+		logs int64
+	}{
+		{"enforced", true, 0},
+		{"off", false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openFile(t, func(cfg *config.Config) {
+				cfg.SQLiteForeignKeys = tt.enforce
+				cfg.DBMaxOpenConns = 3
+			})
+			want := "0"
+			if tt.enforce {
+				want = "1"
+			}
+			for _, got := range pragmaOnEveryConnection(t, db, "foreign_keys") {
+				if got != want {
+					t.Errorf("got foreign_keys %s, want %s", got, want)
+				}
+			}
+
+			hive := models.Hive{HiveName: 7}
+			if err := db.Create(&hive).Error; err != nil {
+				t.Fatal(err)
+			}
+			logs := []models.Log{{HiveID: 7, Content: "Inspected"}, {HiveID: 7, Content: "Fed"}}
+			if err := db.Create(&logs).Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := db.Delete(&hive).Error; err != nil {
+				t.Fatal(err)
+			}
+			var left int64
+			if err := db.Model(&models.Log{}).Where("hive_id = ?", 7).Count(&left).Error; err != nil {
+				t.Fatal(err)
+			}
+			if left != tt.logs {
+				t.Errorf("got %d logs after deleting their hive, want %d", left, tt.logs)
+			}
+
+			// Logs of missing hives are rejected while enforced
+			err := db.Create(&models.Log{HiveID: 8, Content: "Orphan"}).Error
+			if tt.enforce && err == nil {
+				t.Error("created a log of a missing hive")
+			}
+			if !tt.enforce && err != nil {
+				t.Errorf("creating a log of a missing hive: %v", err)
+			}
+		})
+	}
+}
+
+func TestSQLiteFTS5(t *testing.T) {
+	db := openFile(t, nil)
+	if err := db.Exec("CREATE VIRTUAL TABLE log_search USING fts5(content)").Error; err != nil {
+		if !withFTS5 {
+			t.Skipf("%s is built without FTS5, run the tests with -tags sqlite_fts5: %v", SQLiteDriver, err)
+		}
+		t.Fatalf("creating an FTS5 table with %s: %v", SQLiteDriver, err)
+	}
+	for _, content := range []string{"Queen spotted, brood looks healthy", "Fed 2 litres of syrup", "Swarm cells removed"} {
+		if err := db.Exec("INSERT INTO log_search (content) VALUES (?)", content).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	var found []string
+	if err := db.Raw("SELECT content FROM log_search WHERE log_search MATCH ? ORDER BY rank", "queen OR swarm").Scan(&found).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 {
+		t.Errorf("got %q, want the queen and swarm logs", found)
+	}
+}
@@ -0,0 +1,157 @@
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Event types emitted by the API
+const (
+	HiveCreated   = "hive.created"
+	HiveUpdated   = "hive.updated"
+	HiveDeleted   = "hive.deleted"
+	LogCreated    = "log.created"
+	LogUpdated    = "log.updated"
+	LogDeleted    = "log.deleted"
+	TaskCreated   = "task.created"
+	TaskUpdated   = "task.updated"
+	TaskCompleted = "task.completed"
+	TaskDeleted   = "task.deleted"
+	TaskAssigned  = "task.assigned"
+	AlertRaised   = "alert.raised"
+
+	IncidentReported = "incident.reported"
+	IncidentUpdated  = "incident.updated"
+)
+
+// Types lists every event type, in the order they are documented
+var Types = []string{
+	HiveCreated, HiveUpdated, HiveDeleted,
+	LogCreated, LogUpdated, LogDeleted,
+	TaskCreated, TaskUpdated, TaskCompleted, TaskDeleted, TaskAssigned,
+	AlertRaised,
+	IncidentReported, IncidentUpdated,
+}
+
+// Event describes a change to a hive, log, task, alert or incident
+type Event struct {
+	ID         uint64    `json:"id" example:"1705314600000001"`
+	Type       string    `json:"type" example:"log.created"`
+	HiveID     int       `json:"hive_id" example:"123"`
+	Data       any       `json:"data"`
+	OccurredAt time.Time `json:"occurred_at" example:"2024-01-15T10:30:00Z"`
+}
+
+// Bus fans published events out to every subscriber. Publishing never blocks
+// on subscribers: one that falls behind by more than its buffer is closed, so
+// it can resume from the buffered events with Since.
+// Handlers, which must not miss any, are called before Publish returns. The
+// most recent events are kept in a bounded buffer so clients can resume.
+type Bus struct {
+	mu          sync.Mutex
+	lastID      uint64
+	subscribers map[chan Event]struct{}
+	handlers    []func(Event)
+	history     []Event
+	next        int
+}
+
+// NewBus creates an event bus remembering the last historySize events. Event
+// IDs start from the current time in microseconds so they keep increasing
+// across restarts.
+func NewBus(historySize int) *Bus {
+	return &Bus{
+		lastID:      uint64(time.Now().UnixMicro()),
+		subscribers: make(map[chan Event]struct{}),
+		history:     make([]Event, 0, max(historySize, 1)),
+	}
+}
+
+// Publish assigns the event an ID, delivers it to all subscribers and then
+// calls the handlers with it.
+func (b *Bus) Publish(eventType string, hiveID int, data any) Event {
+	b.mu.Lock()
+
+	b.lastID++
+	event := Event{
+		ID:         b.lastID,
+		Type:       eventType,
+		HiveID:     hiveID,
+		Data:       data,
+		OccurredAt: time.Now().UTC(),
+	}
+
+	if len(b.history) < cap(b.history) {
+		b.history = append(b.history, event)
+	} else {
+		b.history[b.next] = event
+		b.next = (b.next + 1) % len(b.history)
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("Event subscriber fell behind, closing it at %s event %d", event.Type, event.ID)
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+	handlers := b.handlers
+	b.mu.Unlock()
+
+	// Handlers run without the lock, so they may publish events themselves
+	for _, handle := range handlers {
+		handle(event)
+	}
+	return event
+}
+
+// Handle registers a function that is called with every event published from
+// now on, in the publisher's goroutine. Unlike subscribers, handlers never
+// miss an event, but they hold up the request that published it: they should
+// only do quick work such as keeping the event for a goroutine of their own,
+// never database queries or network calls.
+func (b *Bus) Handle(handler func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Since returns the buffered events published after the event with the given
+// ID, oldest first. Events that no longer fit in the buffer are not returned.
+func (b *Bus) Since(id uint64) []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var missed []Event
+	for i := range b.history {
+		event := b.history[(b.next+i)%len(b.history)]
+		if event.ID > id {
+			missed = append(missed, event)
+		}
+	}
+	return missed
+}
+
+// Subscribe returns a channel receiving every event published from now on and
+// a function that cancels the subscription and closes the channel. The
+// channel is closed as well once more than buffer events wait in it.
+func (b *Bus) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	cancel := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+	return ch, cancel
+}
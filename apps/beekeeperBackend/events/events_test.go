@@ -0,0 +1,45 @@
+package events
+
+import "testing"
+
+// TestSubscriberFallsBehind fills a subscriber's buffer and expects it to be
+// closed on the next event, which stays available from the buffer
+func TestSubscriberFallsBehind(t *testing.T) {
+	bus := NewBus(10)
+	slow, cancelSlow := bus.Subscribe(2)
+	defer cancelSlow()
+	fast, cancelFast := bus.Subscribe(10)
+	defer cancelFast()
+
+	var published []Event
+	for range 3 {
+		published = append(published, bus.Publish(LogCreated, 1, nil))
+	}
+
+	for _, want := range published[:2] {
+		if event, ok := <-slow; !ok || event.ID != want.ID {
+			t.Fatalf("got event %d (open %v), want %d", event.ID, ok, want.ID)
+		}
+	}
+	select {
+	case event, ok := <-slow:
+		if ok {
+			t.Fatalf("got event %d, want the subscriber closed", event.ID)
+		}
+	default:
+		t.Fatal("got the subscriber open, want it closed")
+	}
+	if missed := bus.Since(published[1].ID); len(missed) != 1 || missed[0].ID != published[2].ID {
+		t.Errorf("got %v, want the dropped event %d to resume from", missed, published[2].ID)
+	}
+	if len(fast) != 3 {
+		t.Errorf("got %d events for the subscriber keeping up, want 3", len(fast))
+	}
+
+	// Cancelling a closed subscription is a no-op
+	cancelSlow()
+	bus.Publish(LogCreated, 1, nil)
+	if len(fast) != 4 {
+		t.Errorf("got %d events for the subscriber keeping up, want 4", len(fast))
+	}
+}
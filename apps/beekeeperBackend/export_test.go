@@ -0,0 +1,223 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"beekeeper-api/features/export"
+	"beekeeper-api/models"
+)
+
+func TestExport(t *testing.T) {
+	// createJournal writes two logs, one in January and one in March, and a
+	// task for hives 1 and 2
+	createJournal := func(s *testServer) {
+		s.do(http.MethodPost, "/api/logs", map[string]any{"hiveID": 1, "content": `Inspected, "calm"`}, http.StatusCreated, nil)
+		s.do(http.MethodPost, "/api/logs", map[string]any{"hiveID": 2, "content": "Swarm cells"}, http.StatusCreated, nil)
+		s.do(http.MethodPost, "/api/tasks", map[string]any{"hiveID": 1, "content": "Add super"}, http.StatusCreated, nil)
+		for id, at := range map[int]time.Time{
+			1: time.Date(2024, 1, 10, 15, 0, 0, 0, time.Local),
+			2: time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local),
+		} {
+			if err := s.db.Model(&models.Log{}).Where("id = ?", id).Update("created_at", at).Error; err != nil {
+				s.t.Fatal(err)
+			}
+		}
+	}
+	// contents returns the hive names and the contents of the logs and tasks
+	// of a JSON export
+	contents := func(t *testing.T, body []byte) ([]int, []string, []string) {
+		t.Helper()
+		document := decode[export.Document](t, body)
+		hives, logs, tasks := []int{}, []string{}, []string{}
+		for _, hive := range document.Hives {
+			hives = append(hives, hive.HiveName)
+		}
+		for _, entry := range document.Logs {
+			logs = append(logs, entry.Content)
+		}
+		for _, task := range document.Tasks {
+			tasks = append(tasks, task.Content)
+		}
+		return hives, logs, tasks
+	}
+
+	runCases(t, []apiCase{
+		{
+			name:   "json",
+			setup:  createJournal,
+			method: http.MethodGet, path: "/api/export",
+			status: http.StatusOK,
+			dbOnly: true,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				hives, logs, tasks := contents(t, body)
+				if !reflect.DeepEqual(hives, []int{1, 2}) || !reflect.DeepEqual(logs, []string{`Inspected, "calm"`, "Swarm cells"}) || !reflect.DeepEqual(tasks, []string{"Add super"}) {
+					t.Errorf("got hives %v, logs %v and tasks %v", hives, logs, tasks)
+				}
+			},
+		},
+		{
+			name:   "json of an empty journal",
+			method: http.MethodGet, path: "/api/export?format=json",
+			status: http.StatusOK,
+			dbOnly: true,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				var document map[string]json.RawMessage
+				if err := json.Unmarshal(body, &document); err != nil {
+					t.Fatal(err)
+				}
+				for _, key := range []string{"hives", "logs", "tasks"} {
+					if string(document[key]) != "[]" {
+						t.Errorf("got %s %s, want []", key, document[key])
+					}
+				}
+			},
+		},
+		{
+			name:   "one hive",
+			setup:  createJournal,
+			method: http.MethodGet, path: "/api/export?hive_id=2",
+			status: http.StatusOK,
+			dbOnly: true,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				hives, logs, tasks := contents(t, body)
+				if !reflect.DeepEqual(hives, []int{2}) || !reflect.DeepEqual(logs, []string{"Swarm cells"}) || len(tasks) != 0 {
+					t.Errorf("got hives %v, logs %v and tasks %v, want only hive 2", hives, logs, tasks)
+				}
+			},
+		},
+		{
+			name:   "from a date",
+			setup:  createJournal,
+			method: http.MethodGet, path: "/api/export?from=2024-02-01",
+			status: http.StatusOK,
+			dbOnly: true,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				// The task was created today
+				if _, logs, tasks := contents(t, body); !reflect.DeepEqual(logs, []string{"Swarm cells"}) || len(tasks) != 1 {
+					t.Errorf("got logs %v and tasks %v", logs, tasks)
+				}
+			},
+		},
+		{
+			name:   "up to a whole day",
+			setup:  createJournal,
+			method: http.MethodGet, path: "/api/export?to=2024-01-10",
+			status: http.StatusOK,
+			dbOnly: true,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				if _, logs, tasks := contents(t, body); !reflect.DeepEqual(logs, []string{`Inspected, "calm"`}) || len(tasks) != 0 {
+					t.Errorf("got logs %v and tasks %v, want the January log", logs, tasks)
+				}
+			},
+		},
+		{
+			name:   "up to a timestamp",
+			setup:  createJournal,
+			method: http.MethodGet, path: "/api/export?to=" + time.Date(2024, 1, 10, 14, 0, 0, 0, time.Local).Format(time.RFC3339),
+			status: http.StatusOK,
+			dbOnly: true,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				if _, logs, _ := contents(t, body); len(logs) != 0 {
+					t.Errorf("got logs %v, want none before 14:00", logs)
+				}
+			},
+		},
+		{
+			name:   "ndjson",
+			setup:  createJournal,
+			method: http.MethodGet, path: "/api/export?format=ndjson",
+			status: http.StatusOK,
+			dbOnly: true,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				var types []string
+				for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+					var item struct {
+						Type string          `json:"type"`
+						Data json.RawMessage `json:"data"`
+					}
+					if err := json.Unmarshal([]byte(line), &item); err != nil {
+						t.Fatalf("decoding line %s: %v", line, err)
+					}
+					types = append(types, item.Type)
+				}
+				if want := []string{"hive", "hive", "log", "log", "task"}; !reflect.DeepEqual(types, want) {
+					t.Errorf("got lines of %v, want %v", types, want)
+				}
+			},
+		},
+		{
+			name:   "csv",
+			setup:  createJournal,
+			method: http.MethodGet, path: "/api/export?format=csv&hive_id=1",
+			status: http.StatusOK,
+			dbOnly: true,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				files := map[string][][]string{}
+				var names []string
+				for _, file := range archive.File {
+					r, err := file.Open()
+					if err != nil {
+						t.Fatal(err)
+					}
+					data, err := io.ReadAll(r)
+					r.Close()
+					if err != nil {
+						t.Fatal(err)
+					}
+					records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+					if err != nil {
+						t.Fatalf("%s: %v", file.Name, err)
+					}
+					names = append(names, file.Name)
+					files[file.Name] = records
+				}
+				if want := []string{"hives.csv", "logs.csv", "tasks.csv"}; !reflect.DeepEqual(names, want) {
+					t.Fatalf("got files %v, want %v", names, want)
+				}
+				if hives := files["hives.csv"]; len(hives) != 2 || hives[1][1] != "1" {
+					t.Errorf("got hives %v", hives)
+				}
+				if logs := files["logs.csv"]; len(logs) != 2 || logs[0][2] != "content" || logs[1][2] != `Inspected, "calm"` || logs[1][3] != time.Date(2024, 1, 10, 15, 0, 0, 0, time.Local).UTC().Format(time.RFC3339Nano) {
+					t.Errorf("got logs %v", logs)
+				}
+				if tasks := files["tasks.csv"]; len(tasks) != 2 || tasks[1][2] != "Add super" || tasks[1][3] != models.PriorityNormal || tasks[1][5] != "" {
+					t.Errorf("got tasks %v", tasks)
+				}
+			},
+		},
+		{
+			name:   "unknown format",
+			method: http.MethodGet, path: "/api/export?format=xlsx",
+			status: http.StatusBadRequest,
+			dbOnly: true,
+			check:  hasError("Unsupported format, use csv, json or ndjson"),
+		},
+		{
+			name:   "invalid hive",
+			method: http.MethodGet, path: "/api/export?hive_id=one",
+			status: http.StatusBadRequest,
+			dbOnly: true,
+			check:  hasError(`invalid hive_id "one"`),
+		},
+		{
+			name:   "invalid date",
+			method: http.MethodGet, path: "/api/export?from=10.01.2024",
+			status: http.StatusBadRequest,
+			dbOnly: true,
+			check:  hasError(`invalid date "10.01.2024", use YYYY-MM-DD or RFC 3339`),
+		},
+	})
+}
@@ -0,0 +1,113 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"beekeeper-api/access"
+	"beekeeper-api/backup"
+)
+
+// --- Route Registration ---
+
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, backups *backup.Manager) {
+	h := &handler{backups: backups}
+	guard := access.New(db)
+	manage := guard.Require(access.BackupsManage, access.Global)
+
+	adminRoutes := router.Group("/admin")
+	{
+		adminRoutes.POST("/backups", manage, h.CreateBackup)
+		adminRoutes.GET("/backups", manage, h.ListBackups)
+		adminRoutes.GET("/backups/:name", manage, h.DownloadBackup)
+	}
+}
+
+// --- Handler ---
+
+type handler struct {
+	backups *backup.Manager
+}
+
+// CreateBackup godoc
+// @Summary Back up the database
+// @Description Write a consistent snapshot of the SQLite database while the API keeps running. The oldest snapshots beyond BACKUP_RETENTION are deleted.
+// @Tags admin
+// @Produce  json
+// @Security BearerAuth
+// @Success 201 {object} backup.Backup
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Router /admin/backups [post]
+func (h *handler) CreateBackup(c *gin.Context) {
+	created, err := h.backups.Create()
+	if errors.Is(err, backup.ErrUnsupported) {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Backups are only supported for SQLite"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create backup"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// ListBackups godoc
+// @Summary List backups
+// @Description List the database snapshots, newest first
+// @Tags admin
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {array} backup.Backup
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Router /admin/backups [get]
+func (h *handler) ListBackups(c *gin.Context) {
+	backups, err := h.backups.List()
+	if errors.Is(err, backup.ErrUnsupported) {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Backups are only supported for SQLite"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve backups"})
+		return
+	}
+
+	c.JSON(http.StatusOK, backups)
+}
+
+// DownloadBackup godoc
+// @Summary Download a backup
+// @Description Download a database snapshot, which can be restored with the restore command
+// @Tags admin
+// @Produce  application/vnd.sqlite3
+// @Security BearerAuth
+// @Param name path string true "Backup name"
+// @Success 200 {file} file
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Router /admin/backups/{name} [get]
+func (h *handler) DownloadBackup(c *gin.Context) {
+	path, err := h.backups.Path(c.Param("name"))
+	if errors.Is(err, backup.ErrUnsupported) {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Backups are only supported for SQLite"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Backup not found"})
+		return
+	}
+
+	c.Header("Content-Type", "application/vnd.sqlite3")
+	c.FileAttachment(path, c.Param("name"))
+}
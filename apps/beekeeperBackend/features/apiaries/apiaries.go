@@ -0,0 +1,500 @@
+package apiaries
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"beekeeper-api/access"
+	"beekeeper-api/auth"
+	"beekeeper-api/models"
+)
+
+const earthRadiusKm = 6371.0
+
+var errApiaryHasHives = errors.New("apiary has hives")
+
+// --- Structs for Input Validation ---
+
+type CreateApiaryInput struct {
+	Name               string       `json:"name" binding:"required" example:"Orchard"`
+	RegistrationNumber string       `json:"registrationNumber" example:"276 09 162 0001"`
+	Address            string       `json:"address" example:"Am Obstgarten 3, 85354 Freising"`
+	Latitude           *float64     `json:"latitude" binding:"required,min=-90,max=90" example:"48.4029"`
+	Longitude          *float64     `json:"longitude" binding:"required,min=-180,max=180" example:"11.7489"`
+	Boundary           [][2]float64 `json:"boundary"`
+	Notes              string       `json:"notes" example:"Access via the gate on the north side"`
+	Timezone           string       `json:"timezone" example:"Europe/Berlin"`
+	MembersOnly        bool         `json:"membersOnly" example:"false"`
+}
+
+type UpdateApiaryInput struct {
+	Name               string       `json:"name" example:"Orchard"`
+	RegistrationNumber *string      `json:"registrationNumber" example:"276 09 162 0001"`
+	Address            *string      `json:"address" example:"Am Obstgarten 3, 85354 Freising"`
+	Latitude           *float64     `json:"latitude" binding:"omitempty,min=-90,max=90" example:"48.4029"`
+	Longitude          *float64     `json:"longitude" binding:"omitempty,min=-180,max=180" example:"11.7489"`
+	Boundary           [][2]float64 `json:"boundary"`
+	Notes              *string      `json:"notes" example:"Access via the gate on the north side"`
+	Timezone           *string      `json:"timezone" example:"Europe/Berlin"`
+	MembersOnly        *bool        `json:"membersOnly" example:"true"`
+}
+
+// NearbyApiary is an apiary with its distance from the searched point
+type NearbyApiary struct {
+	models.Apiary
+	DistanceKm float64 `json:"distance_km" example:"2.41"`
+}
+
+// FeatureCollection is a GeoJSON (RFC 7946) feature collection
+type FeatureCollection struct {
+	Type     string    `json:"type" example:"FeatureCollection"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a GeoJSON feature
+type Feature struct {
+	Type       string         `json:"type" example:"Feature"`
+	ID         string         `json:"id" example:"apiary-1"`
+	Geometry   Geometry       `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+// Geometry is a GeoJSON Point or Polygon
+type Geometry struct {
+	Type string `json:"type" example:"Point"`
+	// [longitude, latitude] for a Point, a list of closed rings of
+	// [longitude, latitude] for a Polygon
+	Coordinates any `json:"coordinates"`
+}
+
+// --- Route Registration ---
+
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
+	h := &handler{db: db}
+	guard := access.New(db)
+
+	apiaryRoutes := router.Group("/apiaries")
+	{
+		apiaryRoutes.POST("", guard.Require(access.HivesWrite, access.Global), h.CreateApiary)
+		apiaryRoutes.GET("", guard.Require(access.HivesRead, access.Anywhere), h.ListApiaries)
+		apiaryRoutes.GET("/nearby", guard.Require(access.HivesRead, access.Anywhere), h.NearbyApiaries)
+		apiaryRoutes.GET("/:id", guard.Require(access.HivesRead, access.Apiary("id")), h.GetApiary)
+		apiaryRoutes.PATCH("/:id", guard.Require(access.HivesWrite, access.Apiary("id")), h.UpdateApiary)
+		apiaryRoutes.DELETE("/:id", guard.Require(access.HivesDelete, access.Apiary("id")), h.DeleteApiary)
+	}
+	router.GET("/apiaries.geojson", guard.Require(access.HivesRead, access.Anywhere), h.ExportGeoJSON)
+}
+
+// normalizeBoundary checks a boundary ring, drops repeated consecutive points
+// and closes it if the last point does not repeat the first.
+func normalizeBoundary(boundary [][2]float64) ([][2]float64, error) {
+	if len(boundary) == 0 {
+		return nil, nil
+	}
+	ring := make([][2]float64, 0, len(boundary)+1)
+	distinct := make(map[[2]float64]bool)
+	for _, point := range boundary {
+		if point[0] < -180 || point[0] > 180 || point[1] < -90 || point[1] > 90 {
+			return nil, errors.New("boundary points must be [longitude, latitude] pairs")
+		}
+		if len(ring) > 0 && ring[len(ring)-1] == point {
+			continue
+		}
+		ring = append(ring, point)
+		distinct[point] = true
+	}
+	if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+		ring = ring[:len(ring)-1]
+	}
+	if len(distinct) < 3 {
+		return nil, errors.New("boundary needs at least three distinct points")
+	}
+	// Twice the area by the shoelace formula; points on a line enclose none
+	var area float64
+	for i, point := range ring {
+		next := ring[(i+1)%len(ring)]
+		area += point[0]*next[1] - next[0]*point[1]
+	}
+	if math.Abs(area) < 1e-12 {
+		return nil, errors.New("boundary must enclose an area")
+	}
+	return append(ring, ring[0]), nil
+}
+
+// distanceKm returns the great-circle distance between two coordinates using
+// the haversine formula.
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// --- Handler ---
+
+type handler struct {
+	db *gorm.DB
+}
+
+// visible restricts a query on apiaries to those the current user can read.
+// Anonymous requests are not restricted.
+func (h *handler) visible(c *gin.Context, query *gorm.DB) *gorm.DB {
+	user, ok := auth.CurrentUser(c)
+	if !ok {
+		return query
+	}
+	return query.Where("id IN (?)", access.Apiaries(h.db, user.ID, access.HivesRead))
+}
+
+// CreateApiary godoc
+// @Summary Create a new apiary
+// @Description Create an apiary location. The optional boundary is a list of [longitude, latitude] points and is closed automatically. The optional IANA time zone sets when the day starts and ends for anomaly detection; without it ANOMALY_TIMEZONE applies. Only users who manage teams can make an apiary members only, which limits its hives to the members of its team and global owners.
+// @Tags apiaries
+// @Accept  json
+// @Produce  json
+// @Param apiary body CreateApiaryInput true "Apiary data"
+// @Success 201 {object} models.Apiary
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apiaries [post]
+func (h *handler) CreateApiary(c *gin.Context) {
+	var input CreateApiaryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	boundary, err := normalizeBoundary(input.Boundary)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := time.LoadLocation(input.Timezone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown timezone"})
+		return
+	}
+	if user, ok := auth.CurrentUser(c); ok && input.MembersOnly && !access.Can(user.Role, access.TeamManage) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only users who manage teams can make an apiary members only"})
+		return
+	}
+
+	apiary := models.Apiary{
+		Name:               input.Name,
+		RegistrationNumber: input.RegistrationNumber,
+		Address:            input.Address,
+		Latitude:           *input.Latitude,
+		Longitude:          *input.Longitude,
+		Boundary:           boundary,
+		Notes:              input.Notes,
+		Timezone:           input.Timezone,
+		MembersOnly:        input.MembersOnly,
+	}
+	if result := h.db.Create(&apiary); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create apiary"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, apiary)
+}
+
+// ListApiaries godoc
+// @Summary List all apiaries
+// @Description Get a list of all apiaries the user can read
+// @Tags apiaries
+// @Produce  json
+// @Success 200 {array} models.Apiary
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apiaries [get]
+func (h *handler) ListApiaries(c *gin.Context) {
+	var apiaries []models.Apiary
+	if result := h.visible(c, h.db.Order("name")).Find(&apiaries); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve apiaries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, apiaries)
+}
+
+// NearbyApiaries godoc
+// @Summary Find apiaries near a point
+// @Description List the apiaries the user can read within radius_km of the given coordinates, closest first
+// @Tags apiaries
+// @Produce  json
+// @Param lat query number true "Latitude"
+// @Param lon query number true "Longitude"
+// @Param radius_km query number false "Search radius in kilometres (default 10)"
+// @Success 200 {array} NearbyApiary
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apiaries/nearby [get]
+func (h *handler) NearbyApiaries(c *gin.Context) {
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lon, errLon := strconv.ParseFloat(c.Query("lon"), 64)
+	if errLat != nil || errLon != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lon must be valid coordinates"})
+		return
+	}
+	radius, err := strconv.ParseFloat(c.DefaultQuery("radius_km", "10"), 64)
+	if err != nil || radius <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "radius_km must be a positive number"})
+		return
+	}
+
+	// One degree of latitude is roughly 111 km everywhere, which makes for a
+	// cheap pre-filter before computing exact distances.
+	latDelta := radius / 111.0
+	var candidates []models.Apiary
+	result := h.visible(c, h.db.Where("latitude BETWEEN ? AND ?", lat-latDelta, lat+latDelta)).Find(&candidates)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve apiaries"})
+		return
+	}
+
+	nearby := []NearbyApiary{}
+	for _, apiary := range candidates {
+		distance := distanceKm(lat, lon, apiary.Latitude, apiary.Longitude)
+		if distance <= radius {
+			nearby = append(nearby, NearbyApiary{Apiary: apiary, DistanceKm: math.Round(distance*100) / 100})
+		}
+	}
+	sort.Slice(nearby, func(i, j int) bool { return nearby[i].DistanceKm < nearby[j].DistanceKm })
+
+	c.JSON(http.StatusOK, nearby)
+}
+
+// GetApiary godoc
+// @Summary Get an apiary by ID
+// @Description Retrieve an apiary together with its hives
+// @Tags apiaries
+// @Produce  json
+// @Param id path int true "Apiary ID"
+// @Success 200 {object} models.Apiary
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /apiaries/{id} [get]
+func (h *handler) GetApiary(c *gin.Context) {
+	id := c.Param("id")
+	var apiary models.Apiary
+
+	if result := h.db.Preload("Hives").First(&apiary, id); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Apiary not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, apiary)
+}
+
+// UpdateApiary godoc
+// @Summary Update an apiary
+// @Description Update an apiary's details, location, boundary or time zone. An empty boundary list removes the boundary, an empty time zone reverts to ANOMALY_TIMEZONE. Only users who manage the apiary's team can change whether it is members only.
+// @Tags apiaries
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Apiary ID"
+// @Param apiary body UpdateApiaryInput true "Apiary update data"
+// @Success 200 {object} models.Apiary
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apiaries/{id} [patch]
+func (h *handler) UpdateApiary(c *gin.Context) {
+	id := c.Param("id")
+	var apiary models.Apiary
+
+	if result := h.db.First(&apiary, id); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Apiary not found"})
+		return
+	}
+
+	var input UpdateApiaryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if input.Name != "" {
+		apiary.Name = input.Name
+	}
+	if input.RegistrationNumber != nil {
+		apiary.RegistrationNumber = *input.RegistrationNumber
+	}
+	if input.Address != nil {
+		apiary.Address = *input.Address
+	}
+	if input.Latitude != nil {
+		apiary.Latitude = *input.Latitude
+	}
+	if input.Longitude != nil {
+		apiary.Longitude = *input.Longitude
+	}
+	if input.Notes != nil {
+		apiary.Notes = *input.Notes
+	}
+	if input.Timezone != nil {
+		if _, err := time.LoadLocation(*input.Timezone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown timezone"})
+			return
+		}
+		apiary.Timezone = *input.Timezone
+	}
+	if input.Boundary != nil {
+		boundary, err := normalizeBoundary(input.Boundary)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		apiary.Boundary = boundary
+	}
+	if input.MembersOnly != nil && *input.MembersOnly != apiary.MembersOnly {
+		if user, ok := auth.CurrentUser(c); ok {
+			role, err := access.ApiaryRole(h.db, user, apiary.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+				return
+			}
+			if !access.Can(role, access.TeamManage) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Only users who manage the team can change whether the apiary is members only"})
+				return
+			}
+		}
+		apiary.MembersOnly = *input.MembersOnly
+	}
+
+	if result := h.db.Save(&apiary); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+
+	c.JSON(http.StatusOK, apiary)
+}
+
+// DeleteApiary godoc
+// @Summary Delete an apiary
+// @Description Delete an apiary by ID together with its team and invitations. Apiaries that still have hives are not deleted; move the hives to another apiary or out of apiaries first.
+// @Tags apiaries
+// @Produce  json
+// @Param id path int true "Apiary ID"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apiaries/{id} [delete]
+func (h *handler) DeleteApiary(c *gin.Context) {
+	id := c.Param("id")
+	var apiary models.Apiary
+
+	if result := h.db.First(&apiary, id); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Apiary not found"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var hives int64
+		if err := tx.Model(&models.Hive{}).Where("apiary_id = ?", apiary.ID).Count(&hives).Error; err != nil {
+			return err
+		}
+		if hives > 0 {
+			return errApiaryHasHives
+		}
+		return tx.Delete(&apiary).Error
+	})
+	if errors.Is(err, errApiaryHasHives) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Apiary still has hives, move them to another apiary or out of apiaries first"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete apiary"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ExportGeoJSON godoc
+// @Summary Export apiaries as GeoJSON
+// @Description Export all apiaries the user can read as a GeoJSON FeatureCollection for map views and registration with veterinary authorities. Every apiary is a Point feature with its hive count and last inspection date; apiaries with a boundary get an additional Polygon feature.
+// @Tags apiaries
+// @Produce  json
+// @Success 200 {object} FeatureCollection
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apiaries.geojson [get]
+func (h *handler) ExportGeoJSON(c *gin.Context) {
+	var apiaries []models.Apiary
+	if result := h.visible(c, h.db.Preload("Hives").Order("name")).Find(&apiaries); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve apiaries"})
+		return
+	}
+
+	collection := FeatureCollection{Type: "FeatureCollection", Features: []Feature{}}
+	for _, apiary := range apiaries {
+		hiveNames := make([]int, len(apiary.Hives))
+		for i, hive := range apiary.Hives {
+			hiveNames[i] = hive.HiveName
+		}
+
+		var lastInspection *time.Time
+		if len(hiveNames) > 0 {
+			var latest []time.Time
+			result := h.db.Model(&models.Log{}).
+				Where("hive_id IN ?", hiveNames).
+				Order("created_at DESC").
+				Limit(1).
+				Pluck("created_at", &latest)
+			if result.Error != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve inspections"})
+				return
+			}
+			if len(latest) > 0 {
+				lastInspection = &latest[0]
+			}
+		}
+
+		properties := map[string]any{
+			"apiary_id":           apiary.ID,
+			"name":                apiary.Name,
+			"registration_number": apiary.RegistrationNumber,
+			"address":             apiary.Address,
+			"hive_count":          len(hiveNames),
+			"hives":               hiveNames,
+			"last_inspection_at":  lastInspection,
+		}
+		collection.Features = append(collection.Features, Feature{
+			Type:       "Feature",
+			ID:         "apiary-" + strconv.FormatUint(uint64(apiary.ID), 10),
+			Geometry:   Geometry{Type: "Point", Coordinates: [2]float64{apiary.Longitude, apiary.Latitude}},
+			Properties: properties,
+		})
+		if len(apiary.Boundary) > 0 {
+			collection.Features = append(collection.Features, Feature{
+				Type:       "Feature",
+				ID:         "apiary-" + strconv.FormatUint(uint64(apiary.ID), 10) + "-boundary",
+				Geometry:   Geometry{Type: "Polygon", Coordinates: [][][2]float64{apiary.Boundary}},
+				Properties: map[string]any{"apiary_id": apiary.ID, "name": apiary.Name},
+			})
+		}
+	}
+
+	c.Header("Content-Type", "application/geo+json")
+	c.JSON(http.StatusOK, collection)
+}
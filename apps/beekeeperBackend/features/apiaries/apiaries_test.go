@@ -0,0 +1,68 @@
+package apiaries
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestDistanceKm(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		km                     float64
+	}{
+		{"same point", 48.4, 11.7, 48.4, 11.7, 0},
+		{"one degree along the equator", 0, 0, 0, 1, 111.195},
+		{"equator to pole", 0, 0, 90, 0, 10007.543},
+		{"antipodes", 0, 0, 0, 180, 20015.087},
+		{"Munich to Berlin", 48.1372, 11.5756, 52.52, 13.405, 504.305},
+		{"Berlin to Munich", 52.52, 13.405, 48.1372, 11.5756, 504.305},
+		{"across the date line", 0, 179.5, 0, -179.5, 111.195},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if km := distanceKm(tt.lat1, tt.lon1, tt.lat2, tt.lon2); math.Abs(km-tt.km) > 0.001 {
+				t.Errorf("got %.3f km, want %.3f", km, tt.km)
+			}
+		})
+	}
+}
+
+func TestNormalizeBoundary(t *testing.T) {
+	square := [][2]float64{{11.7, 48.4}, {11.71, 48.4}, {11.71, 48.41}, {11.7, 48.41}, {11.7, 48.4}}
+	tests := []struct {
+		name     string
+		boundary [][2]float64
+		want     [][2]float64
+		err      string
+	}{
+		{"none", nil, nil, ""},
+		{"closed", square, square, ""},
+		{"closed automatically", square[:4], square, ""},
+		{"repeated points dropped", [][2]float64{{11.7, 48.4}, {11.7, 48.4}, {11.71, 48.4}, {11.71, 48.41}, {11.71, 48.41}, {11.7, 48.41}}, square, ""},
+		{"latitude first", [][2]float64{{48.4, 11.7}, {48.4, 181}, {48.41, 11.7}}, nil, "boundary points must be [longitude, latitude] pairs"},
+		{"two points", [][2]float64{{11.7, 48.4}, {11.71, 48.4}}, nil, "boundary needs at least three distinct points"},
+		{"two points repeated", [][2]float64{{11.7, 48.4}, {11.7, 48.4}, {11.71, 48.4}, {11.7, 48.4}}, nil, "boundary needs at least three distinct points"},
+		{"back and forth", [][2]float64{{11.7, 48.4}, {11.71, 48.4}, {11.7, 48.4}, {11.71, 48.4}}, nil, "boundary needs at least three distinct points"},
+		{"on a line", [][2]float64{{11.7, 48.4}, {11.71, 48.4}, {11.72, 48.4}}, nil, "boundary must enclose an area"},
+		{"on a diagonal", [][2]float64{{0, 0}, {1, 1}, {2, 2}, {0, 0}}, nil, "boundary must enclose an area"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeBoundary(tt.boundary)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("got %v, %v, want error %q", got, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
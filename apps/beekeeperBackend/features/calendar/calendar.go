@@ -0,0 +1,422 @@
+package calendar
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"beekeeper-api/access"
+	"beekeeper-api/auth"
+	"beekeeper-api/events"
+	"beekeeper-api/models"
+	"beekeeper-api/services"
+	"beekeeper-api/tagging"
+)
+
+// ImportResult summarises an iCalendar import
+type ImportResult struct {
+	Created int          `json:"created" example:"3"`
+	Updated int          `json:"updated" example:"1"`
+	Skipped []SkippedRow `json:"skipped"`
+}
+
+// SkippedRow is an iCalendar entry that could not be imported
+type SkippedRow struct {
+	UID    string `json:"uid" example:"0b9e3c1a@example.com"`
+	Reason string `json:"reason" example:"no hive number in summary"`
+}
+
+// --- Route Registration ---
+
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, bus *events.Bus) {
+	h := &handler{db: db, bus: bus}
+	guard := access.New(db)
+
+	// The feed authenticates with the calendar token in the URL instead
+	router.GET("/tasks/calendar.ics", h.GetCalendar)
+	router.POST("/tasks/calendar.ics", guard.Require(access.TasksWrite, access.Anywhere), h.ImportCalendar)
+}
+
+// --- Handler ---
+
+type handler struct {
+	db  *gorm.DB
+	bus *events.Bus
+}
+
+// taskUID identifies a task in the feed; events get their own UID so
+// clients subscribed with type=both do not merge the event and the to-do.
+func taskUID(task models.Task, component string) string {
+	if component == "VEVENT" {
+		return fmt.Sprintf("task-%d-event@beekeeper", task.ID)
+	}
+	return fmt.Sprintf("task-%d@beekeeper", task.ID)
+}
+
+// icsPriority maps task priorities to iCalendar's 1 (highest) to 9 (lowest)
+func icsPriority(priority string) string {
+	switch priority {
+	case models.PriorityHigh:
+		return "1"
+	case models.PriorityLow:
+		return "9"
+	}
+	return "5"
+}
+
+func taskPriority(value string) string {
+	p, _ := strconv.Atoi(value)
+	switch {
+	case p >= 1 && p <= 4:
+		return models.PriorityHigh
+	case p >= 6:
+		return models.PriorityLow
+	}
+	return models.PriorityNormal
+}
+
+// GetCalendar godoc
+// @Summary Task calendar feed
+// @Description iCalendar feed of tasks for subscribing from phone and desktop calendars. Tasks with a due date are rendered as events; type=todo renders every task as a to-do instead, type=both renders both. The summary starts with the hive number. Authenticate with the user's calendar token in the URL, since calendar apps cannot send headers; the feed only has tasks of hives the user can read tasks of.
+// @Tags tasks
+// @Produce text/calendar
+// @Param token query string true "Calendar token of the user"
+// @Param type query string false "Render tasks as event, todo or both" default(event)
+// @Param hive_id query int false "Only tasks for this hive"
+// @Param include_completed query bool false "Also include completed tasks as events"
+// @Success 200 {string} string "iCalendar document"
+// @Success 304
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /tasks/calendar.ics [get]
+func (h *handler) GetCalendar(c *gin.Context) {
+	var user models.User
+	token := c.Query("token")
+	if token == "" || h.db.First(&user, "calendar_token_hash = ?", auth.HashToken(token)).Error != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid calendar token"})
+		return
+	}
+
+	kind := c.DefaultQuery("type", "event")
+	if kind != "event" && kind != "todo" && kind != "both" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be event, todo or both"})
+		return
+	}
+	includeCompleted, _ := strconv.ParseBool(c.Query("include_completed"))
+
+	query := h.db.Where("hive_id IN (?)", access.Hives(h.db, user.ID, access.TasksRead)).Order("due_at, id")
+	if hiveID := c.Query("hive_id"); hiveID != "" {
+		id, err := strconv.Atoi(hiveID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hive_id"})
+			return
+		}
+		query = query.Where("hive_id = ?", id)
+	}
+	if kind == "event" {
+		query = query.Where("due_at IS NOT NULL")
+	}
+
+	var tasks []models.Task
+	if result := query.Find(&tasks); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks"})
+		return
+	}
+
+	w := &icsWriter{}
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", "-//Beekeeper//Beekeeper API//EN")
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	w.text("X-WR-CALNAME", "Beekeeper tasks")
+	w.line("REFRESH-INTERVAL;VALUE=DURATION", "PT1H")
+	w.line("X-PUBLISHED-TTL", "PT1H")
+	for _, task := range tasks {
+		if kind != "todo" && task.DueAt != nil && (task.CompletedAt == nil || includeCompleted) {
+			writeEvent(w, task)
+		}
+		if kind != "event" {
+			writeTodo(w, task)
+		}
+	}
+	w.line("END", "VCALENDAR")
+
+	// Calendar apps poll the feed, so let them skip unchanged downloads
+	body := w.String()
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256([]byte(body)))
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, max-age=300")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="beekeeper-tasks.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(body))
+}
+
+func summary(task models.Task) string {
+	return fmt.Sprintf("Hive %d: %s", task.HiveID, firstLine(task.Content))
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+func writeCommon(w *icsWriter, task models.Task, component string) {
+	w.line("BEGIN", component)
+	w.line("UID", taskUID(task, component))
+	w.utc("DTSTAMP", task.UpdatedAt)
+	w.utc("CREATED", task.CreatedAt)
+	w.utc("LAST-MODIFIED", task.UpdatedAt)
+	w.text("SUMMARY", summary(task))
+	w.text("DESCRIPTION", task.Content)
+	w.line("PRIORITY", icsPriority(task.Priority))
+	w.line("CATEGORIES", "Beekeeping,"+escapeText(fmt.Sprintf("Hive %d", task.HiveID)))
+	w.line("X-BEEKEEPER-HIVE", strconv.Itoa(task.HiveID))
+}
+
+func writeEvent(w *icsWriter, task models.Task) {
+	writeCommon(w, task, "VEVENT")
+	w.timeOrDate("DTSTART", *task.DueAt)
+	if isAllDay(*task.DueAt) {
+		w.timeOrDate("DTEND", task.DueAt.AddDate(0, 0, 1))
+	} else {
+		w.line("DURATION", "PT1H")
+	}
+	w.line("TRANSP", "TRANSPARENT")
+	w.line("END", "VEVENT")
+}
+
+func writeTodo(w *icsWriter, task models.Task) {
+	writeCommon(w, task, "VTODO")
+	if task.DueAt != nil {
+		w.timeOrDate("DUE", *task.DueAt)
+	}
+	if task.CompletedAt != nil {
+		w.line("STATUS", "COMPLETED")
+		w.utc("COMPLETED", *task.CompletedAt)
+		w.line("PERCENT-COMPLETE", "100")
+	} else {
+		w.line("STATUS", "NEEDS-ACTION")
+	}
+	w.line("END", "VTODO")
+}
+
+var (
+	// summaryHive matches summaries like "Hive 12: Add a super"
+	summaryHive = regexp.MustCompile(`(?i)^\s*hive\s*#?(\d+)\s*[:\-–]\s*(.*)$`)
+	ownUID      = regexp.MustCompile(`^task-(\d+)(?:-event)?@beekeeper$`)
+)
+
+// ImportCalendar godoc
+// @Summary Import tasks from iCalendar
+// @Description Create tasks from the VTODO and VEVENT entries of an iCalendar file. The hive is taken from an X-BEEKEEPER-HIVE property, a summary starting with "Hive 12:", or the hive_id parameter. Entries exported by the calendar feed update their original task instead of creating a new one; due dates and completion are only changed by entries that carry them. Hashtags become tags as for tasks written through the API. Missing hives are created. When authenticated, entries for hives the current user may not write tasks of are skipped.
+// @Tags tasks
+// @Accept text/calendar
+// @Produce json
+// @Param hive_id query int false "Hive for entries that do not name one"
+// @Success 200 {object} ImportResult
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /tasks/calendar.ics [post]
+func (h *handler) ImportCalendar(c *gin.Context) {
+	defaultHive := 0
+	if value := c.Query("hive_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hive_id"})
+			return
+		}
+		defaultHive = id
+	}
+
+	components, err := parseICS(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, _ := auth.CurrentUser(c)
+	result := ImportResult{Skipped: []SkippedRow{}}
+	var created, updated []models.Task
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		for _, comp := range components {
+			uid := comp.text("UID")
+			task, reason := taskFromComponent(comp, defaultHive)
+			if reason != "" {
+				result.Skipped = append(result.Skipped, SkippedRow{UID: uid, Reason: reason})
+				continue
+			}
+
+			allowed, err := canWrite(tx, user, task.HiveID)
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				result.Skipped = append(result.Skipped, SkippedRow{UID: uid, Reason: "no permission for this hive"})
+				continue
+			}
+
+			if m := ownUID.FindStringSubmatch(uid); m != nil {
+				var existing models.Task
+				if tx.First(&existing, m[1]).Error == nil {
+					// Moving a task needs permission for the hive it leaves too
+					allowed, err := canWrite(tx, user, existing.HiveID)
+					if err != nil {
+						return err
+					}
+					if !allowed {
+						result.Skipped = append(result.Skipped, SkippedRow{UID: uid, Reason: "no permission for this task"})
+						continue
+					}
+					if _, err := services.FindOrCreateHive(tx, task.HiveID); err != nil {
+						return err
+					}
+					// Properties the entry does not carry leave the task as it is
+					existing.HiveID = task.HiveID
+					existing.Content = task.Content
+					if _, ok := comp.props["PRIORITY"]; ok {
+						existing.Priority = task.Priority
+					}
+					if _, ok := comp.props[dueProperty(comp)]; ok {
+						existing.DueAt = task.DueAt
+					}
+					if carriesCompletion(comp) {
+						// A done task keeps its completion time unless the entry has one
+						if task.CompletedAt == nil || existing.CompletedAt == nil || comp.props["COMPLETED"].value != "" {
+							existing.CompletedAt = task.CompletedAt
+						}
+					}
+					if err := tx.Save(&existing).Error; err != nil {
+						return err
+					}
+					if err := tagging.TagHashtags(tx, &existing, existing.Content); err != nil {
+						return err
+					}
+					updated = append(updated, existing)
+					continue
+				}
+			}
+
+			if _, err := services.FindOrCreateHive(tx, task.HiveID); err != nil {
+				return err
+			}
+			if err := tx.Create(&task).Error; err != nil {
+				return err
+			}
+			if err := tagging.TagHashtags(tx, &task, task.Content); err != nil {
+				return err
+			}
+			created = append(created, task)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Import failed, nothing was imported"})
+		return
+	}
+
+	for _, task := range created {
+		h.bus.Publish(events.TaskCreated, task.HiveID, task)
+	}
+	for _, task := range updated {
+		h.bus.Publish(events.TaskUpdated, task.HiveID, task)
+	}
+	result.Created = len(created)
+	result.Updated = len(updated)
+	c.JSON(http.StatusOK, result)
+}
+
+// canWrite reports whether a user may write the tasks of a hive. Anonymous
+// imports, which the guard only lets through without users, may write all.
+func canWrite(tx *gorm.DB, user *models.User, hiveName int) (bool, error) {
+	if user == nil {
+		return true, nil
+	}
+	role, err := access.HiveRole(tx, user, hiveName)
+	if err != nil {
+		return false, err
+	}
+	return access.Can(role, access.TasksWrite), nil
+}
+
+// dueProperty names the property the due date of a component is in
+func dueProperty(comp component) string {
+	if comp.name == "VEVENT" {
+		return "DTSTART"
+	}
+	return "DUE"
+}
+
+// carriesCompletion reports whether a component says if its task is done: by
+// a completion time, or by its status, which for events only counts when it
+// is COMPLETED
+func carriesCompletion(comp component) bool {
+	if _, ok := comp.props["COMPLETED"]; ok {
+		return true
+	}
+	status, ok := comp.props["STATUS"]
+	return ok && (comp.name == "VTODO" || status.value == "COMPLETED")
+}
+
+// taskFromComponent converts a VTODO or VEVENT into a task, or returns why it cannot.
+func taskFromComponent(comp component, defaultHive int) (models.Task, string) {
+	task := models.Task{Priority: taskPriority(comp.props["PRIORITY"].value)}
+
+	content := strings.TrimSpace(comp.text("SUMMARY"))
+	if m := summaryHive.FindStringSubmatch(content); m != nil {
+		task.HiveID, _ = strconv.Atoi(m[1])
+		content = strings.TrimSpace(m[2])
+	}
+	if hive := comp.props["X-BEEKEEPER-HIVE"].value; hive != "" {
+		task.HiveID, _ = strconv.Atoi(hive)
+	}
+	if task.HiveID == 0 {
+		task.HiveID = defaultHive
+	}
+	if task.HiveID <= 0 {
+		return task, "no hive number in summary"
+	}
+
+	// Our own feed repeats the full content in the description
+	if description := strings.TrimSpace(comp.text("DESCRIPTION")); description != "" {
+		if strings.HasPrefix(description, content) {
+			content = description
+		} else {
+			content = content + "\n" + description
+		}
+	}
+	if content == "" {
+		return task, "empty summary"
+	}
+	task.Content = content
+
+	dueAt, err := parseTime(comp.props[dueProperty(comp)])
+	if err != nil {
+		return task, err.Error()
+	}
+	task.DueAt = dueAt
+
+	if comp.props["STATUS"].value == "COMPLETED" || comp.props["COMPLETED"].value != "" {
+		completedAt, err := parseTime(comp.props["COMPLETED"])
+		if err != nil || completedAt == nil {
+			now := time.Now()
+			completedAt = &now
+		}
+		task.CompletedAt = completedAt
+	}
+	return task, ""
+}
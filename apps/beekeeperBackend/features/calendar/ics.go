@@ -0,0 +1,188 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// --- Writing ---
+
+// icsWriter writes iCalendar (RFC 5545) content lines, folded at 75 octets
+// and terminated with CRLF.
+type icsWriter struct {
+	b strings.Builder
+}
+
+func (w *icsWriter) line(name, value string) {
+	line := name + ":" + value
+	for len(line) > 75 {
+		cut := 75
+		for !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+	w.b.WriteString(line + "\r\n")
+}
+
+func (w *icsWriter) text(name, value string) {
+	w.line(name, escapeText(value))
+}
+
+func (w *icsWriter) utc(name string, t time.Time) {
+	w.line(name, t.UTC().Format("20060102T150405Z"))
+}
+
+// timeOrDate writes a timestamp, or a date value for times at local midnight,
+// which is how all-day due dates are stored.
+func (w *icsWriter) timeOrDate(name string, t time.Time) {
+	if isAllDay(t) {
+		w.line(name+";VALUE=DATE", t.Local().Format("20060102"))
+		return
+	}
+	w.utc(name, t)
+}
+
+func (w *icsWriter) String() string {
+	return w.b.String()
+}
+
+func isAllDay(t time.Time) bool {
+	local := t.Local()
+	return local.Hour() == 0 && local.Minute() == 0 && local.Second() == 0 && local.Nanosecond() == 0
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// --- Parsing ---
+
+type property struct {
+	params map[string]string
+	value  string
+}
+
+// component is a VTODO or VEVENT with its properties by name
+type component struct {
+	name  string
+	props map[string]property
+}
+
+func (c component) text(name string) string {
+	return unescapeText(c.props[name].value)
+}
+
+// parseICS reads the VTODO and VEVENT components of an iCalendar file.
+func parseICS(r io.Reader) ([]component, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	// Unfold continuation lines, which start with a space or tab
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, fmt.Errorf("not an iCalendar file")
+	}
+
+	var components []component
+	var current *component
+	depth := 0
+	for _, line := range lines {
+		nameAndParams, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		parts := strings.Split(nameAndParams, ";")
+		name := strings.ToUpper(parts[0])
+
+		switch name {
+		case "BEGIN":
+			kind := strings.ToUpper(value)
+			if current == nil && (kind == "VTODO" || kind == "VEVENT") {
+				current = &component{name: kind, props: make(map[string]property)}
+				depth = 0
+			} else if current != nil {
+				depth++ // nested component such as VALARM
+			}
+			continue
+		case "END":
+			if current != nil {
+				if depth == 0 {
+					components = append(components, *current)
+					current = nil
+				} else {
+					depth--
+				}
+			}
+			continue
+		}
+
+		if current == nil || depth > 0 {
+			continue
+		}
+		prop := property{params: make(map[string]string), value: value}
+		for _, param := range parts[1:] {
+			if key, val, ok := strings.Cut(param, "="); ok {
+				prop.params[strings.ToUpper(key)] = strings.Trim(val, `"`)
+			}
+		}
+		current.props[name] = prop
+	}
+	return components, nil
+}
+
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func unescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
+
+// parseTime reads DATE and DATE-TIME values: UTC, floating or with a TZID.
+func parseTime(prop property) (*time.Time, error) {
+	value := prop.value
+	if value == "" {
+		return nil, nil
+	}
+
+	loc := time.Local
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+
+	var t time.Time
+	var err error
+	switch {
+	case strings.HasSuffix(value, "Z"):
+		t, err = time.Parse("20060102T150405Z", value)
+	case len(value) == len("20060102"):
+		t, err = time.ParseInLocation("20060102", value, time.Local) // all-day dates are stored at local midnight
+	default:
+		t, err = time.ParseInLocation("20060102T150405", value, loc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q", value)
+	}
+	return &t, nil
+}
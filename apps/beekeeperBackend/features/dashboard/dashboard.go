@@ -0,0 +1,334 @@
+package dashboard
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"beekeeper-api/access"
+	"beekeeper-api/models"
+)
+
+// Thresholds and penalties for the health score, which starts at 100
+const (
+	staleLogDays        = 14
+	veryStaleLogDays    = 30
+	varroaWarning       = 10
+	varroaCritical      = 30
+	weightLossPerDayKg  = 0.5
+	weightTrendWindow   = 7 * 24 * time.Hour
+	recentAlertWindow   = 7 * 24 * time.Hour
+	signalWindow        = 90 * 24 * time.Hour
+	attentionScoreBelow = 80
+	criticalScoreBelow  = 50
+)
+
+// Colony states derived from the health score
+const (
+	StatusOK        = "ok"
+	StatusAttention = "attention"
+	StatusCritical  = "critical"
+)
+
+// --- Response Structs ---
+
+// Dashboard lists every hive's status, worst first
+type Dashboard struct {
+	GeneratedAt time.Time    `json:"generated_at" example:"2024-01-15T10:30:00Z"`
+	Hives       []HiveStatus `json:"hives"`
+}
+
+// HiveStatus summarises the condition of one colony
+type HiveStatus struct {
+	HiveName            int        `json:"hive_name" example:"123"`
+	ApiaryID            *uint      `json:"apiary_id" example:"1"`
+	Score               int        `json:"score" example:"65"`
+	Status              string     `json:"status" example:"attention"`
+	Reasons             []string   `json:"reasons" example:"2 overdue tasks,Varroa count 14 on 2024-01-10"`
+	LastLogAt           *time.Time `json:"last_log_at" example:"2024-01-10T10:30:00Z"`
+	DaysSinceLastLog    *int       `json:"days_since_last_log" example:"5"`
+	OpenTasks           int        `json:"open_tasks" example:"3"`
+	OverdueTasks        int        `json:"overdue_tasks" example:"2"`
+	LastVarroaCount     *int       `json:"last_varroa_count" example:"14"`
+	LastVarroaAt        *time.Time `json:"last_varroa_at" example:"2024-01-10T10:30:00Z"`
+	WeightTrendKgPerDay *float64   `json:"weight_trend_kg_per_day" example:"-0.3"`
+	QueenStatus         string     `json:"queen_status" example:"present"`
+	QueenStatusAt       *time.Time `json:"queen_status_at" example:"2024-01-10T10:30:00Z"`
+	RecentAlerts        int        `json:"recent_alerts" example:"0"`
+}
+
+// --- Route Registration ---
+
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
+	h := &handler{db: db, now: time.Now}
+	guard := access.New(db)
+
+	router.GET("/dashboard", guard.Require(access.HivesRead, access.Anywhere), h.GetDashboard)
+}
+
+// --- Handler ---
+
+type handler struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+// GetDashboard godoc
+// @Summary Which hives need attention?
+// @Description Compute the status of every hive the user can read from its logs, tasks and telemetry: days since the last log, open and overdue tasks, the last varroa count and queen status mentioned in the logs of the last 90 days, the weight trend over the last week and recent alerts. Each hive gets a health score from 0 to 100 with the reasons for any deductions, and the list is sorted worst first.
+// @Tags dashboard
+// @Produce  json
+// @Param apiary_id query int false "Only hives in this apiary"
+// @Success 200 {object} Dashboard
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /dashboard [get]
+func (h *handler) GetDashboard(c *gin.Context) {
+	now := h.now()
+
+	query := access.Visible(c, h.db, h.db.Order("hive_name"), access.HivesRead, "hive_name")
+	if value := c.Query("apiary_id"); value != "" {
+		apiaryID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid apiary_id"})
+			return
+		}
+		query = query.Where("apiary_id = ?", apiaryID)
+	}
+	var hives []models.Hive
+	if result := query.Find(&hives); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve hives"})
+		return
+	}
+
+	statuses := make(map[int]*HiveStatus, len(hives))
+	names := make([]int, len(hives))
+	for i, hive := range hives {
+		names[i] = hive.HiveName
+		statuses[hive.HiveName] = &HiveStatus{HiveName: hive.HiveName, ApiaryID: hive.ApiaryID, QueenStatus: QueenUnknown}
+	}
+
+	if len(hives) > 0 {
+		for _, collect := range []func([]int, map[int]*HiveStatus, time.Time) error{h.collectLogs, h.collectTasks, h.collectWeight, h.collectAlerts} {
+			if err := collect(names, statuses, now); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute hive status"})
+				return
+			}
+		}
+	}
+
+	dashboard := Dashboard{GeneratedAt: now, Hives: make([]HiveStatus, 0, len(hives))}
+	for _, name := range names {
+		status := statuses[name]
+		score(status)
+		dashboard.Hives = append(dashboard.Hives, *status)
+	}
+	sort.SliceStable(dashboard.Hives, func(i, j int) bool { return dashboard.Hives[i].Score < dashboard.Hives[j].Score })
+
+	c.JSON(http.StatusOK, dashboard)
+}
+
+// collectLogs picks up the last log date and the most recent varroa count
+// and queen observation of every hive. Only the newest log of each hive and
+// the logs of the last signalWindow are read, newest first, until every hive
+// has both signals.
+func (h *handler) collectLogs(names []int, statuses map[int]*HiveStatus, now time.Time) error {
+	var latest []models.Log
+	err := h.db.Model(&models.Log{}).
+		Select("hive_id, created_at").
+		Where("hive_id IN ?", names).
+		Where("created_at = (SELECT MAX(l.created_at) FROM logs l WHERE l.hive_id = logs.hive_id)").
+		Find(&latest).Error
+	if err != nil {
+		return err
+	}
+	for _, entry := range latest {
+		status := statuses[entry.HiveID]
+		if status.LastLogAt == nil {
+			createdAt := entry.CreatedAt
+			days := int(now.Sub(createdAt).Hours() / 24)
+			status.LastLogAt = &createdAt
+			status.DaysSinceLastLog = &days
+		}
+	}
+
+	rows, err := h.db.Model(&models.Log{}).
+		Select("hive_id, content, created_at").
+		Where("hive_id IN ? AND created_at >= ?", names, now.Add(-signalWindow)).
+		Order("created_at DESC").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	pending := len(names)
+	for pending > 0 && rows.Next() {
+		var entry models.Log
+		if err := h.db.ScanRows(rows, &entry); err != nil {
+			return err
+		}
+		status := statuses[entry.HiveID]
+		if status.LastVarroaCount != nil && status.QueenStatusAt != nil {
+			continue
+		}
+		createdAt := entry.CreatedAt
+
+		if status.LastVarroaCount == nil {
+			if count, ok := varroaCount(entry.Content); ok {
+				status.LastVarroaCount = &count
+				status.LastVarroaAt = &createdAt
+			}
+		}
+		if status.QueenStatusAt == nil {
+			if queen, ok := queenStatus(entry.Content); ok {
+				status.QueenStatus = queen
+				status.QueenStatusAt = &createdAt
+			}
+		}
+		if status.LastVarroaCount != nil && status.QueenStatusAt != nil {
+			pending--
+		}
+	}
+	return rows.Err()
+}
+
+func (h *handler) collectTasks(names []int, statuses map[int]*HiveStatus, now time.Time) error {
+	var counts []struct {
+		HiveID  int
+		Open    int
+		Overdue int
+	}
+	err := h.db.Model(&models.Task{}).
+		Select("hive_id, COUNT(*) AS open, SUM(CASE WHEN due_at IS NOT NULL AND due_at < ? THEN 1 ELSE 0 END) AS overdue", now).
+		Where("hive_id IN ? AND completed_at IS NULL", names).
+		Group("hive_id").
+		Scan(&counts).Error
+	if err != nil {
+		return err
+	}
+	for _, count := range counts {
+		statuses[count.HiveID].OpenTasks = count.Open
+		statuses[count.HiveID].OverdueTasks = count.Overdue
+	}
+	return nil
+}
+
+// collectWeight fits the weight trend of the last week. Readings are stored
+// in UTC and SQLite compares times as text, so the window is given in UTC.
+func (h *handler) collectWeight(names []int, statuses map[int]*HiveStatus, now time.Time) error {
+	var readings []models.Telemetry
+	err := h.db.
+		Where("hive_id IN ? AND metric = ? AND recorded_at >= ?", names, "weight", now.UTC().Add(-weightTrendWindow)).
+		Find(&readings).Error
+	if err != nil {
+		return err
+	}
+
+	samples := make(map[int][]sample)
+	for _, reading := range readings {
+		samples[reading.HiveID] = append(samples[reading.HiveID], sample{at: reading.RecordedAt, value: reading.Value})
+	}
+	for hiveID, hiveSamples := range samples {
+		if trend, ok := trendPerDay(hiveSamples); ok {
+			trend = math.Round(trend*100) / 100
+			statuses[hiveID].WeightTrendKgPerDay = &trend
+		}
+	}
+	return nil
+}
+
+func (h *handler) collectAlerts(names []int, statuses map[int]*HiveStatus, now time.Time) error {
+	var counts []struct {
+		HiveID int
+		Count  int
+	}
+	err := h.db.Model(&models.Alert{}).
+		Select("hive_id, COUNT(*) AS count").
+		Where("hive_id IN ? AND created_at >= ?", names, now.Add(-recentAlertWindow)).
+		Group("hive_id").
+		Scan(&counts).Error
+	if err != nil {
+		return err
+	}
+	for _, count := range counts {
+		statuses[count.HiveID].RecentAlerts = count.Count
+	}
+	return nil
+}
+
+// score computes the health score from the collected signals and explains
+// every deduction.
+func score(status *HiveStatus) {
+	points := 100
+	reasons := []string{}
+	deduct := func(penalty int, reason string) {
+		points -= penalty
+		reasons = append(reasons, reason)
+	}
+
+	switch {
+	case status.DaysSinceLastLog == nil:
+		deduct(20, "Never inspected")
+	case *status.DaysSinceLastLog >= veryStaleLogDays:
+		deduct(25, fmt.Sprintf("No log for %d days", *status.DaysSinceLastLog))
+	case *status.DaysSinceLastLog >= staleLogDays:
+		deduct(10, fmt.Sprintf("No log for %d days", *status.DaysSinceLastLog))
+	}
+
+	if status.OverdueTasks > 0 {
+		deduct(min(10*status.OverdueTasks, 30), plural(status.OverdueTasks, "overdue task"))
+	}
+
+	if status.LastVarroaCount != nil {
+		count := *status.LastVarroaCount
+		date := status.LastVarroaAt.Format("2006-01-02")
+		switch {
+		case count >= varroaCritical:
+			deduct(30, fmt.Sprintf("Varroa count %d on %s", count, date))
+		case count >= varroaWarning:
+			deduct(15, fmt.Sprintf("Varroa count %d on %s", count, date))
+		}
+	}
+
+	if status.WeightTrendKgPerDay != nil && *status.WeightTrendKgPerDay <= -weightLossPerDayKg {
+		deduct(15, fmt.Sprintf("Losing %.2f kg per day", -*status.WeightTrendKgPerDay))
+	}
+
+	switch status.QueenStatus {
+	case QueenMissing:
+		deduct(30, "Queen missing since "+status.QueenStatusAt.Format("2006-01-02"))
+	case QueenCells:
+		deduct(10, "Queen cells seen on "+status.QueenStatusAt.Format("2006-01-02"))
+	}
+
+	if status.RecentAlerts > 0 {
+		deduct(min(10*status.RecentAlerts, 20), plural(status.RecentAlerts, "alert")+" in the last week")
+	}
+
+	status.Score = max(points, 0)
+	status.Reasons = reasons
+	switch {
+	case status.Score < criticalScoreBelow:
+		status.Status = StatusCritical
+	case status.Score < attentionScoreBelow:
+		status.Status = StatusAttention
+	default:
+		status.Status = StatusOK
+	}
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
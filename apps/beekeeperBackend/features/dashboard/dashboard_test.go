@@ -0,0 +1,139 @@
+package dashboard
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"beekeeper-api/config"
+	"beekeeper-api/database"
+	"beekeeper-api/migrate"
+	"beekeeper-api/models"
+)
+
+var databases atomic.Int64
+
+// openDB creates a migrated in-memory database
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	cfg := config.New()
+	cfg.DBDriver = "sqlite"
+	cfg.DatabaseURL = fmt.Sprintf("file:dashboard%d?mode=memory&cache=shared", databases.Add(1))
+	cfg.DBMaxOpenConns = 1
+	cfg.DBMaxIdleConns = 1
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db.Logger = logger.Discard
+
+	migrator, err := migrate.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// collect runs a collector over new statuses of the hives
+func collect(t *testing.T, fn func([]int, map[int]*HiveStatus, time.Time) error, now time.Time, names ...int) map[int]*HiveStatus {
+	t.Helper()
+	statuses := make(map[int]*HiveStatus)
+	for _, name := range names {
+		statuses[name] = &HiveStatus{HiveName: name, QueenStatus: QueenUnknown}
+	}
+	if err := fn(names, statuses, now); err != nil {
+		t.Fatal(err)
+	}
+	return statuses
+}
+
+func TestCollectLogs(t *testing.T) {
+	db := openDB(t)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local)
+	day := 24 * time.Hour
+	rows := []any{
+		&models.Hive{HiveName: 1}, &models.Hive{HiveName: 2}, &models.Hive{HiveName: 3},
+		// Signals older than signalWindow no longer count
+		&models.Log{HiveID: 1, Content: "Varroa count 40, queenless", CreatedAt: now.Add(-200 * day)},
+		&models.Log{HiveID: 1, Content: "Mites: 12", CreatedAt: now.Add(-20 * day)},
+		&models.Log{HiveID: 1, Content: "Inspected", CreatedAt: now.Add(-10 * day)},
+		// The newest signal wins
+		&models.Log{HiveID: 2, Content: "No queen, 3 mites on the board", CreatedAt: now.Add(-5 * day)},
+		&models.Log{HiveID: 2, Content: "Saw the queen", CreatedAt: now.Add(-2 * day)},
+		&models.Log{HiveID: 2, Content: "Varroa drop 31", CreatedAt: now.Add(-day)},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := &handler{db: db}
+	statuses := collect(t, h.collectLogs, now, 1, 2, 3)
+
+	first := statuses[1]
+	if first.DaysSinceLastLog == nil || *first.DaysSinceLastLog != 10 || !first.LastLogAt.Equal(now.Add(-10*day)) {
+		t.Errorf("got last log %v, %v days ago for hive 1, want 10", first.LastLogAt, first.DaysSinceLastLog)
+	}
+	if first.LastVarroaCount == nil || *first.LastVarroaCount != 12 || first.QueenStatus != QueenUnknown || first.QueenStatusAt != nil {
+		t.Errorf("got varroa %v and queen %q for hive 1, want 12 and nothing older than the window", first.LastVarroaCount, first.QueenStatus)
+	}
+
+	second := statuses[2]
+	if second.DaysSinceLastLog == nil || *second.DaysSinceLastLog != 1 {
+		t.Errorf("got %v days since the last log of hive 2, want 1", second.DaysSinceLastLog)
+	}
+	if second.LastVarroaCount == nil || *second.LastVarroaCount != 31 || !second.LastVarroaAt.Equal(now.Add(-day)) {
+		t.Errorf("got varroa %v at %v for hive 2, want 31 yesterday", second.LastVarroaCount, second.LastVarroaAt)
+	}
+	if second.QueenStatus != QueenPresent || !second.QueenStatusAt.Equal(now.Add(-2*day)) {
+		t.Errorf("got queen %q at %v for hive 2, want present 2 days ago", second.QueenStatus, second.QueenStatusAt)
+	}
+
+	if third := statuses[3]; third.LastLogAt != nil || third.LastVarroaCount != nil || third.QueenStatus != QueenUnknown {
+		t.Errorf("got %+v for hive 3 without logs", third)
+	}
+}
+
+func TestCollectWeight(t *testing.T) {
+	db := openDB(t)
+	// Ten hours ahead of UTC, the local date of now sorts after the stored UTC
+	// readings of the same instant
+	now := time.Date(2024, 6, 1, 8, 0, 0, 0, time.FixedZone("AEST", 10*60*60))
+	hour := time.Hour
+	rows := []any{
+		&models.Hive{HiveName: 1}, &models.Hive{HiveName: 2},
+		&models.Telemetry{HiveID: 1, Metric: "weight", Value: 40, RecordedAt: now.Add(-167 * hour).UTC()},
+		&models.Telemetry{HiveID: 1, Metric: "weight", Value: 34, RecordedAt: now.Add(-23 * hour).UTC()},
+		// Outside the week
+		&models.Telemetry{HiveID: 1, Metric: "weight", Value: 10, RecordedAt: now.Add(-200 * hour).UTC()},
+		&models.Telemetry{HiveID: 1, Metric: "temperature", Value: 35, RecordedAt: now.Add(-hour).UTC()},
+		&models.Telemetry{HiveID: 2, Metric: "weight", Value: 30, RecordedAt: now.Add(-hour).UTC()},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := &handler{db: db}
+	statuses := collect(t, h.collectWeight, now, 1, 2)
+	if trend := statuses[1].WeightTrendKgPerDay; trend == nil || *trend != -1 {
+		t.Errorf("got a trend of %v for hive 1, want -1 kg per day", trend)
+	}
+	if trend := statuses[2].WeightTrendKgPerDay; trend != nil {
+		t.Errorf("got a trend of %v for hive 2 with a single reading", *trend)
+	}
+}
@@ -0,0 +1,90 @@
+package dashboard
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Queen states read from log entries
+const (
+	QueenUnknown = "unknown"
+	QueenPresent = "present"
+	QueenCells   = "queen_cells"
+	QueenMissing = "missing"
+)
+
+var (
+	varroaPattern = regexp.MustCompile(`(?i)\b(?:varroa|mites?)(?:\s+(?:count|drop|load|fall))?\s*[:=]?\s*(\d+)`)
+	mitesPattern  = regexp.MustCompile(`(?i)\b(\d+)\s+(?:varroa|mites?)\b`)
+
+	queenMissingPattern = regexp.MustCompile(`(?i)\bqueenless\b|\bno queen\b|\bqueen (?:is )?(?:missing|gone|not (?:seen|found|spotted))`)
+	queenCellsPattern   = regexp.MustCompile(`(?i)\b(?:queen|swarm|supersedure) cells?\b`)
+	queenPresentPattern = regexp.MustCompile(`(?i)\bqueen (?:seen|spotted|present|found|laying)\b|\b(?:saw|spotted|found) (?:the )?queen\b|\b(?:fresh )?eggs (?:seen|present)\b|\bfresh eggs\b`)
+)
+
+// varroaCount extracts a mite count from a log entry such as "Varroa count: 12"
+// or "3 mites on the board".
+func varroaCount(content string) (int, bool) {
+	for _, pattern := range []*regexp.Regexp{varroaPattern, mitesPattern} {
+		if match := pattern.FindStringSubmatch(content); match != nil {
+			if count, err := strconv.Atoi(match[1]); err == nil {
+				return count, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// queenStatus reads what a log entry says about the queen, if anything.
+func queenStatus(content string) (string, bool) {
+	switch {
+	case queenMissingPattern.MatchString(content):
+		return QueenMissing, true
+	case queenCellsPattern.MatchString(content):
+		return QueenCells, true
+	case queenPresentPattern.MatchString(content):
+		return QueenPresent, true
+	}
+	return QueenUnknown, false
+}
+
+type sample struct {
+	at    time.Time
+	value float64
+}
+
+// trendPerDay fits a least-squares line through the samples and returns its
+// slope in units per day. It needs readings covering at least half a day.
+func trendPerDay(samples []sample) (float64, bool) {
+	if len(samples) < 2 {
+		return 0, false
+	}
+	first, last := samples[0].at, samples[0].at
+	for _, s := range samples {
+		if s.at.Before(first) {
+			first = s.at
+		}
+		if s.at.After(last) {
+			last = s.at
+		}
+	}
+	if last.Sub(first) < 12*time.Hour {
+		return 0, false
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range samples {
+		x := s.at.Sub(first).Hours() / 24
+		sumX += x
+		sumY += s.value
+		sumXY += x * s.value
+		sumXX += x * x
+	}
+	n := float64(len(samples))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, false
+	}
+	return (n*sumXY - sumX*sumY) / denominator, true
+}
@@ -0,0 +1,151 @@
+package dashboard
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestVarroaCount(t *testing.T) {
+	tests := []struct {
+		content string
+		count   int
+		ok      bool
+	}{
+		{"Varroa count: 12", 12, true},
+		{"varroa 7 after treatment", 7, true},
+		{"Mite drop = 4", 4, true},
+		{"mites fall 9", 9, true},
+		{"VARROA LOAD 31", 31, true},
+		{"3 mites on the board", 3, true},
+		{"Counted 12 Varroa", 12, true},
+		{"Varroa count 0", 0, true},
+		// The first count wins
+		{"Varroa 5, last week 9 mites", 5, true},
+		{"Treated for varroa", 0, false},
+		{"No varroa seen", 0, false},
+		{"Termites 5", 0, false},
+		{"Hive 7 inspected, 8 frames", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			count, ok := varroaCount(tt.content)
+			if count != tt.count || ok != tt.ok {
+				t.Errorf("got %d, %v, want %d, %v", count, ok, tt.count, tt.ok)
+			}
+		})
+	}
+}
+
+func TestQueenStatus(t *testing.T) {
+	tests := []struct {
+		content string
+		status  string
+		ok      bool
+	}{
+		{"Queenless colony", QueenMissing, true},
+		{"No queen found", QueenMissing, true},
+		{"Queen not seen today", QueenMissing, true},
+		{"queen is missing", QueenMissing, true},
+		{"Queen gone after swarming", QueenMissing, true},
+		{"Swarm cells on frame 3", QueenCells, true},
+		{"One supersedure cell", QueenCells, true},
+		{"Queen cells", QueenCells, true},
+		{"Queen spotted, laying well", QueenPresent, true},
+		{"Saw the queen", QueenPresent, true},
+		{"found queen on frame 4", QueenPresent, true},
+		{"Fresh eggs everywhere", QueenPresent, true},
+		{"Eggs present", QueenPresent, true},
+		// Missing beats cells, cells beat present
+		{"The queen is missing but queen cells are capped", QueenMissing, true},
+		{"Queen seen next to queen cells", QueenCells, true},
+		{"Added a super", QueenUnknown, false},
+		{"Queen excluder fitted", QueenUnknown, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			status, ok := queenStatus(tt.content)
+			if status != tt.status || ok != tt.ok {
+				t.Errorf("got %q, %v, want %q, %v", status, ok, tt.status, tt.ok)
+			}
+		})
+	}
+}
+
+func TestTrendPerDay(t *testing.T) {
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hours float64, value float64) sample {
+		return sample{at: start.Add(time.Duration(hours * float64(time.Hour))), value: value}
+	}
+	tests := []struct {
+		name    string
+		samples []sample
+		trend   float64
+		ok      bool
+	}{
+		{"losing a kilo a day", []sample{at(0, 40), at(24, 39), at(48, 38)}, -1, true},
+		{"in any order", []sample{at(48, 38), at(0, 40), at(24, 39)}, -1, true},
+		{"gaining over half a day", []sample{at(0, 30), at(12, 31)}, 2, true},
+		{"flat", []sample{at(0, 30), at(24, 30), at(48, 30)}, 0, true},
+		{"least squares through noise", []sample{at(0, 30), at(24, 32), at(48, 30)}, 0, true},
+		{"hourly readings", []sample{at(0, 50), at(1, 49.9), at(2, 49.8), at(23, 47.7), at(24, 47.6)}, -2.4, true},
+		{"no samples", nil, 0, false},
+		{"one sample", []sample{at(0, 30)}, 0, false},
+		{"less than half a day", []sample{at(0, 30), at(11, 20)}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trend, ok := trendPerDay(tt.samples)
+			if ok != tt.ok || math.Abs(trend-tt.trend) > 1e-9 {
+				t.Errorf("got %v, %v, want %v, %v", trend, ok, tt.trend, tt.ok)
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	seen := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	days := func(n int) *int { return &n }
+	count := func(n int) *int { return &n }
+	trend := func(kg float64) *float64 { return &kg }
+
+	tests := []struct {
+		name    string
+		status  HiveStatus
+		score   int
+		state   string
+		reasons []string
+	}{
+		{"healthy", HiveStatus{DaysSinceLastLog: days(0)}, 100, StatusOK, []string{}},
+		{"never inspected", HiveStatus{}, 80, StatusOK, []string{"Never inspected"}},
+		{"13 days", HiveStatus{DaysSinceLastLog: days(13)}, 100, StatusOK, []string{}},
+		{"14 days", HiveStatus{DaysSinceLastLog: days(14)}, 90, StatusOK, []string{"No log for 14 days"}},
+		{"30 days", HiveStatus{DaysSinceLastLog: days(30)}, 75, StatusAttention, []string{"No log for 30 days"}},
+		{"one overdue task", HiveStatus{DaysSinceLastLog: days(0), OverdueTasks: 1}, 90, StatusOK, []string{"1 overdue task"}},
+		{"overdue tasks are capped", HiveStatus{DaysSinceLastLog: days(0), OverdueTasks: 5}, 70, StatusAttention, []string{"5 overdue tasks"}},
+		{"varroa below warning", HiveStatus{DaysSinceLastLog: days(0), LastVarroaCount: count(9), LastVarroaAt: &seen}, 100, StatusOK, []string{}},
+		{"varroa warning", HiveStatus{DaysSinceLastLog: days(0), LastVarroaCount: count(10), LastVarroaAt: &seen}, 85, StatusOK, []string{"Varroa count 10 on 2024-05-01"}},
+		{"varroa critical", HiveStatus{DaysSinceLastLog: days(0), LastVarroaCount: count(30), LastVarroaAt: &seen}, 70, StatusAttention, []string{"Varroa count 30 on 2024-05-01"}},
+		{"slight weight loss", HiveStatus{DaysSinceLastLog: days(0), WeightTrendKgPerDay: trend(-0.49)}, 100, StatusOK, []string{}},
+		{"weight loss", HiveStatus{DaysSinceLastLog: days(0), WeightTrendKgPerDay: trend(-0.75)}, 85, StatusOK, []string{"Losing 0.75 kg per day"}},
+		{"queen cells", HiveStatus{DaysSinceLastLog: days(0), QueenStatus: QueenCells, QueenStatusAt: &seen}, 90, StatusOK, []string{"Queen cells seen on 2024-05-01"}},
+		{"alerts are capped", HiveStatus{DaysSinceLastLog: days(0), RecentAlerts: 3}, 80, StatusOK, []string{"3 alerts in the last week"}},
+		{"50 is attention", HiveStatus{DaysSinceLastLog: days(0), QueenStatus: QueenMissing, QueenStatusAt: &seen, RecentAlerts: 2}, 50, StatusAttention, []string{"Queen missing since 2024-05-01", "2 alerts in the last week"}},
+		{"below 50 is critical", HiveStatus{DaysSinceLastLog: days(30), LastVarroaCount: count(30), LastVarroaAt: &seen}, 45, StatusCritical, []string{"No log for 30 days", "Varroa count 30 on 2024-05-01"}},
+		{"never below 0", HiveStatus{OverdueTasks: 3, LastVarroaCount: count(40), LastVarroaAt: &seen, WeightTrendKgPerDay: trend(-1), QueenStatus: QueenMissing, QueenStatusAt: &seen, RecentAlerts: 2}, 0, StatusCritical, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := tt.status
+			score(&status)
+			if status.Score != tt.score || status.Status != tt.state {
+				t.Errorf("got %d %s, want %d %s", status.Score, status.Status, tt.score, tt.state)
+			}
+			if tt.reasons != nil && !slices.Equal(status.Reasons, tt.reasons) {
+				t.Errorf("got reasons %q, want %q", status.Reasons, tt.reasons)
+			}
+		})
+	}
+}
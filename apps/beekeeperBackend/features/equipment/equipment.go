@@ -0,0 +1,145 @@
+package equipment
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"beekeeper-api/access"
+	"beekeeper-api/features/incidents"
+	"beekeeper-api/models"
+	"beekeeper-api/services"
+)
+
+// --- Structs for Input Validation ---
+
+type CreateMoveInput struct {
+	FromHiveID int        `json:"fromHiveID" binding:"required" example:"123"`
+	ToHiveID   *int       `json:"toHiveID" example:"124"`
+	Equipment  string     `json:"equipment" binding:"required" example:"super"`
+	Quantity   int        `json:"quantity" binding:"omitempty,min=1" example:"2"`
+	MovedAt    *time.Time `json:"movedAt" example:"2024-05-02T10:00:00Z"`
+	Notes      string     `json:"notes" example:"Drawn comb for the new split"`
+}
+
+// --- Route Registration ---
+
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
+	h := &handler{db: db}
+	guard := access.New(db)
+
+	moveRoutes := router.Group("/equipment-moves")
+	{
+		moveRoutes.POST("", guard.Require(access.HivesWrite, access.HiveInBody("fromHiveID"), access.HiveInBody("toHiveID")), h.CreateMove)
+		moveRoutes.GET("", guard.Require(access.HivesRead, access.Anywhere), h.ListMoves)
+	}
+}
+
+// --- Handler ---
+
+type handler struct {
+	db *gorm.DB
+}
+
+// CreateMove godoc
+// @Summary Record an equipment move
+// @Description Record supers, frames or other equipment leaving a hive, either to another hive or to storage when toHiveID is omitted. Equipment cannot be moved out of a hive under quarantine. Unknown hives are created automatically.
+// @Tags equipment
+// @Accept  json
+// @Produce  json
+// @Param move body CreateMoveInput true "Equipment move"
+// @Success 201 {object} models.EquipmentMove
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /equipment-moves [post]
+func (h *handler) CreateMove(c *gin.Context) {
+	var input CreateMoveInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if input.ToHiveID != nil && *input.ToHiveID == input.FromHiveID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Equipment must move to a different hive"})
+		return
+	}
+
+	move := models.EquipmentMove{
+		FromHiveID: input.FromHiveID,
+		ToHiveID:   input.ToHiveID,
+		Equipment:  input.Equipment,
+		Quantity:   input.Quantity,
+		MovedAt:    time.Now(),
+		Notes:      input.Notes,
+	}
+	if move.Quantity == 0 {
+		move.Quantity = 1
+	}
+	if input.MovedAt != nil {
+		move.MovedAt = *input.MovedAt
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := services.FindOrCreateHive(tx, input.FromHiveID); err != nil {
+			return err
+		}
+		if input.ToHiveID != nil {
+			if _, err := services.FindOrCreateHive(tx, *input.ToHiveID); err != nil {
+				return err
+			}
+		}
+		if err := incidents.CheckQuarantine(tx, input.FromHiveID); err != nil {
+			return err
+		}
+		return tx.Create(&move).Error
+	})
+	if errors.Is(err, incidents.ErrQuarantined) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Hive %d is under quarantine, no equipment can be moved out until its incidents are cleared", input.FromHiveID)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not record equipment move"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, move)
+}
+
+// ListMoves godoc
+// @Summary List equipment moves
+// @Description List equipment moves out of the hives the user can read, newest first
+// @Tags equipment
+// @Produce  json
+// @Param hive_id query int false "Only moves into or out of this hive"
+// @Success 200 {array} models.EquipmentMove
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /equipment-moves [get]
+func (h *handler) ListMoves(c *gin.Context) {
+	query := access.Visible(c, h.db, h.db.Order("moved_at DESC"), access.HivesRead, "from_hive_id")
+	if value := c.Query("hive_id"); value != "" {
+		hiveID, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hive_id"})
+			return
+		}
+		query = query.Where("from_hive_id = ? OR to_hive_id = ?", hiveID, hiveID)
+	}
+
+	var moves []models.EquipmentMove
+	if result := query.Find(&moves); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve equipment moves"})
+		return
+	}
+
+	c.JSON(http.StatusOK, moves)
+}
@@ -0,0 +1,324 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"beekeeper-api/access"
+	"beekeeper-api/auth"
+	"beekeeper-api/models"
+)
+
+// --- Route Registration ---
+
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
+	h := &handler{db: db}
+	guard := access.New(db)
+
+	router.GET("/export", guard.Require(access.HivesRead, access.Anywhere), h.Export)
+}
+
+// --- Handler ---
+
+type handler struct {
+	db *gorm.DB
+}
+
+// filter restricts an export to one hive and/or a creation date range, and to
+// what the user can read; anonymous exports have no user.
+type filter struct {
+	hiveID   *int
+	from, to *time.Time
+	user     *models.User
+}
+
+// ParseTime accepts either an RFC 3339 timestamp or a plain date. A plain date
+// used as an upper bound includes the whole day.
+func ParseTime(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q, use YYYY-MM-DD or RFC 3339", value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return &t, nil
+}
+
+func parseFilter(c *gin.Context) (filter, error) {
+	var f filter
+	if user, ok := auth.CurrentUser(c); ok {
+		f.user = user
+	}
+	if value := c.Query("hive_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return f, fmt.Errorf("invalid hive_id %q", value)
+		}
+		f.hiveID = &id
+	}
+	var err error
+	if f.from, err = ParseTime(c.Query("from"), false); err != nil {
+		return f, err
+	}
+	if f.to, err = ParseTime(c.Query("to"), true); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+func (f filter) hives(db *gorm.DB) *gorm.DB {
+	query := db.Model(&models.Hive{}).Order("hive_name")
+	if f.hiveID != nil {
+		query = query.Where("hive_name = ?", *f.hiveID)
+	}
+	if f.user != nil {
+		query = query.Where("hive_name IN (?)", access.Hives(db, f.user.ID, access.HivesRead))
+	}
+	return query
+}
+
+func (f filter) entries(db *gorm.DB, model any, perm access.Permission) *gorm.DB {
+	query := db.Model(model).Order("created_at, id")
+	if f.hiveID != nil {
+		query = query.Where("hive_id = ?", *f.hiveID)
+	}
+	if f.user != nil {
+		query = query.Where("hive_id IN (?)", access.Hives(db, f.user.ID, perm))
+	}
+	if f.from != nil {
+		query = query.Where("created_at >= ?", *f.from)
+	}
+	if f.to != nil {
+		query = query.Where("created_at <= ?", *f.to)
+	}
+	return query
+}
+
+// each streams the rows of a query one at a time instead of loading them all.
+func each[T any](db *gorm.DB, query *gorm.DB, fn func(*T) error) error {
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item T
+		if err := db.ScanRows(rows, &item); err != nil {
+			return err
+		}
+		if err := fn(&item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Export godoc
+// @Summary Export the journal
+// @Description Stream the hives, logs and tasks the user can read. "json" is a single document with hives, logs and tasks arrays, "ndjson" writes one {"type","data"} object per line, and "csv" returns a zip archive with one CSV file per entity. Logs and tasks can be limited to one hive and a creation date range.
+// @Tags export
+// @Produce json
+// @Produce application/x-ndjson
+// @Produce application/zip
+// @Param format query string false "Export format: csv, json or ndjson" default(json)
+// @Param from query string false "Only entries created on or after this date (YYYY-MM-DD or RFC 3339)"
+// @Param to query string false "Only entries created on or before this date (YYYY-MM-DD or RFC 3339)"
+// @Param hive_id query int false "Only export this hive"
+// @Success 200 {object} Document
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /export [get]
+func (h *handler) Export(c *gin.Context) {
+	f, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	filename := "beekeeper-export-" + time.Now().Format("20060102")
+
+	var write func(io.Writer, filter) error
+	switch format {
+	case "json":
+		c.Header("Content-Type", "application/json")
+		write = h.writeJSON
+	case "ndjson":
+		c.Header("Content-Type", "application/x-ndjson")
+		write = h.writeNDJSON
+	case "csv":
+		c.Header("Content-Type", "application/zip")
+		format = "zip"
+		write = h.writeCSVZip
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported format, use csv, json or ndjson"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format))
+	c.Status(http.StatusOK)
+
+	// The status line is already sent, so a failure can only cut the stream short
+	if err := write(c.Writer, f); err != nil {
+		log.Printf("Export failed: %v", err)
+		c.Abort()
+	}
+}
+
+// Document is the layout of a JSON export, also accepted by the import endpoint
+type Document struct {
+	ExportedAt time.Time     `json:"exported_at"`
+	Hives      []models.Hive `json:"hives"`
+	Logs       []models.Log  `json:"logs"`
+	Tasks      []models.Task `json:"tasks"`
+}
+
+func (h *handler) writeJSON(w io.Writer, f filter) error {
+	fmt.Fprintf(w, `{"exported_at":%q,`, time.Now().UTC().Format(time.RFC3339))
+
+	enc := json.NewEncoder(w)
+	writeArray := func(name string, stream func(func(any) error) error) error {
+		fmt.Fprintf(w, "%q:[", name)
+		first := true
+		err := stream(func(item any) error {
+			if !first {
+				io.WriteString(w, ",")
+			}
+			first = false
+			return enc.Encode(item)
+		})
+		io.WriteString(w, "]")
+		return err
+	}
+
+	if err := writeArray("hives", h.streamHives(f)); err != nil {
+		return err
+	}
+	io.WriteString(w, ",")
+	if err := writeArray("logs", h.streamLogs(f)); err != nil {
+		return err
+	}
+	io.WriteString(w, ",")
+	if err := writeArray("tasks", h.streamTasks(f)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "}\n")
+	return err
+}
+
+func (h *handler) writeNDJSON(w io.Writer, f filter) error {
+	enc := json.NewEncoder(w)
+	for _, part := range []struct {
+		kind   string
+		stream func(func(any) error) error
+	}{
+		{"hive", h.streamHives(f)},
+		{"log", h.streamLogs(f)},
+		{"task", h.streamTasks(f)},
+	} {
+		err := part.stream(func(item any) error {
+			return enc.Encode(struct {
+				Type string `json:"type"`
+				Data any    `json:"data"`
+			}{part.kind, item})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *handler) writeCSVZip(w io.Writer, f filter) error {
+	archive := zip.NewWriter(w)
+
+	writeFile := func(name string, header []string, stream func(func(any) error) error, record func(any) []string) error {
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return err
+		}
+		out := csv.NewWriter(file)
+		if err := out.Write(header); err != nil {
+			return err
+		}
+		if err := stream(func(item any) error { return out.Write(record(item)) }); err != nil {
+			return err
+		}
+		out.Flush()
+		return out.Error()
+	}
+
+	err := writeFile("hives.csv", []string{"id", "hive_name", "created_at", "updated_at"}, h.streamHives(f), func(item any) []string {
+		hive := item.(*models.Hive)
+		return []string{uintString(hive.ID), strconv.Itoa(hive.HiveName), timeString(&hive.CreatedAt), timeString(&hive.UpdatedAt)}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeFile("logs.csv", []string{"id", "hive_id", "content", "created_at", "updated_at"}, h.streamLogs(f), func(item any) []string {
+		entry := item.(*models.Log)
+		return []string{uintString(entry.ID), strconv.Itoa(entry.HiveID), entry.Content, timeString(&entry.CreatedAt), timeString(&entry.UpdatedAt)}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeFile("tasks.csv", []string{"id", "hive_id", "content", "priority", "due_at", "completed_at", "created_at", "updated_at"}, h.streamTasks(f), func(item any) []string {
+		task := item.(*models.Task)
+		return []string{uintString(task.ID), strconv.Itoa(task.HiveID), task.Content, task.Priority, timeString(task.DueAt), timeString(task.CompletedAt), timeString(&task.CreatedAt), timeString(&task.UpdatedAt)}
+	})
+	if err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+func (h *handler) streamHives(f filter) func(func(any) error) error {
+	return func(fn func(any) error) error {
+		return each(h.db, f.hives(h.db), func(hive *models.Hive) error { return fn(hive) })
+	}
+}
+
+func (h *handler) streamLogs(f filter) func(func(any) error) error {
+	return func(fn func(any) error) error {
+		return each(h.db, f.entries(h.db, &models.Log{}, access.LogsRead), func(entry *models.Log) error { return fn(entry) })
+	}
+}
+
+func (h *handler) streamTasks(f filter) func(func(any) error) error {
+	return func(fn func(any) error) error {
+		return each(h.db, f.entries(h.db, &models.Task{}, access.TasksRead), func(task *models.Task) error { return fn(task) })
+	}
+}
+
+func uintString(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+func timeString(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
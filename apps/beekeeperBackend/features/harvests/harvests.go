@@ -0,0 +1,181 @@
+package harvests
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"beekeeper-api/access"
+	"beekeeper-api/features/incidents"
+	"beekeeper-api/models"
+	"beekeeper-api/services"
+)
+
+// --- Structs for Input Validation ---
+
+type CreateHarvestInput struct {
+	HiveID      int        `json:"hiveID" binding:"required" example:"123"`
+	Product     string     `json:"product" binding:"omitempty,oneof=honey wax pollen propolis royal_jelly" example:"honey"`
+	WeightKg    float64    `json:"weightKg" binding:"required,gt=0" example:"12.5"`
+	HarvestedAt *time.Time `json:"harvestedAt" example:"2024-07-20T10:00:00Z"`
+	Notes       string     `json:"notes" example:"Spring honey, 4 supers"`
+}
+
+// --- Route Registration ---
+
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
+	h := &handler{db: db}
+	guard := access.New(db)
+
+	harvestRoutes := router.Group("/harvests")
+	{
+		harvestRoutes.POST("", guard.Require(access.LogsWrite, access.HiveInBody("hiveID")), h.CreateHarvest)
+		harvestRoutes.GET("", guard.Require(access.LogsRead, access.Anywhere), h.ListHarvests)
+		harvestRoutes.GET("/:id", guard.Require(access.LogsRead, access.Harvest("id")), h.GetHarvest)
+		harvestRoutes.DELETE("/:id", guard.Require(access.LogsDelete, access.Harvest("id")), h.DeleteHarvest)
+	}
+}
+
+// --- Handler ---
+
+type handler struct {
+	db *gorm.DB
+}
+
+// CreateHarvest godoc
+// @Summary Record a harvest
+// @Description Record honey or other products taken from a hive. Hives under quarantine cannot be harvested. If the hive doesn't exist, it will be created automatically.
+// @Tags harvests
+// @Accept  json
+// @Produce  json
+// @Param harvest body CreateHarvestInput true "Harvest data"
+// @Success 201 {object} models.Harvest
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /harvests [post]
+func (h *handler) CreateHarvest(c *gin.Context) {
+	var input CreateHarvestInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	harvest := models.Harvest{
+		HiveID:      input.HiveID,
+		Product:     input.Product,
+		WeightKg:    input.WeightKg,
+		HarvestedAt: time.Now(),
+		Notes:       input.Notes,
+	}
+	if harvest.Product == "" {
+		harvest.Product = "honey"
+	}
+	if input.HarvestedAt != nil {
+		harvest.HarvestedAt = *input.HarvestedAt
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := services.FindOrCreateHive(tx, input.HiveID); err != nil {
+			return err
+		}
+		if err := incidents.CheckQuarantine(tx, input.HiveID); err != nil {
+			return err
+		}
+		return tx.Create(&harvest).Error
+	})
+	if errors.Is(err, incidents.ErrQuarantined) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Hive %d is under quarantine, no harvests can be recorded until its incidents are cleared", input.HiveID)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not record harvest"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, harvest)
+}
+
+// ListHarvests godoc
+// @Summary List harvests
+// @Description List harvests from the hives the user can read, newest first
+// @Tags harvests
+// @Produce  json
+// @Param hive_id query int false "Only harvests from this hive"
+// @Success 200 {array} models.Harvest
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /harvests [get]
+func (h *handler) ListHarvests(c *gin.Context) {
+	query := access.Visible(c, h.db, h.db.Order("harvested_at DESC"), access.LogsRead, "hive_id")
+	if value := c.Query("hive_id"); value != "" {
+		hiveID, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hive_id"})
+			return
+		}
+		query = query.Where("hive_id = ?", hiveID)
+	}
+
+	var harvests []models.Harvest
+	if result := query.Find(&harvests); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve harvests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, harvests)
+}
+
+// GetHarvest godoc
+// @Summary Get a harvest by ID
+// @Description Retrieve a specific harvest by ID
+// @Tags harvests
+// @Produce  json
+// @Param id path int true "Harvest ID"
+// @Success 200 {object} models.Harvest
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /harvests/{id} [get]
+func (h *handler) GetHarvest(c *gin.Context) {
+	id := c.Param("id")
+	var harvest models.Harvest
+
+	if result := h.db.First(&harvest, id); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Harvest not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, harvest)
+}
+
+// DeleteHarvest godoc
+// @Summary Delete a harvest
+// @Description Delete a harvest by ID
+// @Tags harvests
+// @Produce  json
+// @Param id path int true "Harvest ID"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /harvests/{id} [delete]
+func (h *handler) DeleteHarvest(c *gin.Context) {
+	id := c.Param("id")
+
+	if result := h.db.Delete(&models.Harvest{}, id); result.Error != nil || result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Harvest not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -1,44 +1,56 @@
 package hives
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
-	"beekeeper-api/models"
+	"beekeeper-api/access"
+	"beekeeper-api/events"
+	"beekeeper-api/features/tags"
+	"beekeeper-api/services"
 )
 
 // --- Structs for Input Validation ---
 
 type CreateHiveInput struct {
-	HiveName int `json:"hiveName" binding:"required"`
+	HiveName int      `json:"hiveName" binding:"required"`
+	ApiaryID *uint    `json:"apiaryID" example:"1"`
+	Tags     []string `json:"tags" example:"nucleus"`
 }
 
 type UpdateHiveInput struct {
 	HiveName int `json:"hiveName"`
+	// ApiaryID moves the hive to another apiary, 0 removes it from its apiary
+	ApiaryID *uint `json:"apiaryID" example:"1"`
+	// Tags replace the hive's tags when given
+	Tags []string `json:"tags" example:"nucleus"`
 }
 
 // --- Route Registration ---
 
-func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
-	h := &handler{db: db}
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, svc *services.Services, bus *events.Bus) {
+	h := &handler{hives: svc.Hives, bus: bus}
+	guard := access.New(db)
 
 	hiveRoutes := router.Group("/hives")
 	{
-		hiveRoutes.POST("", h.CreateHive)
-		hiveRoutes.GET("", h.ListHives)
-		hiveRoutes.GET("/:id", h.GetHive)
-		hiveRoutes.PATCH("/:id", h.UpdateHive)
-		hiveRoutes.DELETE("/:id", h.DeleteHive)
+		hiveRoutes.POST("", guard.Require(access.HivesWrite, access.HiveInBody("hiveName"), access.ApiaryInBody("apiaryID")), h.CreateHive)
+		hiveRoutes.GET("", guard.Require(access.HivesRead, access.Anywhere), h.ListHives)
+		hiveRoutes.GET("/:id", guard.Require(access.HivesRead, access.Hive("id")), h.GetHive)
+		hiveRoutes.PATCH("/:id", guard.Require(access.HivesWrite, access.Hive("id"), access.ApiaryInBody("apiaryID")), h.UpdateHive)
+		hiveRoutes.DELETE("/:id", guard.Require(access.HivesDelete, access.Hive("id")), h.DeleteHive)
 	}
 }
 
 // --- Handler ---
 
 type handler struct {
-	db *gorm.DB
+	hives services.HiveService
+	bus   *events.Bus
 }
 
 // CreateHive godoc
@@ -50,6 +62,9 @@ type handler struct {
 // @Param hive body CreateHiveInput true "Hive data"
 // @Success 201 {object} models.Hive
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /hives [post]
 func (h *handler) CreateHive(c *gin.Context) {
@@ -59,11 +74,20 @@ func (h *handler) CreateHive(c *gin.Context) {
 		return
 	}
 
-	hive := models.Hive{HiveName: input.HiveName}
-	if result := h.db.Create(&hive); result.Error != nil {
+	hive, err := h.hives.Create(services.HiveInput{HiveName: input.HiveName, ApiaryID: input.ApiaryID, Tags: input.Tags})
+	if errors.Is(err, services.ErrApiaryNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Apiary not found"})
+		return
+	}
+	if errors.Is(err, services.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Hive already exists"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create hive"})
 		return
 	}
+	h.bus.Publish(events.HiveCreated, hive.HiveName, hive)
 
 	c.JSON(http.StatusCreated, hive)
 }
@@ -73,12 +97,18 @@ func (h *handler) CreateHive(c *gin.Context) {
 // @Description Get a list of all hives
 // @Tags hives
 // @Produce  json
+// @Param tag query string false "Only hives with all of these tags, comma-separated"
 // @Success 200 {array} models.Hive
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /hives [get]
 func (h *handler) ListHives(c *gin.Context) {
-	var hives []models.Hive
-	if result := h.db.Find(&hives); result.Error != nil {
+	hives, err := h.hives.List(services.ListOptions{
+		Tags:   tags.ParseFilter(c),
+		Viewer: services.ViewerFrom(c, access.HivesRead),
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve hives"})
 		return
 	}
@@ -94,16 +124,27 @@ func (h *handler) ListHives(c *gin.Context) {
 // @Param id path int true "Hive ID"
 // @Success 200 {object} models.Hive
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /hives/{id} [get]
 func (h *handler) GetHive(c *gin.Context) {
-	id := c.Param("id")
-	var hive models.Hive
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
 
-	if result := h.db.Preload("Logs").Preload("Tasks").First(&hive, "hive_name = ?", id); result.Error != nil {
+	hive, err := h.hives.Get(id)
+	if errors.Is(err, services.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Hive not found"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve hive"})
+		return
+	}
 
 	c.JSON(http.StatusOK, hive)
 }
@@ -118,15 +159,16 @@ func (h *handler) GetHive(c *gin.Context) {
 // @Param hive body UpdateHiveInput true "Updated hive data"
 // @Success 200 {object} models.Hive
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /hives/{id} [patch]
 func (h *handler) UpdateHive(c *gin.Context) {
-	id := c.Param("id")
-	var hive models.Hive
-
-	if result := h.db.First(&hive, "hive_name = ?", id); result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Hive not found"})
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 		return
 	}
 
@@ -136,10 +178,24 @@ func (h *handler) UpdateHive(c *gin.Context) {
 		return
 	}
 
-	if result := h.db.Model(&hive).Updates(models.Hive{HiveName: input.HiveName}); result.Error != nil {
+	hive, err := h.hives.Update(id, services.HiveInput{HiveName: input.HiveName, ApiaryID: input.ApiaryID, Tags: input.Tags})
+	if errors.Is(err, services.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Hive not found"})
+		return
+	}
+	if errors.Is(err, services.ErrApiaryNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Apiary not found"})
+		return
+	}
+	if errors.Is(err, services.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Hive already exists"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
 		return
 	}
+	h.bus.Publish(events.HiveUpdated, hive.HiveName, hive)
 
 	c.JSON(http.StatusOK, hive)
 }
@@ -152,7 +208,10 @@ func (h *handler) UpdateHive(c *gin.Context) {
 // @Param id path int true "Hive ID"
 // @Success 204
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /hives/{id} [delete]
 func (h *handler) DeleteHive(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -160,13 +219,17 @@ func (h *handler) DeleteHive(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 		return
 	}
-	
-	if result := h.db.Delete(&models.Hive{}, "hive_name = ?", id); result.Error != nil || result.RowsAffected == 0 {
+
+	err = h.hives.Delete(id)
+	if errors.Is(err, services.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Hive not found"})
 		return
 	}
-	
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete hive"})
+		return
+	}
+	h.bus.Publish(events.HiveDeleted, id, gin.H{"hive_name": id})
+
 	c.Status(http.StatusNoContent)
 }
-
-
@@ -0,0 +1,607 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"beekeeper-api/access"
+	"beekeeper-api/auth"
+	"beekeeper-api/models"
+	"beekeeper-api/services"
+	"beekeeper-api/tagging"
+)
+
+// --- Structs for Input Validation ---
+
+// Mapping maps journal fields to the column headers of an uploaded CSV file.
+// Fields left empty default to the column of the same name, so files from
+// the CSV export can be imported without a mapping.
+type Mapping struct {
+	HiveID      string `json:"hive_id" example:"Hive"`
+	Content     string `json:"content" example:"Notes"`
+	CreatedAt   string `json:"created_at" example:"Date"`
+	Priority    string `json:"priority" example:"Priority"`
+	DueAt       string `json:"due_at" example:"Due"`
+	CompletedAt string `json:"completed_at" example:"Done on"`
+}
+
+// RowError describes why one row of the import cannot be imported
+type RowError struct {
+	Entity  string `json:"entity" example:"log"`
+	Row     int    `json:"row" example:"4"`
+	Message string `json:"message" example:"content is empty"`
+}
+
+// Report summarises what an import did, or would do in a dry run
+type Report struct {
+	DryRun        bool       `json:"dry_run" example:"true"`
+	Hives         int        `json:"hives" example:"3"`
+	HivesToCreate []int      `json:"hives_to_create" example:"12,14"`
+	Logs          int        `json:"logs" example:"120"`
+	Tasks         int        `json:"tasks" example:"8"`
+	Duplicates    int        `json:"duplicates" example:"2"`
+	Errors        []RowError `json:"errors"`
+}
+
+// Document is the part of a JSON export that is imported: hives, content,
+// dates and priorities. IDs, assignees and tags refer to records of the
+// instance the export comes from and are left out.
+type Document struct {
+	Hives []Hive `json:"hives"`
+	Logs  []Log  `json:"logs"`
+	Tasks []Task `json:"tasks"`
+}
+
+// Hive is an imported hive
+type Hive struct {
+	HiveName  int       `json:"hive_name" example:"12"`
+	CreatedAt time.Time `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+}
+
+// Log is an imported log entry
+type Log struct {
+	HiveID    int       `json:"hive_id" example:"12"`
+	Content   string    `json:"content" example:"Queen seen, 6 frames of brood"`
+	CreatedAt time.Time `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+}
+
+// Task is an imported task
+type Task struct {
+	HiveID      int        `json:"hive_id" example:"12"`
+	Content     string     `json:"content" example:"Add a super"`
+	Priority    string     `json:"priority" example:"normal"`
+	DueAt       *time.Time `json:"due_at" example:"2024-01-20T09:00:00Z"`
+	CompletedAt *time.Time `json:"completed_at" example:"2024-01-19T16:00:00Z"`
+	CreatedAt   time.Time  `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt   time.Time  `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+}
+
+// --- Route Registration ---
+
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
+	h := &handler{db: db}
+	guard := access.New(db)
+
+	router.POST("/import", guard.Require(access.LogsWrite, access.Anywhere), h.Import)
+}
+
+// --- Handler ---
+
+type handler struct {
+	db *gorm.DB
+}
+
+type logRow struct {
+	row       int
+	log       models.Log
+	duplicate bool
+}
+
+type taskRow struct {
+	row       int
+	task      models.Task
+	duplicate bool
+}
+
+// batch is an import normalised from either input format
+type batch struct {
+	hives  []models.Hive
+	logs   []logRow
+	tasks  []taskRow
+	errors []RowError
+}
+
+// Import godoc
+// @Summary Import journal entries
+// @Description Import a JSON export (application/json) or a CSV file of logs or tasks (multipart/form-data). Run with dry_run=true first to get a validation report without writing anything. A real import is atomic: if any row is invalid nothing is imported. Only hives, content, dates and task priorities are imported; assignees and tags of the export are not, hashtags in the content become tags as usual. Missing hives are created, entries already in the journal (same hive, content and creation time) are skipped, and original creation times are kept. Rows for hives the user cannot write are reported as invalid.
+// @Tags import
+// @Accept json
+// @Accept multipart/form-data
+// @Produce json
+// @Param dry_run query bool false "Only validate and report"
+// @Param document body Document false "JSON export document"
+// @Param file formData file false "CSV file"
+// @Param entity formData string false "What the CSV rows are: logs or tasks"
+// @Param mapping formData string false "Column mapping as JSON, see Mapping"
+// @Param time_format formData string false "Go time layout of the date columns, e.g. 02.01.2006 15:04"
+// @Param delimiter formData string false "CSV field delimiter" default(,)
+// @Success 200 {object} Report
+// @Success 201 {object} Report
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 422 {object} Report
+// @Failure 500 {object} map[string]string
+// @Router /import [post]
+func (h *handler) Import(c *gin.Context) {
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	var b batch
+	var err error
+	switch c.ContentType() {
+	case "application/json":
+		b, err = parseJSON(c.Request.Body)
+	case "multipart/form-data":
+		b, err = parseCSVUpload(c)
+	default:
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Send a JSON export or a CSV file as multipart/form-data"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, _ := auth.CurrentUser(c)
+	if dryRun {
+		report, err := plan(h.db, &b, user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate import"})
+			return
+		}
+		report.DryRun = true
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	var report Report
+	errInvalid := errors.New("import has invalid rows")
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if report, err = plan(tx, &b, user); err != nil {
+			return err
+		}
+		if len(report.Errors) > 0 {
+			return errInvalid
+		}
+		return apply(tx, &b, report.HivesToCreate)
+	})
+	switch {
+	case errors.Is(err, errInvalid):
+		c.JSON(http.StatusUnprocessableEntity, report)
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Import failed, nothing was imported"})
+	default:
+		c.JSON(http.StatusCreated, report)
+	}
+}
+
+// plan validates the batch, checks the user may write every row, marks
+// entries that are already in the journal and reports which hives will have
+// to be created.
+func plan(db *gorm.DB, b *batch, user *models.User) (Report, error) {
+	report := Report{Errors: append([]RowError{}, b.errors...), HivesToCreate: []int{}}
+	referenced := make(map[int]bool)
+
+	for i, hive := range b.hives {
+		if hive.HiveName <= 0 {
+			report.Errors = append(report.Errors, RowError{"hive", i + 1, "hive_name must be a positive number"})
+			continue
+		}
+		allowed, err := canWrite(db, user, hive.HiveName, access.HivesWrite)
+		if err != nil {
+			return report, err
+		}
+		if !allowed {
+			report.Errors = append(report.Errors, RowError{"hive", i + 1, "no permission for this hive"})
+			continue
+		}
+		referenced[hive.HiveName] = true
+	}
+
+	seen := make(map[string]bool)
+	for i := range b.logs {
+		row := &b.logs[i]
+		if msg := validateEntry(row.log.HiveID, row.log.Content); msg != "" {
+			report.Errors = append(report.Errors, RowError{"log", row.row, msg})
+			continue
+		}
+		allowed, err := canWrite(db, user, row.log.HiveID, access.LogsWrite)
+		if err != nil {
+			return report, err
+		}
+		if !allowed {
+			report.Errors = append(report.Errors, RowError{"log", row.row, "no permission for this hive"})
+			continue
+		}
+		referenced[row.log.HiveID] = true
+		dup, err := isDuplicate(db, &models.Log{}, seen, "log", row.log.HiveID, row.log.Content, row.log.CreatedAt)
+		if err != nil {
+			return report, err
+		}
+		row.duplicate = dup
+		if dup {
+			report.Duplicates++
+		} else {
+			report.Logs++
+		}
+	}
+
+	for i := range b.tasks {
+		row := &b.tasks[i]
+		if msg := validateEntry(row.task.HiveID, row.task.Content); msg != "" {
+			report.Errors = append(report.Errors, RowError{"task", row.row, msg})
+			continue
+		}
+		switch row.task.Priority {
+		case "":
+			row.task.Priority = models.PriorityNormal
+		case models.PriorityLow, models.PriorityNormal, models.PriorityHigh:
+		default:
+			report.Errors = append(report.Errors, RowError{"task", row.row, fmt.Sprintf("unknown priority %q", row.task.Priority)})
+			continue
+		}
+		allowed, err := canWrite(db, user, row.task.HiveID, access.TasksWrite)
+		if err != nil {
+			return report, err
+		}
+		if !allowed {
+			report.Errors = append(report.Errors, RowError{"task", row.row, "no permission for this hive"})
+			continue
+		}
+		referenced[row.task.HiveID] = true
+		dup, err := isDuplicate(db, &models.Task{}, seen, "task", row.task.HiveID, row.task.Content, row.task.CreatedAt)
+		if err != nil {
+			return report, err
+		}
+		row.duplicate = dup
+		if dup {
+			report.Duplicates++
+		} else {
+			report.Tasks++
+		}
+	}
+
+	report.Hives = len(referenced)
+	if len(referenced) > 0 {
+		names := make([]int, 0, len(referenced))
+		for name := range referenced {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		var existing []int
+		if err := db.Model(&models.Hive{}).Where("hive_name IN ?", names).Pluck("hive_name", &existing).Error; err != nil {
+			return report, err
+		}
+		found := make(map[int]bool, len(existing))
+		for _, name := range existing {
+			found[name] = true
+		}
+		for _, name := range names {
+			if !found[name] {
+				report.HivesToCreate = append(report.HivesToCreate, name)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// canWrite reports whether a user holds a write permission for a hive.
+// Anonymous imports, which the guard only lets through without users, may
+// write all.
+func canWrite(db *gorm.DB, user *models.User, hiveName int, perm access.Permission) (bool, error) {
+	if user == nil {
+		return true, nil
+	}
+	role, err := access.HiveRole(db, user, hiveName)
+	if err != nil {
+		return false, err
+	}
+	return access.Can(role, perm), nil
+}
+
+func validateEntry(hiveID int, content string) string {
+	if hiveID <= 0 {
+		return "hive_id must be a positive number"
+	}
+	if strings.TrimSpace(content) == "" {
+		return "content is empty"
+	}
+	return ""
+}
+
+// isDuplicate reports whether an entry with the same hive, content and creation
+// time is already in the journal or earlier in this import. Times are compared
+// in Go so differently stored time zones still match.
+func isDuplicate(db *gorm.DB, model any, seen map[string]bool, kind string, hiveID int, content string, createdAt time.Time) (bool, error) {
+	if createdAt.IsZero() {
+		return false, nil // gets the current time, so it cannot be a re-import
+	}
+
+	key := fmt.Sprintf("%s|%d|%d|%s", kind, hiveID, createdAt.UnixNano(), content)
+	if seen[key] {
+		return true, nil
+	}
+	seen[key] = true
+
+	var times []time.Time
+	err := db.Model(model).Where("hive_id = ? AND content = ?", hiveID, content).Pluck("created_at", &times).Error
+	if err != nil {
+		return false, err
+	}
+	for _, t := range times {
+		if t.Equal(createdAt) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// apply writes a planned batch, creating the hives the report lists as
+// missing. Creation times from the import are kept; GORM only fills in
+// timestamps that are zero.
+func apply(tx *gorm.DB, b *batch, missing []int) error {
+	ensured := make(map[int]bool)
+	ensureHive := func(hiveID int) error {
+		if ensured[hiveID] {
+			return nil
+		}
+		ensured[hiveID] = true
+		_, err := services.FindOrCreateHive(tx, hiveID)
+		return err
+	}
+
+	// Hives of a JSON export that are new keep their original times
+	for _, hive := range b.hives {
+		if err := ensureHive(hive.HiveName); err != nil {
+			return err
+		}
+		if !slices.Contains(missing, hive.HiveName) || hive.CreatedAt.IsZero() {
+			continue
+		}
+		updatedAt := hive.UpdatedAt
+		if updatedAt.IsZero() {
+			updatedAt = hive.CreatedAt
+		}
+		err := tx.Model(&models.Hive{}).
+			Where("hive_name = ?", hive.HiveName).
+			UpdateColumns(map[string]any{"created_at": hive.CreatedAt, "updated_at": updatedAt}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, row := range b.logs {
+		if row.duplicate {
+			continue
+		}
+		if err := ensureHive(row.log.HiveID); err != nil {
+			return err
+		}
+		entry := row.log
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+		if err := tagging.TagHashtags(tx, &entry, entry.Content); err != nil {
+			return err
+		}
+	}
+
+	for _, row := range b.tasks {
+		if row.duplicate {
+			continue
+		}
+		if err := ensureHive(row.task.HiveID); err != nil {
+			return err
+		}
+		task := row.task
+		if err := tx.Create(&task).Error; err != nil {
+			return err
+		}
+		if err := tagging.TagHashtags(tx, &task, task.Content); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// --- Input formats ---
+
+func parseJSON(body io.Reader) (batch, error) {
+	var doc Document
+	if err := json.NewDecoder(body).Decode(&doc); err != nil {
+		return batch{}, fmt.Errorf("invalid JSON export: %v", err)
+	}
+
+	var b batch
+	for _, hive := range doc.Hives {
+		b.hives = append(b.hives, models.Hive{HiveName: hive.HiveName, CreatedAt: hive.CreatedAt, UpdatedAt: hive.UpdatedAt})
+	}
+	for i, entry := range doc.Logs {
+		b.logs = append(b.logs, logRow{row: i + 1, log: models.Log{
+			HiveID:    entry.HiveID,
+			Content:   entry.Content,
+			CreatedAt: entry.CreatedAt,
+			UpdatedAt: entry.UpdatedAt,
+		}})
+	}
+	for i, task := range doc.Tasks {
+		b.tasks = append(b.tasks, taskRow{row: i + 1, task: models.Task{
+			HiveID:      task.HiveID,
+			Content:     task.Content,
+			Priority:    task.Priority,
+			DueAt:       task.DueAt,
+			CompletedAt: task.CompletedAt,
+			CreatedAt:   task.CreatedAt,
+			UpdatedAt:   task.UpdatedAt,
+		}})
+	}
+	return b, nil
+}
+
+// hiveNumber finds the hive number in values like "12", "Hive 12" or "#12"
+var hiveNumber = regexp.MustCompile(`\d+`)
+
+func parseCSVUpload(c *gin.Context) (batch, error) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return batch{}, errors.New("missing CSV file in form field 'file'")
+	}
+	entity := c.PostForm("entity")
+	if entity != "logs" && entity != "tasks" {
+		return batch{}, errors.New("entity must be 'logs' or 'tasks'")
+	}
+
+	var mapping Mapping
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			return batch{}, fmt.Errorf("invalid mapping: %v", err)
+		}
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return batch{}, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	if delimiter := c.PostForm("delimiter"); delimiter != "" {
+		reader.Comma = []rune(delimiter)[0]
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return batch{}, fmt.Errorf("could not read CSV header: %v", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	column := func(mapped, fallback string) (int, bool) {
+		if mapped == "" {
+			mapped = fallback
+		}
+		i, ok := columns[strings.ToLower(strings.TrimSpace(mapped))]
+		return i, ok
+	}
+
+	hiveCol, ok := column(mapping.HiveID, "hive_id")
+	if !ok {
+		return batch{}, errors.New("CSV has no hive_id column, add it to the mapping")
+	}
+	contentCol, ok := column(mapping.Content, "content")
+	if !ok {
+		return batch{}, errors.New("CSV has no content column, add it to the mapping")
+	}
+	createdCol, hasCreated := column(mapping.CreatedAt, "created_at")
+	priorityCol, hasPriority := column(mapping.Priority, "priority")
+	dueCol, hasDue := column(mapping.DueAt, "due_at")
+	completedCol, hasCompleted := column(mapping.CompletedAt, "completed_at")
+	timeFormat := c.PostForm("time_format")
+
+	var b batch
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return batch{}, fmt.Errorf("CSV line %d: %v", line, err)
+		}
+		field := func(i int, ok bool) string {
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		hiveID, _ := strconv.Atoi(hiveNumber.FindString(field(hiveCol, true)))
+		createdAt, err := parseTime(field(createdCol, hasCreated), timeFormat)
+		if err != nil {
+			b.errors = append(b.errors, RowError{strings.TrimSuffix(entity, "s"), line, err.Error()})
+			continue
+		}
+
+		if entity == "logs" {
+			b.logs = append(b.logs, logRow{row: line, log: models.Log{
+				HiveID:    hiveID,
+				Content:   field(contentCol, true),
+				CreatedAt: createdAt,
+			}})
+			continue
+		}
+
+		task := models.Task{
+			HiveID:    hiveID,
+			Content:   field(contentCol, true),
+			Priority:  strings.ToLower(field(priorityCol, hasPriority)),
+			CreatedAt: createdAt,
+		}
+		if due := field(dueCol, hasDue); due != "" {
+			dueAt, err := parseTime(due, timeFormat)
+			if err != nil {
+				b.errors = append(b.errors, RowError{"task", line, err.Error()})
+				continue
+			}
+			task.DueAt = &dueAt
+		}
+		if completed := field(completedCol, hasCompleted); completed != "" {
+			completedAt, err := parseTime(completed, timeFormat)
+			if err != nil {
+				b.errors = append(b.errors, RowError{"task", line, err.Error()})
+				continue
+			}
+			task.CompletedAt = &completedAt
+		}
+		b.tasks = append(b.tasks, taskRow{row: line, task: task})
+	}
+
+	return b, nil
+}
+
+// timeLayouts are tried in order when no time_format is given
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04", time.DateOnly}
+
+func parseTime(value, layout string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	layouts := timeLayouts
+	if layout != "" {
+		layouts = []string{layout}
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date %q", value)
+}
@@ -0,0 +1,319 @@
+package incidents
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"beekeeper-api/access"
+	"beekeeper-api/events"
+	"beekeeper-api/models"
+	"beekeeper-api/services"
+)
+
+// ErrQuarantined is returned by CheckQuarantine for hives under quarantine.
+var ErrQuarantined = errors.New("hive is under quarantine")
+
+// --- Structs for Input Validation ---
+
+type CreateIncidentInput struct {
+	HiveID                int        `json:"hiveID" binding:"required" example:"123"`
+	LogID                 *uint      `json:"logID" example:"1"`
+	Disease               string     `json:"disease" binding:"required,oneof=afb efb shb tropilaelaps asian_hornet other" example:"afb"`
+	Status                string     `json:"status" binding:"omitempty,oneof=suspected confirmed" example:"suspected"`
+	SampleSentAt          *time.Time `json:"sampleSentAt" example:"2024-01-16T09:00:00Z"`
+	SampleReference       string     `json:"sampleReference" example:"LAB-2024-0042"`
+	ActionsTaken          string     `json:"actionsTaken" example:"Hive entrance closed, veterinary office informed"`
+	ReportedToAuthorityAt *time.Time `json:"reportedToAuthorityAt" example:"2024-01-15T12:00:00Z"`
+}
+
+type UpdateIncidentInput struct {
+	Status                string     `json:"status" binding:"omitempty,oneof=suspected confirmed cleared" example:"confirmed"`
+	LogID                 *uint      `json:"logID" example:"1"`
+	SampleSentAt          *time.Time `json:"sampleSentAt" example:"2024-01-16T09:00:00Z"`
+	SampleReference       *string    `json:"sampleReference" example:"LAB-2024-0042"`
+	LabResult             *string    `json:"labResult" example:"Paenibacillus larvae detected"`
+	LabResultAt           *time.Time `json:"labResultAt" example:"2024-01-22T09:00:00Z"`
+	ActionsTaken          *string    `json:"actionsTaken" example:"Colony destroyed by burning"`
+	ReportedToAuthorityAt *time.Time `json:"reportedToAuthorityAt" example:"2024-01-15T12:00:00Z"`
+}
+
+// --- Route Registration ---
+
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, bus *events.Bus) {
+	h := &handler{db: db, bus: bus}
+	guard := access.New(db)
+
+	incidentRoutes := router.Group("/incidents")
+	{
+		incidentRoutes.POST("", guard.Require(access.HivesWrite, access.HiveInBody("hiveID")), h.CreateIncident)
+		incidentRoutes.GET("", guard.Require(access.HivesRead, access.Anywhere), h.ListIncidents)
+		incidentRoutes.GET("/:id", guard.Require(access.HivesRead, access.Incident("id")), h.GetIncident)
+		incidentRoutes.PATCH("/:id", guard.Require(access.HivesWrite, access.Incident("id")), h.UpdateIncident)
+	}
+}
+
+// CheckQuarantine returns ErrQuarantined if the hive is under quarantine.
+// Unknown hives are not quarantined.
+func CheckQuarantine(db *gorm.DB, hiveName int) error {
+	var hive models.Hive
+	err := db.Where("hive_name = ?", hiveName).Limit(1).Find(&hive).Error
+	if err != nil {
+		return err
+	}
+	if hive.QuarantinedSince != nil {
+		return ErrQuarantined
+	}
+	return nil
+}
+
+// updateQuarantine puts the hive under quarantine while it has open incidents
+// and lifts it once all of them are cleared. It reports whether the hive's
+// quarantine status changed.
+func updateQuarantine(tx *gorm.DB, hiveName int) (hive models.Hive, changed bool, err error) {
+	if err := tx.First(&hive, "hive_name = ?", hiveName).Error; err != nil {
+		return hive, false, err
+	}
+	var open int64
+	if err := tx.Model(&models.Incident{}).Where("hive_id = ? AND status <> ?", hiveName, models.IncidentCleared).Count(&open).Error; err != nil {
+		return hive, false, err
+	}
+
+	switch {
+	case open > 0 && hive.QuarantinedSince == nil:
+		now := time.Now()
+		hive.QuarantinedSince = &now
+	case open == 0 && hive.QuarantinedSince != nil:
+		hive.QuarantinedSince = nil
+	default:
+		return hive, false, nil
+	}
+	return hive, true, tx.Model(&hive).Update("quarantined_since", hive.QuarantinedSince).Error
+}
+
+// --- Handler ---
+
+type handler struct {
+	db  *gorm.DB
+	bus *events.Bus
+}
+
+// CreateIncident godoc
+// @Summary Report a disease or pest incident
+// @Description Report a suspected or confirmed case of a notifiable disease or pest (AFB, EFB, small hive beetle, Tropilaelaps, Asian hornet). The hive is put under quarantine, which blocks harvests and moving equipment out of it until every incident is cleared. If the hive doesn't exist, it will be created automatically.
+// @Tags incidents
+// @Accept  json
+// @Produce  json
+// @Param incident body CreateIncidentInput true "Incident data"
+// @Success 201 {object} models.Incident
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /incidents [post]
+func (h *handler) CreateIncident(c *gin.Context) {
+	var input CreateIncidentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if input.Status == "" {
+		input.Status = models.IncidentSuspected
+	}
+
+	var incident models.Incident
+	var hive models.Hive
+	var quarantineChanged bool
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := services.FindOrCreateHive(tx, input.HiveID); err != nil {
+			return err
+		}
+		if input.LogID != nil {
+			if err := tx.First(&models.Log{}, "id = ? AND hive_id = ?", *input.LogID, input.HiveID).Error; err != nil {
+				return err
+			}
+		}
+
+		incident = models.Incident{
+			HiveID:                input.HiveID,
+			LogID:                 input.LogID,
+			Disease:               input.Disease,
+			Status:                input.Status,
+			SampleSentAt:          input.SampleSentAt,
+			SampleReference:       input.SampleReference,
+			ActionsTaken:          input.ActionsTaken,
+			ReportedToAuthorityAt: input.ReportedToAuthorityAt,
+		}
+		if err := tx.Create(&incident).Error; err != nil {
+			return err
+		}
+
+		var err error
+		hive, quarantineChanged, err = updateQuarantine(tx, input.HiveID)
+		return err
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Log not found for this hive"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not report incident"})
+		return
+	}
+	h.bus.Publish(events.IncidentReported, incident.HiveID, incident)
+	if quarantineChanged {
+		h.bus.Publish(events.HiveUpdated, hive.HiveName, hive)
+	}
+
+	c.JSON(http.StatusCreated, incident)
+}
+
+// ListIncidents godoc
+// @Summary List incidents
+// @Description List disease and pest incidents of the hives the user can read, newest first
+// @Tags incidents
+// @Produce  json
+// @Param hive_id query int false "Only incidents of this hive"
+// @Param status query string false "Only incidents with this status (suspected, confirmed, cleared)"
+// @Param open query bool false "Only incidents that are not cleared"
+// @Success 200 {array} models.Incident
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /incidents [get]
+func (h *handler) ListIncidents(c *gin.Context) {
+	query := access.Visible(c, h.db, h.db.Order("created_at DESC"), access.HivesRead, "hive_id")
+	if value := c.Query("hive_id"); value != "" {
+		hiveID, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hive_id"})
+			return
+		}
+		query = query.Where("hive_id = ?", hiveID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if c.Query("open") == "true" {
+		query = query.Where("status <> ?", models.IncidentCleared)
+	}
+
+	var incidents []models.Incident
+	if result := query.Find(&incidents); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve incidents"})
+		return
+	}
+
+	c.JSON(http.StatusOK, incidents)
+}
+
+// GetIncident godoc
+// @Summary Get an incident by ID
+// @Description Retrieve a specific incident by ID
+// @Tags incidents
+// @Produce  json
+// @Param id path int true "Incident ID"
+// @Success 200 {object} models.Incident
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /incidents/{id} [get]
+func (h *handler) GetIncident(c *gin.Context) {
+	id := c.Param("id")
+	var incident models.Incident
+
+	if result := h.db.First(&incident, id); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, incident)
+}
+
+// UpdateIncident godoc
+// @Summary Update an incident
+// @Description Record samples, lab results and actions taken, confirm an incident or clear it. Clearing the last open incident of a hive lifts its quarantine.
+// @Tags incidents
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Incident ID"
+// @Param incident body UpdateIncidentInput true "Incident update data"
+// @Success 200 {object} models.Incident
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /incidents/{id} [patch]
+func (h *handler) UpdateIncident(c *gin.Context) {
+	id := c.Param("id")
+	var incident models.Incident
+
+	if result := h.db.First(&incident, id); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+		return
+	}
+
+	var input UpdateIncidentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if input.Status != "" && input.Status != incident.Status {
+		incident.Status = input.Status
+		if input.Status == models.IncidentCleared {
+			now := time.Now()
+			incident.ClearedAt = &now
+		} else {
+			incident.ClearedAt = nil
+		}
+	}
+	if input.LogID != nil {
+		incident.LogID = input.LogID
+	}
+	if input.SampleSentAt != nil {
+		incident.SampleSentAt = input.SampleSentAt
+	}
+	if input.SampleReference != nil {
+		incident.SampleReference = *input.SampleReference
+	}
+	if input.LabResult != nil {
+		incident.LabResult = *input.LabResult
+	}
+	if input.LabResultAt != nil {
+		incident.LabResultAt = input.LabResultAt
+	}
+	if input.ActionsTaken != nil {
+		incident.ActionsTaken = *input.ActionsTaken
+	}
+	if input.ReportedToAuthorityAt != nil {
+		incident.ReportedToAuthorityAt = input.ReportedToAuthorityAt
+	}
+
+	var hive models.Hive
+	var quarantineChanged bool
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&incident).Error; err != nil {
+			return err
+		}
+		var err error
+		hive, quarantineChanged, err = updateQuarantine(tx, incident.HiveID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+	h.bus.Publish(events.IncidentUpdated, incident.HiveID, incident)
+	if quarantineChanged {
+		h.bus.Publish(events.HiveUpdated, hive.HiveName, hive)
+	}
+
+	c.JSON(http.StatusOK, incident)
+}
@@ -0,0 +1,199 @@
+package login
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+
+	"beekeeper-api/auth"
+	"beekeeper-api/models"
+)
+
+// loginTimeout is how long a user has to log in at the issuer
+const loginTimeout = 10 * time.Minute
+
+// maxPending caps the logins in progress, which anyone can start
+const maxPending = 1000
+
+// stateCookie binds a login to the browser that started it, so a callback
+// with someone else's code and state is refused
+const stateCookie = "beekeeper_login_state"
+
+// --- Response Structs ---
+
+// LoginResponse is the user who logged in with an API token for the client,
+// which is only shown once
+type LoginResponse struct {
+	User  models.User `json:"user"`
+	Token string      `json:"token" example:"9b1c6f0e2d..."`
+}
+
+// --- Route Registration ---
+
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, provider *auth.OIDC) {
+	h := &handler{db: db, provider: provider, pending: make(map[string]pendingLogin)}
+
+	authRoutes := router.Group("/auth")
+	{
+		authRoutes.GET("/login", h.Login)
+		authRoutes.GET("/callback", h.Callback)
+	}
+}
+
+// --- Handler ---
+
+// pendingLogin is a login started at the issuer, keyed by its state
+type pendingLogin struct {
+	verifier  string
+	nonce     string
+	expiresAt time.Time
+}
+
+type handler struct {
+	db       *gorm.DB
+	provider *auth.OIDC
+
+	mu      sync.Mutex
+	pending map[string]pendingLogin
+}
+
+// Login godoc
+// @Summary Log in with OpenID Connect
+// @Description Redirect the browser to the configured issuer (e.g. Keycloak) to log in, using the authorization code flow with PKCE. The state of the login is also set in an HttpOnly cookie, so only this browser can finish it.
+// @Tags auth
+// @Success 302
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /auth/login [get]
+func (h *handler) Login(c *gin.Context) {
+	if h.provider == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "OIDC login is not configured"})
+		return
+	}
+
+	state, _, err := auth.NewToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not start login"})
+		return
+	}
+	nonce, _, err := auth.NewToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not start login"})
+		return
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	now := time.Now()
+	h.mu.Lock()
+	for key, login := range h.pending {
+		if now.After(login.expiresAt) {
+			delete(h.pending, key)
+		}
+	}
+	full := len(h.pending) >= maxPending
+	if !full {
+		h.pending[state] = pendingLogin{verifier: verifier, nonce: nonce, expiresAt: now.Add(loginTimeout)}
+	}
+	h.mu.Unlock()
+	if full {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Too many logins in progress, please try again later"})
+		return
+	}
+
+	h.setStateCookie(c, state, int(loginTimeout.Seconds()))
+	c.Redirect(http.StatusFound, h.provider.OAuth2.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier), oidc.Nonce(nonce)))
+}
+
+// Callback godoc
+// @Summary Finish an OpenID Connect login
+// @Description The issuer redirects here after logging in, in the browser that started the login. The code is exchanged for an ID token, whose claims are mapped to a local user (by subject, then by verified email if a single account not yet linked to a login uses it; unknown users are created). The response contains a new API token for the client, or redirects to OIDC_POST_LOGIN_URL with the token in the URL fragment if that is set.
+// @Tags auth
+// @Produce  json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State from the login"
+// @Success 200 {object} LoginResponse
+// @Success 302
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/callback [get]
+func (h *handler) Callback(c *gin.Context) {
+	if h.provider == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "OIDC login is not configured"})
+		return
+	}
+	if reason := c.Query("error"); reason != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login failed: " + reason})
+		return
+	}
+
+	state := c.Query("state")
+	cookie, err := c.Cookie(stateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Login was not started in this browser, please start again"})
+		return
+	}
+	h.setStateCookie(c, "", -1)
+
+	h.mu.Lock()
+	login, ok := h.pending[state]
+	delete(h.pending, state)
+	h.mu.Unlock()
+	if !ok || time.Now().After(login.expiresAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown or expired login, please start again"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	token, err := h.provider.OAuth2.Exchange(ctx, c.Query("code"), oauth2.VerifierOption(login.verifier))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Could not exchange authorization code"})
+		return
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Issuer returned no ID token"})
+		return
+	}
+	user, err := h.provider.VerifyIDToken(ctx, rawIDToken, login.nonce)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid ID token"})
+		return
+	}
+
+	secret, hash, err := auth.NewToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create token"})
+		return
+	}
+	apiToken := models.APIToken{UserID: user.ID, Name: "OIDC login", TokenHash: hash}
+	if result := h.db.Create(&apiToken); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create token"})
+		return
+	}
+
+	if h.provider.PostLoginURL != "" {
+		c.Redirect(http.StatusFound, h.provider.PostLoginURL+"#token="+url.QueryEscape(secret))
+		return
+	}
+	c.JSON(http.StatusOK, LoginResponse{User: *user, Token: secret})
+}
+
+// setStateCookie sets or, with a negative maxAge, clears the state cookie for
+// the auth routes. It is sent along when the issuer redirects back.
+func (h *handler) setStateCookie(c *gin.Context, state string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	secure := strings.HasPrefix(h.provider.OAuth2.RedirectURL, "https://")
+	c.SetCookie(stateCookie, state, maxAge, path.Dir(c.Request.URL.Path), "", secure, true)
+}
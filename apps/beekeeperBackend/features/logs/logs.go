@@ -1,81 +1,78 @@
 package logs
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
-    "gorm.io/gorm/clause"
 
-	"beekeeper-api/models"
+	"beekeeper-api/access"
+	"beekeeper-api/events"
+	"beekeeper-api/features/tags"
+	"beekeeper-api/services"
 )
 
 // --- Structs for Input Validation ---
 
 type CreateEntryInput struct {
-	Content string `json:"content" binding:"required"`
-	HiveID  int    `json:"hiveID" binding:"required"`
+	Content string   `json:"content" binding:"required"`
+	HiveID  int      `json:"hiveID" binding:"required"`
+	Tags    []string `json:"tags" example:"winter-prep"`
 }
 
 type UpdateEntryInput struct {
-	Content string `json:"content"`
-	HiveID  int    `json:"hiveID"`
+	Content string   `json:"content"`
+	HiveID  int      `json:"hiveID"`
+	Tags    []string `json:"tags" example:"winter-prep"`
 }
 
 // --- Route Registration ---
 
-func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
-	h := &handler{db: db}
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, svc *services.Services, bus *events.Bus) {
+	h := &handler{logs: svc.Logs, bus: bus}
+	guard := access.New(db)
 
 	logRoutes := router.Group("/logs")
 	{
-		logRoutes.POST("", h.CreateLog)
-		logRoutes.GET("", h.ListLogs)
-        logRoutes.GET("/last", h.GetLastLog)
-		logRoutes.GET("/:id", h.GetLog)
-		logRoutes.PUT("/:id", h.UpdateLog)
-		logRoutes.DELETE("/:id", h.DeleteLog)
+		logRoutes.POST("", guard.Require(access.LogsWrite, access.HiveInBody("hiveID")), h.CreateLog)
+		logRoutes.GET("", guard.Require(access.LogsRead, access.Anywhere), h.ListLogs)
+		logRoutes.GET("/last", guard.Require(access.LogsRead, access.Anywhere), h.GetLastLog)
+		logRoutes.GET("/:id", guard.Require(access.LogsRead, access.Log("id")), h.GetLog)
+		logRoutes.PUT("/:id", guard.Require(access.LogsWrite, access.Log("id"), access.HiveInBody("hiveID")), h.UpdateLog)
+		logRoutes.DELETE("/:id", guard.Require(access.LogsDelete, access.Log("id")), h.DeleteLog)
 	}
 }
 
 // --- Handler ---
 
 type handler struct {
-	db *gorm.DB
+	logs services.LogService
+	bus  *events.Bus
 }
 
-
-// findOrCreateHive finds a hive by its ID (name) or creates it if it doesn't exist.
-// This implements the "lazy creation" logic described in the ADR.
-func (h *handler) findOrCreateHive(hiveID int) (models.Hive, error) {
-    var hive models.Hive
-    err := h.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&hive, "hive_name = ?", hiveID).Error
-    if err != nil {
-        if err == gorm.ErrRecordNotFound {
-            // Hive not found, create it
-            hive = models.Hive{HiveName: hiveID}
-            if createErr := h.db.Create(&hive).Error; createErr != nil {
-                return models.Hive{}, createErr
-            }
-        } else {
-            // Another error occurred
-            return models.Hive{}, err
-        }
-    }
-    return hive, nil
+// logID reads the ID of a log entry from the path
+func logID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return 0, false
+	}
+	return uint(id), true
 }
 
-
 // CreateLog godoc
 // @Summary Create a new log entry
-// @Description Create a new log entry for a hive. If the hive doesn't exist, it will be created automatically.
+// @Description Create a new log entry for a hive. If the hive doesn't exist, it will be created automatically. Hashtags in the content ("#requeen" or dictated "hashtag requeen") are added to the given tags.
 // @Tags logs
 // @Accept  json
 // @Produce  json
 // @Param log body CreateEntryInput true "Log creation data"
 // @Success 201 {object} models.Log
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /logs [post]
 func (h *handler) CreateLog(c *gin.Context) {
@@ -85,29 +82,14 @@ func (h *handler) CreateLog(c *gin.Context) {
 		return
 	}
 
-    // Transaction to ensure atomicity of find/create hive and create log
-    err := h.db.Transaction(func(tx *gorm.DB) error {
-        if _, err := h.findOrCreateHive(input.HiveID); err != nil {
-            return err
-        }
-
-        logEntry := models.Log{
-            HiveID:  input.HiveID,
-            Content: input.Content,
-        }
-
-        if result := tx.Create(&logEntry); result.Error != nil {
-            return result.Error
-        }
-
-        c.JSON(http.StatusCreated, logEntry)
-        return nil
-    })
-
+	logEntry, err := h.logs.Create(services.LogInput{HiveID: input.HiveID, Content: input.Content, Tags: input.Tags})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		return
 	}
+	h.bus.Publish(events.LogCreated, logEntry.HiveID, logEntry)
+
+	c.JSON(http.StatusCreated, logEntry)
 }
 
 // ListLogs godoc
@@ -115,12 +97,18 @@ func (h *handler) CreateLog(c *gin.Context) {
 // @Description Retrieve all log entries from the database
 // @Tags logs
 // @Produce  json
+// @Param tag query string false "Only entries with all of these tags, comma-separated"
 // @Success 200 {array} models.Log
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /logs [get]
 func (h *handler) ListLogs(c *gin.Context) {
-	var logs []models.Log
-	if result := h.db.Find(&logs); result.Error != nil {
+	logs, err := h.logs.List(services.ListOptions{
+		Tags:   tags.ParseFilter(c),
+		Viewer: services.ViewerFrom(c, access.LogsRead),
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve logs"})
 		return
 	}
@@ -136,16 +124,26 @@ func (h *handler) ListLogs(c *gin.Context) {
 // @Param id path int true "Log ID"
 // @Success 200 {object} models.Log
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /logs/{id} [get]
 func (h *handler) GetLog(c *gin.Context) {
-	id := c.Param("id")
-	var log models.Log
+	id, ok := logID(c)
+	if !ok {
+		return
+	}
 
-	if result := h.db.First(&log, id); result.Error != nil {
+	log, err := h.logs.Get(id)
+	if errors.Is(err, services.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Log not found"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve log"})
+		return
+	}
 
 	c.JSON(http.StatusOK, log)
 }
@@ -156,25 +154,27 @@ func (h *handler) GetLog(c *gin.Context) {
 // @Tags logs
 // @Produce  json
 // @Success 200 {object} models.Log
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /logs/last [get]
 func (h *handler) GetLastLog(c *gin.Context) {
-    var log models.Log
-    if result := h.db.Order("created_at desc").First(&log); result.Error != nil {
-        if result.Error == gorm.ErrRecordNotFound {
-            c.JSON(http.StatusNotFound, gin.H{"error": "No logs found"})
-            return
-        }
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve last log"})
-        return
-    }
-    c.JSON(http.StatusOK, log)
+	log, err := h.logs.Last(services.ListOptions{Viewer: services.ViewerFrom(c, access.LogsRead)})
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No logs found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve last log"})
+		return
+	}
+	c.JSON(http.StatusOK, log)
 }
 
-
 // UpdateLog godoc
 // @Summary Update a log entry
-// @Description Update an existing log entry by ID
+// @Description Update an existing log entry by ID. Tags, if given, replace the entry's tags; hashtags in new content are added.
 // @Tags logs
 // @Accept  json
 // @Produce  json
@@ -182,15 +182,24 @@ func (h *handler) GetLastLog(c *gin.Context) {
 // @Param log body UpdateEntryInput true "Log update data"
 // @Success 200 {object} models.Log
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /logs/{id} [put]
 func (h *handler) UpdateLog(c *gin.Context) {
-	id := c.Param("id")
-	var log models.Log
+	id, ok := logID(c)
+	if !ok {
+		return
+	}
 
-	if result := h.db.First(&log, id); result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Log not found"})
+	// A missing log is reported before the body is looked at
+	if _, err := h.logs.Get(id); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Log not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update log"})
 		return
 	}
 
@@ -200,10 +209,16 @@ func (h *handler) UpdateLog(c *gin.Context) {
 		return
 	}
 
-	if result := h.db.Model(&log).Updates(input); result.Error != nil {
+	log, err := h.logs.Update(id, services.LogInput{HiveID: input.HiveID, Content: input.Content, Tags: input.Tags})
+	if errors.Is(err, services.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Log not found"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update log"})
 		return
 	}
+	h.bus.Publish(events.LogUpdated, log.HiveID, log)
 
 	c.JSON(http.StatusOK, log)
 }
@@ -216,20 +231,27 @@ func (h *handler) UpdateLog(c *gin.Context) {
 // @Param id path int true "Log ID"
 // @Success 204
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /logs/{id} [delete]
 func (h *handler) DeleteLog(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+	id, ok := logID(c)
+	if !ok {
 		return
 	}
 
-	if result := h.db.Delete(&models.Log{}, id); result.Error != nil || result.RowsAffected == 0 {
+	log, err := h.logs.Delete(id)
+	if errors.Is(err, services.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Log not found"})
 		return
 	}
-	
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete log"})
+		return
+	}
+	h.bus.Publish(events.LogDeleted, log.HiveID, log)
+
 	c.Status(http.StatusNoContent)
 }
-
@@ -0,0 +1,172 @@
+package notifications
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"beekeeper-api/access"
+	"beekeeper-api/models"
+	"beekeeper-api/notify"
+)
+
+// --- Structs for Input Validation ---
+
+type NotificationPreferenceInput struct {
+	Channels        []string `json:"channels" binding:"dive,oneof=email webhook ntfy" example:"email,ntfy"`
+	WebhookURL      string   `json:"webhookURL" binding:"omitempty,url" example:"https://example.com/hooks/reminders"`
+	NtfyTopic       string   `json:"ntfyTopic" example:"my-apiary-reminders"`
+	Timezone        string   `json:"timezone" example:"Europe/Berlin"`
+	QuietHoursStart string   `json:"quietHoursStart" example:"22:00"`
+	QuietHoursEnd   string   `json:"quietHoursEnd" example:"07:00"`
+	Digest          bool     `json:"digest" example:"false"`
+	DigestTime      string   `json:"digestTime" example:"07:00"`
+}
+
+// --- Route Registration ---
+
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
+	h := &handler{db: db}
+	guard := access.New(db)
+
+	notificationRoutes := router.Group("/users/:id/notifications")
+	{
+		notificationRoutes.GET("", guard.Require(access.UsersManage, access.Self("id")), h.GetPreferences)
+		notificationRoutes.PUT("", guard.Require(access.UsersManage, access.Self("id")), h.UpdatePreferences)
+	}
+}
+
+// validate checks that every chosen channel has a valid destination and that the
+// timezone and times of day can be understood. Whether a URL points to a
+// public address is checked when sending, see notify.PublicClient.
+func (input *NotificationPreferenceInput) validate() error {
+	if input.WebhookURL != "" && !notify.IsURL(input.WebhookURL) {
+		return errors.New("webhookURL must be an http or https URL")
+	}
+	for _, channel := range input.Channels {
+		if channel == models.ChannelWebhook && input.WebhookURL == "" {
+			return errors.New("webhookURL is required for the webhook channel")
+		}
+		if channel == models.ChannelNtfy && input.NtfyTopic == "" {
+			return errors.New("ntfyTopic is required for the ntfy channel")
+		}
+	}
+	if input.NtfyTopic != "" && !notify.ValidTopic(input.NtfyTopic) {
+		return errors.New("ntfyTopic must be a topic name of up to 64 letters, digits, '-' and '_', or an http or https URL")
+	}
+	if input.Timezone == "" {
+		input.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(input.Timezone); err != nil {
+		return errors.New("unknown timezone")
+	}
+	if (input.QuietHoursStart == "") != (input.QuietHoursEnd == "") {
+		return errors.New("quietHoursStart and quietHoursEnd must be set together")
+	}
+	if input.DigestTime == "" {
+		input.DigestTime = "07:00"
+	}
+	for _, value := range []string{input.QuietHoursStart, input.QuietHoursEnd, input.DigestTime} {
+		if value == "" {
+			continue
+		}
+		if _, err := notify.ParseClock(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// --- Handler ---
+
+type handler struct {
+	db *gorm.DB
+}
+
+// GetPreferences godoc
+// @Summary Get a user's reminder settings
+// @Description Get how and when a user is reminded of due and overdue tasks. Users without settings get no reminders.
+// @Tags notifications
+// @Produce  json
+// @Param id path int true "User ID"
+// @Success 200 {object} models.NotificationPreference
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/notifications [get]
+func (h *handler) GetPreferences(c *gin.Context) {
+	var user models.User
+	if result := h.db.First(&user, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	pref := models.NotificationPreference{UserID: user.ID, Channels: []string{}, Timezone: "UTC", DigestTime: "07:00"}
+	if result := h.db.Where("user_id = ?", user.ID).Limit(1).Find(&pref); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve notification settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pref)
+}
+
+// UpdatePreferences godoc
+// @Summary Set a user's reminder settings
+// @Description Replace how and when a user is reminded of due and overdue tasks. Reminders held back by quiet hours are sent once they end; in digest mode all reminders are bundled into one message a day at digestTime. ntfy topics are names of up to 64 letters, digits, '-' and '_' on the configured server, or full topic URLs. Webhook URLs and ntfy topic URLs must point to public addresses; reminders to loopback, private or link-local addresses are not sent.
+// @Tags notifications
+// @Accept  json
+// @Produce  json
+// @Param id path int true "User ID"
+// @Param preferences body NotificationPreferenceInput true "Reminder settings"
+// @Success 200 {object} models.NotificationPreference
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/notifications [put]
+func (h *handler) UpdatePreferences(c *gin.Context) {
+	var user models.User
+	if result := h.db.First(&user, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var input NotificationPreferenceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := input.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Channels == nil {
+		input.Channels = []string{}
+	}
+
+	var pref models.NotificationPreference
+	if result := h.db.Where("user_id = ?", user.ID).Limit(1).Find(&pref); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save notification settings"})
+		return
+	}
+	pref.UserID = user.ID
+	pref.Channels = input.Channels
+	pref.WebhookURL = input.WebhookURL
+	pref.NtfyTopic = input.NtfyTopic
+	pref.Timezone = input.Timezone
+	pref.QuietHoursStart = input.QuietHoursStart
+	pref.QuietHoursEnd = input.QuietHoursEnd
+	pref.Digest = input.Digest
+	pref.DigestTime = input.DigestTime
+
+	if result := h.db.Save(&pref); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save notification settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pref)
+}
@@ -0,0 +1,246 @@
+package reports
+
+import (
+	"embed"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+
+	"beekeeper-api/models"
+)
+
+const (
+	pageWidth  = 180.0 // A4 minus 15 mm margins
+	lineHeight = 5.0
+	font       = "DejaVu"
+)
+
+// fonts are embedded into every report, as the PDF core fonts only cover
+// cp1252
+//
+//go:embed fonts/*.ttf
+var fonts embed.FS
+
+// fontFiles maps the styles used in reports to their font files
+var fontFiles = map[string]string{
+	"":  "fonts/DejaVuSansCondensed.ttf",
+	"B": "fonts/DejaVuSansCondensed-Bold.ttf",
+	"I": "fonts/DejaVuSansCondensed-Oblique.ttf",
+}
+
+// document wraps an A4 PDF using the embedded DejaVu Sans font. Text is
+// limited to the Basic Multilingual Plane the font's widths cover, other
+// characters such as emoji are replaced.
+type document struct {
+	pdf *fpdf.Fpdf
+	tr  func(string) string
+}
+
+func newDocument(title string, p period) *document {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 18)
+	pdf.SetTitle(title, true)
+	pdf.SetCreator("Beekeeper API", true)
+	pdf.AliasNbPages("")
+	for style, file := range fontFiles {
+		data, err := fonts.ReadFile(file)
+		if err != nil {
+			pdf.SetError(err)
+			break
+		}
+		pdf.AddUTF8FontFromBytes(font, style, data)
+	}
+
+	d := &document{pdf: pdf, tr: basicPlane}
+	generated := time.Now().Format("2006-01-02 15:04")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-13)
+		pdf.SetFont(font, "I", 8)
+		pdf.SetTextColor(110, 110, 110)
+		pdf.CellFormat(pageWidth/2, lineHeight, d.tr(title+" - generated "+generated), "", 0, "L", false, 0, "")
+		pdf.CellFormat(pageWidth/2, lineHeight, fmt.Sprintf("Page %d/{nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+
+	pdf.AddPage()
+	pdf.SetFont(font, "B", 18)
+	pdf.CellFormat(pageWidth, 10, d.tr(title), "", 1, "L", false, 0, "")
+	pdf.SetFont(font, "", 10)
+	pdf.CellFormat(pageWidth, lineHeight, d.tr("Period: "+p.String()), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+	return d
+}
+
+func (d *document) heading(text string) {
+	d.pdf.Ln(2)
+	d.pdf.SetFont(font, "B", 13)
+	d.pdf.CellFormat(pageWidth, 8, d.tr(text), "B", 1, "L", false, 0, "")
+	d.pdf.Ln(2)
+}
+
+// facts prints label/value pairs in two columns
+func (d *document) facts(rows [][2]string) {
+	for _, row := range rows {
+		d.pdf.SetFont(font, "B", 10)
+		d.pdf.CellFormat(55, lineHeight+1, d.tr(row[0]), "", 0, "L", false, 0, "")
+		d.pdf.SetFont(font, "", 10)
+		d.pdf.CellFormat(pageWidth-55, lineHeight+1, d.tr(row[1]), "", 1, "L", false, 0, "")
+	}
+}
+
+// table prints rows with wrapped cells, repeating the header after page breaks.
+func (d *document) table(widths []float64, header []string, rows [][]string) {
+	pdf := d.pdf
+	printHeader := func() {
+		pdf.SetFont(font, "B", 9)
+		pdf.SetFillColor(240, 200, 80)
+		for i, title := range header {
+			pdf.CellFormat(widths[i], lineHeight+2, d.tr(title), "1", 0, "L", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont(font, "", 9)
+	}
+
+	printHeader()
+	if len(rows) == 0 {
+		pdf.SetFont(font, "I", 9)
+		pdf.CellFormat(sum(widths), lineHeight+2, "None", "1", 1, "L", false, 0, "")
+		return
+	}
+
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	for _, row := range rows {
+		lines := make([][]string, len(row))
+		height := 0.0
+		for i, cell := range row {
+			lines[i] = pdf.SplitText(d.tr(cell), widths[i]-2)
+			height = max(height, float64(len(lines[i]))*lineHeight+2)
+		}
+
+		if pdf.GetY()+height > pageHeight-bottom-5 {
+			pdf.AddPage()
+			printHeader()
+		}
+
+		x, y := pdf.GetXY()
+		for i := range row {
+			pdf.Rect(x, y, widths[i], height, "D")
+			for j, line := range lines[i] {
+				pdf.SetXY(x+1, y+1+float64(j)*lineHeight)
+				pdf.CellFormat(widths[i]-2, lineHeight, line, "", 0, "L", false, 0, "")
+			}
+			x += widths[i]
+		}
+		pdf.SetXY(15, y+height)
+	}
+}
+
+func (d *document) hiveSection(r hiveReport) {
+	d.heading(fmt.Sprintf("Hive %d", r.hive.HiveName))
+
+	lastInspection := "never"
+	if len(r.logs) > 0 {
+		lastInspection = formatDateTime(r.logs[len(r.logs)-1].CreatedAt)
+	}
+	weight := "no readings"
+	if r.lastWeight != nil {
+		weight = fmt.Sprintf("%.1f kg (%s)", r.lastWeight.Value, formatDateTime(r.lastWeight.RecordedAt))
+	}
+	d.facts([][2]string{
+		{"Hive number", strconv.Itoa(r.hive.HiveName)},
+		{"Registered", formatDate(r.hive.CreatedAt)},
+		{"Last log entry", lastInspection},
+		{"Latest weight", weight},
+	})
+
+	d.heading("Summary")
+	high := 0
+	for _, task := range r.openTasks {
+		if task.Priority == models.PriorityHigh {
+			high++
+		}
+	}
+	d.facts([][2]string{
+		{"Log entries", strconv.Itoa(len(r.logs))},
+		{"Average interval", averageInterval(r.logs)},
+		{"Tasks completed", strconv.FormatInt(r.completedTasks, 10)},
+		{"Open tasks", fmt.Sprintf("%d (%d high priority)", len(r.openTasks), high)},
+		{"Sensor alerts", strconv.FormatInt(r.alerts, 10)},
+	})
+
+	d.heading("Log")
+	rows := make([][]string, 0, len(r.logs))
+	for _, entry := range r.logs {
+		rows = append(rows, []string{formatDateTime(entry.CreatedAt), entry.Content})
+	}
+	d.table([]float64{35, pageWidth - 35}, []string{"Date", "Entry"}, rows)
+
+	d.heading("Open tasks")
+	rows = make([][]string, 0, len(r.openTasks))
+	for _, task := range r.openTasks {
+		rows = append(rows, []string{formatDate(task.CreatedAt), task.Priority, task.Content})
+	}
+	d.table([]float64{25, 20, pageWidth - 45}, []string{"Created", "Priority", "Task"}, rows)
+}
+
+func (d *document) overview(reports []hiveReport) {
+	d.heading("Overview")
+	rows := make([][]string, 0, len(reports))
+	totalLogs, totalOpen := 0, 0
+	for _, r := range reports {
+		last := "never"
+		if len(r.logs) > 0 {
+			last = formatDate(r.logs[len(r.logs)-1].CreatedAt)
+		}
+		rows = append(rows, []string{strconv.Itoa(r.hive.HiveName), strconv.Itoa(len(r.logs)), last, strconv.Itoa(len(r.openTasks)), strconv.FormatInt(r.alerts, 10)})
+		totalLogs += len(r.logs)
+		totalOpen += len(r.openTasks)
+	}
+	d.facts([][2]string{
+		{"Hives", strconv.Itoa(len(reports))},
+		{"Log entries", strconv.Itoa(totalLogs)},
+		{"Open tasks", strconv.Itoa(totalOpen)},
+	})
+	d.pdf.Ln(3)
+	d.table([]float64{30, 35, 45, 35, 35}, []string{"Hive", "Log entries", "Last entry", "Open tasks", "Alerts"}, rows)
+}
+
+// basicPlane replaces the characters beyond the Basic Multilingual Plane.
+func basicPlane(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r > 0xFFFF {
+			return '\uFFFD'
+		}
+		return r
+	}, text)
+}
+
+func averageInterval(logs []models.Log) string {
+	if len(logs) < 2 {
+		return "-"
+	}
+	span := logs[len(logs)-1].CreatedAt.Sub(logs[0].CreatedAt)
+	days := span.Hours() / 24 / float64(len(logs)-1)
+	return fmt.Sprintf("%.1f days between entries", days)
+}
+
+func sum(values []float64) float64 {
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func formatDate(t time.Time) string {
+	return t.Local().Format("2006-01-02")
+}
+
+func formatDateTime(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04")
+}
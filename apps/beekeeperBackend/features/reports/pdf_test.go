@@ -0,0 +1,57 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"beekeeper-api/models"
+)
+
+// utf16be is how text in the embedded font appears in an uncompressed page
+func utf16be(text string) []byte {
+	var b []byte
+	for _, unit := range utf16.Encode([]rune(text)) {
+		b = binary.BigEndian.AppendUint16(b, unit)
+	}
+	return b
+}
+
+func TestReportText(t *testing.T) {
+	created := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	report := hiveReport{
+		hive: models.Hive{HiveName: 7, CreatedAt: created},
+		logs: []models.Log{
+			{HiveID: 7, Content: "Žluté plástve, 8 rámků", CreatedAt: created},
+			{HiveID: 7, Content: "Пасіка біля лісу", CreatedAt: created.Add(time.Hour)},
+			{HiveID: 7, Content: "Swarm 🐝 caught", CreatedAt: created.Add(2 * time.Hour)},
+		},
+		openTasks: []models.Task{{HiveID: 7, Content: "Μέλι να τρυγηθεί", Priority: models.PriorityHigh, CreatedAt: created}},
+	}
+
+	doc := newDocument("Hive 7 - Inspection Report", period{})
+	doc.pdf.SetCompression(false)
+	doc.hiveSection(report)
+	var out bytes.Buffer
+	if err := doc.pdf.Output(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, text string
+	}{
+		{"Latin Extended", "Žluté plástve, 8 rámků"},
+		{"Cyrillic", "Пасіка біля лісу"},
+		{"Greek", "Μέλι να τρυγηθεί"},
+		{"beyond the font", "Swarm � caught"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !bytes.Contains(out.Bytes(), utf16be(tt.text)) {
+				t.Errorf("report does not contain %q", tt.text)
+			}
+		})
+	}
+}
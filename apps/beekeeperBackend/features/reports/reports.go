@@ -0,0 +1,219 @@
+package reports
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"beekeeper-api/access"
+	"beekeeper-api/features/export"
+	"beekeeper-api/models"
+)
+
+// --- Route Registration ---
+
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
+	h := &handler{db: db}
+	guard := access.New(db)
+
+	router.GET("/hives/:id/report.pdf", guard.Require(access.HivesRead, access.Hive("id")), h.GetHiveReport)
+	router.GET("/reports/apiary.pdf", guard.Require(access.HivesRead, access.Anywhere), h.GetApiaryReport)
+}
+
+// --- Handler ---
+
+type handler struct {
+	db *gorm.DB
+}
+
+// period is the date range a report covers; nil bounds are open
+type period struct {
+	from, to *time.Time
+}
+
+func parsePeriod(c *gin.Context) (period, error) {
+	var p period
+	var err error
+	if p.from, err = export.ParseTime(c.Query("from"), false); err != nil {
+		return p, err
+	}
+	if p.to, err = export.ParseTime(c.Query("to"), true); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+func (p period) String() string {
+	switch {
+	case p.from != nil && p.to != nil:
+		return formatDate(*p.from) + " - " + formatDate(*p.to)
+	case p.from != nil:
+		return "since " + formatDate(*p.from)
+	case p.to != nil:
+		return "until " + formatDate(*p.to)
+	}
+	return "all records"
+}
+
+func (p period) apply(query *gorm.DB, column string) *gorm.DB {
+	if p.from != nil {
+		query = query.Where(column+" >= ?", *p.from)
+	}
+	if p.to != nil {
+		query = query.Where(column+" <= ?", *p.to)
+	}
+	return query
+}
+
+// hiveReport is everything printed about one hive
+type hiveReport struct {
+	hive           models.Hive
+	logs           []models.Log
+	openTasks      []models.Task
+	completedTasks int64
+	alerts         int64
+	lastWeight     *models.Telemetry
+}
+
+func (h *handler) loadHiveReport(hive models.Hive, p period) (hiveReport, error) {
+	r := hiveReport{hive: hive}
+
+	if err := p.apply(h.db.Where("hive_id = ?", hive.HiveName), "created_at").Order("created_at").Find(&r.logs).Error; err != nil {
+		return r, err
+	}
+	if err := h.db.Where("hive_id = ? AND completed_at IS NULL", hive.HiveName).Order("created_at").Find(&r.openTasks).Error; err != nil {
+		return r, err
+	}
+	if err := p.apply(h.db.Model(&models.Task{}).Where("hive_id = ? AND completed_at IS NOT NULL", hive.HiveName), "completed_at").Count(&r.completedTasks).Error; err != nil {
+		return r, err
+	}
+	if err := p.apply(h.db.Model(&models.Alert{}).Where("hive_id = ?", hive.HiveName), "created_at").Count(&r.alerts).Error; err != nil {
+		return r, err
+	}
+
+	var weight models.Telemetry
+	err := p.apply(h.db.Where("hive_id = ? AND metric = ?", hive.HiveName, "weight"), "recorded_at").Order("recorded_at desc").Limit(1).Find(&weight).Error
+	if err != nil {
+		return r, err
+	}
+	if weight.ID != 0 {
+		r.lastWeight = &weight
+	}
+	return r, nil
+}
+
+// GetHiveReport godoc
+// @Summary Hive inspection report
+// @Description Render a printable PDF record of a hive: metadata, a chronological log table, open tasks and summary statistics for the chosen date range
+// @Tags reports
+// @Produce application/pdf
+// @Param id path int true "Hive ID"
+// @Param from query string false "Start of the period (YYYY-MM-DD or RFC 3339)"
+// @Param to query string false "End of the period (YYYY-MM-DD or RFC 3339)"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /hives/{id}/report.pdf [get]
+func (h *handler) GetHiveReport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	p, err := parsePeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var hive models.Hive
+	if result := h.db.First(&hive, "hive_name = ?", id); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Hive not found"})
+		return
+	}
+
+	report, err := h.loadHiveReport(hive, p)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load report data"})
+		return
+	}
+
+	doc := newDocument(fmt.Sprintf("Hive %d - Inspection Report", hive.HiveName), p)
+	doc.hiveSection(report)
+	h.send(c, doc, fmt.Sprintf("hive-%d-report.pdf", hive.HiveName))
+}
+
+// GetApiaryReport godoc
+// @Summary Apiary inspection report
+// @Description Render a printable PDF record of all hives the user can read, or of those in one apiary: an overview table followed by one section per hive
+// @Tags reports
+// @Produce application/pdf
+// @Param apiary_id query int false "Only hives in this apiary"
+// @Param from query string false "Start of the period (YYYY-MM-DD or RFC 3339)"
+// @Param to query string false "End of the period (YYYY-MM-DD or RFC 3339)"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /reports/apiary.pdf [get]
+func (h *handler) GetApiaryReport(c *gin.Context) {
+	p, err := parsePeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := access.Visible(c, h.db, h.db.Order("hive_name"), access.HivesRead, "hive_name")
+	filename := "apiary-report.pdf"
+	if value := c.Query("apiary_id"); value != "" {
+		apiaryID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid apiary_id"})
+			return
+		}
+		query = query.Where("apiary_id = ?", apiaryID)
+		filename = fmt.Sprintf("apiary-%d-report.pdf", apiaryID)
+	}
+	var hives []models.Hive
+	if result := query.Find(&hives); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve hives"})
+		return
+	}
+
+	reports := make([]hiveReport, 0, len(hives))
+	for _, hive := range hives {
+		report, err := h.loadHiveReport(hive, p)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load report data"})
+			return
+		}
+		reports = append(reports, report)
+	}
+
+	doc := newDocument("Apiary Inspection Report", p)
+	doc.overview(reports)
+	for _, report := range reports {
+		doc.pdf.AddPage()
+		doc.hiveSection(report)
+	}
+	h.send(c, doc, filename)
+}
+
+func (h *handler) send(c *gin.Context, doc *document, filename string) {
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+	if err := doc.pdf.Output(c.Writer); err != nil {
+		log.Printf("Failed to render PDF report: %v", err)
+		c.Abort()
+	}
+}
@@ -0,0 +1,207 @@
+package stream
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"beekeeper-api/access"
+	"beekeeper-api/auth"
+	"beekeeper-api/events"
+	"beekeeper-api/models"
+)
+
+// heartbeatInterval keeps idle connections from being closed by proxies
+const heartbeatInterval = 25 * time.Second
+
+// roleTTL is how long a stream uses a user's role for a hive before looking
+// it up again, so changed memberships apply to open streams soon after
+const roleTTL = 30 * time.Second
+
+// --- Route Registration ---
+
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, bus *events.Bus) {
+	h := &handler{db: db, bus: bus}
+	guard := access.New(db)
+
+	router.GET("/events", guard.Require(access.HivesRead, access.Anywhere), h.StreamEvents)
+}
+
+// --- Handler ---
+
+type handler struct {
+	db  *gorm.DB
+	bus *events.Bus
+}
+
+// permission returns what a user needs to see an event: reading logs for
+// log events, tasks for task events, and the hive for everything else.
+func permission(eventType string) access.Permission {
+	switch {
+	case strings.HasPrefix(eventType, "log."):
+		return access.LogsRead
+	case strings.HasPrefix(eventType, "task."):
+		return access.TasksRead
+	default:
+		return access.HivesRead
+	}
+}
+
+// cachedRole is a user's role for a hive as looked up at a point in time
+type cachedRole struct {
+	role      string
+	checkedAt time.Time
+}
+
+// viewer decides which events the user of one stream may see. It remembers
+// the user's role for each hive for roleTTL, as hives move between apiaries
+// and memberships expire while the stream is open. It is used by the
+// stream's goroutine only.
+type viewer struct {
+	db    *gorm.DB
+	user  *models.User
+	roles map[int]cachedRole
+	now   func() time.Time
+}
+
+// visible reports whether the user may see an event. Anonymous streams see
+// every event.
+func (v *viewer) visible(event events.Event) bool {
+	if v.user == nil {
+		return true
+	}
+	// A deleted hive can no longer be looked up, so its deletion is shown
+	// with the role the user had for it and hidden when none is known
+	if event.Type == events.HiveDeleted {
+		cached, ok := v.roles[event.HiveID]
+		delete(v.roles, event.HiveID)
+		return ok && access.Can(cached.role, permission(event.Type))
+	}
+	// A hive that changed may have moved to another apiary
+	if event.Type == events.HiveUpdated {
+		delete(v.roles, event.HiveID)
+	}
+	cached, ok := v.roles[event.HiveID]
+	if !ok || v.now().Sub(cached.checkedAt) > roleTTL {
+		role, err := access.HiveRole(v.db, v.user, event.HiveID)
+		if err != nil {
+			log.Printf("Failed to check access to %s event %d: %v", event.Type, event.ID, err)
+			return false
+		}
+		cached = cachedRole{role: role, checkedAt: v.now()}
+		v.roles[event.HiveID] = cached
+	}
+	return access.Can(cached.role, permission(event.Type))
+}
+
+// parseHiveFilter reads a comma-separated list of hive IDs; nil means all hives.
+func parseHiveFilter(value string) (map[int]bool, error) {
+	if value == "" {
+		return nil, nil
+	}
+	hives := make(map[int]bool)
+	for _, item := range strings.Split(value, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil {
+			return nil, fmt.Errorf("invalid hive ID %q", item)
+		}
+		hives[id] = true
+	}
+	return hives, nil
+}
+
+// StreamEvents godoc
+// @Summary Stream live changes
+// @Description Server-Sent Events stream of changes to the hives, logs, tasks and alerts the user can read. Each SSE message uses the event type as its name and the event ID as its id. Reconnecting clients send Last-Event-ID (or the last_event_id query parameter) to receive the events they missed, as far as they are still buffered. The buffer is kept in memory, so events published before the server restarted are not replayed. Streams that fall behind are ended, so the client reconnects and resumes. Changes to the user's access apply to an open stream within 30 seconds.
+// @Tags events
+// @Produce text/event-stream
+// @Param hive_id query string false "Only stream events for these hives (comma-separated hive IDs)"
+// @Param last_event_id query int false "Resume after this event ID"
+// @Param Last-Event-ID header int false "Resume after this event ID"
+// @Success 200 {object} events.Event
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /events [get]
+func (h *handler) StreamEvents(c *gin.Context) {
+	hives, err := parseHiveFilter(c.Query("hive_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var lastID uint64
+	if lastEventID != "" {
+		if lastID, err = strconv.ParseUint(lastEventID, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+			return
+		}
+	}
+
+	user, _ := auth.CurrentUser(c)
+	viewer := &viewer{db: h.db, user: user, roles: make(map[int]cachedRole), now: time.Now}
+
+	// Subscribe before replaying so nothing published in between is lost
+	ch, cancel := h.bus.Subscribe(64)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	send := func(event events.Event) {
+		lastID = event.ID
+		if (hives != nil && !hives[event.HiveID]) || !viewer.visible(event) {
+			return
+		}
+		c.Render(-1, sse.Event{
+			Id:    strconv.FormatUint(event.ID, 10),
+			Event: event.Type,
+			Data:  event,
+		})
+	}
+
+	if lastEventID != "" {
+		for _, event := range h.bus.Since(lastID) {
+			send(event)
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-ch:
+			if !ok {
+				// The stream fell behind and the bus closed it. Ending the
+				// response makes the client reconnect with Last-Event-ID and
+				// receive the missed events from the buffer.
+				return
+			}
+			if event.ID <= lastID {
+				continue // already sent while replaying
+			}
+			send(event)
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
@@ -0,0 +1,153 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"beekeeper-api/config"
+	"beekeeper-api/database"
+	"beekeeper-api/events"
+	"beekeeper-api/migrate"
+	"beekeeper-api/models"
+)
+
+// openDB creates a migrated in-memory database
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	cfg := config.New()
+	cfg.DBDriver = "sqlite"
+	cfg.DatabaseURL = "file:stream?mode=memory&cache=shared"
+	cfg.DBMaxOpenConns = 1
+	cfg.DBMaxIdleConns = 1
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db.Logger = logger.Discard
+
+	migrator, err := migrate.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// TestViewerCachesRoles streams events of hive 1, in an apiary the user is a
+// viewer in, and expects the role to be looked up once per roleTTL or after
+// the hive changed
+func TestViewerCachesRoles(t *testing.T) {
+	db := openDB(t)
+	user := models.User{Name: "Ana", Email: "ana@example.com", Role: models.RoleNone, CalendarTokenHash: "hash"}
+	apiary := models.Apiary{Name: "Orchard"}
+	for _, row := range []any{&user, &apiary} {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	membership := models.TeamMember{ApiaryID: apiary.ID, UserID: user.ID, Role: models.RoleViewer}
+	for _, row := range []any{&models.Hive{HiveName: 1, ApiaryID: &apiary.ID}, &membership} {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var queries int
+	if err := db.Callback().Query().After("gorm:query").Register("test:count", func(*gorm.DB) { queries++ }); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	v := &viewer{db: db, user: &user, roles: make(map[int]cachedRole), now: func() time.Time { return now }}
+	logged := events.Event{Type: events.LogCreated, HiveID: 1}
+
+	if !v.visible(logged) {
+		t.Fatal("got the log hidden from a viewer of its apiary")
+	}
+	lookup := queries
+	for range 100 {
+		v.visible(logged)
+	}
+	if queries != lookup {
+		t.Errorf("got %d queries for 100 more events, want none", queries-lookup)
+	}
+
+	// Without the membership the log is hidden once the role is looked up again
+	if err := db.Delete(&membership).Error; err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(roleTTL / 2)
+	if !v.visible(logged) {
+		t.Error("got the cached role dropped before roleTTL")
+	}
+	now = now.Add(roleTTL)
+	if v.visible(logged) {
+		t.Error("got the log visible after the membership ended")
+	}
+
+	// A changed hive is looked up right away
+	membership.ID = 0
+	if err := db.Create(&membership).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !v.visible(events.Event{Type: events.HiveUpdated, HiveID: 1}) {
+		t.Error("got the updated hive hidden after the membership was renewed")
+	}
+}
+
+// TestViewerHiveDeleted expects a deleted hive to be shown with the role the
+// user had for it, and hidden from users whose role was never looked up
+func TestViewerHiveDeleted(t *testing.T) {
+	db := openDB(t)
+	viewerUser := models.User{Name: "Ana", Email: "ana@example.com", Role: models.RoleNone, CalendarTokenHash: "ana"}
+	outsider := models.User{Name: "Ben", Email: "ben@example.com", Role: models.RoleNone, CalendarTokenHash: "ben"}
+	apiary := models.Apiary{Name: "Orchard"}
+	for _, row := range []any{&viewerUser, &outsider, &apiary} {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	hive := models.Hive{HiveName: 1, ApiaryID: &apiary.ID}
+	for _, row := range []any{&hive, &models.TeamMember{ApiaryID: apiary.ID, UserID: viewerUser.ID, Role: models.RoleViewer}} {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hiveID := int(hive.ID)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	newViewer := func(user *models.User) *viewer {
+		return &viewer{db: db, user: user, roles: make(map[int]cachedRole), now: func() time.Time { return now }}
+	}
+	member, other, late := newViewer(&viewerUser), newViewer(&outsider), newViewer(&viewerUser)
+	updated := events.Event{Type: events.HiveUpdated, HiveID: hiveID}
+	if !member.visible(updated) || other.visible(updated) {
+		t.Fatal("got the hive shown to the wrong users before its deletion")
+	}
+
+	if err := db.Delete(&hive).Error; err != nil {
+		t.Fatal(err)
+	}
+	deleted := events.Event{Type: events.HiveDeleted, HiveID: hiveID}
+	if !member.visible(deleted) {
+		t.Error("got the deletion hidden from a viewer of its apiary")
+	}
+	if other.visible(deleted) {
+		t.Error("got the deletion shown to a user outside its apiary")
+	}
+	if late.visible(deleted) {
+		t.Error("got the deletion shown without a known role")
+	}
+	if _, ok := member.roles[hiveID]; ok {
+		t.Error("got the role of the deleted hive kept")
+	}
+}
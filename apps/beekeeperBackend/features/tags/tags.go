@@ -0,0 +1,106 @@
+package tags
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"beekeeper-api/access"
+	"beekeeper-api/auth"
+	"beekeeper-api/models"
+	"beekeeper-api/tagging"
+)
+
+// --- Response Structs ---
+
+// TagUsage is a tag with the number of entries labelled with it
+type TagUsage struct {
+	ID    uint   `json:"id" example:"1"`
+	Name  string `json:"name" example:"swarm-prevention"`
+	Hives int    `json:"hives" example:"2"`
+	Logs  int    `json:"logs" example:"14"`
+	Tasks int    `json:"tasks" example:"3"`
+	Total int    `json:"total" example:"19"`
+}
+
+// --- Route Registration ---
+
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
+	h := &handler{db: db}
+	guard := access.New(db)
+
+	router.GET("/tags", guard.Require(access.HivesRead, access.Anywhere), h.ListTags)
+}
+
+// ParseFilter reads the tag filter of a list endpoint, given as ?tag=a,b or
+// ?tag=a&tag=b.
+func ParseFilter(c *gin.Context) []string {
+	var names []string
+	for _, value := range c.QueryArray("tag") {
+		for _, name := range strings.Split(value, ",") {
+			if name = tagging.Normalize(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// --- Handler ---
+
+type handler struct {
+	db *gorm.DB
+}
+
+// ListTags godoc
+// @Summary List tags with usage counts
+// @Description List all tags with the number of hives, logs and tasks the user can read labelled with each, most used first. Signed in users only see the tags in use on those.
+// @Tags tags
+// @Produce  json
+// @Success 200 {array} TagUsage
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /tags [get]
+func (h *handler) ListTags(c *gin.Context) {
+	count := func(joinTable, table, column string, perm access.Permission, hiveColumn string) *gorm.DB {
+		query := h.db.Table(joinTable).
+			Select("COUNT(*)").
+			Joins("JOIN " + table + " ON " + table + ".id = " + joinTable + "." + column).
+			Where(joinTable + ".tag_id = tags.id")
+		return access.Visible(c, h.db, query, perm, table+"."+hiveColumn)
+	}
+	result := []TagUsage{}
+	err := h.db.Model(&models.Tag{}).
+		Select("tags.id, tags.name, (?) AS hives, (?) AS logs, (?) AS tasks",
+			count("hive_tags", "hives", "hive_id", access.HivesRead, "hive_name"),
+			count("log_tags", "logs", "log_id", access.LogsRead, "hive_id"),
+			count("task_tags", "tasks", "task_id", access.TasksRead, "hive_id")).
+		Scan(&result).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tags"})
+		return
+	}
+
+	// A tag only used on other hives would tell the user what is written there
+	_, signedIn := auth.CurrentUser(c)
+	usages := []TagUsage{}
+	for _, usage := range result {
+		usage.Total = usage.Hives + usage.Logs + usage.Tasks
+		if signedIn && usage.Total == 0 {
+			continue
+		}
+		usages = append(usages, usage)
+	}
+	sort.SliceStable(usages, func(i, j int) bool {
+		if usages[i].Total != usages[j].Total {
+			return usages[i].Total > usages[j].Total
+		}
+		return usages[i].Name < usages[j].Name
+	})
+
+	c.JSON(http.StatusOK, usages)
+}
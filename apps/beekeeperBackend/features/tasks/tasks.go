@@ -1,81 +1,85 @@
 package tasks
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
-    "gorm.io/gorm/clause"
 
-
-	"beekeeper-api/models"
+	"beekeeper-api/access"
+	"beekeeper-api/events"
+	"beekeeper-api/features/tags"
+	"beekeeper-api/services"
 )
 
 // --- Structs for Input Validation ---
 
 type CreateEntryInput struct {
-	Content string `json:"content" binding:"required"`
-	HiveID  int    `json:"hiveID" binding:"required"`
+	Content    string     `json:"content" binding:"required"`
+	HiveID     int        `json:"hiveID" binding:"required"`
+	Priority   string     `json:"priority" binding:"omitempty,oneof=low normal high" example:"normal"`
+	DueAt      *time.Time `json:"dueAt" example:"2024-01-20T09:00:00Z"`
+	Tags       []string   `json:"tags" example:"requeen"`
+	AssigneeID *uint      `json:"assigneeID" example:"2"`
 }
 
 type UpdateEntryInput struct {
-	Content string `json:"content"`
-	HiveID  int    `json:"hiveID"`
+	Content   string     `json:"content"`
+	HiveID    int        `json:"hiveID"`
+	Priority  string     `json:"priority" binding:"omitempty,oneof=low normal high" example:"high"`
+	DueAt     *time.Time `json:"dueAt" example:"2024-01-20T09:00:00Z"`
+	Completed *bool      `json:"completed" example:"true"`
+	Tags      []string   `json:"tags" example:"requeen"`
 }
 
-
 // --- Route Registration ---
 
-func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
-	h := &handler{db: db}
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, svc *services.Services, bus *events.Bus) {
+	h := &handler{tasks: svc.Tasks, bus: bus}
+	guard := access.New(db)
 
 	taskRoutes := router.Group("/tasks")
 	{
-		taskRoutes.POST("", h.CreateTask)
-		taskRoutes.GET("", h.ListTasks)
-        taskRoutes.GET("/last", h.GetLastTask)
-		taskRoutes.GET("/:id", h.GetTask)
-		taskRoutes.PUT("/:id", h.UpdateTask)
-		taskRoutes.DELETE("/:id", h.DeleteTask)
+		taskRoutes.POST("", guard.Require(access.TasksWrite, access.HiveInBody("hiveID")), h.CreateTask)
+		taskRoutes.GET("", guard.Require(access.TasksRead, access.Anywhere), h.ListTasks)
+		taskRoutes.GET("/last", guard.Require(access.TasksRead, access.Anywhere), h.GetLastTask)
+		taskRoutes.GET("/:id", guard.Require(access.TasksRead, access.Task("id")), h.GetTask)
+		taskRoutes.PUT("/:id", guard.Require(access.TasksWrite, access.Task("id"), access.HiveInBody("hiveID")), h.UpdateTask)
+		taskRoutes.DELETE("/:id", guard.Require(access.TasksDelete, access.Task("id")), h.DeleteTask)
 	}
 }
 
 // --- Handler ---
 
 type handler struct {
-	db *gorm.DB
+	tasks services.TaskService
+	bus   *events.Bus
 }
 
-// findOrCreateHive finds a hive by its ID (name) or creates it if it doesn't exist.
-func (h *handler) findOrCreateHive(hiveID int) (models.Hive, error) {
-    var hive models.Hive
-    err := h.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&hive, "hive_name = ?", hiveID).Error
-    if err != nil {
-        if err == gorm.ErrRecordNotFound {
-            // Hive not found, create it
-            hive = models.Hive{HiveName: hiveID}
-            if createErr := h.db.Create(&hive).Error; createErr != nil {
-                return models.Hive{}, createErr
-            }
-        } else {
-            // Another error occurred
-            return models.Hive{}, err
-        }
-    }
-    return hive, nil
+// taskID reads the ID of a task from the path
+func taskID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return 0, false
+	}
+	return uint(id), true
 }
 
-
 // CreateTask godoc
 // @Summary Create a new task
-// @Description Create a new task for a hive. If the hive doesn't exist, it will be created automatically.
+// @Description Create a new task for a hive. If the hive doesn't exist, it will be created automatically. Hashtags in the content ("#requeen" or dictated "hashtag requeen") are added to the given tags.
 // @Tags tasks
 // @Accept  json
 // @Produce  json
 // @Param task body CreateEntryInput true "Task creation data"
 // @Success 201 {object} models.Task
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /tasks [post]
 func (h *handler) CreateTask(c *gin.Context) {
@@ -85,28 +89,25 @@ func (h *handler) CreateTask(c *gin.Context) {
 		return
 	}
 
-    err := h.db.Transaction(func(tx *gorm.DB) error {
-        if _, err := h.findOrCreateHive(input.HiveID); err != nil {
-            return err
-        }
-
-        task := models.Task{
-            HiveID:  input.HiveID,
-            Content: input.Content,
-        }
-
-        if result := tx.Create(&task); result.Error != nil {
-            return result.Error
-        }
-
-        c.JSON(http.StatusCreated, task)
-        return nil
-    })
-
+	task, err := h.tasks.Create(services.TaskInput{
+		HiveID:     input.HiveID,
+		Content:    input.Content,
+		Priority:   input.Priority,
+		DueAt:      input.DueAt,
+		AssigneeID: input.AssigneeID,
+		Tags:       input.Tags,
+	})
+	if errors.Is(err, services.ErrInvalidAssignee) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Assignee not found or has no access to this hive"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		return
 	}
+	h.bus.Publish(events.TaskCreated, task.HiveID, task)
+
+	c.JSON(http.StatusCreated, task)
 }
 
 // ListTasks godoc
@@ -114,12 +115,31 @@ func (h *handler) CreateTask(c *gin.Context) {
 // @Description Retrieve all tasks from the database
 // @Tags tasks
 // @Produce  json
+// @Param tag query string false "Only tasks with all of these tags, comma-separated"
+// @Param assignee_id query int false "Only tasks assigned to this user"
 // @Success 200 {array} models.Task
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /tasks [get]
 func (h *handler) ListTasks(c *gin.Context) {
-	var tasks []models.Task
-	if result := h.db.Find(&tasks); result.Error != nil {
+	opts := services.ListOptions{
+		Tags:   tags.ParseFilter(c),
+		Viewer: services.ViewerFrom(c, access.TasksRead),
+	}
+	if assignee := c.Query("assignee_id"); assignee != "" {
+		id, err := strconv.ParseUint(assignee, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignee_id"})
+			return
+		}
+		assigneeID := uint(id)
+		opts.AssigneeID = &assigneeID
+	}
+
+	tasks, err := h.tasks.List(opts)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks"})
 		return
 	}
@@ -135,16 +155,26 @@ func (h *handler) ListTasks(c *gin.Context) {
 // @Param id path int true "Task ID"
 // @Success 200 {object} models.Task
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /tasks/{id} [get]
 func (h *handler) GetTask(c *gin.Context) {
-	id := c.Param("id")
-	var task models.Task
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
 
-	if result := h.db.First(&task, id); result.Error != nil {
+	task, err := h.tasks.Get(id)
+	if errors.Is(err, services.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task"})
+		return
+	}
 
 	c.JSON(http.StatusOK, task)
 }
@@ -155,25 +185,27 @@ func (h *handler) GetTask(c *gin.Context) {
 // @Tags tasks
 // @Produce  json
 // @Success 200 {object} models.Task
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /tasks/last [get]
 func (h *handler) GetLastTask(c *gin.Context) {
-    var task models.Task
-    if result := h.db.Order("created_at desc").First(&task); result.Error != nil {
-        if result.Error == gorm.ErrRecordNotFound {
-            c.JSON(http.StatusNotFound, gin.H{"error": "No tasks found"})
-            return
-        }
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve last task"})
-        return
-    }
-    c.JSON(http.StatusOK, task)
+	task, err := h.tasks.Last(services.ListOptions{Viewer: services.ViewerFrom(c, access.TasksRead)})
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No tasks found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve last task"})
+		return
+	}
+	c.JSON(http.StatusOK, task)
 }
 
-
 // UpdateTask godoc
 // @Summary Update a task
-// @Description Update an existing task by ID
+// @Description Update an existing task by ID. Tags, if given, replace the task's tags; hashtags in new content are added.
 // @Tags tasks
 // @Accept  json
 // @Produce  json
@@ -181,15 +213,24 @@ func (h *handler) GetLastTask(c *gin.Context) {
 // @Param task body UpdateEntryInput true "Task update data"
 // @Success 200 {object} models.Task
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /tasks/{id} [put]
 func (h *handler) UpdateTask(c *gin.Context) {
-	id := c.Param("id")
-	var task models.Task
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
 
-	if result := h.db.First(&task, id); result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+	// A missing task is reported before the body is looked at
+	if _, err := h.tasks.Get(id); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
 		return
 	}
 
@@ -199,11 +240,28 @@ func (h *handler) UpdateTask(c *gin.Context) {
 		return
 	}
 
-	if result := h.db.Model(&task).Updates(input); result.Error != nil {
+	task, completed, err := h.tasks.Update(id, services.TaskInput{
+		HiveID:    input.HiveID,
+		Content:   input.Content,
+		Priority:  input.Priority,
+		DueAt:     input.DueAt,
+		Completed: input.Completed,
+		Tags:      input.Tags,
+	})
+	if errors.Is(err, services.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
 		return
 	}
 
+	h.bus.Publish(events.TaskUpdated, task.HiveID, task)
+	if completed {
+		h.bus.Publish(events.TaskCompleted, task.HiveID, task)
+	}
+
 	c.JSON(http.StatusOK, task)
 }
 
@@ -215,20 +273,27 @@ func (h *handler) UpdateTask(c *gin.Context) {
 // @Param id path int true "Task ID"
 // @Success 204
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /tasks/{id} [delete]
 func (h *handler) DeleteTask(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+	id, ok := taskID(c)
+	if !ok {
 		return
 	}
-	
-	if result := h.db.Delete(&models.Task{}, id); result.Error != nil || result.RowsAffected == 0 {
+
+	task, err := h.tasks.Delete(id)
+	if errors.Is(err, services.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task"})
+		return
+	}
+	h.bus.Publish(events.TaskDeleted, task.HiveID, task)
 
 	c.Status(http.StatusNoContent)
 }
-
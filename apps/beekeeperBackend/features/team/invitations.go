@@ -0,0 +1,260 @@
+package team
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"beekeeper-api/auth"
+	"beekeeper-api/models"
+)
+
+// invitationValidity is how long an invitation can be accepted
+const invitationValidity = 7 * 24 * time.Hour
+
+// --- Structs for Input Validation ---
+
+type CreateInvitationInput struct {
+	Email string `json:"email" binding:"required,email" example:"inspector@veterinary.example"`
+	Role  string `json:"role" binding:"required,oneof=owner editor viewer inspector" example:"inspector"`
+	// AccessExpiresAt ends the membership created by accepting, required for
+	// inspectors
+	AccessExpiresAt *time.Time `json:"accessExpiresAt" example:"2024-02-15T00:00:00Z"`
+}
+
+type AcceptInvitationInput struct {
+	Token string `json:"token" binding:"required" example:"9b1c6f0e2d..."`
+	// Name of the account created for invitees without one
+	Name string `json:"name" example:"Dr. Vet Inspector"`
+}
+
+// --- Response Structs ---
+
+// CreateInvitationResponse is a new invitation with its token, which is only
+// shown once and has to be passed on to the invitee
+type CreateInvitationResponse struct {
+	models.Invitation
+	Token string `json:"token" example:"9b1c6f0e2d..."`
+}
+
+// AcceptInvitationResponse is the membership created by accepting an
+// invitation. Anonymous invitees also get an API token, which is only shown
+// once.
+type AcceptInvitationResponse struct {
+	Member models.TeamMember `json:"member"`
+	Token  string            `json:"token,omitempty" example:"9b1c6f0e2d..."`
+}
+
+var errInvitationNotFound = errors.New("invitation not found")
+
+// --- Handler ---
+
+// CreateInvitation godoc
+// @Summary Invite someone to an apiary
+// @Description Invite someone by email to join an apiary's team with a role. The response contains the invitation token, which is not shown again; the invitee accepts with it within 7 days. Inspector invitations need accessExpiresAt, after which the inspector loses access.
+// @Tags team
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Apiary ID"
+// @Param invitation body CreateInvitationInput true "Invitation data"
+// @Success 201 {object} CreateInvitationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apiaries/{id}/invitations [post]
+func (h *handler) CreateInvitation(c *gin.Context) {
+	var apiary models.Apiary
+	if result := h.db.First(&apiary, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Apiary not found"})
+		return
+	}
+
+	var input CreateInvitationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	now := time.Now()
+	if input.Role == models.RoleInspector && input.AccessExpiresAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Inspector invitations need accessExpiresAt"})
+		return
+	}
+	if input.AccessExpiresAt != nil && !input.AccessExpiresAt.After(now) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "accessExpiresAt must be in the future"})
+		return
+	}
+
+	token, hash, err := auth.NewToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create invitation"})
+		return
+	}
+	invitation := models.Invitation{
+		ApiaryID:        apiary.ID,
+		Email:           strings.ToLower(input.Email),
+		Role:            input.Role,
+		TokenHash:       hash,
+		ExpiresAt:       now.Add(invitationValidity),
+		AccessExpiresAt: input.AccessExpiresAt,
+	}
+	if user, ok := auth.CurrentUser(c); ok {
+		invitation.InvitedByID = &user.ID
+	}
+	if result := h.db.Create(&invitation); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create invitation"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateInvitationResponse{Invitation: invitation, Token: token})
+}
+
+// ListInvitations godoc
+// @Summary List an apiary's invitations
+// @Description List the invitations to an apiary that have not been accepted yet, including expired ones
+// @Tags team
+// @Produce  json
+// @Param id path int true "Apiary ID"
+// @Success 200 {array} models.Invitation
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apiaries/{id}/invitations [get]
+func (h *handler) ListInvitations(c *gin.Context) {
+	var invitations []models.Invitation
+	result := h.db.Where("apiary_id = ? AND accepted_at IS NULL", c.Param("id")).Order("created_at").Find(&invitations)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve invitations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// RevokeInvitation godoc
+// @Summary Revoke an invitation
+// @Description Delete an invitation so it can no longer be accepted. Memberships created from it are not affected.
+// @Tags team
+// @Produce  json
+// @Param id path int true "Apiary ID"
+// @Param invitationID path int true "Invitation ID"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /apiaries/{id}/invitations/{invitationID} [delete]
+func (h *handler) RevokeInvitation(c *gin.Context) {
+	result := h.db.Where("apiary_id = ?", c.Param("id")).Delete(&models.Invitation{}, c.Param("invitationID"))
+	if result.Error != nil || result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AcceptInvitation godoc
+// @Summary Accept an invitation
+// @Description Join an apiary's team with the role of the invitation. Authenticated users join themselves. Without an API token, the invitee joins as the user with the invited email, which is created with no global role if needed, and gets an API token in the response.
+// @Tags team
+// @Accept  json
+// @Produce  json
+// @Param invitation body AcceptInvitationInput true "Invitation token"
+// @Success 200 {object} AcceptInvitationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /invitations/accept [post]
+func (h *handler) AcceptInvitation(c *gin.Context) {
+	var input AcceptInvitationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	current, authenticated := auth.CurrentUser(c)
+
+	var response AcceptInvitationResponse
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var invitation models.Invitation
+		err := tx.Where("token_hash = ? AND accepted_at IS NULL AND expires_at > ?", auth.HashToken(input.Token), time.Now()).
+			First(&invitation).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errInvitationNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var user models.User
+		if authenticated {
+			user = *current
+		} else if user, err = h.invitee(tx, invitation.Email, input.Name); err != nil {
+			return err
+		}
+
+		// Accepting again, e.g. a new inspection, replaces the old membership
+		member := models.TeamMember{ApiaryID: invitation.ApiaryID, UserID: user.ID}
+		if err := tx.Where(member).Delete(&models.TeamMember{}).Error; err != nil {
+			return err
+		}
+		member.Role = invitation.Role
+		member.ExpiresAt = invitation.AccessExpiresAt
+		if err := tx.Omit("User").Create(&member).Error; err != nil {
+			return err
+		}
+		member.User = user
+		response.Member = member
+
+		if err := tx.Model(&invitation).Update("accepted_at", time.Now()).Error; err != nil {
+			return err
+		}
+		if authenticated {
+			return nil
+		}
+
+		token, hash, err := auth.NewToken()
+		if err != nil {
+			return err
+		}
+		apiToken := models.APIToken{UserID: user.ID, Name: "Invitation to apiary", TokenHash: hash}
+		if err := tx.Create(&apiToken).Error; err != nil {
+			return err
+		}
+		response.Token = token
+		return nil
+	})
+	if errors.Is(err, errInvitationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found or expired"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// invitee finds the user with the invited email, or creates one without a
+// global role so the invitation only grants access to its apiary.
+func (h *handler) invitee(tx *gorm.DB, email, name string) (models.User, error) {
+	var user models.User
+	if err := tx.Where("LOWER(email) = ?", email).Limit(1).Find(&user).Error; err != nil || user.ID != 0 {
+		return user, err
+	}
+
+	_, hash, err := auth.NewToken()
+	if err != nil {
+		return user, err
+	}
+	if name == "" {
+		name = email
+	}
+	user = models.User{Name: name, Email: email, Role: models.RoleNone, CalendarTokenHash: hash}
+	return user, tx.Create(&user).Error
+}
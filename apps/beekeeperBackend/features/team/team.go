@@ -0,0 +1,551 @@
+package team
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"beekeeper-api/access"
+	"beekeeper-api/auth"
+	"beekeeper-api/events"
+	"beekeeper-api/models"
+)
+
+// --- Structs for Input Validation ---
+
+type AddMemberInput struct {
+	UserID uint   `json:"userID" binding:"required" example:"2"`
+	Role   string `json:"role" binding:"omitempty,oneof=owner editor viewer inspector" example:"editor"`
+	// ExpiresAt ends the membership, e.g. for an inspector's visit
+	ExpiresAt *time.Time `json:"expiresAt" example:"2024-02-15T00:00:00Z"`
+}
+
+type UpdateMemberInput struct {
+	Role string `json:"role" binding:"omitempty,oneof=owner editor viewer inspector" example:"viewer"`
+	// ExpiresAt replaces the membership's expiry, null keeps it forever
+	ExpiresAt *time.Time `json:"expiresAt" example:"2024-02-15T00:00:00Z"`
+}
+
+type AssignTaskInput struct {
+	// UserID is the new assignee, null unassigns the task
+	UserID *uint `json:"userID" example:"2"`
+}
+
+type ReassignTasksInput struct {
+	FromUserID uint `json:"fromUserID" binding:"required" example:"2"`
+	// ToUserID is the new assignee, null unassigns the tasks
+	ToUserID *uint `json:"toUserID" example:"3"`
+	// ApiaryID limits the reassignment to tasks of hives in this apiary
+	ApiaryID *uint `json:"apiaryID" example:"1"`
+}
+
+// --- Response Structs ---
+
+// ReassignResult lists the tasks that were moved to the new assignee and the
+// ones the new assignee has no access to
+type ReassignResult struct {
+	Reassigned []uint `json:"reassigned" example:"4,7"`
+	Skipped    []uint `json:"skipped" example:"9"`
+}
+
+// Workload summarises everyone's tasks
+type Workload struct {
+	WeekStart  time.Time        `json:"week_start" example:"2024-01-15T00:00:00Z"`
+	People     []PersonWorkload `json:"people"`
+	Unassigned TaskCounts       `json:"unassigned"`
+}
+
+// PersonWorkload is one person's share of the tasks
+type PersonWorkload struct {
+	UserID uint   `json:"user_id" example:"2"`
+	Name   string `json:"name" example:"Ana Beekeeper"`
+	TaskCounts
+}
+
+// TaskCounts counts open, overdue and recently completed tasks
+type TaskCounts struct {
+	Open              int `json:"open" example:"5"`
+	Overdue           int `json:"overdue" example:"1"`
+	CompletedThisWeek int `json:"completed_this_week" example:"3"`
+}
+
+// --- Route Registration ---
+
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, bus *events.Bus) {
+	h := &handler{db: db, bus: bus}
+	guard := access.New(db)
+
+	memberRoutes := router.Group("/apiaries/:id/members")
+	{
+		memberRoutes.GET("", guard.Require(access.HivesRead, access.Apiary("id")), h.ListMembers)
+		memberRoutes.POST("", guard.Require(access.TeamManage, access.Apiary("id")), h.AddMember)
+		memberRoutes.PATCH("/:userID", guard.Require(access.TeamManage, access.Apiary("id")), h.UpdateMember)
+		memberRoutes.DELETE("/:userID", guard.Require(access.TeamManage, access.Apiary("id")), h.RemoveMember)
+	}
+
+	invitationRoutes := router.Group("/apiaries/:id/invitations")
+	{
+		invitationRoutes.POST("", guard.Require(access.TeamManage, access.Apiary("id")), h.CreateInvitation)
+		invitationRoutes.GET("", guard.Require(access.TeamManage, access.Apiary("id")), h.ListInvitations)
+		invitationRoutes.DELETE("/:invitationID", guard.Require(access.TeamManage, access.Apiary("id")), h.RevokeInvitation)
+	}
+	router.POST("/invitations/accept", h.AcceptInvitation)
+
+	router.GET("/me", h.GetMe)
+	router.GET("/me/tasks", h.ListMyTasks)
+	router.PUT("/tasks/:id/assignee", guard.Require(access.TasksWrite, access.Task("id")), h.AssignTask)
+	router.POST("/tasks/reassign", guard.Require(access.TasksWrite, access.Anywhere), h.ReassignTasks)
+	router.GET("/team/workload", guard.Require(access.TasksRead, access.Anywhere), h.GetWorkload)
+}
+
+// startOfWeek returns Monday 00:00 of the week containing t.
+func startOfWeek(t time.Time) time.Time {
+	year, month, day := t.Date()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+}
+
+// --- Handler ---
+
+type handler struct {
+	db  *gorm.DB
+	bus *events.Bus
+}
+
+// ListMembers godoc
+// @Summary List an apiary's team
+// @Description List the users with a role in the apiary. A membership adds to the user's global role, except in members only apiaries, where it is the only role besides a global owner's.
+// @Tags team
+// @Produce  json
+// @Param id path int true "Apiary ID"
+// @Success 200 {array} models.TeamMember
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apiaries/{id}/members [get]
+func (h *handler) ListMembers(c *gin.Context) {
+	var members []models.TeamMember
+	if result := h.db.Preload("User").Where("apiary_id = ?", c.Param("id")).Find(&members); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve members"})
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// AddMember godoc
+// @Summary Add a user to an apiary's team
+// @Description Give a user a role in an apiary, editor by default. The more privileged of the membership and the user's global role applies; in members only apiaries only members (and global owners) can access its hives.
+// @Tags team
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Apiary ID"
+// @Param member body AddMemberInput true "Member data"
+// @Success 201 {object} models.TeamMember
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /apiaries/{id}/members [post]
+func (h *handler) AddMember(c *gin.Context) {
+	var apiary models.Apiary
+	if result := h.db.First(&apiary, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Apiary not found"})
+		return
+	}
+
+	var input AddMemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	var user models.User
+	if result := h.db.First(&user, input.UserID); result.Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
+		return
+	}
+
+	member := models.TeamMember{ApiaryID: apiary.ID, UserID: user.ID, Role: input.Role, ExpiresAt: input.ExpiresAt}
+	if member.Role == "" {
+		member.Role = models.RoleEditor
+	}
+	if result := h.db.Omit("User").Create(&member); result.Error != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "User is already a member of this apiary"})
+		return
+	}
+	member.User = user
+
+	c.JSON(http.StatusCreated, member)
+}
+
+// UpdateMember godoc
+// @Summary Change a team member's role
+// @Description Change the role of a member of an apiary's team and when their membership expires
+// @Tags team
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Apiary ID"
+// @Param userID path int true "User ID"
+// @Param member body UpdateMemberInput true "Member update data"
+// @Success 200 {object} models.TeamMember
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /apiaries/{id}/members/{userID} [patch]
+func (h *handler) UpdateMember(c *gin.Context) {
+	var member models.TeamMember
+	if result := h.db.Preload("User").First(&member, "apiary_id = ? AND user_id = ?", c.Param("id"), c.Param("userID")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+
+	var input UpdateMemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	updates := map[string]any{"expires_at": input.ExpiresAt}
+	if input.Role != "" {
+		updates["role"] = input.Role
+	}
+	if result := h.db.Model(&member).Omit("User").Updates(updates); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update member"})
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveMember godoc
+// @Summary Remove a user from an apiary's team
+// @Description Revoke a user's access to the hives of an apiary. Tasks stay assigned to them.
+// @Tags team
+// @Produce  json
+// @Param id path int true "Apiary ID"
+// @Param userID path int true "User ID"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /apiaries/{id}/members/{userID} [delete]
+func (h *handler) RemoveMember(c *gin.Context) {
+	result := h.db.Where("apiary_id = ? AND user_id = ?", c.Param("id"), c.Param("userID")).Delete(&models.TeamMember{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetMe godoc
+// @Summary Get the current user
+// @Description Get the user the API token belongs to
+// @Tags team
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {object} models.User
+// @Failure 401 {object} map[string]string
+// @Router /me [get]
+func (h *handler) GetMe(c *gin.Context) {
+	user, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ListMyTasks godoc
+// @Summary List my tasks
+// @Description List the tasks assigned to the current user in hives they have access to, by due date
+// @Tags team
+// @Produce  json
+// @Security BearerAuth
+// @Param status query string false "open (default), completed or all"
+// @Success 200 {array} models.Task
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /me/tasks [get]
+func (h *handler) ListMyTasks(c *gin.Context) {
+	user, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	query := h.db.Preload("Tags").
+		Where("assignee_id = ? AND hive_id IN (?)", user.ID, access.Hives(h.db, user.ID, access.TasksRead)).
+		Order("due_at IS NULL, due_at, created_at")
+	switch c.DefaultQuery("status", "open") {
+	case "open":
+		query = query.Where("completed_at IS NULL")
+	case "completed":
+		query = query.Where("completed_at IS NOT NULL")
+	case "all":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be open, completed or all"})
+		return
+	}
+
+	var tasks []models.Task
+	if result := query.Find(&tasks); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// AssignTask godoc
+// @Summary Assign a task
+// @Description Assign a task to a team member, or unassign it with a null userID. The assignee must be allowed to work on the task's hive.
+// @Tags team
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Task ID"
+// @Param assignee body AssignTaskInput true "New assignee"
+// @Success 200 {object} models.Task
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /tasks/{id}/assignee [put]
+func (h *handler) AssignTask(c *gin.Context) {
+	var task models.Task
+	if result := h.db.First(&task, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	var input AssignTaskInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if input.UserID != nil {
+		if err := h.checkAssignee(*input.UserID, task.HiveID); err != nil {
+			status, message := assigneeError(err)
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
+	}
+
+	if result := h.db.Model(&task).Update("assignee_id", input.UserID); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign task"})
+		return
+	}
+	task.AssigneeID = input.UserID
+	h.bus.Publish(events.TaskAssigned, task.HiveID, task)
+
+	c.JSON(http.StatusOK, task)
+}
+
+var (
+	errUnknownAssignee = errors.New("assignee not found")
+	errNoHiveAccess    = errors.New("assignee has no access to this hive")
+)
+
+func assigneeError(err error) (int, string) {
+	switch err {
+	case errUnknownAssignee:
+		return http.StatusBadRequest, "Assignee not found"
+	case errNoHiveAccess:
+		return http.StatusForbidden, "Assignee has no access to this hive"
+	}
+	return http.StatusInternalServerError, "Failed to assign task"
+}
+
+// checkAssignee makes sure a user exists and may work on the hive's tasks.
+func (h *handler) checkAssignee(userID uint, hiveName int) error {
+	var user models.User
+	if err := h.db.Limit(1).Find(&user, userID).Error; err != nil {
+		return err
+	}
+	if user.ID == 0 {
+		return errUnknownAssignee
+	}
+	allowed, err := canWorkOn(h.db, &user, hiveName)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errNoHiveAccess
+	}
+	return nil
+}
+
+// canWorkOn reports whether a user may work on the tasks of a hive.
+func canWorkOn(db *gorm.DB, user *models.User, hiveName int) (bool, error) {
+	role, err := access.HiveRole(db, user, hiveName)
+	if err != nil {
+		return false, err
+	}
+	return access.Can(role, access.TasksWrite), nil
+}
+
+// ReassignTasks godoc
+// @Summary Reassign open tasks
+// @Description Move all open tasks from one person to another, e.g. before a holiday, optionally only in one apiary. Tasks in hives the new assignee may not work on are skipped. When authenticated, only tasks in hives the current user may work on are moved.
+// @Tags team
+// @Accept  json
+// @Produce  json
+// @Param reassignment body ReassignTasksInput true "Reassignment"
+// @Success 200 {object} ReassignResult
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /tasks/reassign [post]
+func (h *handler) ReassignTasks(c *gin.Context) {
+	var input ReassignTasksInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	var assignee models.User
+	if input.ToUserID != nil {
+		if result := h.db.First(&assignee, *input.ToUserID); result.Error != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Assignee not found"})
+			return
+		}
+	}
+
+	query := h.db.Where("assignee_id = ? AND completed_at IS NULL", input.FromUserID)
+	if input.ApiaryID != nil {
+		query = query.Where("hive_id IN (?)", h.db.Model(&models.Hive{}).Select("hive_name").Where("apiary_id = ?", *input.ApiaryID))
+	}
+	if user, ok := auth.CurrentUser(c); ok {
+		query = query.Where("hive_id IN (?)", access.Hives(h.db, user.ID, access.TasksWrite))
+	}
+	var tasks []models.Task
+	if result := query.Find(&tasks); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks"})
+		return
+	}
+
+	result := ReassignResult{Reassigned: []uint{}, Skipped: []uint{}}
+	var moved []models.Task
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		for _, task := range tasks {
+			if input.ToUserID != nil {
+				allowed, err := canWorkOn(tx, &assignee, task.HiveID)
+				if err != nil {
+					return err
+				}
+				if !allowed {
+					result.Skipped = append(result.Skipped, task.ID)
+					continue
+				}
+			}
+			if err := tx.Model(&task).Update("assignee_id", input.ToUserID).Error; err != nil {
+				return err
+			}
+			task.AssigneeID = input.ToUserID
+			moved = append(moved, task)
+			result.Reassigned = append(result.Reassigned, task.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reassign tasks"})
+		return
+	}
+	for _, task := range moved {
+		h.bus.Publish(events.TaskAssigned, task.HiveID, task)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetWorkload godoc
+// @Summary Workload per person
+// @Description Count open, overdue and this week's completed tasks for every person, and the open tasks nobody is assigned to. With apiary_id, only that apiary's hives and the people who may work on them are counted. When authenticated, only hives the current user can read tasks of are counted.
+// @Tags team
+// @Produce  json
+// @Param apiary_id query int false "Only this apiary"
+// @Success 200 {object} Workload
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /team/workload [get]
+func (h *handler) GetWorkload(c *gin.Context) {
+	now := time.Now()
+	workload := Workload{WeekStart: startOfWeek(now), People: []PersonWorkload{}}
+
+	tasks := h.db.Model(&models.Task{})
+	people := h.db.Model(&models.User{}).Order("name")
+	if value := c.Query("apiary_id"); value != "" {
+		apiaryID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid apiary_id"})
+			return
+		}
+		if user, ok := auth.CurrentUser(c); ok {
+			role, err := access.ApiaryRole(h.db, user, uint(apiaryID))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute workload"})
+				return
+			}
+			if !access.Can(role, access.TasksRead) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "You have no access to this apiary"})
+				return
+			}
+		}
+		tasks = tasks.Where("hive_id IN (?)", h.db.Model(&models.Hive{}).Select("hive_name").Where("apiary_id = ?", apiaryID))
+
+		people = people.Where("id IN (?)", access.ApiaryUsers(h.db, uint(apiaryID), access.TasksWrite))
+	}
+	if user, ok := auth.CurrentUser(c); ok {
+		tasks = tasks.Where("hive_id IN (?)", access.Hives(h.db, user.ID, access.TasksRead))
+	}
+
+	var counts []struct {
+		AssigneeID        *uint
+		Open              int
+		Overdue           int
+		CompletedThisWeek int
+	}
+	err := tasks.
+		Select(`assignee_id,
+			SUM(CASE WHEN completed_at IS NULL THEN 1 ELSE 0 END) AS open,
+			SUM(CASE WHEN completed_at IS NULL AND due_at IS NOT NULL AND due_at < ? THEN 1 ELSE 0 END) AS overdue,
+			SUM(CASE WHEN completed_at >= ? THEN 1 ELSE 0 END) AS completed_this_week`, now, workload.WeekStart).
+		Group("assignee_id").
+		Scan(&counts).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute workload"})
+		return
+	}
+	var users []models.User
+	if err := people.Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute workload"})
+		return
+	}
+
+	byUser := make(map[uint]TaskCounts, len(counts))
+	for _, count := range counts {
+		taskCounts := TaskCounts{Open: count.Open, Overdue: count.Overdue, CompletedThisWeek: count.CompletedThisWeek}
+		if count.AssigneeID == nil {
+			workload.Unassigned = taskCounts
+		} else {
+			byUser[*count.AssigneeID] = taskCounts
+		}
+	}
+	for _, user := range users {
+		workload.People = append(workload.People, PersonWorkload{UserID: user.ID, Name: user.Name, TaskCounts: byUser[user.ID]})
+	}
+
+	c.JSON(http.StatusOK, workload)
+}
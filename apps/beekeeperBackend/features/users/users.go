@@ -0,0 +1,368 @@
+package users
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"beekeeper-api/access"
+	"beekeeper-api/auth"
+	"beekeeper-api/models"
+)
+
+// --- Structs for Input Validation ---
+
+type CreateUserInput struct {
+	Name  string `json:"name" binding:"required" example:"Ana Beekeeper"`
+	Email string `json:"email" binding:"required,email" example:"ana@example.com"`
+	// Role is the global role, editor by default and owner for the first user
+	Role string `json:"role" binding:"omitempty,oneof=owner editor viewer none" example:"editor"`
+}
+
+type UpdateUserInput struct {
+	Name  string `json:"name" example:"Ana Beekeeper"`
+	Email string `json:"email" binding:"omitempty,email" example:"ana@example.com"`
+	// Role changes the global role, which needs the users:manage permission
+	Role string `json:"role" binding:"omitempty,oneof=owner editor viewer none" example:"viewer"`
+}
+
+type CreateAPITokenInput struct {
+	Name string `json:"name" binding:"required" example:"Phone app"`
+}
+
+// CreateUserResponse is the created user with its calendar feed details
+type CreateUserResponse struct {
+	User     models.User           `json:"user"`
+	Calendar CalendarTokenResponse `json:"calendar"`
+}
+
+// CalendarTokenResponse holds a newly issued calendar token, which is only
+// shown once
+type CalendarTokenResponse struct {
+	Token string `json:"token" example:"9b1c6f0e2d..."`
+	URL   string `json:"url" example:"/api/tasks/calendar.ics?token=9b1c6f0e2d..."`
+}
+
+// CreateAPITokenResponse is a newly issued API token, whose secret is only
+// shown once
+type CreateAPITokenResponse struct {
+	models.APIToken
+	Token string `json:"token" example:"9b1c6f0e2d..."`
+}
+
+// --- Route Registration ---
+
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
+	h := &handler{db: db}
+	guard := access.New(db)
+	self := guard.Require(access.UsersManage, access.Self("id"))
+
+	userRoutes := router.Group("/users")
+	{
+		userRoutes.POST("", guard.Require(access.UsersManage, access.Global), h.CreateUser)
+		userRoutes.GET("", guard.Require(access.HivesRead, access.Anywhere), h.ListUsers)
+		userRoutes.GET("/:id", guard.Require(access.HivesRead, access.Anywhere), h.GetUser)
+		userRoutes.PATCH("/:id", self, h.UpdateUser)
+		userRoutes.DELETE("/:id", guard.Require(access.UsersManage, access.Global), h.DeleteUser)
+		userRoutes.POST("/:id/calendar-token", self, h.RotateCalendarToken)
+		userRoutes.POST("/:id/api-tokens", self, h.CreateAPIToken)
+		userRoutes.GET("/:id/api-tokens", self, h.ListAPITokens)
+		userRoutes.DELETE("/:id/api-tokens/:tokenID", self, h.DeleteAPIToken)
+	}
+}
+
+func calendarTokenResponse(token string) CalendarTokenResponse {
+	return CalendarTokenResponse{Token: token, URL: "/api/tasks/calendar.ics?token=" + token}
+}
+
+// --- Handler ---
+
+type handler struct {
+	db *gorm.DB
+}
+
+// CreateUser godoc
+// @Summary Create a new user
+// @Description Create a user. The first user becomes an owner, later ones editors unless a role is given. The response contains the user's calendar token and feed URL, which are not shown again.
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param user body CreateUserInput true "User data"
+// @Success 201 {object} CreateUserResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users [post]
+func (h *handler) CreateUser(c *gin.Context) {
+	var input CreateUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	token, hash, err := auth.NewToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create user"})
+		return
+	}
+
+	user := models.User{Name: input.Name, Email: input.Email, Role: input.Role, CalendarTokenHash: hash}
+	if user.Role == "" {
+		var count int64
+		if result := h.db.Model(&models.User{}).Count(&count); result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create user"})
+			return
+		}
+		user.Role = models.RoleEditor
+		if count == 0 {
+			user.Role = models.RoleOwner
+		}
+	}
+	if result := h.db.Create(&user); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create user"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateUserResponse{User: user, Calendar: calendarTokenResponse(token)})
+}
+
+// visible restricts a query on users to those the current user may see, see
+// access.Users. Anonymous requests, only possible while there are no users,
+// are not restricted.
+func (h *handler) visible(c *gin.Context, query *gorm.DB) *gorm.DB {
+	user, ok := auth.CurrentUser(c)
+	if !ok {
+		return query
+	}
+	return query.Where("id IN (?)", access.Users(h.db, user))
+}
+
+// ListUsers godoc
+// @Summary List users
+// @Description Get a list of the users the current user may see: all of them for owners, otherwise themselves and the members of their apiaries
+// @Tags users
+// @Produce  json
+// @Success 200 {array} models.User
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users [get]
+func (h *handler) ListUsers(c *gin.Context) {
+	var users []models.User
+	if result := h.visible(c, h.db).Find(&users); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// GetUser godoc
+// @Summary Get a user by ID
+// @Description Retrieve a specific user by ID, if the current user may see them, see GET /users
+// @Tags users
+// @Produce  json
+// @Param id path int true "User ID"
+// @Success 200 {object} models.User
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id} [get]
+func (h *handler) GetUser(c *gin.Context) {
+	id := c.Param("id")
+	var user models.User
+
+	if result := h.visible(c, h.db).First(&user, id); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateUser godoc
+// @Summary Update a user
+// @Description Update a user's name, email or global role. Users can update themselves, but only owners can change roles.
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param id path int true "User ID"
+// @Param user body UpdateUserInput true "User update data"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id} [patch]
+func (h *handler) UpdateUser(c *gin.Context) {
+	id := c.Param("id")
+	var user models.User
+
+	if result := h.db.First(&user, id); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var input UpdateUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if current, ok := auth.CurrentUser(c); ok && input.Role != "" && !access.Can(current.Role, access.UsersManage) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied: " + string(access.UsersManage) + " required"})
+		return
+	}
+
+	if result := h.db.Model(&user).Updates(models.User{Name: input.Name, Email: input.Email, Role: input.Role}); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteUser godoc
+// @Summary Delete a user
+// @Description Delete a user by ID
+// @Tags users
+// @Produce  json
+// @Param id path int true "User ID"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id} [delete]
+func (h *handler) DeleteUser(c *gin.Context) {
+	id := c.Param("id")
+
+	if result := h.db.Delete(&models.User{}, id); result.Error != nil || result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RotateCalendarToken godoc
+// @Summary Issue a new calendar token
+// @Description Replace the user's calendar feed token, invalidating the old feed URL
+// @Tags users
+// @Produce  json
+// @Param id path int true "User ID"
+// @Success 200 {object} CalendarTokenResponse
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/calendar-token [post]
+func (h *handler) RotateCalendarToken(c *gin.Context) {
+	id := c.Param("id")
+	var user models.User
+
+	if result := h.db.First(&user, id); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	token, hash, err := auth.NewToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create token"})
+		return
+	}
+	if result := h.db.Model(&user).Update("calendar_token_hash", hash); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, calendarTokenResponse(token))
+}
+
+// CreateAPIToken godoc
+// @Summary Issue an API token
+// @Description Create a token the user authenticates API requests with, sent as "Authorization: Bearer <token>". The token is only shown in this response.
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param id path int true "User ID"
+// @Param token body CreateAPITokenInput true "Token data"
+// @Success 201 {object} CreateAPITokenResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/api-tokens [post]
+func (h *handler) CreateAPIToken(c *gin.Context) {
+	id := c.Param("id")
+	var user models.User
+
+	if result := h.db.First(&user, id); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var input CreateAPITokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	token, hash, err := auth.NewToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create token"})
+		return
+	}
+	apiToken := models.APIToken{UserID: user.ID, Name: input.Name, TokenHash: hash}
+	if result := h.db.Create(&apiToken); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateAPITokenResponse{APIToken: apiToken, Token: token})
+}
+
+// ListAPITokens godoc
+// @Summary List a user's API tokens
+// @Description List the user's API tokens without their secrets
+// @Tags users
+// @Produce  json
+// @Param id path int true "User ID"
+// @Success 200 {array} models.APIToken
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/api-tokens [get]
+func (h *handler) ListAPITokens(c *gin.Context) {
+	var tokens []models.APIToken
+	if result := h.db.Where("user_id = ?", c.Param("id")).Find(&tokens); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// DeleteAPIToken godoc
+// @Summary Revoke an API token
+// @Description Delete one of the user's API tokens
+// @Tags users
+// @Produce  json
+// @Param id path int true "User ID"
+// @Param tokenID path int true "Token ID"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/api-tokens/{tokenID} [delete]
+func (h *handler) DeleteAPIToken(c *gin.Context) {
+	result := h.db.Where("user_id = ?", c.Param("id")).Delete(&models.APIToken{}, c.Param("tokenID"))
+	if result.Error != nil || result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
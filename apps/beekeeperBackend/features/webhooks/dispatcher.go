@@ -0,0 +1,239 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"beekeeper-api/config"
+	"beekeeper-api/events"
+	"beekeeper-api/models"
+)
+
+const (
+	pollInterval  = 5 * time.Second
+	maxRetryDelay = 6 * time.Hour
+	batchSize     = 50
+)
+
+// Dispatcher turns events into queued deliveries and sends them. The queue is
+// the webhook_deliveries table, so pending deliveries survive a restart.
+// Events are matched against the webhooks in the background, so publishing
+// one does not wait for the database; events published but not yet matched
+// are lost if the server stops.
+type Dispatcher struct {
+	db          *gorm.DB
+	client      *http.Client
+	maxAttempts int
+	retryBase   time.Duration
+	wake        chan struct{}
+
+	mu        sync.Mutex
+	published []events.Event
+}
+
+// NewDispatcher creates a dispatcher using the webhook settings from the configuration.
+func NewDispatcher(cfg *config.Config, db *gorm.DB) *Dispatcher {
+	return &Dispatcher{
+		db:          db,
+		client:      &http.Client{Timeout: cfg.WebhookTimeout},
+		maxAttempts: cfg.WebhookMaxAttempts,
+		retryBase:   cfg.WebhookRetryBase,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Start queues deliveries for every event published on the bus from now on
+// and begins delivering in the background.
+func (d *Dispatcher) Start(bus *events.Bus) {
+	bus.Handle(d.collect)
+
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-d.wake:
+			}
+			d.enqueuePublished()
+			d.deliverDue()
+		}
+	}()
+}
+
+// collect keeps a published event for the delivery goroutine. It runs in the
+// publisher's goroutine, so it only appends to a slice.
+func (d *Dispatcher) collect(event events.Event) {
+	d.mu.Lock()
+	d.published = append(d.published, event)
+	d.mu.Unlock()
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// enqueuePublished queues deliveries for the events collected so far, in the
+// order they were published.
+func (d *Dispatcher) enqueuePublished() {
+	d.mu.Lock()
+	published := d.published
+	d.published = nil
+	d.mu.Unlock()
+	if len(published) == 0 {
+		return
+	}
+
+	var webhooks []models.Webhook
+	if err := d.db.Where("active = ?", true).Find(&webhooks).Error; err != nil {
+		log.Printf("Failed to load webhooks for %d events: %v", len(published), err)
+		return
+	}
+	for _, event := range published {
+		d.enqueue(webhooks, event)
+	}
+}
+
+// matches reports whether an event type passes a webhook's event filters.
+func matches(filters []string, eventType string) bool {
+	for _, filter := range filters {
+		if filter == "*" || filter == eventType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(filter, "*"); ok && strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// enqueue queues a delivery of the event for every webhook whose filters it
+// passes.
+func (d *Dispatcher) enqueue(webhooks []models.Webhook, event events.Event) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to encode %s event: %v", event.Type, err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if !matches(webhook.Events, event.Type) {
+			continue
+		}
+		delivery := models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: time.Now(),
+		}
+		if err := d.db.Create(&delivery).Error; err != nil {
+			log.Printf("Failed to queue %s event for webhook %d: %v", event.Type, webhook.ID, err)
+		}
+	}
+}
+
+func (d *Dispatcher) deliverDue() {
+	var due []models.WebhookDelivery
+	err := d.db.
+		Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, time.Now()).
+		Order("next_attempt_at").
+		Limit(batchSize).
+		Find(&due).Error
+	if err != nil {
+		log.Printf("Failed to load pending webhook deliveries: %v", err)
+		return
+	}
+
+	for i := range due {
+		delivery := &due[i]
+		var webhook models.Webhook
+		if err := d.db.First(&webhook, delivery.WebhookID).Error; err != nil {
+			delivery.Status = models.DeliveryFailed
+			delivery.LastError = "webhook no longer exists"
+		} else if !webhook.Active {
+			delivery.Status = models.DeliveryFailed
+			delivery.LastError = "webhook is inactive"
+		} else {
+			d.attempt(webhook, delivery)
+		}
+
+		if err := d.db.Save(delivery).Error; err != nil {
+			log.Printf("Failed to update webhook delivery %d: %v", delivery.ID, err)
+		}
+	}
+}
+
+// Sign returns the value of the X-Beekeeper-Signature header for a payload.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// attempt sends the delivery once and schedules a retry with exponential
+// backoff if it fails.
+func (d *Dispatcher) attempt(webhook models.Webhook, delivery *models.WebhookDelivery) {
+	delivery.Attempts++
+	code, err := d.send(webhook, delivery)
+	delivery.ResponseCode = code
+
+	if err == nil {
+		now := time.Now()
+		delivery.Status = models.DeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= d.maxAttempts {
+		delivery.Status = models.DeliveryFailed
+		return
+	}
+	delay := d.retryBase << (delivery.Attempts - 1)
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	delivery.NextAttemptAt = time.Now().Add(delay)
+}
+
+func (d *Dispatcher) send(webhook models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Beekeeper-Webhook/1.0")
+	req.Header.Set("X-Beekeeper-Event", delivery.EventType)
+	req.Header.Set("X-Beekeeper-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Beekeeper-Signature", Sign(webhook.Secret, payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
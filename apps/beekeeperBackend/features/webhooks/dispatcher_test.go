@@ -0,0 +1,117 @@
+package webhooks
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"beekeeper-api/config"
+	"beekeeper-api/database"
+	"beekeeper-api/events"
+	"beekeeper-api/migrate"
+	"beekeeper-api/models"
+)
+
+var databases atomic.Int64
+
+// openDB creates a migrated in-memory database
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	cfg := config.New()
+	cfg.DBDriver = "sqlite"
+	cfg.DatabaseURL = fmt.Sprintf("file:webhooks%d?mode=memory&cache=shared", databases.Add(1))
+	cfg.DBMaxOpenConns = 1
+	cfg.DBMaxIdleConns = 1
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db.Logger = logger.Discard
+
+	migrator, err := migrate.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// TestDispatcherQueuesEveryEvent publishes a burst of events, more than any
+// subscriber buffer holds, while the only database connection is taken, and
+// expects publishing not to wait for it and a delivery for each event to be
+// queued once it is free.
+func TestDispatcherQueuesEveryEvent(t *testing.T) {
+	const burst = 500
+	db := openDB(t)
+
+	received := make(chan *http.Request, burst)
+	bodies := make(chan []byte, burst)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	t.Cleanup(receiver.Close)
+	webhook := models.Webhook{URL: receiver.URL, Events: []string{"log.*"}, Secret: "s3cret", Active: true}
+	if err := db.Create(&webhook).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	bus := events.NewBus(10)
+	NewDispatcher(config.New(), db).Start(bus)
+	tx := db.Begin()
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		for i := range burst {
+			bus.Publish(events.LogCreated, 1, models.Log{ID: uint(i + 1), HiveID: 1, Content: "Inspected"})
+			bus.Publish(events.HiveUpdated, 1, models.Hive{HiveName: 1})
+		}
+	}()
+	select {
+	case <-published:
+	case <-time.After(10 * time.Second):
+		t.Fatal("publishing waited for the database")
+	}
+	if err := tx.Rollback().Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var queued []uint64
+	for deadline := time.Now().Add(10 * time.Second); len(queued) < burst && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if err := db.Model(&models.WebhookDelivery{}).Order("id").Pluck("event_id", &queued).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(queued) != burst {
+		t.Fatalf("got %d deliveries queued, want %d", len(queued), burst)
+	}
+	if !slices.IsSorted(queued) {
+		t.Error("deliveries were not queued in the order of their events")
+	}
+
+	select {
+	case r := <-received:
+		body := <-bodies
+		if r.Header.Get("X-Beekeeper-Event") != events.LogCreated || r.Header.Get("X-Beekeeper-Signature") != Sign("s3cret", body) {
+			t.Errorf("got delivery %v: %s", r.Header, body)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("nothing was delivered")
+	}
+}
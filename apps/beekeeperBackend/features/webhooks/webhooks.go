@@ -0,0 +1,344 @@
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"beekeeper-api/access"
+	"beekeeper-api/events"
+	"beekeeper-api/models"
+)
+
+// --- Structs for Input Validation ---
+
+type CreateWebhookInput struct {
+	URL         string   `json:"url" binding:"required,url" example:"https://homeassistant.local/api/webhook/beekeeper"`
+	Events      []string `json:"events" binding:"required,min=1" example:"log.created,task.completed"`
+	Secret      string   `json:"secret" example:"s3cr3t"`
+	Description string   `json:"description" example:"Home Assistant"`
+}
+
+type UpdateWebhookInput struct {
+	URL         string   `json:"url" binding:"omitempty,url"`
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"`
+}
+
+// CreateWebhookResponse is the created webhook including its signing secret,
+// which is not returned by any other endpoint
+type CreateWebhookResponse struct {
+	models.Webhook
+	Secret string `json:"secret" example:"3f1c9a0e5b..."`
+}
+
+// --- Route Registration ---
+
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
+	h := &handler{db: db}
+	guard := access.New(db)
+	manage := guard.Require(access.WebhooksManage, access.Global)
+
+	webhookRoutes := router.Group("/webhooks")
+	{
+		webhookRoutes.POST("", manage, h.CreateWebhook)
+		webhookRoutes.GET("", manage, h.ListWebhooks)
+		webhookRoutes.GET("/:id", manage, h.GetWebhook)
+		webhookRoutes.PATCH("/:id", manage, h.UpdateWebhook)
+		webhookRoutes.DELETE("/:id", manage, h.DeleteWebhook)
+		webhookRoutes.GET("/:id/deliveries", manage, h.ListDeliveries)
+		webhookRoutes.POST("/:id/deliveries/:deliveryID/redeliver", manage, h.Redeliver)
+	}
+}
+
+// --- Handler ---
+
+type handler struct {
+	db *gorm.DB
+}
+
+// validateEvents checks event filters: an exact event type, a resource
+// wildcard like "log.*", or "*" for every event.
+func validateEvents(filters []string) error {
+	for _, filter := range filters {
+		if filter == "*" {
+			continue
+		}
+		known := false
+		for _, eventType := range events.Types {
+			if filter == eventType || filter == strings.SplitN(eventType, ".", 2)[0]+".*" {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown event %q", filter)
+		}
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CreateWebhook godoc
+// @Summary Register a webhook
+// @Description Register a URL that receives signed POST requests for the selected events. Payloads are signed with HMAC-SHA256 in the X-Beekeeper-Signature header. If no secret is given, one is generated; it is only returned in this response. Only owners can manage webhooks.
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param webhook body CreateWebhookInput true "Webhook data"
+// @Success 201 {object} CreateWebhookResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks [post]
+func (h *handler) CreateWebhook(c *gin.Context) {
+	var input CreateWebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := validateEvents(input.Events); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate secret"})
+			return
+		}
+		input.Secret = secret
+	}
+
+	webhook := models.Webhook{
+		URL:         input.URL,
+		Events:      input.Events,
+		Secret:      input.Secret,
+		Description: input.Description,
+		Active:      true,
+	}
+	if result := h.db.Create(&webhook); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create webhook"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateWebhookResponse{Webhook: webhook, Secret: webhook.Secret})
+}
+
+// ListWebhooks godoc
+// @Summary List webhooks
+// @Description Get a list of all registered webhooks
+// @Tags webhooks
+// @Produce  json
+// @Success 200 {array} models.Webhook
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks [get]
+func (h *handler) ListWebhooks(c *gin.Context) {
+	var webhooks []models.Webhook
+	if result := h.db.Find(&webhooks); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// GetWebhook godoc
+// @Summary Get a webhook by ID
+// @Description Retrieve a specific webhook by its ID
+// @Tags webhooks
+// @Produce  json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} models.Webhook
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{id} [get]
+func (h *handler) GetWebhook(c *gin.Context) {
+	id := c.Param("id")
+	var webhook models.Webhook
+
+	if result := h.db.First(&webhook, id); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// UpdateWebhook godoc
+// @Summary Update a webhook
+// @Description Change the URL, event filters or description of a webhook, or pause it by setting active to false
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Webhook ID"
+// @Param webhook body UpdateWebhookInput true "Webhook update data"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks/{id} [patch]
+func (h *handler) UpdateWebhook(c *gin.Context) {
+	id := c.Param("id")
+	var webhook models.Webhook
+
+	if result := h.db.First(&webhook, id); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	var input UpdateWebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if input.URL != "" {
+		webhook.URL = input.URL
+	}
+	if input.Events != nil {
+		if err := validateEvents(input.Events); err != nil || len(input.Events) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid events"})
+			return
+		}
+		webhook.Events = input.Events
+	}
+	if input.Description != "" {
+		webhook.Description = input.Description
+	}
+	if input.Active != nil {
+		webhook.Active = *input.Active
+	}
+
+	if result := h.db.Save(&webhook); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Delete a webhook and its delivery log
+// @Tags webhooks
+// @Produce  json
+// @Param id path int true "Webhook ID"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{id} [delete]
+func (h *handler) DeleteWebhook(c *gin.Context) {
+	id := c.Param("id")
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Webhook{}, id)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return result.Error
+	})
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries godoc
+// @Summary List webhook deliveries
+// @Description Get the most recent deliveries of a webhook, newest first
+// @Tags webhooks
+// @Produce  json
+// @Param id path int true "Webhook ID"
+// @Param status query string false "Filter by status (pending, succeeded, failed)"
+// @Success 200 {array} models.WebhookDelivery
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks/{id}/deliveries [get]
+func (h *handler) ListDeliveries(c *gin.Context) {
+	id := c.Param("id")
+	var webhook models.Webhook
+
+	if result := h.db.First(&webhook, id); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	query := h.db.Where("webhook_id = ?", webhook.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []models.WebhookDelivery
+	if result := query.Order("id desc").Limit(100).Find(&deliveries); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// Redeliver godoc
+// @Summary Redeliver a webhook event
+// @Description Queue a new delivery of the same payload as an earlier delivery
+// @Tags webhooks
+// @Produce  json
+// @Param id path int true "Webhook ID"
+// @Param deliveryID path int true "Delivery ID"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks/{id}/deliveries/{deliveryID}/redeliver [post]
+func (h *handler) Redeliver(c *gin.Context) {
+	var original models.WebhookDelivery
+	if result := h.db.First(&original, "id = ? AND webhook_id = ?", c.Param("deliveryID"), c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+
+	delivery := models.WebhookDelivery{
+		WebhookID:     original.WebhookID,
+		EventID:       original.EventID,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: time.Now(),
+	}
+	if result := h.db.Create(&delivery); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not queue delivery"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"beekeeper-api/auth"
+	"beekeeper-api/backup"
+	"beekeeper-api/config"
+	"beekeeper-api/database"
+	"beekeeper-api/events"
+	"beekeeper-api/migrate"
+	"beekeeper-api/models"
+	"beekeeper-api/services"
+)
+
+// The tests run against an in-memory SQLite database by default. Set
+// TEST_DB_DRIVER to postgres or mysql and TEST_DATABASE_URL to an empty
+// database to run them there; its tables are dropped and migrated again for
+// every test.
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	os.Exit(m.Run())
+}
+
+// backend is a way of storing hives, logs and tasks the API is tested with
+type backend struct {
+	name string
+	// memory backends have no database: no users, roles or apiaries
+	memory bool
+}
+
+var backends = []backend{{name: "database"}, {name: "memory", memory: true}}
+
+// testServer is the full API router with fresh storage
+type testServer struct {
+	t      *testing.T
+	db     *gorm.DB
+	router *gin.Engine
+	// token is sent with requests that do not name one, once signed in
+	token string
+}
+
+var memoryDatabases atomic.Int64
+
+// testConfig returns the configuration of the test database
+func testConfig(t *testing.T) *config.Config {
+	cfg := config.New()
+	cfg.AuthRequired = false
+	cfg.BackupInterval = 0
+	cfg.DBDriver = os.Getenv("TEST_DB_DRIVER")
+	cfg.DatabaseURL = os.Getenv("TEST_DATABASE_URL")
+	if cfg.DBDriver == "" {
+		cfg.DBDriver = "sqlite"
+	}
+	if cfg.DBDriver == "sqlite" && cfg.DatabaseURL == "" {
+		// Every connection to a plain :memory: database gets its own, so all
+		// of them share a named one over a single connection
+		cfg.DatabaseURL = fmt.Sprintf("file:test%d?mode=memory&cache=shared", memoryDatabases.Add(1))
+		cfg.DBMaxOpenConns = 1
+		cfg.DBMaxIdleConns = 1
+	}
+	if cfg.DBDriver != "sqlite" && cfg.DatabaseURL == "" {
+		t.Fatalf("TEST_DATABASE_URL is required for TEST_DB_DRIVER=%s", cfg.DBDriver)
+	}
+	return cfg
+}
+
+// openTestDB connects to the test database and migrates it from scratch
+func openTestDB(t *testing.T, cfg *config.Config) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatalf("opening test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	// Expected errors such as missing records are reported by the responses
+	db.Logger = logger.Discard
+
+	migrator, err := migrate.New(db)
+	if err != nil {
+		t.Fatalf("loading migrations: %v", err)
+	}
+	if _, err := migrator.Down(int(migrator.Latest())); err != nil {
+		t.Fatalf("resetting test database: %v", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("migrating test database: %v", err)
+	}
+	return db
+}
+
+// newTestServer builds the router like main does, over a fresh database
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	return newTestServerWith(t, testConfig(t))
+}
+
+// newTestServerWith builds the router over a fresh database of the
+// configuration
+func newTestServerWith(t *testing.T, cfg *config.Config) *testServer {
+	t.Helper()
+	db := openTestDB(t, cfg)
+	bus := events.NewBus(cfg.EventBufferSize)
+	router := newRouter(cfg, db, bus, services.NewGORM(db), backup.NewManager(cfg, db), nil)
+	return &testServer{t: t, db: db, router: router}
+}
+
+// newMemoryServer builds the router over the in-memory services. Only
+// anonymous requests to hives, logs and tasks work without a database.
+func newMemoryServer(t *testing.T) *testServer {
+	t.Helper()
+	cfg := config.New()
+	cfg.AuthRequired = false
+	bus := events.NewBus(cfg.EventBufferSize)
+	router := newRouter(cfg, nil, bus, services.NewMemory(), nil, nil)
+	return &testServer{t: t, router: router}
+}
+
+func (b backend) server(t *testing.T) *testServer {
+	if b.memory {
+		return newMemoryServer(t)
+	}
+	return newTestServer(t)
+}
+
+// request sends a request, with body encoded as JSON unless it is a string,
+// and checks the response against the API documentation
+func (s *testServer) request(method, path string, body any, token string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var reader io.Reader
+	switch body := body.(type) {
+	case nil:
+	case string:
+		reader = bytes.NewBufferString(body)
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	if err := checkDocumented(method, path, rec.Code, rec.Header().Get("Content-Type"), rec.Body.Bytes()); err != nil {
+		s.t.Errorf("%s %s: response does not match docs/swagger.json: %v", method, path, err)
+	}
+	return rec
+}
+
+// do sends a request as the signed in user, if any, and fails the test
+// unless it gets the wanted status. The response is decoded into out if
+// given.
+func (s *testServer) do(method, path string, body any, status int, out any) {
+	s.t.Helper()
+	rec := s.request(method, path, body, s.token)
+	if rec.Code != status {
+		s.t.Fatalf("%s %s: got status %d, want %d: %s", method, path, rec.Code, status, rec.Body)
+	}
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			s.t.Fatalf("%s %s: decoding response: %v", method, path, err)
+		}
+	}
+}
+
+// replay requests the event stream resuming after lastEventID and returns
+// the buffered events it sends before the request is cancelled
+func (s *testServer) replay(path, token, lastEventID string) []events.Event {
+	s.t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, path, nil).WithContext(ctx)
+	req.Header.Set("Last-Event-ID", lastEventID)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		s.t.Fatalf("GET %s: got status %d, want %d: %s", path, rec.Code, http.StatusOK, rec.Body)
+	}
+	return parseEvents(s.t, rec.Body.String())
+}
+
+// parseEvents decodes the data of every message in a Server-Sent Events body
+func parseEvents(t *testing.T, body string) []events.Event {
+	t.Helper()
+	var received []events.Event
+	for _, line := range strings.Split(body, "\n") {
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			continue
+		}
+		var event events.Event
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatalf("decoding event %s: %v", data, err)
+		}
+		received = append(received, event)
+	}
+	return received
+}
+
+// user creates a user with a global role and returns an API token for them
+func (s *testServer) user(role string) (models.User, string) {
+	s.t.Helper()
+	if s.db == nil {
+		s.t.Fatal("users need a database")
+	}
+	_, calendarHash, err := auth.NewToken()
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	user := models.User{
+		Name:              role,
+		Email:             fmt.Sprintf("%s-%s@example.com", role, calendarHash[:8]),
+		Role:              role,
+		CalendarTokenHash: calendarHash,
+	}
+	if err := s.db.Create(&user).Error; err != nil {
+		s.t.Fatal(err)
+	}
+	token, hash, err := auth.NewToken()
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if err := s.db.Create(&models.APIToken{UserID: user.ID, Name: "test", TokenHash: hash}).Error; err != nil {
+		s.t.Fatal(err)
+	}
+	return user, token
+}
+
+// signIn creates a user with a global role and sends their token with all
+// further requests that do not name one. Once users exist, anonymous
+// requests are rejected.
+func (s *testServer) signIn(role string) models.User {
+	s.t.Helper()
+	user, token := s.user(role)
+	s.token = token
+	return user
+}
+
+// apiCase is a request to the API and the response it should get, after
+// setting up the server
+type apiCase struct {
+	name   string
+	setup  func(s *testServer)
+	method string
+	path   string
+	body   any
+	// token returns the API token to send, e.g. of a user it creates,
+	// instead of the signed in user's
+	token  func(s *testServer) string
+	status int
+	check  func(t *testing.T, s *testServer, body []byte)
+	// dbOnly cases need users, roles or apiaries
+	dbOnly bool
+}
+
+// runCases runs every case against a fresh server of every backend
+func runCases(t *testing.T, cases []apiCase) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			for _, tc := range cases {
+				t.Run(tc.name, func(t *testing.T) {
+					if tc.dbOnly && b.memory {
+						t.Skip("needs a database")
+					}
+					s := b.server(t)
+					if tc.setup != nil {
+						tc.setup(s)
+					}
+					token := s.token
+					if tc.token != nil {
+						token = tc.token(s)
+					}
+					rec := s.request(tc.method, tc.path, tc.body, token)
+					if rec.Code != tc.status {
+						t.Fatalf("got status %d, want %d: %s", rec.Code, tc.status, rec.Body)
+					}
+					if tc.check != nil {
+						tc.check(t, s, rec.Body.Bytes())
+					}
+				})
+			}
+		})
+	}
+}
+
+// decode unmarshals a response body or fails the test
+func decode[T any](t *testing.T, body []byte) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(body, &v); err != nil {
+		t.Fatalf("decoding %s: %v", body, err)
+	}
+	return v
+}
+
+// tagNames returns the sorted names of tags
+func tagNames(labels []models.Tag) []string {
+	names := []string{}
+	for _, tag := range labels {
+		names = append(names, tag.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hasError is a check that the error message of a response is the wanted one
+func hasError(want string) func(t *testing.T, s *testServer, body []byte) {
+	return func(t *testing.T, s *testServer, body []byte) {
+		t.Helper()
+		if got := decode[map[string]string](t, body)["error"]; got != want {
+			t.Errorf("got error %q, want %q", got, want)
+		}
+	}
+}
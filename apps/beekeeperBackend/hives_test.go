@@ -0,0 +1,351 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"beekeeper-api/models"
+)
+
+// createApiary adds an apiary and returns its ID
+func (s *testServer) createApiary() uint {
+	s.t.Helper()
+	apiary := models.Apiary{Name: "Orchard", Latitude: 48.4, Longitude: 11.7}
+	if err := s.db.Create(&apiary).Error; err != nil {
+		s.t.Fatal(err)
+	}
+	return apiary.ID
+}
+
+// createMembersOnlyApiary adds an apiary only its team and global owners can
+// access and returns its ID
+func (s *testServer) createMembersOnlyApiary() uint {
+	s.t.Helper()
+	apiary := models.Apiary{Name: "Orchard", Latitude: 48.4, Longitude: 11.7, MembersOnly: true}
+	if err := s.db.Create(&apiary).Error; err != nil {
+		s.t.Fatal(err)
+	}
+	return apiary.ID
+}
+
+func TestHives(t *testing.T) {
+	createHives := func(s *testServer) {
+		s.do(http.MethodPost, "/api/hives", map[string]any{"hiveName": 1, "tags": []string{"Nucleus"}}, http.StatusCreated, nil)
+		s.do(http.MethodPost, "/api/hives", map[string]any{"hiveName": 2, "tags": []string{"nucleus", "Queenless"}}, http.StatusCreated, nil)
+		s.do(http.MethodPost, "/api/hives", map[string]any{"hiveName": 3}, http.StatusCreated, nil)
+	}
+
+	runCases(t, []apiCase{
+		{
+			name:   "create",
+			method: http.MethodPost, path: "/api/hives",
+			body:   map[string]any{"hiveName": 7, "tags": []string{"#Swarm Catch", "swarm-catch", "nucleus"}},
+			status: http.StatusCreated,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				hive := decode[models.Hive](t, body)
+				if hive.HiveName != 7 || hive.ID == 0 || hive.ApiaryID != nil {
+					t.Errorf("got %+v", hive)
+				}
+				if got, want := tagNames(hive.Tags), []string{"nucleus", "swarm-catch"}; !reflect.DeepEqual(got, want) {
+					t.Errorf("got tags %v, want %v", got, want)
+				}
+			},
+		},
+		{
+			name:   "create without name",
+			method: http.MethodPost, path: "/api/hives",
+			body:   map[string]any{"tags": []string{"nucleus"}},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "create with malformed JSON",
+			method: http.MethodPost, path: "/api/hives",
+			body:   `{"hiveName": `,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "create existing",
+			setup:  createHives,
+			method: http.MethodPost, path: "/api/hives",
+			body:   map[string]any{"hiveName": 1},
+			status: http.StatusConflict,
+			check:  hasError("Hive already exists"),
+		},
+		{
+			name:   "create in unknown apiary",
+			method: http.MethodPost, path: "/api/hives",
+			body:   map[string]any{"hiveName": 7, "apiaryID": 99},
+			status: http.StatusBadRequest,
+			check:  hasError("Apiary not found"),
+		},
+		{
+			name:   "create in apiary",
+			setup:  func(s *testServer) { s.createApiary() },
+			method: http.MethodPost, path: "/api/hives",
+			body:   map[string]any{"hiveName": 7, "apiaryID": 1},
+			status: http.StatusCreated,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				if hive := decode[models.Hive](t, body); hive.ApiaryID == nil || *hive.ApiaryID != 1 {
+					t.Errorf("got apiary %v, want 1", hive.ApiaryID)
+				}
+			},
+			dbOnly: true,
+		},
+		{
+			name:   "create as viewer",
+			method: http.MethodPost, path: "/api/hives",
+			body: map[string]any{"hiveName": 7},
+			token: func(s *testServer) string {
+				_, token := s.user(models.RoleViewer)
+				return token
+			},
+			status: http.StatusForbidden,
+			dbOnly: true,
+		},
+		{
+			name:   "list empty",
+			method: http.MethodGet, path: "/api/hives",
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				if string(body) != "[]" {
+					t.Errorf("got %s, want []", body)
+				}
+			},
+		},
+		{
+			name:   "list",
+			setup:  createHives,
+			method: http.MethodGet, path: "/api/hives",
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				if hives := decode[[]models.Hive](t, body); len(hives) != 3 {
+					t.Errorf("got %d hives, want 3", len(hives))
+				}
+			},
+		},
+		{
+			name:   "list by tags",
+			setup:  createHives,
+			method: http.MethodGet, path: "/api/hives?tag=nucleus,queenless",
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				hives := decode[[]models.Hive](t, body)
+				if len(hives) != 1 || hives[0].HiveName != 2 {
+					t.Errorf("got %+v, want hive 2", hives)
+				}
+			},
+		},
+		{
+			name: "list as viewer",
+			setup: func(s *testServer) {
+				apiary := s.createMembersOnlyApiary()
+				createHives(s)
+				s.do(http.MethodPatch, "/api/hives/2", map[string]any{"apiaryID": apiary}, http.StatusOK, nil)
+				// Only the team sees the hives of a members only apiary
+				member, _ := s.user(models.RoleEditor)
+				if err := s.db.Create(&models.TeamMember{ApiaryID: apiary, UserID: member.ID, Role: models.RoleEditor}).Error; err != nil {
+					t.Fatal(err)
+				}
+			},
+			method: http.MethodGet, path: "/api/hives",
+			token: func(s *testServer) string {
+				_, token := s.user(models.RoleViewer)
+				return token
+			},
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				var names []int
+				for _, hive := range decode[[]models.Hive](t, body) {
+					names = append(names, hive.HiveName)
+				}
+				if !reflect.DeepEqual(names, []int{1, 3}) {
+					t.Errorf("got hives %v, want 1 and 3", names)
+				}
+			},
+			dbOnly: true,
+		},
+		{
+			name: "get with entries",
+			setup: func(s *testServer) {
+				createHives(s)
+				s.do(http.MethodPost, "/api/logs", map[string]any{"hiveID": 2, "content": "Inspected"}, http.StatusCreated, nil)
+				s.do(http.MethodPost, "/api/tasks", map[string]any{"hiveID": 2, "content": "Feed"}, http.StatusCreated, nil)
+				s.do(http.MethodPost, "/api/logs", map[string]any{"hiveID": 3, "content": "Elsewhere"}, http.StatusCreated, nil)
+			},
+			method: http.MethodGet, path: "/api/hives/2",
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				hive := decode[models.Hive](t, body)
+				if hive.HiveName != 2 || len(hive.Logs) != 1 || len(hive.Tasks) != 1 || len(hive.Tags) != 2 {
+					t.Errorf("got %+v", hive)
+				}
+			},
+		},
+		{
+			name:   "get missing",
+			setup:  createHives,
+			method: http.MethodGet, path: "/api/hives/99",
+			status: http.StatusNotFound,
+			check:  hasError("Hive not found"),
+		},
+		{
+			name:   "get with invalid ID",
+			method: http.MethodGet, path: "/api/hives/abc",
+			status: http.StatusBadRequest,
+			check:  hasError("Invalid ID"),
+		},
+		{
+			name:   "rename and retag",
+			setup:  createHives,
+			method: http.MethodPatch, path: "/api/hives/1",
+			body:   map[string]any{"hiveName": 10, "tags": []string{"overwintered"}},
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				hive := decode[models.Hive](t, body)
+				if hive.HiveName != 10 || !reflect.DeepEqual(tagNames(hive.Tags), []string{"overwintered"}) {
+					t.Errorf("got %+v", hive)
+				}
+				s.do(http.MethodGet, "/api/hives/1", nil, http.StatusNotFound, nil)
+				s.do(http.MethodGet, "/api/hives/10", nil, http.StatusOK, nil)
+			},
+		},
+		{
+			name: "rename with entries",
+			setup: func(s *testServer) {
+				createHives(s)
+				s.do(http.MethodPost, "/api/logs", map[string]any{"hiveID": 1, "content": "Inspected #calm"}, http.StatusCreated, nil)
+				s.do(http.MethodPost, "/api/tasks", map[string]any{"hiveID": 1, "content": "Feed"}, http.StatusCreated, nil)
+			},
+			method: http.MethodPatch, path: "/api/hives/1",
+			body:   map[string]any{"hiveName": 10},
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				var hive models.Hive
+				s.do(http.MethodGet, "/api/hives/10", nil, http.StatusOK, &hive)
+				if len(hive.Logs) != 1 || len(hive.Tasks) != 1 {
+					t.Fatalf("got %d logs and %d tasks, want them moved along", len(hive.Logs), len(hive.Tasks))
+				}
+				var entry models.Log
+				s.do(http.MethodGet, "/api/logs/1", nil, http.StatusOK, &entry)
+				if entry.HiveID != 10 || !reflect.DeepEqual(tagNames(entry.Tags), []string{"calm"}) {
+					t.Errorf("got %+v", entry)
+				}
+			},
+		},
+		{
+			name:   "update keeps omitted fields",
+			setup:  createHives,
+			method: http.MethodPatch, path: "/api/hives/2",
+			body:   map[string]any{},
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				hive := decode[models.Hive](t, body)
+				if hive.HiveName != 2 || len(hive.Tags) != 2 {
+					t.Errorf("got %+v", hive)
+				}
+			},
+		},
+		{
+			name:   "update to a taken name",
+			setup:  createHives,
+			method: http.MethodPatch, path: "/api/hives/1",
+			body:   map[string]any{"hiveName": 2},
+			status: http.StatusConflict,
+		},
+		{
+			name:   "update into unknown apiary",
+			setup:  createHives,
+			method: http.MethodPatch, path: "/api/hives/1",
+			body:   map[string]any{"apiaryID": 99},
+			status: http.StatusBadRequest,
+			check:  hasError("Apiary not found"),
+		},
+		{
+			name: "update out of apiary",
+			setup: func(s *testServer) {
+				apiary := s.createApiary()
+				s.do(http.MethodPost, "/api/hives", map[string]any{"hiveName": 1, "apiaryID": apiary}, http.StatusCreated, nil)
+			},
+			method: http.MethodPatch, path: "/api/hives/1",
+			body:   map[string]any{"apiaryID": 0},
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				if hive := decode[models.Hive](t, body); hive.ApiaryID != nil {
+					t.Errorf("got apiary %v, want none", *hive.ApiaryID)
+				}
+			},
+			dbOnly: true,
+		},
+		{
+			name:   "update with invalid input",
+			setup:  createHives,
+			method: http.MethodPatch, path: "/api/hives/1",
+			body:   map[string]any{"hiveName": "one"},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "update missing",
+			method: http.MethodPatch, path: "/api/hives/99",
+			body:   map[string]any{"tags": []string{"x"}},
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "update with invalid ID",
+			method: http.MethodPatch, path: "/api/hives/abc",
+			body:   map[string]any{},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "delete with entries",
+			setup: func(s *testServer) {
+				createHives(s)
+				s.do(http.MethodPost, "/api/logs", map[string]any{"hiveID": 1, "content": "Inspected #calm"}, http.StatusCreated, nil)
+				s.do(http.MethodPost, "/api/tasks", map[string]any{"hiveID": 1, "content": "Feed"}, http.StatusCreated, nil)
+			},
+			method: http.MethodDelete, path: "/api/hives/1",
+			status: http.StatusNoContent,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				s.do(http.MethodGet, "/api/hives/1", nil, http.StatusNotFound, nil)
+				s.do(http.MethodGet, "/api/logs/1", nil, http.StatusNotFound, nil)
+				s.do(http.MethodGet, "/api/tasks/1", nil, http.StatusNotFound, nil)
+			},
+		},
+		{
+			name:   "delete as viewer",
+			setup:  createHives,
+			method: http.MethodDelete, path: "/api/hives/1",
+			token: func(s *testServer) string {
+				_, token := s.user(models.RoleViewer)
+				return token
+			},
+			status: http.StatusForbidden,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				s.signIn(models.RoleOwner)
+				s.do(http.MethodGet, "/api/hives/1", nil, http.StatusOK, nil)
+			},
+			dbOnly: true,
+		},
+		{
+			name: "delete without a token once users exist",
+			setup: func(s *testServer) {
+				createHives(s)
+				s.user(models.RoleViewer)
+			},
+			method: http.MethodDelete, path: "/api/hives/1",
+			status: http.StatusUnauthorized,
+			check:  hasError("Authentication required"),
+			dbOnly: true,
+		},
+		{
+			name:   "delete missing",
+			method: http.MethodDelete, path: "/api/hives/99",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "delete with invalid ID",
+			method: http.MethodDelete, path: "/api/hives/abc",
+			status: http.StatusBadRequest,
+		},
+	})
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"beekeeper-api/features/importer"
+	"beekeeper-api/models"
+)
+
+func TestImportJSON(t *testing.T) {
+	s := newTestServer(t)
+	s.signIn(models.RoleOwner)
+	s.do(http.MethodPost, "/api/hives", map[string]any{"hiveName": 1}, http.StatusCreated, nil)
+	var before models.Hive
+	s.do(http.MethodGet, "/api/hives/1", nil, http.StatusOK, &before)
+
+	day := func(month time.Month, d int) time.Time { return time.Date(2023, month, d, 9, 0, 0, 0, time.UTC) }
+	export := map[string]any{
+		"exported_at": day(7, 1),
+		"hives": []map[string]any{
+			{"hive_name": 1, "created_at": day(1, 1)},
+			{"hive_name": 3, "created_at": day(4, 1), "updated_at": day(4, 2)},
+		},
+		"logs": []map[string]any{
+			{"hive_id": 3, "content": "Split from hive 1", "created_at": day(5, 1)},
+			{"hive_id": 5, "content": "Caught a swarm", "created_at": day(6, 1)},
+		},
+		"tasks": []map[string]any{
+			{"hive_id": 1, "content": "Requeen", "priority": "high", "created_at": day(6, 2)},
+		},
+	}
+
+	var report importer.Report
+	s.do(http.MethodPost, "/api/import?dry_run=true", export, http.StatusOK, &report)
+	if !slices.Equal(report.HivesToCreate, []int{3, 5}) || report.Logs != 2 || report.Tasks != 1 {
+		t.Errorf("got dry run report %+v", report)
+	}
+
+	s.do(http.MethodPost, "/api/import", export, http.StatusCreated, &report)
+
+	// New hives keep the times of the export, existing ones are left alone
+	var hive models.Hive
+	s.do(http.MethodGet, "/api/hives/3", nil, http.StatusOK, &hive)
+	if !hive.CreatedAt.Equal(day(4, 1)) || !hive.UpdatedAt.Equal(day(4, 2)) {
+		t.Errorf("got hive 3 created %v, updated %v", hive.CreatedAt, hive.UpdatedAt)
+	}
+	s.do(http.MethodGet, "/api/hives/1", nil, http.StatusOK, &hive)
+	if !hive.CreatedAt.Equal(before.CreatedAt) {
+		t.Errorf("got hive 1 created %v, want %v", hive.CreatedAt, before.CreatedAt)
+	}
+	// Hives only referenced by entries are created like any other
+	s.do(http.MethodGet, "/api/hives/5", nil, http.StatusOK, &hive)
+
+	var logs []models.Log
+	s.do(http.MethodGet, "/api/logs", nil, http.StatusOK, &logs)
+	if len(logs) != 2 || !logs[0].CreatedAt.Equal(day(5, 1)) || !logs[1].CreatedAt.Equal(day(6, 1)) {
+		t.Errorf("got logs %+v", logs)
+	}
+
+	s.do(http.MethodPost, "/api/import", export, http.StatusCreated, &report)
+	if report.Duplicates != 3 || report.Logs != 0 || report.Tasks != 0 || len(report.HivesToCreate) != 0 {
+		t.Errorf("got report %+v for importing again", report)
+	}
+}
+
+// upload posts a CSV file with form fields as the signed in user
+func (s *testServer) upload(path, csv string, fields map[string]string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	file, err := form.CreateFormFile("file", "journal.csv")
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	io.WriteString(file, csv)
+	if err := form.Close(); err != nil {
+		s.t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	if err := checkDocumented(http.MethodPost, path, rec.Code, rec.Header().Get("Content-Type"), rec.Body.Bytes()); err != nil {
+		s.t.Errorf("POST %s: response does not match docs/swagger.json: %v", path, err)
+	}
+	return rec
+}
+
+// TestImportCSV imports tasks from a spreadsheet with its own column names,
+// separator and date format
+func TestImportCSV(t *testing.T) {
+	s := newTestServer(t)
+	s.signIn(models.RoleOwner)
+
+	csv := "Hive;Notes;Date;Prio;Due\n" +
+		"Hive 12;Add a super;01.05.2023;HIGH;15.05.2023\n" +
+		"#14;Check for queen cells;02.05.2023;;\n"
+	fields := map[string]string{
+		"entity":      "tasks",
+		"mapping":     `{"hive_id":"Hive","content":"Notes","created_at":"Date","priority":"Prio","due_at":"Due"}`,
+		"time_format": "02.01.2006",
+		"delimiter":   ";",
+	}
+
+	rec := s.upload("/api/import?dry_run=true", csv, fields)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d for the dry run: %s", rec.Code, rec.Body)
+	}
+	report := decode[importer.Report](t, rec.Body.Bytes())
+	if !report.DryRun || report.Tasks != 2 || !slices.Equal(report.HivesToCreate, []int{12, 14}) || len(report.Errors) != 0 {
+		t.Errorf("got dry run report %+v", report)
+	}
+	// A dry run writes nothing
+	s.do(http.MethodGet, "/api/hives/12", nil, http.StatusNotFound, nil)
+
+	if rec := s.upload("/api/import", csv, fields); rec.Code != http.StatusCreated {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+	}
+	var tasks []models.Task
+	s.do(http.MethodGet, "/api/tasks", nil, http.StatusOK, &tasks)
+	if len(tasks) != 2 {
+		t.Fatalf("got %d tasks, want 2", len(tasks))
+	}
+	created := time.Date(2023, 5, 1, 0, 0, 0, 0, time.Local)
+	due := time.Date(2023, 5, 15, 0, 0, 0, 0, time.Local)
+	first, second := tasks[0], tasks[1]
+	if first.HiveID != 12 || first.Content != "Add a super" || first.Priority != models.PriorityHigh || !first.CreatedAt.Equal(created) || first.DueAt == nil || !first.DueAt.Equal(due) {
+		t.Errorf("got first task %+v", first)
+	}
+	if second.HiveID != 14 || second.Priority != models.PriorityNormal || second.DueAt != nil {
+		t.Errorf("got second task %+v", second)
+	}
+}
+
+// TestImportRejected checks that one invalid row keeps the whole import
+// from being written
+func TestImportRejected(t *testing.T) {
+	s := newTestServer(t)
+	s.signIn(models.RoleOwner)
+
+	export := map[string]any{
+		"logs": []map[string]any{
+			{"hive_id": 7, "content": "Inspected"},
+			{"hive_id": 7, "content": "  "},
+		},
+		"tasks": []map[string]any{
+			{"hive_id": 8, "content": "Feed", "priority": "urgent"},
+		},
+	}
+	var report importer.Report
+	s.do(http.MethodPost, "/api/import", export, http.StatusUnprocessableEntity, &report)
+	want := []importer.RowError{
+		{Entity: "log", Row: 2, Message: "content is empty"},
+		{Entity: "task", Row: 1, Message: `unknown priority "urgent"`},
+	}
+	if !slices.Equal(report.Errors, want) {
+		t.Errorf("got errors %+v, want %+v", report.Errors, want)
+	}
+
+	var logs []models.Log
+	s.do(http.MethodGet, "/api/logs", nil, http.StatusOK, &logs)
+	if len(logs) != 0 {
+		t.Errorf("got logs %+v, want none", logs)
+	}
+	s.do(http.MethodGet, "/api/hives/7", nil, http.StatusNotFound, nil)
+}
+
+// TestImportLeavesOutReferences imports entries naming an assignee, tags and
+// weather of the instance they were exported from
+func TestImportLeavesOutReferences(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.signIn(models.RoleOwner)
+
+	export := map[string]any{
+		"logs": []map[string]any{{
+			"id": 40, "hive_id": 1, "content": "Calm #gentle",
+			"tags":    []map[string]any{{"id": 9, "name": "aggressive"}},
+			"weather": map[string]any{"id": 3, "log_id": 40, "temperature_c": 21},
+		}},
+		"tasks": []map[string]any{{"id": 41, "hive_id": 1, "content": "Requeen", "assignee_id": owner.ID + 1}},
+	}
+	s.do(http.MethodPost, "/api/import", export, http.StatusCreated, nil)
+
+	var entry models.Log
+	s.do(http.MethodGet, "/api/logs/1", nil, http.StatusOK, &entry)
+	if entry.Weather != nil || !slices.Equal(tagNames(entry.Tags), []string{"gentle"}) {
+		t.Errorf("got log %+v, want only the tag of its hashtag", entry)
+	}
+	var task models.Task
+	s.do(http.MethodGet, "/api/tasks/1", nil, http.StatusOK, &task)
+	if task.AssigneeID != nil {
+		t.Errorf("got assignee %d, want none", *task.AssigneeID)
+	}
+}
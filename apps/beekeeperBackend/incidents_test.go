@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"beekeeper-api/models"
+)
+
+func TestQuarantine(t *testing.T) {
+	// reportIncident puts hive 1 under quarantine
+	reportIncident := func(s *testServer) {
+		s.do(http.MethodPost, "/api/incidents", map[string]any{"hiveID": 1, "disease": "afb"}, http.StatusCreated, nil)
+	}
+	// clearIncidents clears every open incident, lifting the quarantine
+	clearIncidents := func(s *testServer) {
+		var incidents []models.Incident
+		s.do(http.MethodGet, "/api/incidents", nil, http.StatusOK, &incidents)
+		for _, incident := range incidents {
+			s.do(http.MethodPatch, fmt.Sprintf("/api/incidents/%d", incident.ID), map[string]any{"status": "cleared"}, http.StatusOK, nil)
+		}
+	}
+	quarantined := func(want bool) func(t *testing.T, s *testServer, body []byte) {
+		return func(t *testing.T, s *testServer, body []byte) {
+			var hive models.Hive
+			s.do(http.MethodGet, "/api/hives/1", nil, http.StatusOK, &hive)
+			if got := hive.QuarantinedSince != nil; got != want {
+				t.Errorf("got hive 1 quarantined %v, want %v", got, want)
+			}
+		}
+	}
+	harvest := map[string]any{"hiveID": 1, "weightKg": 12.5}
+
+	runCases(t, []apiCase{
+		{
+			name:   "harvest under quarantine",
+			setup:  reportIncident,
+			method: http.MethodPost, path: "/api/harvests",
+			body:   harvest,
+			status: http.StatusConflict,
+			dbOnly: true,
+			check:  hasError("Hive 1 is under quarantine, no harvests can be recorded until its incidents are cleared"),
+		},
+		{
+			name:   "move equipment out under quarantine",
+			setup:  reportIncident,
+			method: http.MethodPost, path: "/api/equipment-moves",
+			body:   map[string]any{"fromHiveID": 1, "toHiveID": 2, "equipment": "super"},
+			status: http.StatusConflict,
+			dbOnly: true,
+			check:  hasError("Hive 1 is under quarantine, no equipment can be moved out until its incidents are cleared"),
+		},
+		{
+			name:   "move equipment to storage under quarantine",
+			setup:  reportIncident,
+			method: http.MethodPost, path: "/api/equipment-moves",
+			body:   map[string]any{"fromHiveID": 1, "equipment": "super"},
+			status: http.StatusConflict,
+			dbOnly: true,
+		},
+		{
+			name:   "move equipment into a quarantined hive",
+			setup:  reportIncident,
+			method: http.MethodPost, path: "/api/equipment-moves",
+			body:   map[string]any{"fromHiveID": 2, "toHiveID": 1, "equipment": "super"},
+			status: http.StatusCreated,
+			dbOnly: true,
+		},
+		{
+			name: "harvest after clearing",
+			setup: func(s *testServer) {
+				reportIncident(s)
+				clearIncidents(s)
+			},
+			method: http.MethodPost, path: "/api/harvests",
+			body:   harvest,
+			status: http.StatusCreated,
+			dbOnly: true,
+			check:  quarantined(false),
+		},
+		{
+			name: "move equipment out after clearing",
+			setup: func(s *testServer) {
+				reportIncident(s)
+				clearIncidents(s)
+			},
+			method: http.MethodPost, path: "/api/equipment-moves",
+			body:   map[string]any{"fromHiveID": 1, "toHiveID": 2, "equipment": "super"},
+			status: http.StatusCreated,
+			dbOnly: true,
+			check:  quarantined(false),
+		},
+		{
+			name: "harvest with an incident left open",
+			setup: func(s *testServer) {
+				reportIncident(s)
+				s.do(http.MethodPost, "/api/incidents", map[string]any{"hiveID": 1, "disease": "efb", "status": "confirmed"}, http.StatusCreated, nil)
+				s.do(http.MethodPatch, "/api/incidents/1", map[string]any{"status": "cleared"}, http.StatusOK, nil)
+			},
+			method: http.MethodPost, path: "/api/harvests",
+			body:   harvest,
+			status: http.StatusConflict,
+			dbOnly: true,
+			check:  quarantined(true),
+		},
+	})
+}
@@ -0,0 +1,503 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	mochi "github.com/mochi-mqtt/server/v2"
+	mqttauth "github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+
+	"beekeeper-api/config"
+	"beekeeper-api/models"
+	"beekeeper-api/telemetry"
+)
+
+func TestLogs(t *testing.T) {
+	createLogs := func(s *testServer) {
+		s.do(http.MethodPost, "/api/logs", map[string]any{"hiveID": 1, "content": "Inspected #calm"}, http.StatusCreated, nil)
+		s.do(http.MethodPost, "/api/logs", map[string]any{"hiveID": 2, "content": "Swarm cells", "tags": []string{"swarm", "calm"}}, http.StatusCreated, nil)
+		s.do(http.MethodPost, "/api/logs", map[string]any{"hiveID": 1, "content": "Fed syrup"}, http.StatusCreated, nil)
+	}
+
+	runCases(t, []apiCase{
+		{
+			name:   "create for a new hive",
+			method: http.MethodPost, path: "/api/logs",
+			body:   map[string]any{"hiveID": 5, "content": "Queen seen, hashtag requeen #Calm", "tags": []string{"spring", "calm"}},
+			status: http.StatusCreated,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				log := decode[models.Log](t, body)
+				if log.ID == 0 || log.HiveID != 5 || log.Content != "Queen seen, hashtag requeen #Calm" {
+					t.Errorf("got %+v", log)
+				}
+				if got, want := tagNames(log.Tags), []string{"calm", "requeen", "spring"}; !reflect.DeepEqual(got, want) {
+					t.Errorf("got tags %v, want %v", got, want)
+				}
+				s.do(http.MethodGet, "/api/hives/5", nil, http.StatusOK, nil)
+			},
+		},
+		{
+			name: "create for an existing hive",
+			setup: func(s *testServer) {
+				s.do(http.MethodPost, "/api/hives", map[string]any{"hiveName": 5, "tags": []string{"nucleus"}}, http.StatusCreated, nil)
+			},
+			method: http.MethodPost, path: "/api/logs",
+			body:   map[string]any{"hiveID": 5, "content": "Inspected"},
+			status: http.StatusCreated,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				var hive models.Hive
+				s.do(http.MethodGet, "/api/hives/5", nil, http.StatusOK, &hive)
+				if len(hive.Logs) != 1 || len(hive.Tags) != 1 {
+					t.Errorf("got %+v", hive)
+				}
+			},
+		},
+		{
+			name:   "create without content",
+			method: http.MethodPost, path: "/api/logs",
+			body:   map[string]any{"hiveID": 5},
+			status: http.StatusBadRequest,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				s.do(http.MethodGet, "/api/hives/5", nil, http.StatusNotFound, nil)
+			},
+		},
+		{
+			name:   "create without hive",
+			method: http.MethodPost, path: "/api/logs",
+			body:   map[string]any{"content": "Inspected"},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "create with malformed JSON",
+			method: http.MethodPost, path: "/api/logs",
+			body:   `{"hiveID": 1, "content": }`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "create as viewer",
+			method: http.MethodPost, path: "/api/logs",
+			body: map[string]any{"hiveID": 5, "content": "Inspected"},
+			token: func(s *testServer) string {
+				_, token := s.user(models.RoleViewer)
+				return token
+			},
+			status: http.StatusForbidden,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				s.signIn(models.RoleOwner)
+				s.do(http.MethodGet, "/api/hives/5", nil, http.StatusNotFound, nil)
+			},
+			dbOnly: true,
+		},
+		{
+			name:   "list",
+			setup:  createLogs,
+			method: http.MethodGet, path: "/api/logs",
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				if logs := decode[[]models.Log](t, body); len(logs) != 3 {
+					t.Errorf("got %d logs, want 3", len(logs))
+				}
+			},
+		},
+		{
+			name:   "list by tags",
+			setup:  createLogs,
+			method: http.MethodGet, path: "/api/logs?tag=calm&tag=%23Swarm",
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				logs := decode[[]models.Log](t, body)
+				if len(logs) != 1 || logs[0].HiveID != 2 {
+					t.Errorf("got %+v, want the log of hive 2", logs)
+				}
+			},
+		},
+		{
+			name:   "list by unknown tag",
+			setup:  createLogs,
+			method: http.MethodGet, path: "/api/logs?tag=unknown",
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				if string(body) != "[]" {
+					t.Errorf("got %s, want []", body)
+				}
+			},
+		},
+		{
+			name:   "get",
+			setup:  createLogs,
+			method: http.MethodGet, path: "/api/logs/2",
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				if log := decode[models.Log](t, body); log.ID != 2 || log.Content != "Swarm cells" {
+					t.Errorf("got %+v", log)
+				}
+			},
+		},
+		{
+			name:   "get missing",
+			method: http.MethodGet, path: "/api/logs/99",
+			status: http.StatusNotFound,
+			check:  hasError("Log not found"),
+		},
+		{
+			name:   "get with invalid ID",
+			method: http.MethodGet, path: "/api/logs/abc",
+			status: http.StatusBadRequest,
+			check:  hasError("Invalid ID"),
+		},
+		{
+			name:   "get with negative ID",
+			method: http.MethodGet, path: "/api/logs/-1",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "last",
+			setup:  createLogs,
+			method: http.MethodGet, path: "/api/logs/last",
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				if log := decode[models.Log](t, body); log.Content != "Fed syrup" {
+					t.Errorf("got %+v, want the last log", log)
+				}
+			},
+		},
+		{
+			name:   "last without logs",
+			method: http.MethodGet, path: "/api/logs/last",
+			status: http.StatusNotFound,
+			check:  hasError("No logs found"),
+		},
+		{
+			name:   "update content adds hashtags",
+			setup:  createLogs,
+			method: http.MethodPut, path: "/api/logs/1",
+			body:   map[string]any{"content": "Inspected #varroa"},
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				log := decode[models.Log](t, body)
+				if log.Content != "Inspected #varroa" || log.HiveID != 1 {
+					t.Errorf("got %+v", log)
+				}
+				if got, want := tagNames(log.Tags), []string{"calm", "varroa"}; !reflect.DeepEqual(got, want) {
+					t.Errorf("got tags %v, want %v", got, want)
+				}
+			},
+		},
+		{
+			name:   "update replaces tags",
+			setup:  createLogs,
+			method: http.MethodPut, path: "/api/logs/2",
+			body:   map[string]any{"tags": []string{}},
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				var log models.Log
+				s.do(http.MethodGet, "/api/logs/2", nil, http.StatusOK, &log)
+				if log.Content != "Swarm cells" || len(log.Tags) != 0 {
+					t.Errorf("got %+v", log)
+				}
+			},
+		},
+		{
+			name:   "update moves to a new hive",
+			setup:  createLogs,
+			method: http.MethodPut, path: "/api/logs/1",
+			body:   map[string]any{"hiveID": 9},
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				if log := decode[models.Log](t, body); log.HiveID != 9 || log.Content != "Inspected #calm" {
+					t.Errorf("got %+v", log)
+				}
+				var hive models.Hive
+				s.do(http.MethodGet, "/api/hives/9", nil, http.StatusOK, &hive)
+				if len(hive.Logs) != 1 {
+					t.Errorf("got %d logs for hive 9, want 1", len(hive.Logs))
+				}
+			},
+		},
+		{
+			name:   "update missing",
+			method: http.MethodPut, path: "/api/logs/99",
+			body:   map[string]any{"content": "Inspected"},
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "update missing with invalid input",
+			method: http.MethodPut, path: "/api/logs/99",
+			body:   `{"hiveID":"one"}`,
+			status: http.StatusNotFound,
+			check:  hasError("Log not found"),
+		},
+		{
+			name:   "update with invalid ID",
+			method: http.MethodPut, path: "/api/logs/abc",
+			body:   map[string]any{"content": "Inspected"},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "update with invalid input",
+			setup:  createLogs,
+			method: http.MethodPut, path: "/api/logs/1",
+			body:   map[string]any{"hiveID": "one"},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "delete",
+			setup:  createLogs,
+			method: http.MethodDelete, path: "/api/logs/1",
+			status: http.StatusNoContent,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				s.do(http.MethodGet, "/api/logs/1", nil, http.StatusNotFound, nil)
+				var logs []models.Log
+				s.do(http.MethodGet, "/api/logs?tag=calm", nil, http.StatusOK, &logs)
+				if len(logs) != 1 {
+					t.Errorf("got %d logs tagged calm, want 1", len(logs))
+				}
+			},
+		},
+		{
+			name:   "delete missing",
+			method: http.MethodDelete, path: "/api/logs/99",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "delete with invalid ID",
+			method: http.MethodDelete, path: "/api/logs/abc",
+			status: http.StatusBadRequest,
+		},
+	})
+}
+
+// TestLazyHiveCreationConcurrent writes logs and tasks for the same new hives
+// at once, which must create every hive exactly once
+func TestLazyHiveCreationConcurrent(t *testing.T) {
+	const hives, writers = 3, 8
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			s := b.server(t)
+
+			var wg sync.WaitGroup
+			errs := make(chan error, hives*writers*2)
+			for hive := 1; hive <= hives; hive++ {
+				for i := 0; i < writers; i++ {
+					for _, path := range []string{"/api/logs", "/api/tasks"} {
+						wg.Add(1)
+						go func() {
+							defer wg.Done()
+							rec := s.request(http.MethodPost, path, map[string]any{"hiveID": hive, "content": "Checked"}, "")
+							if rec.Code != http.StatusCreated {
+								errs <- fmt.Errorf("POST %s for hive %d: got status %d: %s", path, hive, rec.Code, rec.Body)
+							}
+						}()
+					}
+				}
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				t.Error(err)
+			}
+
+			var list []models.Hive
+			s.do(http.MethodGet, "/api/hives", nil, http.StatusOK, &list)
+			if len(list) != hives {
+				t.Fatalf("got %d hives, want %d", len(list), hives)
+			}
+			for _, hive := range list {
+				var got models.Hive
+				s.do(http.MethodGet, fmt.Sprintf("/api/hives/%d", hive.HiveName), nil, http.StatusOK, &got)
+				if len(got.Logs) != writers || len(got.Tasks) != writers {
+					t.Errorf("hive %d: got %d logs and %d tasks, want %d each", hive.HiveName, len(got.Logs), len(got.Tasks), writers)
+				}
+			}
+		})
+	}
+}
+
+// startBroker runs an in-process MQTT broker accepting everyone and returns
+// the address it listens on
+func startBroker(t *testing.T) (*mochi.Server, string) {
+	t.Helper()
+	broker := mochi.New(&mochi.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err := broker.AddHook(new(mqttauth.AllowHook), nil); err != nil {
+		t.Fatal(err)
+	}
+	tcp := listeners.NewTCP(listeners.Config{ID: "tcp", Address: "127.0.0.1:0"})
+	if err := broker.AddListener(tcp); err != nil {
+		t.Fatal(err)
+	}
+	if err := broker.Serve(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { broker.Close() })
+	return broker, tcp.Address()
+}
+
+// pooledConfig returns the configuration of a test database with a pool of
+// connections, for requests to race each other
+func pooledConfig(t *testing.T, connections int) *config.Config {
+	cfg := testConfig(t)
+	if cfg.DBDriver == "sqlite" && os.Getenv("TEST_DATABASE_URL") == "" {
+		// The in-memory database has a single connection, which serializes
+		// all requests, so a file with a pool is used instead
+		cfg.DatabaseURL = ""
+		cfg.DBFile = filepath.Join(t.TempDir(), "stress.db")
+		cfg.DBMaxOpenConns = connections
+		cfg.DBMaxIdleConns = connections
+	}
+	return cfg
+}
+
+// TestLazyHiveCreationStress posts logs and tasks for the same new hive from
+// many clients at once over a pool of connections, while sensors publish
+// readings for it over MQTT. Each request's and reading's transaction races
+// the others to create the hive. None may fail.
+func TestLazyHiveCreationStress(t *testing.T) {
+	if testing.Short() {
+		t.Skip("stress test")
+	}
+	const rounds, clients, sensors = 20, 32, 8
+	// The requests have to run in parallel to race, even on a single CPU
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(8))
+
+	cfg := pooledConfig(t, clients)
+	s := newTestServerWith(t, cfg)
+
+	broker, address := startBroker(t)
+	cfg.MQTTBrokerURL = "tcp://" + address
+	cfg.MQTTTopics = []string{"hive/+/weight"}
+	bridge, err := telemetry.NewBridge(cfg, s.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readings := make(chan models.Telemetry, rounds*sensors)
+	bridge.OnReading(func(reading models.Telemetry) { readings <- reading })
+	// Failures to store readings are logged, they show up as missing ones
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	bridge.Start()
+	defer bridge.Stop()
+	for len(broker.Topics.Subscribers("hive/1/weight").Subscriptions) == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for round := 1; round <= rounds; round++ {
+		hive := 100 + round
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		var failures sync.Map
+		for i := 0; i < sensors; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				if err := broker.Publish(fmt.Sprintf("hive/%d/weight", hive), []byte(fmt.Sprint(40+i)), false, 1); err != nil {
+					failures.Store(-1-i, fmt.Sprintf("publishing a reading: %v", err))
+				}
+			}()
+		}
+		for i := 0; i < clients; i++ {
+			path := "/api/logs"
+			if i%2 == 1 {
+				path = "/api/tasks"
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				rec := s.request(http.MethodPost, path, map[string]any{"hiveID": hive, "content": fmt.Sprintf("Client %d #stress", i)}, "")
+				if rec.Code != http.StatusCreated {
+					failures.Store(i, fmt.Sprintf("POST %s: got status %d: %s", path, rec.Code, rec.Body))
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+		failures.Range(func(_, failure any) bool {
+			t.Errorf("round %d: %s", round, failure)
+			return true
+		})
+
+		var got models.Hive
+		s.do(http.MethodGet, fmt.Sprintf("/api/hives/%d", hive), nil, http.StatusOK, &got)
+		if len(got.Logs)+len(got.Tasks) != clients {
+			t.Errorf("round %d: got %d logs and %d tasks, want %d in all", round, len(got.Logs), len(got.Tasks), clients)
+		}
+		for i := 0; i < sensors; i++ {
+			select {
+			case reading := <-readings:
+				if reading.HiveID != hive {
+					t.Errorf("round %d: got a reading for hive %d", round, reading.HiveID)
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatalf("round %d: got %d of %d readings", round, i, sensors)
+			}
+		}
+	}
+
+	var hives []models.Hive
+	s.do(http.MethodGet, "/api/hives", nil, http.StatusOK, &hives)
+	if len(hives) != rounds {
+		t.Errorf("got %d hives, want %d", len(hives), rounds)
+	}
+}
+
+// TestHiveCreationConcurrent creates the same hive from many clients at once.
+// One of them gets it, all others a conflict.
+func TestHiveCreationConcurrent(t *testing.T) {
+	if testing.Short() {
+		t.Skip("stress test")
+	}
+	const rounds, clients = 10, 16
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(8))
+	s := newTestServerWith(t, pooledConfig(t, clients))
+
+	for round := 1; round <= rounds; round++ {
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		statuses := make(chan int, clients)
+		for i := 0; i < clients; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				rec := s.request(http.MethodPost, "/api/hives", map[string]any{"hiveName": round, "tags": []string{"race"}}, "")
+				if rec.Code != http.StatusCreated && rec.Code != http.StatusConflict {
+					t.Errorf("round %d: got status %d: %s", round, rec.Code, rec.Body)
+				}
+				statuses <- rec.Code
+			}()
+		}
+		close(start)
+		wg.Wait()
+		close(statuses)
+		created := 0
+		for status := range statuses {
+			if status == http.StatusCreated {
+				created++
+			}
+		}
+		if created != 1 {
+			t.Errorf("round %d: created the hive %d times, want once", round, created)
+		}
+
+		var hive models.Hive
+		s.do(http.MethodGet, fmt.Sprintf("/api/hives/%d", round), nil, http.StatusOK, &hive)
+		if !reflect.DeepEqual(tagNames(hive.Tags), []string{"race"}) {
+			t.Errorf("round %d: got tags %v", round, tagNames(hive.Tags))
+		}
+	}
+}
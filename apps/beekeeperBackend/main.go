@@ -1,20 +1,24 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"time"
 
-	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 
+	"beekeeper-api/anomaly"
+	"beekeeper-api/auth"
+	"beekeeper-api/backup"
 	"beekeeper-api/config"
 	"beekeeper-api/database"
-	_ "beekeeper-api/docs" // Import generated docs
-	"beekeeper-api/features/hives"
-	"beekeeper-api/features/logs"
-	"beekeeper-api/features/tasks"
-	"github.com/gin-contrib/cors"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
+	"beekeeper-api/events"
+	"beekeeper-api/features/webhooks"
+	"beekeeper-api/notify"
+	"beekeeper-api/services"
+	"beekeeper-api/telemetry"
+	"beekeeper-api/weather"
 )
 
 // @title Beekeeper API
@@ -29,6 +33,10 @@ import (
 // @host localhost:8000
 // @BasePath /api
 // @securityDefinitions.basic BasicAuth
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description API token of a user, sent as "Bearer <token>"
 func main() {
 	// Load environment variables from .env file
 	// In a production environment, these should be set directly
@@ -40,25 +48,56 @@ func main() {
 	// Initialize configuration
 	cfg := config.New()
 
+	// Maintenance commands such as `migrate up` run instead of the server
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(cfg, os.Args[1:]))
+	}
+
 	// Initialize database connection
 	db := database.Init(cfg)
 
-	// Create a new Gin router
-	router := gin.Default()
+	// Event bus for change notifications, streamed to clients and delivered to webhooks
+	bus := events.NewBus(cfg.EventBufferSize)
+	webhooks.NewDispatcher(cfg, db).Start(bus)
 
-	//Cors
-	router.Use(cors.Default())
+	// Remind users of tasks that are due soon or overdue
+	notify.NewDispatcher(cfg, db, time.Now).Start()
 
-	// API base path
-	api := router.Group("/api")
+	// Attach the weather to new log entries if a provider is configured
+	provider, err := weather.NewProvider(cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up weather lookups: %v", err)
+	}
+	if provider != nil {
+		weather.NewRecorder(cfg, db, bus, provider).Start()
+	}
 
-	// Register feature-specific routes
-	hives.RegisterRoutes(api, db)
-	logs.RegisterRoutes(api, db)
-	tasks.RegisterRoutes(api, db)
+	// Start MQTT telemetry ingestion if a broker is configured
+	if cfg.MQTTBrokerURL != "" {
+		bridge, err := telemetry.NewBridge(cfg, db)
+		if err != nil {
+			log.Fatalf("Failed to set up MQTT telemetry: %v", err)
+		}
+		bridge.OnReading(anomaly.NewEngine(cfg, db, bus).Process)
+		bridge.Start()
+		defer bridge.Stop()
+	}
+
+	// Take scheduled snapshots of the SQLite database
+	backups := backup.NewManager(cfg, db)
+	backups.Start()
+
+	// Hive, log and task rules shared by the features
+	svc := services.NewGORM(db)
+
+	// Sign users in with an OpenID Connect issuer if one is configured
+	oidcProvider, err := auth.NewOIDC(context.Background(), cfg, db)
+	if err != nil {
+		log.Fatalf("Failed to set up OIDC login: %v", err)
+	}
 
-	// Add Swagger endpoint
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	// Create the router serving all features
+	router := newRouter(cfg, db, bus, svc, backups, oidcProvider)
 
 	// Start the server
 	log.Printf("Server starting on port  %s", cfg.Port)
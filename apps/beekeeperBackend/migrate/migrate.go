@@ -0,0 +1,370 @@
+package migrate
+
+import (
+	"bufio"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"beekeeper-api/models"
+)
+
+// Migrations are SQL files per dialect named <version>_<name>.up.sql and
+// <version>_<name>.down.sql, e.g. 0002_logs_by_hive_id.up.sql. Statements end
+// with a semicolon at the end of a line; lines starting with -- are comments.
+//
+//go:embed migrations
+var files embed.FS
+
+// ErrSchemaTooNew is returned when the database was migrated by a newer
+// version of the API than this one.
+var ErrSchemaTooNew = errors.New("database schema is newer than this version supports")
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration and when it was applied, if it was
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// record is a row of schema_migrations
+type record struct {
+	Version   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (record) TableName() string { return "schema_migrations" }
+
+// Migrator applies the embedded migrations of the database's dialect
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New loads the migrations for the dialect of db
+func New(db *gorm.DB) (*Migrator, error) {
+	migrations, err := Load(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Load reads the embedded migrations of a dialect, ordered by version
+func Load(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for %s", dialect)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		name, direction, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+		number, title, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseInt(number, 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration file %s has no version", entry.Name())
+		}
+		content, err := fs.ReadFile(files, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: title}
+			byVersion[version] = migration
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d needs both an up and a down file", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Latest is the version the embedded migrations lead to
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version is the newest version applied to the database, 0 if none is. Like
+// Check, Status and Pending it only reads: a database created by AutoMigrate
+// before there were migrations is at version 0 until Up adopts it.
+func (m *Migrator) Version() (int64, error) {
+	if !m.db.Migrator().HasTable(&record{}) {
+		return 0, nil
+	}
+	var version int64
+	err := m.db.Model(&record{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// Check fails with ErrSchemaTooNew if the database has migrations applied
+// that this version does not know
+func (m *Migrator) Check() error {
+	version, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if version > m.Latest() {
+		return fmt.Errorf("%w: database is at version %d, latest known is %d", ErrSchemaTooNew, version, m.Latest())
+	}
+	return nil
+}
+
+// Status lists all known migrations and when they were applied
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i].Migration = migration
+		if r, ok := applied[migration.Version]; ok {
+			statuses[i].AppliedAt = &r.AppliedAt
+		}
+	}
+	return statuses, nil
+}
+
+// Pending lists the migrations that have not been applied yet
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies all pending migrations in order and returns them
+func (m *Migrator) Up() ([]Migration, error) {
+	if err := m.prepare(); err != nil {
+		return nil, err
+	}
+	if err := m.Check(); err != nil {
+		return nil, err
+	}
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+	for i, migration := range pending {
+		err := m.transaction(func(tx *gorm.DB) error {
+			if err := exec(tx, migration.Up); err != nil {
+				return err
+			}
+			return tx.Create(&record{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return pending[:i], fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+	}
+	return pending, nil
+}
+
+// Down reverts the newest steps applied migrations and returns them
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	if err := m.Check(); err != nil {
+		return nil, err
+	}
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+	var reverted []Migration
+	for i := len(statuses) - 1; i >= 0 && len(reverted) < steps; i-- {
+		if statuses[i].AppliedAt == nil {
+			continue
+		}
+		migration := statuses[i].Migration
+		err := m.transaction(func(tx *gorm.DB) error {
+			if err := exec(tx, migration.Down); err != nil {
+				return err
+			}
+			return tx.Delete(&record{Version: migration.Version}).Error
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+		reverted = append(reverted, migration)
+	}
+	return reverted, nil
+}
+
+// transaction runs a migration in a transaction. SQLite can only change
+// constraints by rebuilding tables, and dropping a table while foreign keys
+// are enforced deletes the rows referencing it, so they are switched off on
+// the migration's connection; that is not possible within a transaction.
+func (m *Migrator) transaction(fn func(tx *gorm.DB) error) error {
+	if m.db.Dialector.Name() != "sqlite" {
+		return m.db.Transaction(fn)
+	}
+	return m.db.Connection(func(conn *gorm.DB) error {
+		conn = conn.Session(&gorm.Session{NewDB: true})
+		var enforced bool
+		if err := conn.Raw("PRAGMA foreign_keys").Scan(&enforced).Error; err != nil {
+			return err
+		}
+		if enforced {
+			if err := conn.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
+				return err
+			}
+			defer conn.Exec("PRAGMA foreign_keys = ON")
+		}
+		return conn.Transaction(fn)
+	})
+}
+
+func (m *Migrator) applied() (map[int64]record, error) {
+	var records []record
+	if m.db.Migrator().HasTable(&record{}) {
+		if err := m.db.Find(&records).Error; err != nil {
+			return nil, err
+		}
+	}
+	applied := make(map[int64]record, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// prepare creates schema_migrations. Databases created by AutoMigrate before
+// there were migrations are brought up to the initial schema and marked as
+// being at version 1, so they continue from there. Fields added by later
+// migrations are tagged -:migration so AutoMigrate leaves them to those.
+//
+// AutoMigrate rebuilds SQLite tables to change them, so it runs like a
+// migration with foreign keys switched off; otherwise rebuilding hives would
+// delete every log and task. Rows it leaves referencing missing rows fail the
+// adoption, apart from those that already did before.
+func (m *Migrator) prepare() error {
+	if m.db.Migrator().HasTable(&record{}) {
+		return nil
+	}
+	if !m.db.Migrator().HasTable("hives") {
+		return createRecords(m.db)
+	}
+
+	return m.transaction(func(tx *gorm.DB) error {
+		dangling, err := danglingRows(tx)
+		if err != nil {
+			return err
+		}
+		err = tx.AutoMigrate(&models.Tag{}, &models.Apiary{}, &models.Hive{}, &models.Log{}, &models.LogWeather{}, &models.Task{}, &models.User{}, &models.APIToken{}, &models.TeamMember{}, &models.Invitation{}, &models.Telemetry{}, &models.Alert{}, &models.Webhook{}, &models.WebhookDelivery{}, &models.NotificationPreference{}, &models.TaskReminder{}, &models.Incident{}, &models.Harvest{}, &models.EquipmentMove{})
+		if err != nil {
+			return fmt.Errorf("bringing existing schema up to version 1: %w", err)
+		}
+		// Index of users.oidc_subject under the name GORM derived before it was named
+		if tx.Migrator().HasIndex(&models.User{}, "idx_users_o_id_c_subject") {
+			if err := tx.Migrator().DropIndex(&models.User{}, "idx_users_o_id_c_subject"); err != nil {
+				return err
+			}
+		}
+		after, err := danglingRows(tx)
+		if err != nil {
+			return err
+		}
+		if after > dangling {
+			return fmt.Errorf("bringing existing schema up to version 1 left %d rows referencing missing rows", after-dangling)
+		}
+
+		if err := createRecords(tx); err != nil {
+			return err
+		}
+		return tx.Create(&record{Version: 1, Name: "initial_schema", AppliedAt: time.Now()}).Error
+	})
+}
+
+// createRecords creates the schema_migrations table
+func createRecords(db *gorm.DB) error {
+	return db.Exec("CREATE TABLE schema_migrations (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at TIMESTAMP NOT NULL)").Error
+}
+
+// danglingRows counts the rows whose foreign keys point nowhere. Only SQLite
+// can have them, as it is the only database that may not enforce foreign keys.
+func danglingRows(tx *gorm.DB) (int, error) {
+	if tx.Dialector.Name() != "sqlite" {
+		return 0, nil
+	}
+	rows, err := tx.Raw("PRAGMA foreign_key_check").Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	dangling := 0
+	for rows.Next() {
+		dangling++
+	}
+	return dangling, rows.Err()
+}
+
+// exec runs the statements of a migration file one by one, as not every
+// driver accepts several statements at once. MySQL commits each DDL statement
+// implicitly, so a failed migration there may be left half applied.
+func exec(tx *gorm.DB, script string) error {
+	var statement strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(script))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "--") {
+			continue
+		}
+		statement.WriteString(line)
+		statement.WriteString("\n")
+		if strings.HasSuffix(line, ";") {
+			if err := tx.Exec(statement.String()).Error; err != nil {
+				return err
+			}
+			statement.Reset()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if strings.TrimSpace(statement.String()) != "" {
+		return errors.New("last statement is not terminated by a semicolon")
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package migrate_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"beekeeper-api/config"
+	"beekeeper-api/database"
+	"beekeeper-api/migrate"
+)
+
+// The tests run on a SQLite file with the default pragmas, foreign keys
+// enforced, using whichever driver the build tags select.
+
+// open connects to a new SQLite file, after running the statements of a
+// fixture in testdata if one is named
+func open(t *testing.T, fixture string) (*gorm.DB, *migrate.Migrator) {
+	t.Helper()
+	cfg := config.New()
+	cfg.DBDriver = "sqlite"
+	cfg.DatabaseURL = ""
+	cfg.DBFile = filepath.Join(t.TempDir(), "beekeeper.db")
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db.Logger = logger.Discard
+
+	if fixture != "" {
+		script, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range strings.Split(string(script), "\n") {
+			if line == "" || strings.HasPrefix(line, "--") {
+				continue
+			}
+			if err := db.Exec(line).Error; err != nil {
+				t.Fatalf("%s: %v", line, err)
+			}
+		}
+	}
+
+	migrator, err := migrate.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, migrator
+}
+
+// counts returns the number of hives, logs and tasks
+func counts(t *testing.T, db *gorm.DB) [3]int64 {
+	t.Helper()
+	var n [3]int64
+	for i, table := range []string{"hives", "logs", "tasks"} {
+		if err := db.Table(table).Count(&n[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return n
+}
+
+func version(t *testing.T, m *migrate.Migrator) int64 {
+	t.Helper()
+	v, err := m.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// TestBaselineUpgrade migrates a database of the first release, whose hives
+// table AutoMigrate has to rebuild, without losing its logs and tasks
+func TestBaselineUpgrade(t *testing.T) {
+	db, m := open(t, "baseline.sql")
+	want := [3]int64{2, 2, 1}
+
+	// Reading the state changes nothing
+	if got := version(t, m); got != 0 {
+		t.Errorf("got version %d before adopting, want 0", got)
+	}
+	if err := m.Check(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Status(); err != nil {
+		t.Fatal(err)
+	}
+	pending, err := m.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != int(m.Latest()) {
+		t.Errorf("got %d pending migrations, want %d", len(pending), m.Latest())
+	}
+	if db.Migrator().HasTable("schema_migrations") || db.Migrator().HasTable("users") {
+		t.Error("reading the migration state changed the schema")
+	}
+
+	applied, err := m.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The initial schema is adopted, not applied
+	if len(applied) != int(m.Latest())-1 || applied[0].Version != 2 {
+		t.Errorf("got %d applied migrations starting at %d, want all but the first", len(applied), applied[0].Version)
+	}
+	if got := version(t, m); got != m.Latest() {
+		t.Errorf("got version %d, want %d", got, m.Latest())
+	}
+	if got := counts(t, db); got != want {
+		t.Errorf("got %v hives, logs and tasks, want %v", got, want)
+	}
+	var dangling []map[string]any
+	if err := db.Raw("PRAGMA foreign_key_check").Scan(&dangling).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(dangling) != 0 {
+		t.Errorf("got rows referencing missing rows: %v", dangling)
+	}
+
+	// The adopted schema takes the later migrations back and forth
+	if _, err := m.Down(int(m.Latest()) - 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := version(t, m); got != 1 {
+		t.Errorf("got version %d after reverting, want 1", got)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if got := counts(t, db); got != want {
+		t.Errorf("got %v hives, logs and tasks after migrating down and up, want %v", got, want)
+	}
+}
+
+// TestBaselineUpgradeKeepsOrphans adopts a database with a log of a deleted
+// hive, written while foreign keys were not enforced, which is left to the
+// repair command
+func TestBaselineUpgradeKeepsOrphans(t *testing.T) {
+	db, m := open(t, "baseline.sql")
+	err := db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
+			return err
+		}
+		defer conn.Exec("PRAGMA foreign_keys = ON")
+		return conn.Exec("DELETE FROM hives WHERE hive_name = 1").Error
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := counts(t, db), [3]int64{1, 2, 1}; got != want {
+		t.Errorf("got %v hives, logs and tasks, want %v", got, want)
+	}
+}
+
+// TestRoundTrip reverts every migration of a new database and applies them
+// again
+func TestRoundTrip(t *testing.T) {
+	db, m := open(t, "")
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO hives (hive_name, created_at, updated_at) VALUES (1, ?, ?)", time.Now(), time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// One step back and forth keeps the data
+	reverted, err := m.Down(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != 1 || reverted[0].Version != m.Latest() {
+		t.Errorf("got reverted %v, want only the latest migration", reverted)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if got := counts(t, db); got != [3]int64{1, 0, 0} {
+		t.Errorf("got %v hives, logs and tasks, want the hive kept", got)
+	}
+
+	reverted, err = m.Down(int(m.Latest()) + 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != int(m.Latest()) {
+		t.Errorf("got %d reverted migrations, want %d", len(reverted), m.Latest())
+	}
+	if got := version(t, m); got != 0 {
+		t.Errorf("got version %d after reverting all, want 0", got)
+	}
+	if db.Migrator().HasTable("hives") {
+		t.Error("got hives table after reverting the initial schema")
+	}
+
+	applied, err := m.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != int(m.Latest()) {
+		t.Errorf("got %d applied migrations, want %d", len(applied), m.Latest())
+	}
+	if reverted, err := m.Down(0); err != nil || len(reverted) != 0 {
+		t.Errorf("got %v, %v for reverting no migrations", reverted, err)
+	}
+}
+
+// TestSchemaTooNew refuses to read, apply or revert anything on a database
+// migrated by a newer version
+func TestSchemaTooNew(t *testing.T) {
+	db, m := open(t, "")
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	newer := m.Latest() + 1
+	if err := db.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, 'from_the_future', ?)", newer, time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if got := version(t, m); got != newer {
+		t.Errorf("got version %d, want %d", got, newer)
+	}
+	if err := m.Check(); !errors.Is(err, migrate.ErrSchemaTooNew) {
+		t.Errorf("got %v from Check, want ErrSchemaTooNew", err)
+	}
+	if _, err := m.Up(); !errors.Is(err, migrate.ErrSchemaTooNew) {
+		t.Errorf("got %v from Up, want ErrSchemaTooNew", err)
+	}
+	if _, err := m.Down(1); !errors.Is(err, migrate.ErrSchemaTooNew) {
+		t.Errorf("got %v from Down, want ErrSchemaTooNew", err)
+	}
+	if got := version(t, m); got != newer {
+		t.Errorf("got version %d after refusing, want %d", got, newer)
+	}
+}
+
+// TestLoad checks that every dialect has the same migrations
+func TestLoad(t *testing.T) {
+	sqlite, err := migrate.Load("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dialect := range []string{"postgres", "mysql"} {
+		migrations, err := migrate.Load(dialect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(migrations) != len(sqlite) {
+			t.Fatalf("got %d migrations for %s, want %d as for sqlite", len(migrations), dialect, len(sqlite))
+		}
+		for i, migration := range migrations {
+			if migration.Version != sqlite[i].Version || migration.Name != sqlite[i].Name {
+				t.Errorf("got %04d %s for %s, want %04d %s", migration.Version, migration.Name, dialect, sqlite[i].Version, sqlite[i].Name)
+			}
+		}
+	}
+	if _, err := migrate.Load("oracle"); err == nil {
+		t.Error("got migrations for an unknown dialect")
+	}
+}
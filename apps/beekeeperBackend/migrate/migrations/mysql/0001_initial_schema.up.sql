@@ -0,0 +1,294 @@
+-- Baseline: the schema AutoMigrate created before versioned migrations
+
+CREATE TABLE `tags` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `name` varchar(100) NOT NULL,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_tags_name` (`name`)
+);
+
+CREATE TABLE `apiaries` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `name` longtext NOT NULL,
+    `registration_number` longtext,
+    `address` longtext,
+    `latitude` double NOT NULL,
+    `longitude` double NOT NULL,
+    `boundary` longtext,
+    `notes` longtext,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_apiaries_latitude` (`latitude`)
+);
+
+CREATE TABLE `hives` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `hive_name` bigint NOT NULL,
+    `apiary_id` bigint unsigned,
+    `quarantined_since` datetime(3) NULL,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_hives_apiary_id` (`apiary_id`),
+    CONSTRAINT `fk_apiaries_hives` FOREIGN KEY (`apiary_id`) REFERENCES `apiaries`(`id`) ON DELETE SET NULL,
+    CONSTRAINT `uni_hives_hive_name` UNIQUE (`hive_name`)
+);
+
+CREATE TABLE `logs` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `hive_id` bigint NOT NULL,
+    `content` longtext NOT NULL,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_hives_logs` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE
+);
+
+CREATE TABLE `log_weathers` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `log_id` bigint unsigned NOT NULL,
+    `provider` longtext,
+    `temperature_c` double,
+    `wind_speed_kmh` double,
+    `cloud_cover_pct` double,
+    `precipitation_mm` double,
+    `observed_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_log_weathers_log_id` (`log_id`),
+    CONSTRAINT `fk_logs_weather` FOREIGN KEY (`log_id`) REFERENCES `logs`(`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `tasks` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `hive_id` bigint NOT NULL,
+    `content` longtext NOT NULL,
+    `priority` varchar(191) NOT NULL DEFAULT 'normal',
+    `due_at` datetime(3) NULL,
+    `completed_at` datetime(3) NULL,
+    `assignee_id` bigint unsigned,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_tasks_assignee_id` (`assignee_id`),
+    CONSTRAINT `fk_hives_tasks` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE
+);
+
+CREATE TABLE `users` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `name` longtext NOT NULL,
+    `email` varchar(255) NOT NULL,
+    `role` varchar(191) NOT NULL DEFAULT 'owner',
+    `calendar_token_hash` varchar(64) NOT NULL,
+    `oidc_subject` varchar(255),
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_users_email` (`email`),
+    UNIQUE INDEX `idx_users_calendar_token_hash` (`calendar_token_hash`),
+    UNIQUE INDEX `idx_users_oidc_subject` (`oidc_subject`)
+);
+
+CREATE TABLE `api_tokens` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `user_id` bigint unsigned NOT NULL,
+    `name` longtext,
+    `token_hash` varchar(64) NOT NULL,
+    `last_used_at` datetime(3) NULL,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_api_tokens_user_id` (`user_id`),
+    UNIQUE INDEX `idx_api_tokens_token_hash` (`token_hash`),
+    CONSTRAINT `fk_api_tokens_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `team_members` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `apiary_id` bigint unsigned NOT NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `role` varchar(191) NOT NULL DEFAULT 'editor',
+    `expires_at` datetime(3) NULL,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_team_members_unique` (`apiary_id`,`user_id`),
+    INDEX `idx_team_members_user_id` (`user_id`),
+    CONSTRAINT `fk_team_members_apiary` FOREIGN KEY (`apiary_id`) REFERENCES `apiaries`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_team_members_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+
+CREATE TABLE `invitations` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `apiary_id` bigint unsigned NOT NULL,
+    `email` longtext NOT NULL,
+    `role` longtext NOT NULL,
+    `token_hash` varchar(64) NOT NULL,
+    `invited_by_id` bigint unsigned,
+    `expires_at` datetime(3) NULL,
+    `access_expires_at` datetime(3) NULL,
+    `accepted_at` datetime(3) NULL,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_invitations_apiary_id` (`apiary_id`),
+    UNIQUE INDEX `idx_invitations_token_hash` (`token_hash`),
+    CONSTRAINT `fk_invitations_apiary` FOREIGN KEY (`apiary_id`) REFERENCES `apiaries`(`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `telemetries` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `hive_id` bigint NOT NULL,
+    `metric` varchar(191) NOT NULL,
+    `value` double NOT NULL,
+    `topic` longtext,
+    `recorded_at` datetime(3) NOT NULL,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_telemetry_hive_metric` (`hive_id`,`metric`,`recorded_at`),
+    CONSTRAINT `fk_hives_telemetry` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE
+);
+
+CREATE TABLE `alerts` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `hive_id` bigint NOT NULL,
+    `detector` varchar(191) NOT NULL,
+    `message` longtext NOT NULL,
+    `value` double,
+    `task_id` bigint unsigned,
+    `log_id` bigint unsigned,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_alerts_hive_detector` (`hive_id`,`detector`,`created_at`),
+    CONSTRAINT `fk_hives_alerts` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE
+);
+
+CREATE TABLE `webhooks` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `url` longtext NOT NULL,
+    `events` longtext NOT NULL,
+    `secret` longtext NOT NULL,
+    `description` longtext,
+    `active` boolean NOT NULL DEFAULT true,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`)
+);
+
+CREATE TABLE `webhook_deliveries` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `webhook_id` bigint unsigned NOT NULL,
+    `event_id` bigint unsigned,
+    `event_type` longtext NOT NULL,
+    `payload` longtext NOT NULL,
+    `status` varchar(191) NOT NULL,
+    `attempts` bigint,
+    `next_attempt_at` datetime(3) NULL,
+    `response_code` bigint,
+    `last_error` longtext,
+    `delivered_at` datetime(3) NULL,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_webhook_deliveries_webhook_id` (`webhook_id`),
+    INDEX `idx_deliveries_due` (`status`,`next_attempt_at`),
+    CONSTRAINT `fk_webhooks_deliveries` FOREIGN KEY (`webhook_id`) REFERENCES `webhooks`(`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `notification_preferences` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `user_id` bigint unsigned NOT NULL,
+    `channels` longtext NOT NULL,
+    `webhook_url` longtext,
+    `ntfy_topic` longtext,
+    `timezone` varchar(191) NOT NULL DEFAULT 'UTC',
+    `quiet_hours_start` longtext,
+    `quiet_hours_end` longtext,
+    `digest` boolean,
+    `digest_time` varchar(191) NOT NULL DEFAULT '07:00',
+    `last_digest_at` datetime(3) NULL,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_notification_preferences_user_id` (`user_id`),
+    CONSTRAINT `fk_notification_preferences_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `task_reminders` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `task_id` bigint unsigned NOT NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `kind` varchar(32) NOT NULL,
+    `sent_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_task_reminders_unique` (`task_id`,`user_id`,`kind`),
+    CONSTRAINT `fk_task_reminders_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_task_reminders_task` FOREIGN KEY (`task_id`) REFERENCES `tasks`(`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `hive_tags` (
+    `hive_id` bigint unsigned,
+    `tag_id` bigint unsigned,
+    PRIMARY KEY (`hive_id`,`tag_id`),
+    CONSTRAINT `fk_hive_tags_hive` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`id`),
+    CONSTRAINT `fk_hive_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`)
+);
+
+CREATE TABLE `log_tags` (
+    `log_id` bigint unsigned,
+    `tag_id` bigint unsigned,
+    PRIMARY KEY (`log_id`,`tag_id`),
+    CONSTRAINT `fk_log_tags_log` FOREIGN KEY (`log_id`) REFERENCES `logs`(`id`),
+    CONSTRAINT `fk_log_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`)
+);
+
+CREATE TABLE `task_tags` (
+    `task_id` bigint unsigned,
+    `tag_id` bigint unsigned,
+    PRIMARY KEY (`task_id`,`tag_id`),
+    CONSTRAINT `fk_task_tags_task` FOREIGN KEY (`task_id`) REFERENCES `tasks`(`id`),
+    CONSTRAINT `fk_task_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`)
+);
+
+CREATE TABLE `incidents` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `hive_id` bigint NOT NULL,
+    `log_id` bigint unsigned,
+    `disease` longtext NOT NULL,
+    `status` varchar(191) NOT NULL,
+    `sample_sent_at` datetime(3) NULL,
+    `sample_reference` longtext,
+    `lab_result` longtext,
+    `lab_result_at` datetime(3) NULL,
+    `actions_taken` longtext,
+    `reported_to_authority_at` datetime(3) NULL,
+    `cleared_at` datetime(3) NULL,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_incidents_hive_id` (`hive_id`),
+    INDEX `idx_incidents_status` (`status`)
+);
+
+CREATE TABLE `harvests` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `hive_id` bigint NOT NULL,
+    `product` varchar(191) NOT NULL DEFAULT 'honey',
+    `weight_kg` double NOT NULL,
+    `harvested_at` datetime(3) NOT NULL,
+    `notes` longtext,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_harvests_hive_id` (`hive_id`)
+);
+
+CREATE TABLE `equipment_moves` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `from_hive_id` bigint NOT NULL,
+    `to_hive_id` bigint,
+    `equipment` longtext NOT NULL,
+    `quantity` bigint NOT NULL DEFAULT 1,
+    `moved_at` datetime(3) NOT NULL,
+    `notes` longtext,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_equipment_moves_from_hive_id` (`from_hive_id`)
+);
@@ -0,0 +1,23 @@
+-- Deleting a hive, log, task, tag or user also deletes its tag links and
+-- team memberships, which failed once foreign keys were enforced
+
+-- MySQL cannot drop and add a foreign key of the same name in one statement
+
+ALTER TABLE `hive_tags` DROP FOREIGN KEY `fk_hive_tags_hive`, DROP FOREIGN KEY `fk_hive_tags_tag`;
+ALTER TABLE `hive_tags`
+    ADD CONSTRAINT `fk_hive_tags_hive` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`id`) ON DELETE CASCADE,
+    ADD CONSTRAINT `fk_hive_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`) ON DELETE CASCADE;
+
+ALTER TABLE `log_tags` DROP FOREIGN KEY `fk_log_tags_log`, DROP FOREIGN KEY `fk_log_tags_tag`;
+ALTER TABLE `log_tags`
+    ADD CONSTRAINT `fk_log_tags_log` FOREIGN KEY (`log_id`) REFERENCES `logs`(`id`) ON DELETE CASCADE,
+    ADD CONSTRAINT `fk_log_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`) ON DELETE CASCADE;
+
+ALTER TABLE `task_tags` DROP FOREIGN KEY `fk_task_tags_task`, DROP FOREIGN KEY `fk_task_tags_tag`;
+ALTER TABLE `task_tags`
+    ADD CONSTRAINT `fk_task_tags_task` FOREIGN KEY (`task_id`) REFERENCES `tasks`(`id`) ON DELETE CASCADE,
+    ADD CONSTRAINT `fk_task_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`) ON DELETE CASCADE;
+
+ALTER TABLE `team_members` DROP FOREIGN KEY `fk_team_members_user`;
+ALTER TABLE `team_members`
+    ADD CONSTRAINT `fk_team_members_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE;
@@ -0,0 +1,8 @@
+-- Restore the cooldown on alert creation times and drop apiary time zones
+
+ALTER TABLE `alerts`
+    DROP INDEX `idx_alerts_hive_detector`,
+    ADD INDEX `idx_alerts_hive_detector` (`hive_id`,`detector`,`created_at`);
+ALTER TABLE `alerts` DROP COLUMN `recorded_at`;
+
+ALTER TABLE `apiaries` DROP COLUMN `timezone`;
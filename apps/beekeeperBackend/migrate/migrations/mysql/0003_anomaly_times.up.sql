@@ -0,0 +1,11 @@
+-- Alerts keep the time of the reading that raised them, so the cooldown
+-- follows the readings even when they arrive late, and apiaries get the time
+-- zone the robbing detector's daytime hours are in
+
+ALTER TABLE `alerts` ADD COLUMN `recorded_at` datetime(3) NULL;
+UPDATE `alerts` SET `recorded_at` = `created_at`;
+ALTER TABLE `alerts`
+    DROP INDEX `idx_alerts_hive_detector`,
+    ADD INDEX `idx_alerts_hive_detector` (`hive_id`,`detector`,`recorded_at`);
+
+ALTER TABLE `apiaries` ADD COLUMN `timezone` varchar(191) NOT NULL DEFAULT '';
@@ -0,0 +1,13 @@
+-- Recreate the foreign keys without ON UPDATE CASCADE
+
+ALTER TABLE `logs` DROP FOREIGN KEY `fk_hives_logs`;
+ALTER TABLE `logs` ADD CONSTRAINT `fk_hives_logs` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE;
+
+ALTER TABLE `tasks` DROP FOREIGN KEY `fk_hives_tasks`;
+ALTER TABLE `tasks` ADD CONSTRAINT `fk_hives_tasks` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE;
+
+ALTER TABLE `telemetries` DROP FOREIGN KEY `fk_hives_telemetry`;
+ALTER TABLE `telemetries` ADD CONSTRAINT `fk_hives_telemetry` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE;
+
+ALTER TABLE `alerts` DROP FOREIGN KEY `fk_hives_alerts`;
+ALTER TABLE `alerts` ADD CONSTRAINT `fk_hives_alerts` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE;
@@ -0,0 +1,16 @@
+-- Renaming a hive moves its logs, tasks, readings and alerts along, which
+-- failed once foreign keys were enforced
+
+-- MySQL cannot drop and add a foreign key of the same name in one statement
+
+ALTER TABLE `logs` DROP FOREIGN KEY `fk_hives_logs`;
+ALTER TABLE `logs` ADD CONSTRAINT `fk_hives_logs` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE `tasks` DROP FOREIGN KEY `fk_hives_tasks`;
+ALTER TABLE `tasks` ADD CONSTRAINT `fk_hives_tasks` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE `telemetries` DROP FOREIGN KEY `fk_hives_telemetry`;
+ALTER TABLE `telemetries` ADD CONSTRAINT `fk_hives_telemetry` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE `alerts` DROP FOREIGN KEY `fk_hives_alerts`;
+ALTER TABLE `alerts` ADD CONSTRAINT `fk_hives_alerts` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE ON UPDATE CASCADE;
@@ -0,0 +1,3 @@
+-- Drop the members only mark of apiaries
+
+ALTER TABLE `apiaries` DROP COLUMN `members_only`;
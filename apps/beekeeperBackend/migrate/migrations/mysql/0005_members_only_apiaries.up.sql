@@ -0,0 +1,4 @@
+-- Team members only add to the global roles, unless an apiary is marked as
+-- open to its members only
+
+ALTER TABLE `apiaries` ADD COLUMN `members_only` boolean NOT NULL DEFAULT false;
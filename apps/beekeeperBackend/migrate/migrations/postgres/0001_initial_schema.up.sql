@@ -0,0 +1,318 @@
+-- Baseline: the schema AutoMigrate created before versioned migrations
+
+CREATE TABLE "tags" (
+    "id" bigserial,
+    "name" varchar(100) NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tags_name" ON "tags" ("name");
+
+CREATE TABLE "apiaries" (
+    "id" bigserial,
+    "name" text NOT NULL,
+    "registration_number" text,
+    "address" text,
+    "latitude" decimal NOT NULL,
+    "longitude" decimal NOT NULL,
+    "boundary" text,
+    "notes" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_apiaries_latitude" ON "apiaries" ("latitude");
+
+CREATE TABLE "hives" (
+    "id" bigserial,
+    "hive_name" bigint NOT NULL,
+    "apiary_id" bigint,
+    "quarantined_since" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_apiaries_hives" FOREIGN KEY ("apiary_id") REFERENCES "apiaries"("id") ON DELETE SET NULL,
+    CONSTRAINT "uni_hives_hive_name" UNIQUE ("hive_name")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_hives_apiary_id" ON "hives" ("apiary_id");
+
+CREATE TABLE "logs" (
+    "id" bigserial,
+    "hive_id" bigint NOT NULL,
+    "content" text NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_hives_logs" FOREIGN KEY ("hive_id") REFERENCES "hives"("hive_name") ON DELETE CASCADE
+);
+
+CREATE TABLE "log_weathers" (
+    "id" bigserial,
+    "log_id" bigint NOT NULL,
+    "provider" text,
+    "temperature_c" decimal,
+    "wind_speed_kmh" decimal,
+    "cloud_cover_pct" decimal,
+    "precipitation_mm" decimal,
+    "observed_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_logs_weather" FOREIGN KEY ("log_id") REFERENCES "logs"("id") ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_log_weathers_log_id" ON "log_weathers" ("log_id");
+
+CREATE TABLE "tasks" (
+    "id" bigserial,
+    "hive_id" bigint NOT NULL,
+    "content" text NOT NULL,
+    "priority" text NOT NULL DEFAULT 'normal',
+    "due_at" timestamptz,
+    "completed_at" timestamptz,
+    "assignee_id" bigint,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_hives_tasks" FOREIGN KEY ("hive_id") REFERENCES "hives"("hive_name") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "idx_tasks_assignee_id" ON "tasks" ("assignee_id");
+
+CREATE TABLE "users" (
+    "id" bigserial,
+    "name" text NOT NULL,
+    "email" varchar(255) NOT NULL,
+    "role" text NOT NULL DEFAULT 'owner',
+    "calendar_token_hash" varchar(64) NOT NULL,
+    "oidc_subject" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_oidc_subject" ON "users" ("oidc_subject");
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_calendar_token_hash" ON "users" ("calendar_token_hash");
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+
+CREATE TABLE "api_tokens" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "name" text,
+    "token_hash" varchar(64) NOT NULL,
+    "last_used_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_api_tokens_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_api_tokens_token_hash" ON "api_tokens" ("token_hash");
+
+CREATE INDEX IF NOT EXISTS "idx_api_tokens_user_id" ON "api_tokens" ("user_id");
+
+CREATE TABLE "team_members" (
+    "id" bigserial,
+    "apiary_id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    "role" text NOT NULL DEFAULT 'editor',
+    "expires_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_team_members_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_team_members_apiary" FOREIGN KEY ("apiary_id") REFERENCES "apiaries"("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "idx_team_members_user_id" ON "team_members" ("user_id");
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_team_members_unique" ON "team_members" ("apiary_id","user_id");
+
+CREATE TABLE "invitations" (
+    "id" bigserial,
+    "apiary_id" bigint NOT NULL,
+    "email" text NOT NULL,
+    "role" text NOT NULL,
+    "token_hash" varchar(64) NOT NULL,
+    "invited_by_id" bigint,
+    "expires_at" timestamptz,
+    "access_expires_at" timestamptz,
+    "accepted_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_invitations_apiary" FOREIGN KEY ("apiary_id") REFERENCES "apiaries"("id") ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_invitations_token_hash" ON "invitations" ("token_hash");
+
+CREATE INDEX IF NOT EXISTS "idx_invitations_apiary_id" ON "invitations" ("apiary_id");
+
+CREATE TABLE "telemetries" (
+    "id" bigserial,
+    "hive_id" bigint NOT NULL,
+    "metric" text NOT NULL,
+    "value" decimal NOT NULL,
+    "topic" text,
+    "recorded_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_hives_telemetry" FOREIGN KEY ("hive_id") REFERENCES "hives"("hive_name") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "idx_telemetry_hive_metric" ON "telemetries" ("hive_id","metric","recorded_at");
+
+CREATE TABLE "alerts" (
+    "id" bigserial,
+    "hive_id" bigint NOT NULL,
+    "detector" text NOT NULL,
+    "message" text NOT NULL,
+    "value" decimal,
+    "task_id" bigint,
+    "log_id" bigint,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_hives_alerts" FOREIGN KEY ("hive_id") REFERENCES "hives"("hive_name") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "idx_alerts_hive_detector" ON "alerts" ("hive_id","detector","created_at");
+
+CREATE TABLE "webhooks" (
+    "id" bigserial,
+    "url" text NOT NULL,
+    "events" text NOT NULL,
+    "secret" text NOT NULL,
+    "description" text,
+    "active" boolean NOT NULL DEFAULT true,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE "webhook_deliveries" (
+    "id" bigserial,
+    "webhook_id" bigint NOT NULL,
+    "event_id" bigint,
+    "event_type" text NOT NULL,
+    "payload" text NOT NULL,
+    "status" text NOT NULL,
+    "attempts" bigint,
+    "next_attempt_at" timestamptz,
+    "response_code" bigint,
+    "last_error" text,
+    "delivered_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_webhooks_deliveries" FOREIGN KEY ("webhook_id") REFERENCES "webhooks"("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "idx_deliveries_due" ON "webhook_deliveries" ("status","next_attempt_at");
+
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_webhook_id" ON "webhook_deliveries" ("webhook_id");
+
+CREATE TABLE "notification_preferences" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "channels" text NOT NULL,
+    "webhook_url" text,
+    "ntfy_topic" text,
+    "timezone" text NOT NULL DEFAULT 'UTC',
+    "quiet_hours_start" text,
+    "quiet_hours_end" text,
+    "digest" boolean,
+    "digest_time" text NOT NULL DEFAULT '07:00',
+    "last_digest_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_notification_preferences_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_notification_preferences_user_id" ON "notification_preferences" ("user_id");
+
+CREATE TABLE "task_reminders" (
+    "id" bigserial,
+    "task_id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    "kind" varchar(32) NOT NULL,
+    "sent_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_task_reminders_task" FOREIGN KEY ("task_id") REFERENCES "tasks"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_task_reminders_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+
+CREATE TABLE "hive_tags" (
+    "hive_id" bigint,
+    "tag_id" bigint,
+    PRIMARY KEY ("hive_id","tag_id"),
+    CONSTRAINT "fk_hive_tags_hive" FOREIGN KEY ("hive_id") REFERENCES "hives"("id"),
+    CONSTRAINT "fk_hive_tags_tag" FOREIGN KEY ("tag_id") REFERENCES "tags"("id")
+);
+
+CREATE TABLE "log_tags" (
+    "log_id" bigint,
+    "tag_id" bigint,
+    PRIMARY KEY ("log_id","tag_id"),
+    CONSTRAINT "fk_log_tags_log" FOREIGN KEY ("log_id") REFERENCES "logs"("id"),
+    CONSTRAINT "fk_log_tags_tag" FOREIGN KEY ("tag_id") REFERENCES "tags"("id")
+);
+
+CREATE TABLE "task_tags" (
+    "task_id" bigint,
+    "tag_id" bigint,
+    PRIMARY KEY ("task_id","tag_id"),
+    CONSTRAINT "fk_task_tags_task" FOREIGN KEY ("task_id") REFERENCES "tasks"("id"),
+    CONSTRAINT "fk_task_tags_tag" FOREIGN KEY ("tag_id") REFERENCES "tags"("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_task_reminders_unique" ON "task_reminders" ("task_id","user_id","kind");
+
+CREATE TABLE "incidents" (
+    "id" bigserial,
+    "hive_id" bigint NOT NULL,
+    "log_id" bigint,
+    "disease" text NOT NULL,
+    "status" text NOT NULL,
+    "sample_sent_at" timestamptz,
+    "sample_reference" text,
+    "lab_result" text,
+    "lab_result_at" timestamptz,
+    "actions_taken" text,
+    "reported_to_authority_at" timestamptz,
+    "cleared_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_incidents_status" ON "incidents" ("status");
+
+CREATE INDEX IF NOT EXISTS "idx_incidents_hive_id" ON "incidents" ("hive_id");
+
+CREATE TABLE "harvests" (
+    "id" bigserial,
+    "hive_id" bigint NOT NULL,
+    "product" text NOT NULL DEFAULT 'honey',
+    "weight_kg" decimal NOT NULL,
+    "harvested_at" timestamptz NOT NULL,
+    "notes" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_harvests_hive_id" ON "harvests" ("hive_id");
+
+CREATE TABLE "equipment_moves" (
+    "id" bigserial,
+    "from_hive_id" bigint NOT NULL,
+    "to_hive_id" bigint,
+    "equipment" text NOT NULL,
+    "quantity" bigint NOT NULL DEFAULT 1,
+    "moved_at" timestamptz NOT NULL,
+    "notes" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_equipment_moves_from_hive_id" ON "equipment_moves" ("from_hive_id");
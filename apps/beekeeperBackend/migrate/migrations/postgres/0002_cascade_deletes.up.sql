@@ -0,0 +1,24 @@
+-- Deleting a hive, log, task, tag or user also deletes its tag links and
+-- team memberships, which failed once foreign keys were enforced
+
+ALTER TABLE "hive_tags"
+    DROP CONSTRAINT "fk_hive_tags_hive",
+    DROP CONSTRAINT "fk_hive_tags_tag",
+    ADD CONSTRAINT "fk_hive_tags_hive" FOREIGN KEY ("hive_id") REFERENCES "hives"("id") ON DELETE CASCADE,
+    ADD CONSTRAINT "fk_hive_tags_tag" FOREIGN KEY ("tag_id") REFERENCES "tags"("id") ON DELETE CASCADE;
+
+ALTER TABLE "log_tags"
+    DROP CONSTRAINT "fk_log_tags_log",
+    DROP CONSTRAINT "fk_log_tags_tag",
+    ADD CONSTRAINT "fk_log_tags_log" FOREIGN KEY ("log_id") REFERENCES "logs"("id") ON DELETE CASCADE,
+    ADD CONSTRAINT "fk_log_tags_tag" FOREIGN KEY ("tag_id") REFERENCES "tags"("id") ON DELETE CASCADE;
+
+ALTER TABLE "task_tags"
+    DROP CONSTRAINT "fk_task_tags_task",
+    DROP CONSTRAINT "fk_task_tags_tag",
+    ADD CONSTRAINT "fk_task_tags_task" FOREIGN KEY ("task_id") REFERENCES "tasks"("id") ON DELETE CASCADE,
+    ADD CONSTRAINT "fk_task_tags_tag" FOREIGN KEY ("tag_id") REFERENCES "tags"("id") ON DELETE CASCADE;
+
+ALTER TABLE "team_members"
+    DROP CONSTRAINT "fk_team_members_user",
+    ADD CONSTRAINT "fk_team_members_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE;
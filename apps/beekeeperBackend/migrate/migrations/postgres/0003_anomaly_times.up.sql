@@ -0,0 +1,10 @@
+-- Alerts keep the time of the reading that raised them, so the cooldown
+-- follows the readings even when they arrive late, and apiaries get the time
+-- zone the robbing detector's daytime hours are in
+
+ALTER TABLE "alerts" ADD COLUMN "recorded_at" timestamptz;
+UPDATE "alerts" SET "recorded_at" = "created_at";
+DROP INDEX IF EXISTS "idx_alerts_hive_detector";
+CREATE INDEX IF NOT EXISTS "idx_alerts_hive_detector" ON "alerts" ("hive_id","detector","recorded_at");
+
+ALTER TABLE "apiaries" ADD COLUMN "timezone" text NOT NULL DEFAULT '';
@@ -0,0 +1,17 @@
+-- Recreate the foreign keys without ON UPDATE CASCADE
+
+ALTER TABLE "logs"
+    DROP CONSTRAINT "fk_hives_logs",
+    ADD CONSTRAINT "fk_hives_logs" FOREIGN KEY ("hive_id") REFERENCES "hives"("hive_name") ON DELETE CASCADE;
+
+ALTER TABLE "tasks"
+    DROP CONSTRAINT "fk_hives_tasks",
+    ADD CONSTRAINT "fk_hives_tasks" FOREIGN KEY ("hive_id") REFERENCES "hives"("hive_name") ON DELETE CASCADE;
+
+ALTER TABLE "telemetries"
+    DROP CONSTRAINT "fk_hives_telemetry",
+    ADD CONSTRAINT "fk_hives_telemetry" FOREIGN KEY ("hive_id") REFERENCES "hives"("hive_name") ON DELETE CASCADE;
+
+ALTER TABLE "alerts"
+    DROP CONSTRAINT "fk_hives_alerts",
+    ADD CONSTRAINT "fk_hives_alerts" FOREIGN KEY ("hive_id") REFERENCES "hives"("hive_name") ON DELETE CASCADE;
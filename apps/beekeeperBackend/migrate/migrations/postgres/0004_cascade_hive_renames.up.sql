@@ -0,0 +1,18 @@
+-- Renaming a hive moves its logs, tasks, readings and alerts along, which
+-- failed once foreign keys were enforced
+
+ALTER TABLE "logs"
+    DROP CONSTRAINT "fk_hives_logs",
+    ADD CONSTRAINT "fk_hives_logs" FOREIGN KEY ("hive_id") REFERENCES "hives"("hive_name") ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE "tasks"
+    DROP CONSTRAINT "fk_hives_tasks",
+    ADD CONSTRAINT "fk_hives_tasks" FOREIGN KEY ("hive_id") REFERENCES "hives"("hive_name") ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE "telemetries"
+    DROP CONSTRAINT "fk_hives_telemetry",
+    ADD CONSTRAINT "fk_hives_telemetry" FOREIGN KEY ("hive_id") REFERENCES "hives"("hive_name") ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE "alerts"
+    DROP CONSTRAINT "fk_hives_alerts",
+    ADD CONSTRAINT "fk_hives_alerts" FOREIGN KEY ("hive_id") REFERENCES "hives"("hive_name") ON DELETE CASCADE ON UPDATE CASCADE;
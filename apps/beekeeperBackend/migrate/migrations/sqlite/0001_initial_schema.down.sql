@@ -0,0 +1,22 @@
+DROP TABLE IF EXISTS `equipment_moves`;
+DROP TABLE IF EXISTS `harvests`;
+DROP TABLE IF EXISTS `incidents`;
+DROP TABLE IF EXISTS `task_tags`;
+DROP TABLE IF EXISTS `log_tags`;
+DROP TABLE IF EXISTS `hive_tags`;
+DROP TABLE IF EXISTS `task_reminders`;
+DROP TABLE IF EXISTS `notification_preferences`;
+DROP TABLE IF EXISTS `webhook_deliveries`;
+DROP TABLE IF EXISTS `webhooks`;
+DROP TABLE IF EXISTS `alerts`;
+DROP TABLE IF EXISTS `telemetries`;
+DROP TABLE IF EXISTS `invitations`;
+DROP TABLE IF EXISTS `team_members`;
+DROP TABLE IF EXISTS `api_tokens`;
+DROP TABLE IF EXISTS `users`;
+DROP TABLE IF EXISTS `tasks`;
+DROP TABLE IF EXISTS `log_weathers`;
+DROP TABLE IF EXISTS `logs`;
+DROP TABLE IF EXISTS `hives`;
+DROP TABLE IF EXISTS `apiaries`;
+DROP TABLE IF EXISTS `tags`;
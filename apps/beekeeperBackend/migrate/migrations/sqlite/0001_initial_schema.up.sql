@@ -0,0 +1,299 @@
+-- Baseline: the schema AutoMigrate created before versioned migrations
+
+CREATE TABLE `tags` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `name` text NOT NULL,
+    `created_at` datetime
+);
+
+CREATE UNIQUE INDEX `idx_tags_name` ON `tags`(`name`);
+
+CREATE TABLE `apiaries` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `name` text NOT NULL,
+    `registration_number` text,
+    `address` text,
+    `latitude` real NOT NULL,
+    `longitude` real NOT NULL,
+    `boundary` text,
+    `notes` text,
+    `created_at` datetime,
+    `updated_at` datetime
+);
+
+CREATE INDEX `idx_apiaries_latitude` ON `apiaries`(`latitude`);
+
+CREATE TABLE `hives` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `hive_name` integer NOT NULL,
+    `apiary_id` integer,
+    `quarantined_since` datetime,
+    `created_at` datetime,
+    `updated_at` datetime,
+    CONSTRAINT `fk_apiaries_hives` FOREIGN KEY (`apiary_id`) REFERENCES `apiaries`(`id`) ON DELETE SET NULL,
+    CONSTRAINT `uni_hives_hive_name` UNIQUE (`hive_name`)
+);
+
+CREATE INDEX `idx_hives_apiary_id` ON `hives`(`apiary_id`);
+
+CREATE TABLE `logs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `hive_id` integer NOT NULL,
+    `content` text NOT NULL,
+    `created_at` datetime,
+    `updated_at` datetime,
+    CONSTRAINT `fk_hives_logs` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE
+);
+
+CREATE TABLE `log_weathers` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `log_id` integer NOT NULL,
+    `provider` text,
+    `temperature_c` real,
+    `wind_speed_kmh` real,
+    `cloud_cover_pct` real,
+    `precipitation_mm` real,
+    `observed_at` datetime,
+    CONSTRAINT `fk_logs_weather` FOREIGN KEY (`log_id`) REFERENCES `logs`(`id`) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX `idx_log_weathers_log_id` ON `log_weathers`(`log_id`);
+
+CREATE TABLE `tasks` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `hive_id` integer NOT NULL,
+    `content` text NOT NULL,
+    `priority` text NOT NULL DEFAULT 'normal',
+    `due_at` datetime,
+    `completed_at` datetime,
+    `assignee_id` integer,
+    `created_at` datetime,
+    `updated_at` datetime,
+    CONSTRAINT `fk_hives_tasks` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_tasks_assignee_id` ON `tasks`(`assignee_id`);
+
+CREATE TABLE `users` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `name` text NOT NULL,
+    `email` text NOT NULL,
+    `role` text NOT NULL DEFAULT 'owner',
+    `calendar_token_hash` text NOT NULL,
+    `oidc_subject` text,
+    `created_at` datetime,
+    `updated_at` datetime
+);
+
+CREATE UNIQUE INDEX `idx_users_oidc_subject` ON `users`(`oidc_subject`);
+
+CREATE UNIQUE INDEX `idx_users_calendar_token_hash` ON `users`(`calendar_token_hash`);
+
+CREATE UNIQUE INDEX `idx_users_email` ON `users`(`email`);
+
+CREATE TABLE `api_tokens` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer NOT NULL,
+    `name` text,
+    `token_hash` text NOT NULL,
+    `last_used_at` datetime,
+    `created_at` datetime,
+    CONSTRAINT `fk_api_tokens_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX `idx_api_tokens_token_hash` ON `api_tokens`(`token_hash`);
+
+CREATE INDEX `idx_api_tokens_user_id` ON `api_tokens`(`user_id`);
+
+CREATE TABLE `team_members` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `apiary_id` integer NOT NULL,
+    `user_id` integer NOT NULL,
+    `role` text NOT NULL DEFAULT 'editor',
+    `expires_at` datetime,
+    `created_at` datetime,
+    CONSTRAINT `fk_team_members_apiary` FOREIGN KEY (`apiary_id`) REFERENCES `apiaries`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_team_members_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+
+CREATE INDEX `idx_team_members_user_id` ON `team_members`(`user_id`);
+
+CREATE UNIQUE INDEX `idx_team_members_unique` ON `team_members`(`apiary_id`,`user_id`);
+
+CREATE TABLE `invitations` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `apiary_id` integer NOT NULL,
+    `email` text NOT NULL,
+    `role` text NOT NULL,
+    `token_hash` text NOT NULL,
+    `invited_by_id` integer,
+    `expires_at` datetime,
+    `access_expires_at` datetime,
+    `accepted_at` datetime,
+    `created_at` datetime,
+    CONSTRAINT `fk_invitations_apiary` FOREIGN KEY (`apiary_id`) REFERENCES `apiaries`(`id`) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX `idx_invitations_token_hash` ON `invitations`(`token_hash`);
+
+CREATE INDEX `idx_invitations_apiary_id` ON `invitations`(`apiary_id`);
+
+CREATE TABLE `telemetries` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `hive_id` integer NOT NULL,
+    `metric` text NOT NULL,
+    `value` real NOT NULL,
+    `topic` text,
+    `recorded_at` datetime NOT NULL,
+    `created_at` datetime,
+    CONSTRAINT `fk_hives_telemetry` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_telemetry_hive_metric` ON `telemetries`(`hive_id`,`metric`,`recorded_at`);
+
+CREATE TABLE `alerts` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `hive_id` integer NOT NULL,
+    `detector` text NOT NULL,
+    `message` text NOT NULL,
+    `value` real,
+    `task_id` integer,
+    `log_id` integer,
+    `created_at` datetime,
+    CONSTRAINT `fk_hives_alerts` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_alerts_hive_detector` ON `alerts`(`hive_id`,`detector`,`created_at`);
+
+CREATE TABLE `webhooks` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `url` text NOT NULL,
+    `events` text NOT NULL,
+    `secret` text NOT NULL,
+    `description` text,
+    `active` numeric NOT NULL DEFAULT true,
+    `created_at` datetime,
+    `updated_at` datetime
+);
+
+CREATE TABLE `webhook_deliveries` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `webhook_id` integer NOT NULL,
+    `event_id` integer,
+    `event_type` text NOT NULL,
+    `payload` text NOT NULL,
+    `status` text NOT NULL,
+    `attempts` integer,
+    `next_attempt_at` datetime,
+    `response_code` integer,
+    `last_error` text,
+    `delivered_at` datetime,
+    `created_at` datetime,
+    `updated_at` datetime,
+    CONSTRAINT `fk_webhooks_deliveries` FOREIGN KEY (`webhook_id`) REFERENCES `webhooks`(`id`) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_deliveries_due` ON `webhook_deliveries`(`status`,`next_attempt_at`);
+
+CREATE INDEX `idx_webhook_deliveries_webhook_id` ON `webhook_deliveries`(`webhook_id`);
+
+CREATE TABLE `notification_preferences` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer NOT NULL,
+    `channels` text NOT NULL,
+    `webhook_url` text,
+    `ntfy_topic` text,
+    `timezone` text NOT NULL DEFAULT 'UTC',
+    `quiet_hours_start` text,
+    `quiet_hours_end` text,
+    `digest` numeric,
+    `digest_time` text NOT NULL DEFAULT '07:00',
+    `last_digest_at` datetime,
+    `created_at` datetime,
+    `updated_at` datetime,
+    CONSTRAINT `fk_notification_preferences_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX `idx_notification_preferences_user_id` ON `notification_preferences`(`user_id`);
+
+CREATE TABLE `task_reminders` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `task_id` integer NOT NULL,
+    `user_id` integer NOT NULL,
+    `kind` text NOT NULL,
+    `sent_at` datetime,
+    CONSTRAINT `fk_task_reminders_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_task_reminders_task` FOREIGN KEY (`task_id`) REFERENCES `tasks`(`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `hive_tags` (
+    `hive_id` integer,
+    `tag_id` integer,
+    PRIMARY KEY (`hive_id`,`tag_id`),
+    CONSTRAINT `fk_hive_tags_hive` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`id`),
+    CONSTRAINT `fk_hive_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`)
+);
+
+CREATE TABLE `log_tags` (
+    `log_id` integer,
+    `tag_id` integer,
+    PRIMARY KEY (`log_id`,`tag_id`),
+    CONSTRAINT `fk_log_tags_log` FOREIGN KEY (`log_id`) REFERENCES `logs`(`id`),
+    CONSTRAINT `fk_log_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`)
+);
+
+CREATE TABLE `task_tags` (
+    `task_id` integer,
+    `tag_id` integer,
+    PRIMARY KEY (`task_id`,`tag_id`),
+    CONSTRAINT `fk_task_tags_task` FOREIGN KEY (`task_id`) REFERENCES `tasks`(`id`),
+    CONSTRAINT `fk_task_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`)
+);
+
+CREATE UNIQUE INDEX `idx_task_reminders_unique` ON `task_reminders`(`task_id`,`user_id`,`kind`);
+
+CREATE TABLE `incidents` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `hive_id` integer NOT NULL,
+    `log_id` integer,
+    `disease` text NOT NULL,
+    `status` text NOT NULL,
+    `sample_sent_at` datetime,
+    `sample_reference` text,
+    `lab_result` text,
+    `lab_result_at` datetime,
+    `actions_taken` text,
+    `reported_to_authority_at` datetime,
+    `cleared_at` datetime,
+    `created_at` datetime,
+    `updated_at` datetime
+);
+
+CREATE INDEX `idx_incidents_status` ON `incidents`(`status`);
+
+CREATE INDEX `idx_incidents_hive_id` ON `incidents`(`hive_id`);
+
+CREATE TABLE `harvests` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `hive_id` integer NOT NULL,
+    `product` text NOT NULL DEFAULT 'honey',
+    `weight_kg` real NOT NULL,
+    `harvested_at` datetime NOT NULL,
+    `notes` text,
+    `created_at` datetime
+);
+
+CREATE INDEX `idx_harvests_hive_id` ON `harvests`(`hive_id`);
+
+CREATE TABLE `equipment_moves` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `from_hive_id` integer NOT NULL,
+    `to_hive_id` integer,
+    `equipment` text NOT NULL,
+    `quantity` integer NOT NULL DEFAULT 1,
+    `moved_at` datetime NOT NULL,
+    `notes` text,
+    `created_at` datetime
+);
+
+CREATE INDEX `idx_equipment_moves_from_hive_id` ON `equipment_moves`(`from_hive_id`);
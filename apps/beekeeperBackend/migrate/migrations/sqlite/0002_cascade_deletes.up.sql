@@ -0,0 +1,58 @@
+-- Deleting a hive, log, task, tag or user also deletes its tag links and
+-- team memberships, which failed once foreign keys were enforced
+
+-- SQLite cannot alter constraints, so the tables are rebuilt. Links to rows
+-- deleted while foreign keys were not enforced are dropped.
+
+CREATE TABLE `hive_tags_rebuild` (
+    `hive_id` integer,
+    `tag_id` integer,
+    PRIMARY KEY (`hive_id`,`tag_id`),
+    CONSTRAINT `fk_hive_tags_hive` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_hive_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`) ON DELETE CASCADE
+);
+INSERT INTO `hive_tags_rebuild` SELECT `hive_id`, `tag_id` FROM `hive_tags`
+    WHERE `hive_id` IN (SELECT `id` FROM `hives`) AND `tag_id` IN (SELECT `id` FROM `tags`);
+DROP TABLE `hive_tags`;
+ALTER TABLE `hive_tags_rebuild` RENAME TO `hive_tags`;
+
+CREATE TABLE `log_tags_rebuild` (
+    `log_id` integer,
+    `tag_id` integer,
+    PRIMARY KEY (`log_id`,`tag_id`),
+    CONSTRAINT `fk_log_tags_log` FOREIGN KEY (`log_id`) REFERENCES `logs`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_log_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`) ON DELETE CASCADE
+);
+INSERT INTO `log_tags_rebuild` SELECT `log_id`, `tag_id` FROM `log_tags`
+    WHERE `log_id` IN (SELECT `id` FROM `logs`) AND `tag_id` IN (SELECT `id` FROM `tags`);
+DROP TABLE `log_tags`;
+ALTER TABLE `log_tags_rebuild` RENAME TO `log_tags`;
+
+CREATE TABLE `task_tags_rebuild` (
+    `task_id` integer,
+    `tag_id` integer,
+    PRIMARY KEY (`task_id`,`tag_id`),
+    CONSTRAINT `fk_task_tags_task` FOREIGN KEY (`task_id`) REFERENCES `tasks`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_task_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`) ON DELETE CASCADE
+);
+INSERT INTO `task_tags_rebuild` SELECT `task_id`, `tag_id` FROM `task_tags`
+    WHERE `task_id` IN (SELECT `id` FROM `tasks`) AND `tag_id` IN (SELECT `id` FROM `tags`);
+DROP TABLE `task_tags`;
+ALTER TABLE `task_tags_rebuild` RENAME TO `task_tags`;
+
+CREATE TABLE `team_members_rebuild` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `apiary_id` integer NOT NULL,
+    `user_id` integer NOT NULL,
+    `role` text NOT NULL DEFAULT 'editor',
+    `expires_at` datetime,
+    `created_at` datetime,
+    CONSTRAINT `fk_team_members_apiary` FOREIGN KEY (`apiary_id`) REFERENCES `apiaries`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_team_members_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+INSERT INTO `team_members_rebuild` SELECT `id`, `apiary_id`, `user_id`, `role`, `expires_at`, `created_at` FROM `team_members`
+    WHERE `apiary_id` IN (SELECT `id` FROM `apiaries`) AND `user_id` IN (SELECT `id` FROM `users`);
+DROP TABLE `team_members`;
+ALTER TABLE `team_members_rebuild` RENAME TO `team_members`;
+CREATE INDEX `idx_team_members_user_id` ON `team_members`(`user_id`);
+CREATE UNIQUE INDEX `idx_team_members_unique` ON `team_members`(`apiary_id`,`user_id`);
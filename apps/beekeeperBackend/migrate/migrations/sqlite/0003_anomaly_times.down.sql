@@ -0,0 +1,7 @@
+-- Restore the cooldown on alert creation times and drop apiary time zones
+
+DROP INDEX `idx_alerts_hive_detector`;
+ALTER TABLE `alerts` DROP COLUMN `recorded_at`;
+CREATE INDEX `idx_alerts_hive_detector` ON `alerts`(`hive_id`,`detector`,`created_at`);
+
+ALTER TABLE `apiaries` DROP COLUMN `timezone`;
@@ -0,0 +1,76 @@
+-- Renaming a hive moves its logs, tasks, readings and alerts along, which
+-- failed once foreign keys were enforced
+
+-- SQLite cannot alter constraints, so the tables are rebuilt. The migrator
+-- switches foreign keys off meanwhile, as dropping a table would otherwise
+-- delete the weather and tags of its logs and the reminders of its tasks.
+-- The autoincrement counters are carried over so IDs are not reused.
+
+CREATE TABLE `logs_rebuild` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `hive_id` integer NOT NULL,
+    `content` text NOT NULL,
+    `created_at` datetime,
+    `updated_at` datetime,
+    CONSTRAINT `fk_hives_logs` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE ON UPDATE CASCADE
+);
+INSERT INTO `logs_rebuild` (`id`, `hive_id`, `content`, `created_at`, `updated_at`) SELECT `id`, `hive_id`, `content`, `created_at`, `updated_at` FROM `logs`;
+DELETE FROM `sqlite_sequence` WHERE `name` = 'logs_rebuild';
+INSERT INTO `sqlite_sequence` (`name`, `seq`) SELECT 'logs_rebuild', `seq` FROM `sqlite_sequence` WHERE `name` = 'logs';
+DROP TABLE `logs`;
+ALTER TABLE `logs_rebuild` RENAME TO `logs`;
+
+CREATE TABLE `tasks_rebuild` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `hive_id` integer NOT NULL,
+    `content` text NOT NULL,
+    `priority` text NOT NULL DEFAULT 'normal',
+    `due_at` datetime,
+    `completed_at` datetime,
+    `assignee_id` integer,
+    `created_at` datetime,
+    `updated_at` datetime,
+    CONSTRAINT `fk_hives_tasks` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE ON UPDATE CASCADE
+);
+INSERT INTO `tasks_rebuild` (`id`, `hive_id`, `content`, `priority`, `due_at`, `completed_at`, `assignee_id`, `created_at`, `updated_at`) SELECT `id`, `hive_id`, `content`, `priority`, `due_at`, `completed_at`, `assignee_id`, `created_at`, `updated_at` FROM `tasks`;
+DELETE FROM `sqlite_sequence` WHERE `name` = 'tasks_rebuild';
+INSERT INTO `sqlite_sequence` (`name`, `seq`) SELECT 'tasks_rebuild', `seq` FROM `sqlite_sequence` WHERE `name` = 'tasks';
+DROP TABLE `tasks`;
+ALTER TABLE `tasks_rebuild` RENAME TO `tasks`;
+CREATE INDEX `idx_tasks_assignee_id` ON `tasks`(`assignee_id`);
+
+CREATE TABLE `telemetries_rebuild` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `hive_id` integer NOT NULL,
+    `metric` text NOT NULL,
+    `value` real NOT NULL,
+    `topic` text,
+    `recorded_at` datetime NOT NULL,
+    `created_at` datetime,
+    CONSTRAINT `fk_hives_telemetry` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE ON UPDATE CASCADE
+);
+INSERT INTO `telemetries_rebuild` (`id`, `hive_id`, `metric`, `value`, `topic`, `recorded_at`, `created_at`) SELECT `id`, `hive_id`, `metric`, `value`, `topic`, `recorded_at`, `created_at` FROM `telemetries`;
+DELETE FROM `sqlite_sequence` WHERE `name` = 'telemetries_rebuild';
+INSERT INTO `sqlite_sequence` (`name`, `seq`) SELECT 'telemetries_rebuild', `seq` FROM `sqlite_sequence` WHERE `name` = 'telemetries';
+DROP TABLE `telemetries`;
+ALTER TABLE `telemetries_rebuild` RENAME TO `telemetries`;
+CREATE INDEX `idx_telemetry_hive_metric` ON `telemetries`(`hive_id`,`metric`,`recorded_at`);
+
+CREATE TABLE `alerts_rebuild` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `hive_id` integer NOT NULL,
+    `detector` text NOT NULL,
+    `message` text NOT NULL,
+    `value` real,
+    `task_id` integer,
+    `log_id` integer,
+    `created_at` datetime,
+    `recorded_at` datetime,
+    CONSTRAINT `fk_hives_alerts` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE ON UPDATE CASCADE
+);
+INSERT INTO `alerts_rebuild` (`id`, `hive_id`, `detector`, `message`, `value`, `task_id`, `log_id`, `created_at`, `recorded_at`) SELECT `id`, `hive_id`, `detector`, `message`, `value`, `task_id`, `log_id`, `created_at`, `recorded_at` FROM `alerts`;
+DELETE FROM `sqlite_sequence` WHERE `name` = 'alerts_rebuild';
+INSERT INTO `sqlite_sequence` (`name`, `seq`) SELECT 'alerts_rebuild', `seq` FROM `sqlite_sequence` WHERE `name` = 'alerts';
+DROP TABLE `alerts`;
+ALTER TABLE `alerts_rebuild` RENAME TO `alerts`;
+CREATE INDEX `idx_alerts_hive_detector` ON `alerts`(`hive_id`,`detector`,`recorded_at`);
@@ -0,0 +1,10 @@
+-- A database created by AutoMigrate of the first release, before there were
+-- migrations, users or apiaries
+CREATE TABLE `hives` (`id` integer PRIMARY KEY AUTOINCREMENT,`hive_name` integer NOT NULL,`created_at` datetime,`updated_at` datetime,CONSTRAINT `uni_hives_hive_name` UNIQUE (`hive_name`));
+CREATE TABLE `logs` (`id` integer PRIMARY KEY AUTOINCREMENT,`hive_id` integer NOT NULL,`content` text NOT NULL,`created_at` datetime,`updated_at` datetime,CONSTRAINT `fk_hives_logs` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE);
+CREATE TABLE `tasks` (`id` integer PRIMARY KEY AUTOINCREMENT,`hive_id` integer NOT NULL,`content` text NOT NULL,`created_at` datetime,`updated_at` datetime,CONSTRAINT `fk_hives_tasks` FOREIGN KEY (`hive_id`) REFERENCES `hives`(`hive_name`) ON DELETE CASCADE);
+INSERT INTO `hives` (`hive_name`,`created_at`,`updated_at`) VALUES (1,'2024-04-02 09:00:00+00:00','2024-04-02 09:00:00+00:00');
+INSERT INTO `hives` (`hive_name`,`created_at`,`updated_at`) VALUES (2,'2024-04-02 09:05:00+00:00','2024-04-02 09:05:00+00:00');
+INSERT INTO `logs` (`hive_id`,`content`,`created_at`,`updated_at`) VALUES (1,'Queen seen, 6 frames of brood','2024-04-10 11:00:00+00:00','2024-04-10 11:00:00+00:00');
+INSERT INTO `logs` (`hive_id`,`content`,`created_at`,`updated_at`) VALUES (2,'Calm, stores low','2024-04-10 11:20:00+00:00','2024-04-10 11:20:00+00:00');
+INSERT INTO `tasks` (`hive_id`,`content`,`created_at`,`updated_at`) VALUES (2,'Feed syrup','2024-04-10 11:25:00+00:00','2024-04-10 11:25:00+00:00');
@@ -4,29 +4,315 @@ import "time"
 
 // Hive represents a beehive in the management system
 type Hive struct {
+	ID       uint  `json:"id" gorm:"primaryKey" example:"1"`
+	HiveName int   `json:"hive_name" gorm:"unique;not null" example:"123"`
+	ApiaryID *uint `json:"apiary_id" gorm:"index" example:"1"`
+	// QuarantinedSince is set while the hive has an open disease incident
+	QuarantinedSince *time.Time  `json:"quarantined_since" example:"2024-01-15T10:30:00Z"`
+	CreatedAt        time.Time   `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt        time.Time   `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+	Logs             []Log       `json:"logs,omitempty" gorm:"foreignKey:HiveID;references:HiveName;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Tasks            []Task      `json:"tasks,omitempty" gorm:"foreignKey:HiveID;references:HiveName;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Telemetry        []Telemetry `json:"-" gorm:"foreignKey:HiveID;references:HiveName;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Alerts           []Alert     `json:"-" gorm:"foreignKey:HiveID;references:HiveName;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Tags             []Tag       `json:"tags,omitempty" gorm:"many2many:hive_tags;constraint:OnDelete:CASCADE;"`
+}
+
+// Apiary represents a location where hives are kept. Boundary is an optional
+// closed ring of [longitude, latitude] points in GeoJSON order. Timezone is the
+// IANA time zone of the location, empty for ANOMALY_TIMEZONE. MembersOnly
+// limits its hives to the members of its team and global owners.
+type Apiary struct {
+	ID                 uint         `json:"id" gorm:"primaryKey" example:"1"`
+	Name               string       `json:"name" gorm:"not null" example:"Orchard"`
+	RegistrationNumber string       `json:"registration_number" example:"276 09 162 0001"`
+	Address            string       `json:"address" example:"Am Obstgarten 3, 85354 Freising"`
+	Latitude           float64      `json:"latitude" gorm:"not null;index" example:"48.4029"`
+	Longitude          float64      `json:"longitude" gorm:"not null" example:"11.7489"`
+	Boundary           [][2]float64 `json:"boundary" gorm:"serializer:json"`
+	Notes              string       `json:"notes" example:"Access via the gate on the north side"`
+	Timezone           string       `json:"timezone" gorm:"-:migration" example:"Europe/Berlin"`
+	MembersOnly        bool         `json:"members_only" gorm:"-:migration;not null;default:false" example:"false"`
+	CreatedAt          time.Time    `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt          time.Time    `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+	Hives              []Hive       `json:"hives,omitempty" gorm:"constraint:OnDelete:SET NULL;"`
+}
+
+// Tag is a label such as "swarm-prevention" shared by hives, logs and tasks
+type Tag struct {
 	ID        uint      `json:"id" gorm:"primaryKey" example:"1"`
-	HiveName  int       `json:"hive_name" gorm:"unique;not null" example:"123"`
-	CreatedAt time.Time `json:"created_at" example:"2024-01-15T10:30:00Z"`
-	UpdatedAt time.Time `json:"updated_at" example:"2024-01-15T10:30:00Z"`
-	Logs      []Log     `json:"logs,omitempty" gorm:"foreignKey:HiveID;references:HiveName;constraint:OnDelete:CASCADE;"`
-	Tasks     []Task    `json:"tasks,omitempty" gorm:"foreignKey:HiveID;references:HiveName;constraint:OnDelete:CASCADE;"`
+	Name      string    `json:"name" gorm:"size:100;uniqueIndex;not null" example:"swarm-prevention"`
+	CreatedAt time.Time `json:"-"`
 }
 
 // Log represents a log entry for a beehive
 type Log struct {
-	ID        uint      `json:"id" gorm:"primaryKey" example:"1"`
-	HiveID    int       `json:"hive_id" gorm:"not null" example:"123"`
-	Content   string    `json:"content" gorm:"not null" example:"Hive inspection completed. Queen spotted, brood pattern looks healthy."`
-	CreatedAt time.Time `json:"created_at" example:"2024-01-15T10:30:00Z"`
-	UpdatedAt time.Time `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+	ID        uint        `json:"id" gorm:"primaryKey" example:"1"`
+	HiveID    int         `json:"hive_id" gorm:"not null" example:"123"`
+	Content   string      `json:"content" gorm:"not null" example:"Hive inspection completed. Queen spotted, brood pattern looks healthy."`
+	CreatedAt time.Time   `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt time.Time   `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+	Weather   *LogWeather `json:"weather,omitempty" gorm:"constraint:OnDelete:CASCADE;"`
+	Tags      []Tag       `json:"tags,omitempty" gorm:"many2many:log_tags;constraint:OnDelete:CASCADE;"`
 }
 
+// LogWeather is the weather at the hive's apiary when a log entry was written
+type LogWeather struct {
+	ID              uint      `json:"-" gorm:"primaryKey"`
+	LogID           uint      `json:"-" gorm:"uniqueIndex;not null"`
+	Provider        string    `json:"provider" example:"open-meteo"`
+	TemperatureC    float64   `json:"temperature_c" example:"21.4"`
+	WindSpeedKmh    float64   `json:"wind_speed_kmh" example:"7.2"`
+	CloudCoverPct   float64   `json:"cloud_cover_pct" example:"30"`
+	PrecipitationMm float64   `json:"precipitation_mm" example:"0"`
+	ObservedAt      time.Time `json:"observed_at" example:"2024-01-15T10:00:00Z"`
+}
+
+// Task priorities
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+)
+
 // Task represents a task entry for a beehive
 type Task struct {
-	ID        uint      `json:"id" gorm:"primaryKey" example:"1"`
-	HiveID    int       `json:"hive_id" gorm:"not null" example:"123"`
-	Content   string    `json:"content" gorm:"not null" example:"Check honey levels and replace frames"`
-	CreatedAt time.Time `json:"created_at" example:"2024-01-15T10:30:00Z"`
-	UpdatedAt time.Time `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+	ID          uint       `json:"id" gorm:"primaryKey" example:"1"`
+	HiveID      int        `json:"hive_id" gorm:"not null" example:"123"`
+	Content     string     `json:"content" gorm:"not null" example:"Check honey levels and replace frames"`
+	Priority    string     `json:"priority" gorm:"not null;default:normal" example:"normal"`
+	DueAt       *time.Time `json:"due_at" example:"2024-01-20T09:00:00Z"`
+	CompletedAt *time.Time `json:"completed_at" example:"2024-01-16T09:00:00Z"`
+	AssigneeID  *uint      `json:"assignee_id" gorm:"index" example:"1"`
+	Tags        []Tag      `json:"tags,omitempty" gorm:"many2many:task_tags;constraint:OnDelete:CASCADE;"`
+	CreatedAt   time.Time  `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt   time.Time  `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+}
+
+// User represents a person using the beekeeping journal
+type User struct {
+	ID                uint      `json:"id" gorm:"primaryKey" example:"1"`
+	Name              string    `json:"name" gorm:"not null" example:"Ana Beekeeper"`
+	Email             string    `json:"email" gorm:"size:255;uniqueIndex;not null" example:"ana@example.com"`
+	Role              string    `json:"role" gorm:"not null;default:owner" example:"owner"`
+	CalendarTokenHash string    `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	OIDCSubject       *string   `json:"-" gorm:"column:oidc_subject;size:255;uniqueIndex:idx_users_oidc_subject"`
+	CreatedAt         time.Time `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt         time.Time `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+}
+
+// APIToken is a secret a user authenticates API requests with, sent as
+// "Authorization: Bearer <token>"
+type APIToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey" example:"1"`
+	UserID     uint       `json:"user_id" gorm:"not null;index" example:"1"`
+	User       User       `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	Name       string     `json:"name" example:"Phone app"`
+	TokenHash  string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	LastUsedAt *time.Time `json:"last_used_at" example:"2024-01-15T10:30:00Z"`
+	CreatedAt  time.Time  `json:"created_at" example:"2024-01-15T10:30:00Z"`
+}
+
+// Roles, from most to least privileged. Users have a global role for hives
+// outside managed apiaries; team members have a role per apiary.
+const (
+	RoleOwner     = "owner"
+	RoleEditor    = "editor"
+	RoleViewer    = "viewer"
+	RoleInspector = "inspector"
+	RoleNone      = "none"
+)
+
+// TeamMember gives a user a role in an apiary, on top of their global role.
+// In members only apiaries the membership is the only role besides a global
+// owner's. Memberships with an expiry date, e.g. for external inspectors,
+// stop granting access after it.
+type TeamMember struct {
+	ID        uint       `json:"id" gorm:"primaryKey" example:"1"`
+	ApiaryID  uint       `json:"apiary_id" gorm:"not null;uniqueIndex:idx_team_members_unique,priority:1" example:"1"`
+	Apiary    Apiary     `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	UserID    uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_team_members_unique,priority:2;index" example:"1"`
+	User      User       `json:"user" gorm:"constraint:OnDelete:CASCADE;"`
+	Role      string     `json:"role" gorm:"not null;default:editor" example:"editor"`
+	ExpiresAt *time.Time `json:"expires_at" example:"2024-02-15T00:00:00Z"`
+	CreatedAt time.Time  `json:"created_at" example:"2024-01-15T10:30:00Z"`
+}
+
+// Invitation invites someone by email to join an apiary's team with a role
+type Invitation struct {
+	ID              uint       `json:"id" gorm:"primaryKey" example:"1"`
+	ApiaryID        uint       `json:"apiary_id" gorm:"not null;index" example:"1"`
+	Apiary          Apiary     `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	Email           string     `json:"email" gorm:"not null" example:"inspector@veterinary.example"`
+	Role            string     `json:"role" gorm:"not null" example:"inspector"`
+	TokenHash       string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	InvitedByID     *uint      `json:"invited_by_id" example:"1"`
+	ExpiresAt       time.Time  `json:"expires_at" example:"2024-01-22T10:30:00Z"`
+	AccessExpiresAt *time.Time `json:"access_expires_at" example:"2024-02-15T00:00:00Z"`
+	AcceptedAt      *time.Time `json:"accepted_at" example:"2024-01-16T08:00:00Z"`
+	CreatedAt       time.Time  `json:"created_at" example:"2024-01-15T10:30:00Z"`
+}
+
+// Telemetry represents a single sensor reading (weight, temperature, ...) for a beehive
+type Telemetry struct {
+	ID         uint      `json:"id" gorm:"primaryKey" example:"1"`
+	HiveID     int       `json:"hive_id" gorm:"not null;index:idx_telemetry_hive_metric,priority:1" example:"123"`
+	Metric     string    `json:"metric" gorm:"not null;index:idx_telemetry_hive_metric,priority:2" example:"weight"`
+	Value      float64   `json:"value" gorm:"not null" example:"42.7"`
+	Topic      string    `json:"topic" example:"apiary/home/hive/123/weight"`
+	RecordedAt time.Time `json:"recorded_at" gorm:"not null;index:idx_telemetry_hive_metric,priority:3" example:"2024-01-15T10:30:00Z"`
+	CreatedAt  time.Time `json:"created_at" example:"2024-01-15T10:30:00Z"`
 }
 
+// Alert represents an anomaly detected in a hive's telemetry, e.g. a possible
+// swarm. RecordedAt is the time of the reading that raised it.
+type Alert struct {
+	ID         uint      `json:"id" gorm:"primaryKey" example:"1"`
+	HiveID     int       `json:"hive_id" gorm:"not null;index:idx_alerts_hive_detector,priority:1" example:"123"`
+	Detector   string    `json:"detector" gorm:"not null;index:idx_alerts_hive_detector,priority:2" example:"step_drop"`
+	Message    string    `json:"message" gorm:"not null" example:"Weight dropped 2.4 kg within 15m0s, possible swarm"`
+	Value      float64   `json:"value" example:"39.1"`
+	TaskID     *uint     `json:"task_id" example:"1"`
+	LogID      *uint     `json:"log_id" example:"1"`
+	RecordedAt time.Time `json:"recorded_at" gorm:"-:migration" example:"2024-01-15T10:25:00Z"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index:idx_alerts_hive_detector,priority:3" example:"2024-01-15T10:30:00Z"`
+}
+
+// Webhook represents an outbound subscription to API events
+type Webhook struct {
+	ID          uint              `json:"id" gorm:"primaryKey" example:"1"`
+	URL         string            `json:"url" gorm:"not null" example:"https://homeassistant.local/api/webhook/beekeeper"`
+	Events      []string          `json:"events" gorm:"serializer:json;not null" example:"log.created,task.completed"`
+	Secret      string            `json:"-" gorm:"not null"`
+	Description string            `json:"description" example:"Home Assistant"`
+	Active      bool              `json:"active" gorm:"not null;default:true" example:"true"`
+	CreatedAt   time.Time         `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt   time.Time         `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+	Deliveries  []WebhookDelivery `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// Webhook delivery states
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one queued or attempted delivery of an event to a webhook
+type WebhookDelivery struct {
+	ID            uint       `json:"id" gorm:"primaryKey" example:"1"`
+	WebhookID     uint       `json:"webhook_id" gorm:"not null;index" example:"1"`
+	EventID       uint64     `json:"event_id" example:"1705314600000001"`
+	EventType     string     `json:"event_type" gorm:"not null" example:"log.created"`
+	Payload       string     `json:"payload" gorm:"not null"`
+	Status        string     `json:"status" gorm:"not null;index:idx_deliveries_due,priority:1" example:"pending"`
+	Attempts      int        `json:"attempts" example:"0"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"index:idx_deliveries_due,priority:2" example:"2024-01-15T10:30:00Z"`
+	ResponseCode  int        `json:"response_code" example:"200"`
+	LastError     string     `json:"last_error"`
+	DeliveredAt   *time.Time `json:"delivered_at" example:"2024-01-15T10:30:01Z"`
+	CreatedAt     time.Time  `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt     time.Time  `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+}
+
+// Notification channels a user can receive task reminders on
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelNtfy    = "ntfy"
+)
+
+// NotificationPreference holds how and when a user wants to be reminded of due tasks
+type NotificationPreference struct {
+	ID              uint       `json:"id" gorm:"primaryKey" example:"1"`
+	UserID          uint       `json:"user_id" gorm:"uniqueIndex;not null" example:"1"`
+	User            User       `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	Channels        []string   `json:"channels" gorm:"serializer:json;not null" example:"email,ntfy"`
+	WebhookURL      string     `json:"webhook_url" example:"https://example.com/hooks/reminders"`
+	NtfyTopic       string     `json:"ntfy_topic" example:"my-apiary-reminders"`
+	Timezone        string     `json:"timezone" gorm:"not null;default:UTC" example:"Europe/Berlin"`
+	QuietHoursStart string     `json:"quiet_hours_start" example:"22:00"`
+	QuietHoursEnd   string     `json:"quiet_hours_end" example:"07:00"`
+	Digest          bool       `json:"digest" example:"false"`
+	DigestTime      string     `json:"digest_time" gorm:"not null;default:07:00" example:"07:00"`
+	LastDigestAt    *time.Time `json:"last_digest_at" example:"2024-01-15T07:00:00Z"`
+	CreatedAt       time.Time  `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt       time.Time  `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+}
+
+// Reminder kinds
+const (
+	ReminderDueSoon = "due_soon"
+	ReminderOverdue = "overdue"
+)
+
+// TaskReminder records that a user was reminded of a task, so each kind of
+// reminder is only sent once
+type TaskReminder struct {
+	ID     uint      `json:"id" gorm:"primaryKey" example:"1"`
+	TaskID uint      `json:"task_id" gorm:"not null;uniqueIndex:idx_task_reminders_unique,priority:1" example:"1"`
+	Task   Task      `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	UserID uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_task_reminders_unique,priority:2" example:"1"`
+	User   User      `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	Kind   string    `json:"kind" gorm:"size:32;not null;uniqueIndex:idx_task_reminders_unique,priority:3" example:"due_soon"`
+	SentAt time.Time `json:"sent_at" example:"2024-01-15T10:30:00Z"`
+}
+
+// Notifiable diseases and pests
+const (
+	DiseaseAFB          = "afb"
+	DiseaseEFB          = "efb"
+	DiseaseSHB          = "shb"
+	DiseaseTropilaelaps = "tropilaelaps"
+	DiseaseAsianHornet  = "asian_hornet"
+	DiseaseOther        = "other"
+)
+
+// Incident states. A hive stays under quarantine until all its incidents are cleared.
+const (
+	IncidentSuspected = "suspected"
+	IncidentConfirmed = "confirmed"
+	IncidentCleared   = "cleared"
+)
+
+// Incident is a suspected or confirmed case of a notifiable disease or pest in a hive
+type Incident struct {
+	ID                    uint       `json:"id" gorm:"primaryKey" example:"1"`
+	HiveID                int        `json:"hive_id" gorm:"not null;index" example:"123"`
+	LogID                 *uint      `json:"log_id" example:"1"`
+	Disease               string     `json:"disease" gorm:"not null" example:"afb"`
+	Status                string     `json:"status" gorm:"not null;index" example:"suspected"`
+	SampleSentAt          *time.Time `json:"sample_sent_at" example:"2024-01-16T09:00:00Z"`
+	SampleReference       string     `json:"sample_reference" example:"LAB-2024-0042"`
+	LabResult             string     `json:"lab_result" example:"Paenibacillus larvae detected"`
+	LabResultAt           *time.Time `json:"lab_result_at" example:"2024-01-22T09:00:00Z"`
+	ActionsTaken          string     `json:"actions_taken" example:"Hive entrance closed, veterinary office informed"`
+	ReportedToAuthorityAt *time.Time `json:"reported_to_authority_at" example:"2024-01-15T12:00:00Z"`
+	ClearedAt             *time.Time `json:"cleared_at" example:"2024-03-01T09:00:00Z"`
+	CreatedAt             time.Time  `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt             time.Time  `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+}
+
+// Harvest records products taken from a hive
+type Harvest struct {
+	ID          uint      `json:"id" gorm:"primaryKey" example:"1"`
+	HiveID      int       `json:"hive_id" gorm:"not null;index" example:"123"`
+	Product     string    `json:"product" gorm:"not null;default:honey" example:"honey"`
+	WeightKg    float64   `json:"weight_kg" gorm:"not null" example:"12.5"`
+	HarvestedAt time.Time `json:"harvested_at" gorm:"not null" example:"2024-07-20T10:00:00Z"`
+	Notes       string    `json:"notes" example:"Spring honey, 4 supers"`
+	CreatedAt   time.Time `json:"created_at" example:"2024-07-20T10:30:00Z"`
+}
+
+// EquipmentMove records equipment such as supers or frames leaving a hive,
+// either to another hive or to storage when ToHiveID is empty
+type EquipmentMove struct {
+	ID         uint      `json:"id" gorm:"primaryKey" example:"1"`
+	FromHiveID int       `json:"from_hive_id" gorm:"not null;index" example:"123"`
+	ToHiveID   *int      `json:"to_hive_id" example:"124"`
+	Equipment  string    `json:"equipment" gorm:"not null" example:"super"`
+	Quantity   int       `json:"quantity" gorm:"not null;default:1" example:"2"`
+	MovedAt    time.Time `json:"moved_at" gorm:"not null" example:"2024-05-02T10:00:00Z"`
+	Notes      string    `json:"notes" example:"Drawn comb for the new split"`
+	CreatedAt  time.Time `json:"created_at" example:"2024-05-02T10:30:00Z"`
+}
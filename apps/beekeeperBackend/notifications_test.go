@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"beekeeper-api/models"
+)
+
+func TestNotificationPreferences(t *testing.T) {
+	ownPreferences := func(s *testServer) {
+		s.signIn(models.RoleOwner)
+	}
+	preferences := func(topic string) map[string]any {
+		return map[string]any{"channels": []string{"ntfy"}, "ntfyTopic": topic}
+	}
+
+	runCases(t, []apiCase{
+		{
+			name:   "ntfy topic name",
+			setup:  ownPreferences,
+			method: http.MethodPut, path: "/api/users/1/notifications",
+			body:   preferences("my-apiary_reminders"),
+			status: http.StatusOK,
+			dbOnly: true,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				if pref := decode[models.NotificationPreference](t, body); pref.NtfyTopic != "my-apiary_reminders" {
+					t.Errorf("got %+v", pref)
+				}
+			},
+		},
+		{
+			name:   "ntfy topic URL",
+			setup:  ownPreferences,
+			method: http.MethodPut, path: "/api/users/1/notifications",
+			body:   preferences("https://ntfy.example.com/reminders"),
+			status: http.StatusOK,
+			dbOnly: true,
+		},
+		{
+			name:   "ntfy topic leaving its path",
+			setup:  ownPreferences,
+			method: http.MethodPut, path: "/api/users/1/notifications",
+			body:   preferences("../x"),
+			status: http.StatusBadRequest,
+			dbOnly: true,
+		},
+		{
+			name:   "ntfy topic with a query",
+			setup:  ownPreferences,
+			method: http.MethodPut, path: "/api/users/1/notifications",
+			body:   preferences("a?b"),
+			status: http.StatusBadRequest,
+			dbOnly: true,
+		},
+		{
+			name:   "webhook URL that is no URL",
+			setup:  ownPreferences,
+			method: http.MethodPut, path: "/api/users/1/notifications",
+			body:   map[string]any{"channels": []string{"webhook"}, "webhookURL": "ftp://example.com/hook"},
+			status: http.StatusBadRequest,
+			dbOnly: true,
+		},
+	})
+}
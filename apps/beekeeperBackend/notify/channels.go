@@ -0,0 +1,208 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/smtp"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"beekeeper-api/models"
+)
+
+// Message is a reminder ready to be sent, covering one or more tasks.
+type Message struct {
+	Subject string
+	Body    string
+	Tasks   []models.Task
+	Overdue bool
+	// Date is when the reminder was made
+	Date time.Time
+}
+
+// Channel delivers messages to a user over one transport.
+type Channel interface {
+	Send(user models.User, pref models.NotificationPreference, msg Message) error
+}
+
+// SMTPChannel sends reminders by email to the user's address.
+type SMTPChannel struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+func (c SMTPChannel) Send(user models.User, _ models.NotificationPreference, msg Message) error {
+	var auth smtp.Auth
+	if c.Username != "" {
+		host, _, _ := strings.Cut(c.Addr, ":")
+		auth = smtp.PlainAuth("", c.Username, c.Password, host)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", c.From)
+	fmt.Fprintf(&b, "To: %s\r\n", user.Email)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", msg.Date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+
+	return smtp.SendMail(c.Addr, auth, c.From, []string{user.Email}, []byte(b.String()))
+}
+
+// ErrNonPublicAddress is returned for requests to a destination a user chose
+// that resolves to a loopback, private, link-local or otherwise non-public
+// address.
+var ErrNonPublicAddress = errors.New("destination is not a public address")
+
+// carrierNAT is the shared address space of carrier-grade NAT, which
+// netip.Addr.IsPrivate does not cover
+var carrierNAT = netip.MustParsePrefix("100.64.0.0/10")
+
+// PublicClient returns an HTTP client for destinations users choose, such as
+// their webhook URL. It only connects to public addresses, so reminders
+// cannot be aimed at the server itself, its network or a cloud metadata
+// service. The address is checked when connecting, after resolving and for
+// every redirect, and no proxy is used.
+func PublicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !public(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrNonPublicAddress, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// public reports whether an address is reachable on the internet
+func public(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !carrierNAT.Contains(addr)
+}
+
+// WebhookChannel posts reminders as JSON to the user's webhook URL. Client
+// should be a PublicClient.
+type WebhookChannel struct {
+	Client *http.Client
+}
+
+type webhookPayload struct {
+	UserID  uint          `json:"user_id"`
+	Subject string        `json:"subject"`
+	Body    string        `json:"body"`
+	Overdue bool          `json:"overdue"`
+	Tasks   []models.Task `json:"tasks"`
+}
+
+func (c WebhookChannel) Send(user models.User, pref models.NotificationPreference, msg Message) error {
+	if pref.WebhookURL == "" {
+		return errors.New("no webhook URL set")
+	}
+	payload, err := json.Marshal(webhookPayload{
+		UserID:  user.ID,
+		Subject: msg.Subject,
+		Body:    msg.Body,
+		Overdue: msg.Overdue,
+		Tasks:   msg.Tasks,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, pref.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Beekeeper-Reminder/1.0")
+	return do(c.Client, req)
+}
+
+// NtfyChannel pushes reminders to an ntfy topic, either on the configured
+// server, which may be in the local network, or at a full topic URL the user
+// chose, which is requested with URLClient, a PublicClient.
+type NtfyChannel struct {
+	BaseURL   string
+	Client    *http.Client
+	URLClient *http.Client
+}
+
+func (c NtfyChannel) Send(_ models.User, pref models.NotificationPreference, msg Message) error {
+	if pref.NtfyTopic == "" {
+		return errors.New("no ntfy topic set")
+	}
+	if !ValidTopic(pref.NtfyTopic) {
+		return fmt.Errorf("invalid ntfy topic %q", pref.NtfyTopic)
+	}
+	target, client := pref.NtfyTopic, c.URLClient
+	if !IsURL(target) {
+		target, client = strings.TrimSuffix(c.BaseURL, "/")+"/"+url.PathEscape(target), c.Client
+	}
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(msg.Body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", mime.QEncoding.Encode("utf-8", msg.Subject))
+	req.Header.Set("Tags", "bee")
+	if msg.Overdue {
+		req.Header.Set("Priority", "high")
+	}
+	return do(client, req)
+}
+
+// topicName matches the names of topics on the configured server. Anything
+// else, such as "../admin" or "a?b", would reach other paths on that server.
+var topicName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ValidTopic reports whether an ntfy topic is a topic name on the configured
+// server or a full topic URL.
+func ValidTopic(topic string) bool {
+	if IsURL(topic) {
+		u, err := url.Parse(topic)
+		return err == nil && u.Host != ""
+	}
+	return topicName.MatchString(topic)
+}
+
+// IsURL reports whether an ntfy topic is a full topic URL rather than the
+// name of a topic on the configured server
+func IsURL(topic string) bool {
+	return strings.HasPrefix(topic, "http://") || strings.HasPrefix(topic, "https://")
+}
+
+func do(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
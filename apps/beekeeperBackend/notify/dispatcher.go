@@ -0,0 +1,303 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"beekeeper-api/access"
+	"beekeeper-api/config"
+	"beekeeper-api/models"
+)
+
+// Dispatcher periodically looks for open tasks that are due soon or overdue and
+// reminds every user with notification preferences about them. Sent reminders
+// are recorded, so each task triggers at most one "due soon" and one "overdue"
+// reminder per user.
+type Dispatcher struct {
+	db       *gorm.DB
+	channels map[string]Channel
+	leadTime time.Duration
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewDispatcher creates a reminder dispatcher from the configuration. Email is
+// only available when an SMTP host is configured. now tells the current time,
+// usually time.Now.
+func NewDispatcher(cfg *config.Config, db *gorm.DB, now func() time.Time) *Dispatcher {
+	public := PublicClient(10 * time.Second)
+	d := &Dispatcher{
+		db: db,
+		channels: map[string]Channel{
+			models.ChannelWebhook: WebhookChannel{Client: public},
+			models.ChannelNtfy:    NtfyChannel{BaseURL: cfg.NtfyBaseURL, Client: &http.Client{Timeout: 10 * time.Second}, URLClient: public},
+		},
+		leadTime: cfg.ReminderLeadTime,
+		interval: cfg.ReminderInterval,
+		now:      now,
+	}
+	if cfg.SMTPHost != "" {
+		d.channels[models.ChannelEmail] = SMTPChannel{
+			Addr:     net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort)),
+			From:     cfg.SMTPFrom,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+		}
+	}
+	return d
+}
+
+// Start checks for due tasks in the background every configured interval. It
+// does nothing if the interval is 0, which disables reminders.
+func (d *Dispatcher) Start() {
+	if d.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		for {
+			d.Run()
+			<-ticker.C
+		}
+	}()
+}
+
+// reminder is a task a user still has to be reminded of.
+type reminder struct {
+	task models.Task
+	kind string
+}
+
+// Run sends all reminders that are due right now.
+func (d *Dispatcher) Run() {
+	now := d.now()
+
+	var tasks []models.Task
+	err := d.db.
+		Where("completed_at IS NULL AND due_at IS NOT NULL AND due_at <= ?", now.Add(d.leadTime)).
+		Order("due_at").
+		Find(&tasks).Error
+	if err != nil {
+		log.Printf("Failed to load due tasks: %v", err)
+		return
+	}
+	if len(tasks) == 0 {
+		return
+	}
+
+	var prefs []models.NotificationPreference
+	if err := d.db.Preload("User").Find(&prefs).Error; err != nil {
+		log.Printf("Failed to load notification preferences: %v", err)
+		return
+	}
+	for i := range prefs {
+		d.remind(&prefs[i], tasks, now)
+	}
+}
+
+func (d *Dispatcher) remind(pref *models.NotificationPreference, tasks []models.Task, now time.Time) {
+	if len(pref.Channels) == 0 || pref.User.ID == 0 {
+		return
+	}
+	loc, err := time.LoadLocation(pref.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	if InQuietHours(pref.QuietHoursStart, pref.QuietHoursEnd, local) {
+		return
+	}
+
+	pending, err := d.pending(pref.UserID, tasks, now)
+	if err != nil {
+		log.Printf("Failed to load sent reminders for user %d: %v", pref.UserID, err)
+		return
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	if pref.Digest {
+		if !digestDue(pref, local) {
+			return
+		}
+		if d.deliver(pref, digestMessage(pending, now, loc)) {
+			d.record(pref.UserID, pending, now)
+			pref.LastDigestAt = &now
+			if err := d.db.Model(pref).Update("last_digest_at", now).Error; err != nil {
+				log.Printf("Failed to update digest time for user %d: %v", pref.UserID, err)
+			}
+		}
+		return
+	}
+
+	for _, r := range pending {
+		if d.deliver(pref, reminderMessage(r, now, loc)) {
+			d.record(pref.UserID, []reminder{r}, now)
+		}
+	}
+}
+
+// pending returns the reminders the user has not received yet. Users are only
+// reminded of tasks assigned to them or to nobody, in hives they have access to.
+func (d *Dispatcher) pending(userID uint, tasks []models.Task, now time.Time) ([]reminder, error) {
+	var accessible []int
+	if err := access.Hives(d.db, userID, access.TasksRead).Pluck("hive_name", &accessible).Error; err != nil {
+		return nil, err
+	}
+	allowed := make(map[int]bool, len(accessible))
+	for _, hiveName := range accessible {
+		allowed[hiveName] = true
+	}
+
+	ids := make([]uint, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	var sent []models.TaskReminder
+	if err := d.db.Where("user_id = ? AND task_id IN ?", userID, ids).Find(&sent).Error; err != nil {
+		return nil, err
+	}
+	done := make(map[string]bool, len(sent))
+	for _, s := range sent {
+		done[fmt.Sprintf("%d/%s", s.TaskID, s.Kind)] = true
+	}
+
+	var pending []reminder
+	for _, task := range tasks {
+		if !allowed[task.HiveID] || (task.AssigneeID != nil && *task.AssigneeID != userID) {
+			continue
+		}
+		kind := models.ReminderDueSoon
+		if !task.DueAt.After(now) {
+			kind = models.ReminderOverdue
+		}
+		if !done[fmt.Sprintf("%d/%s", task.ID, kind)] {
+			pending = append(pending, reminder{task: task, kind: kind})
+		}
+	}
+	return pending, nil
+}
+
+// deliver sends the message on every channel the user chose and reports
+// whether at least one of them succeeded.
+func (d *Dispatcher) deliver(pref *models.NotificationPreference, msg Message) bool {
+	delivered := false
+	for _, name := range pref.Channels {
+		channel, ok := d.channels[name]
+		if !ok {
+			log.Printf("Notification channel %q is not configured, skipping reminder for user %d", name, pref.UserID)
+			continue
+		}
+		if err := channel.Send(pref.User, *pref, msg); err != nil {
+			log.Printf("Failed to send %s reminder to user %d: %v", name, pref.UserID, err)
+			continue
+		}
+		delivered = true
+	}
+	return delivered
+}
+
+func (d *Dispatcher) record(userID uint, reminders []reminder, now time.Time) {
+	for _, r := range reminders {
+		sent := models.TaskReminder{TaskID: r.task.ID, UserID: userID, Kind: r.kind, SentAt: now}
+		if err := d.db.Create(&sent).Error; err != nil {
+			log.Printf("Failed to record reminder for task %d: %v", r.task.ID, err)
+		}
+	}
+}
+
+// ParseClock parses a time of day like "07:30" into minutes after midnight.
+func ParseClock(value string) (int, error) {
+	hours, minutes, ok := strings.Cut(value, ":")
+	h, errH := strconv.Atoi(hours)
+	m, errM := strconv.Atoi(minutes)
+	if !ok || errH != nil || errM != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", value)
+	}
+	return h*60 + m, nil
+}
+
+// InQuietHours reports whether local falls between start and end, which may
+// span midnight. Quiet hours are off unless both are set.
+func InQuietHours(start, end string, local time.Time) bool {
+	from, errFrom := ParseClock(start)
+	to, errTo := ParseClock(end)
+	if errFrom != nil || errTo != nil || from == to {
+		return false
+	}
+	current := local.Hour()*60 + local.Minute()
+	if from < to {
+		return current >= from && current < to
+	}
+	return current >= from || current < to
+}
+
+// digestDue reports whether today's digest time has passed without a digest
+// having been sent since.
+func digestDue(pref *models.NotificationPreference, local time.Time) bool {
+	at, err := ParseClock(pref.DigestTime)
+	if err != nil {
+		at = 7 * 60
+	}
+	year, month, day := local.Date()
+	scheduled := time.Date(year, month, day, at/60, at%60, 0, 0, local.Location())
+	if local.Before(scheduled) {
+		return false
+	}
+	return pref.LastDigestAt == nil || pref.LastDigestAt.Before(scheduled)
+}
+
+func reminderMessage(r reminder, now time.Time, loc *time.Location) Message {
+	content, _, _ := strings.Cut(r.task.Content, "\n")
+	overdue := r.kind == models.ReminderOverdue
+	subject := fmt.Sprintf("Hive %d: task due %s", r.task.HiveID, dueText(*r.task.DueAt, now))
+	if overdue {
+		subject = fmt.Sprintf("Hive %d: task overdue", r.task.HiveID)
+	}
+	body := fmt.Sprintf("%s\n\n%s", content, taskLine(r, now, loc))
+	return Message{Subject: subject, Body: body, Tasks: []models.Task{r.task}, Overdue: overdue, Date: now}
+}
+
+func digestMessage(reminders []reminder, now time.Time, loc *time.Location) Message {
+	msg := Message{Subject: fmt.Sprintf("%d beekeeping tasks need attention", len(reminders)), Date: now}
+	if len(reminders) == 1 {
+		msg.Subject = "1 beekeeping task needs attention"
+	}
+	var b strings.Builder
+	for _, r := range reminders {
+		content, _, _ := strings.Cut(r.task.Content, "\n")
+		fmt.Fprintf(&b, "- Hive %d: %s (%s)\n", r.task.HiveID, content, taskLine(r, now, loc))
+		msg.Tasks = append(msg.Tasks, r.task)
+		if r.kind == models.ReminderOverdue {
+			msg.Overdue = true
+		}
+	}
+	msg.Body = strings.TrimSuffix(b.String(), "\n")
+	return msg
+}
+
+func taskLine(r reminder, now time.Time, loc *time.Location) string {
+	due := r.task.DueAt.In(loc).Format("Mon 2 Jan 15:04")
+	if r.kind == models.ReminderOverdue {
+		return "overdue since " + due
+	}
+	return "due " + due
+}
+
+// dueText describes how far away a due date is, e.g. "in 3h".
+func dueText(dueAt, now time.Time) string {
+	left := dueAt.Sub(now).Round(time.Minute)
+	if left >= time.Hour {
+		return fmt.Sprintf("in %dh", int(left.Round(time.Hour).Hours()))
+	}
+	return fmt.Sprintf("in %dm", int(left.Minutes()))
+}
@@ -0,0 +1,478 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/netip"
+	"net/textproto"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"beekeeper-api/config"
+	"beekeeper-api/database"
+	"beekeeper-api/migrate"
+	"beekeeper-api/models"
+)
+
+var databases atomic.Int64
+
+// openDB creates a migrated in-memory database
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	cfg := config.New()
+	cfg.DBDriver = "sqlite"
+	cfg.DatabaseURL = fmt.Sprintf("file:notify%d?mode=memory&cache=shared", databases.Add(1))
+	cfg.DBMaxOpenConns = 1
+	cfg.DBMaxIdleConns = 1
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db.Logger = logger.Discard
+
+	migrator, err := migrate.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// clock is a fake clock that only moves when told to
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// email is a message received by the fake SMTP server
+type email struct {
+	to      string
+	subject string
+	date    time.Time
+	body    string
+}
+
+// smtpServer is a fake SMTP server keeping the messages it receives
+type smtpServer struct {
+	addr     string
+	mu       sync.Mutex
+	received []email
+}
+
+func startSMTP(t *testing.T) *smtpServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	s := &smtpServer{addr: listener.Addr().String()}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(textproto.NewConn(conn))
+		}
+	}()
+	return s
+}
+
+// serve speaks just enough SMTP for net/smtp to deliver a message
+func (s *smtpServer) serve(conn *textproto.Conn) {
+	defer conn.Close()
+	conn.PrintfLine("220 localhost ESMTP")
+	var to string
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			conn.PrintfLine("250 localhost")
+		case "MAIL":
+			conn.PrintfLine("250 OK")
+		case "RCPT":
+			to = strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+			conn.PrintfLine("250 OK")
+		case "DATA":
+			conn.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := conn.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.keep(to, string(data))
+			conn.PrintfLine("250 OK")
+		case "QUIT":
+			conn.PrintfLine("221 Bye")
+			return
+		default:
+			conn.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+func (s *smtpServer) keep(to, data string) {
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		panic(err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	date, _ := msg.Header.Date()
+	body, _ := io.ReadAll(msg.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.received = append(s.received, email{to: to, subject: subject, date: date, body: string(body)})
+}
+
+// take returns the messages received since the last call, as
+// "recipient: subject" sorted for comparison
+func (s *smtpServer) take() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var got []string
+	for _, e := range s.received {
+		got = append(got, e.to+": "+e.subject)
+	}
+	sort.Strings(got)
+	s.received = nil
+	return got
+}
+
+// fixture is a database with users reminded by email
+type fixture struct {
+	t        *testing.T
+	db       *gorm.DB
+	clock    *clock
+	smtp     *smtpServer
+	dispatch *Dispatcher
+}
+
+func newFixture(t *testing.T, now time.Time) *fixture {
+	f := &fixture{t: t, db: openDB(t), clock: &clock{now: now}, smtp: startSMTP(t)}
+	cfg := config.New()
+	host, port, _ := net.SplitHostPort(f.smtp.addr)
+	cfg.SMTPHost = host
+	fmt.Sscan(port, &cfg.SMTPPort)
+	cfg.SMTPFrom = "beekeeper@example.com"
+	cfg.ReminderLeadTime = 24 * time.Hour
+	f.dispatch = NewDispatcher(cfg, f.db, f.clock.Now)
+	return f
+}
+
+func (f *fixture) create(value any) {
+	f.t.Helper()
+	if err := f.db.Create(value).Error; err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// user creates an editor reminded by email with the given preferences
+func (f *fixture) user(name string, pref models.NotificationPreference) models.User {
+	f.t.Helper()
+	user := models.User{Name: name, Email: name + "@example.com", Role: models.RoleEditor, CalendarTokenHash: name}
+	f.create(&user)
+	pref.UserID = user.ID
+	pref.Channels = []string{models.ChannelEmail}
+	if pref.Timezone == "" {
+		pref.Timezone = "UTC"
+	}
+	if pref.DigestTime == "" {
+		pref.DigestTime = "07:00"
+	}
+	f.create(&pref)
+	return user
+}
+
+// task creates an open task due after the given time from now
+func (f *fixture) task(hive int, content string, due time.Duration, assignee *uint) models.Task {
+	f.t.Helper()
+	dueAt := f.clock.Now().Add(due)
+	task := models.Task{HiveID: hive, Content: content, Priority: models.PriorityNormal, DueAt: &dueAt, AssigneeID: assignee}
+	f.create(&models.Hive{HiveName: hive})
+	f.create(&task)
+	return task
+}
+
+func sameStrings(got, want []string) bool {
+	return strings.Join(got, "\n") == strings.Join(want, "\n")
+}
+
+func TestDispatcherReminders(t *testing.T) {
+	f := newFixture(t, time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	f.user("ana", models.NotificationPreference{})
+	ben := f.user("ben", models.NotificationPreference{})
+
+	f.task(1, "Feed", 2*time.Hour, nil)
+	f.task(2, "Requeen", 3*time.Hour, &ben.ID)
+	f.task(3, "Add a super", 72*time.Hour, nil)
+	done := f.task(4, "Treat", time.Hour, nil)
+	completedAt := f.clock.Now()
+	f.db.Model(&done).Update("completed_at", completedAt)
+
+	// Hive 5 is in a members only apiary whose team only has Ben
+	apiary := models.Apiary{Name: "Orchard", Latitude: 48.4, Longitude: 11.7, MembersOnly: true}
+	f.create(&apiary)
+	f.create(&models.TeamMember{ApiaryID: apiary.ID, UserID: ben.ID, Role: models.RoleEditor})
+	f.task(5, "Inspect", time.Hour, nil)
+	f.db.Model(&models.Hive{}).Where("hive_name = ?", 5).Update("apiary_id", apiary.ID)
+
+	f.dispatch.Run()
+	got := f.smtp.take()
+	want := []string{
+		"ana@example.com: Hive 1: task due in 2h",
+		"ben@example.com: Hive 1: task due in 2h",
+		"ben@example.com: Hive 2: task due in 3h",
+		"ben@example.com: Hive 5: task due in 1h",
+	}
+	if !sameStrings(got, want) {
+		t.Errorf("got reminders\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Every reminder is sent once
+	f.clock.advance(30 * time.Minute)
+	f.dispatch.Run()
+	if got := f.smtp.take(); len(got) != 0 {
+		t.Errorf("got reminders again: %v", got)
+	}
+
+	// Overdue tasks get a second reminder, and the task due in three days
+	// comes within the lead time
+	f.clock.advance(50*time.Hour + 30*time.Minute)
+	f.dispatch.Run()
+	got = f.smtp.take()
+	want = []string{
+		"ana@example.com: Hive 1: task overdue",
+		"ana@example.com: Hive 3: task due in 21h",
+		"ben@example.com: Hive 1: task overdue",
+		"ben@example.com: Hive 2: task overdue",
+		"ben@example.com: Hive 3: task due in 21h",
+		"ben@example.com: Hive 5: task overdue",
+	}
+	if !sameStrings(got, want) {
+		t.Errorf("got reminders\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDispatcherEmail(t *testing.T) {
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	f := newFixture(t, now)
+	f.user("ana", models.NotificationPreference{Timezone: "Europe/Berlin"})
+	f.task(1, "Feed the nucleus\nwith 1:1 syrup", 2*time.Hour, nil)
+
+	f.dispatch.Run()
+	f.smtp.mu.Lock()
+	defer f.smtp.mu.Unlock()
+	if len(f.smtp.received) != 1 {
+		t.Fatalf("got %d emails, want 1", len(f.smtp.received))
+	}
+	e := f.smtp.received[0]
+	if !e.date.Equal(now) {
+		t.Errorf("got date %v, want the clock's %v", e.date, now)
+	}
+	// Due times are shown in the user's time zone
+	if !strings.Contains(e.body, "Feed the nucleus") || !strings.Contains(e.body, "due Wed 1 May 13:00") {
+		t.Errorf("got body %q", e.body)
+	}
+}
+
+func TestDispatcherQuietHours(t *testing.T) {
+	// 23:30 in Berlin
+	f := newFixture(t, time.Date(2024, 5, 1, 21, 30, 0, 0, time.UTC))
+	f.user("ana", models.NotificationPreference{Timezone: "Europe/Berlin", QuietHoursStart: "22:00", QuietHoursEnd: "07:00"})
+	f.task(1, "Feed", 12*time.Hour, nil)
+
+	f.dispatch.Run()
+	if got := f.smtp.take(); len(got) != 0 {
+		t.Errorf("got reminders in quiet hours: %v", got)
+	}
+
+	f.clock.advance(8 * time.Hour)
+	f.dispatch.Run()
+	if got := f.smtp.take(); len(got) != 1 {
+		t.Errorf("got %v after quiet hours, want one reminder", got)
+	}
+}
+
+func TestDispatcherDigest(t *testing.T) {
+	f := newFixture(t, time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC))
+	f.user("ana", models.NotificationPreference{Digest: true, DigestTime: "07:00"})
+	f.task(1, "Feed", 4*time.Hour, nil)
+	f.task(2, "Requeen", 8*time.Hour, nil)
+
+	f.dispatch.Run()
+	if got := f.smtp.take(); len(got) != 0 {
+		t.Errorf("got %v before the digest time", got)
+	}
+
+	f.clock.advance(65 * time.Minute)
+	f.dispatch.Run()
+	want := []string{"ana@example.com: 2 beekeeping tasks need attention"}
+	if got := f.smtp.take(); !sameStrings(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// One digest a day, even with new tasks
+	f.task(3, "Inspect", 2*time.Hour, nil)
+	f.clock.advance(time.Hour)
+	f.dispatch.Run()
+	if got := f.smtp.take(); len(got) != 0 {
+		t.Errorf("got a second digest: %v", got)
+	}
+
+	f.clock.advance(23 * time.Hour)
+	f.dispatch.Run()
+	want = []string{"ana@example.com: 3 beekeeping tasks need attention"}
+	if got := f.smtp.take(); !sameStrings(got, want) {
+		t.Errorf("got %v the next day, want %v", got, want)
+	}
+}
+
+func TestDispatcherDisabled(t *testing.T) {
+	f := newFixture(t, time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	f.user("ana", models.NotificationPreference{})
+	f.task(1, "Feed", time.Hour, nil)
+
+	cfg := config.New()
+	cfg.ReminderInterval = 0
+	NewDispatcher(cfg, f.db, f.clock.Now).Start()
+	time.Sleep(50 * time.Millisecond)
+	if got := f.smtp.take(); len(got) != 0 {
+		t.Errorf("got reminders with reminders disabled: %v", got)
+	}
+}
+
+func TestPublicAddresses(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.178.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::ffff:127.0.0.1", false},
+		{"224.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := public(netip.MustParseAddr(tt.addr)); got != tt.public {
+			t.Errorf("public(%s) = %v, want %v", tt.addr, got, tt.public)
+		}
+	}
+}
+
+// TestPublicClient checks that reminders to a webhook on the server itself
+// or the cloud metadata service are not sent
+func TestPublicClient(t *testing.T) {
+	var received atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+	}))
+	defer server.Close()
+
+	channel := WebhookChannel{Client: PublicClient(time.Second)}
+	for _, url := range []string{server.URL, "http://169.254.169.254/latest/meta-data/"} {
+		err := channel.Send(models.User{ID: 1}, models.NotificationPreference{WebhookURL: url}, Message{Subject: "Due"})
+		if !errors.Is(err, ErrNonPublicAddress) {
+			t.Errorf("got %v for %s, want ErrNonPublicAddress", err, url)
+		}
+	}
+	ntfy := NtfyChannel{BaseURL: server.URL, Client: server.Client(), URLClient: PublicClient(time.Second)}
+	if err := ntfy.Send(models.User{}, models.NotificationPreference{NtfyTopic: server.URL + "/reminders"}, Message{Subject: "Due"}); !errors.Is(err, ErrNonPublicAddress) {
+		t.Errorf("got %v for a topic URL on the server, want ErrNonPublicAddress", err)
+	}
+	if received.Load() != 0 {
+		t.Errorf("got %d requests on the server", received.Load())
+	}
+
+	// Topics on the configured server may be in the local network
+	if err := ntfy.Send(models.User{}, models.NotificationPreference{NtfyTopic: "reminders"}, Message{Subject: "Due"}); err != nil {
+		t.Errorf("sending to a topic on the configured server: %v", err)
+	}
+	if received.Load() != 1 {
+		t.Errorf("got %d requests on the configured server, want 1", received.Load())
+	}
+}
+
+// TestNtfyTopics checks that only topic names and full URLs are accepted, so
+// a topic cannot reach other paths on the configured server
+func TestNtfyTopics(t *testing.T) {
+	tests := []struct {
+		topic string
+		ok    bool
+	}{
+		{"my-apiary_reminders", true},
+		{strings.Repeat("a", 64), true},
+		{"https://ntfy.example.com/reminders", true},
+		{"", false},
+		{strings.Repeat("a", 65), false},
+		{"../x", false},
+		{"a?b", false},
+		{"a/b", false},
+		{"a#b", false},
+		{"bienenstöcke", false},
+		{"https://", false},
+	}
+	for _, tt := range tests {
+		if got := ValidTopic(tt.topic); got != tt.ok {
+			t.Errorf("got %v for %q, want %v", got, tt.topic, tt.ok)
+		}
+	}
+
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.RawPath+r.URL.Path+"?"+r.URL.RawQuery)
+	}))
+	defer server.Close()
+	ntfy := NtfyChannel{BaseURL: server.URL + "/", Client: server.Client()}
+	for _, topic := range []string{"../x", "a?b"} {
+		if err := ntfy.Send(models.User{}, models.NotificationPreference{NtfyTopic: topic}, Message{Subject: "Due"}); err == nil {
+			t.Errorf("sent to topic %q", topic)
+		}
+	}
+	if err := ntfy.Send(models.User{}, models.NotificationPreference{NtfyTopic: "reminders"}, Message{Subject: "Due"}); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(paths, []string{"/reminders?"}) {
+		t.Errorf("got requests to %v, want only /reminders", paths)
+	}
+}
@@ -0,0 +1,503 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"beekeeper-api/auth"
+	"beekeeper-api/backup"
+	"beekeeper-api/config"
+	"beekeeper-api/events"
+	"beekeeper-api/features/login"
+	"beekeeper-api/models"
+	"beekeeper-api/services"
+)
+
+// issuer is a fake OpenID Connect issuer serving its discovery document, its
+// signing key and a token endpoint for the authorization code flow with PKCE
+type issuer struct {
+	*httptest.Server
+	t   *testing.T
+	key *rsa.PrivateKey
+
+	mu sync.Mutex
+	// codes are the authorizations handed out, by code
+	codes map[string]authorization
+}
+
+// authorization is a login the issuer accepted, waiting for its code to be
+// exchanged
+type authorization struct {
+	challenge string
+	nonce     string
+	claims    map[string]any
+}
+
+func newIssuer(t *testing.T) *issuer {
+	t.Helper()
+	i := &issuer{t: t, key: newKey(t), codes: make(map[string]authorization)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                i.URL,
+			"authorization_endpoint":                i.URL + "/authorize",
+			"token_endpoint":                        i.URL + "/token",
+			"jwks_uri":                              i.URL + "/keys",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   encode(i.key.N.Bytes()),
+			"e":   encode(big.NewInt(int64(i.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", i.exchange)
+	i.Server = httptest.NewServer(mux)
+	t.Cleanup(i.Close)
+	return i
+}
+
+func newKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// exchange swaps a code for an ID token if the PKCE verifier matches the
+// challenge of its login
+func (i *issuer) exchange(w http.ResponseWriter, r *http.Request) {
+	i.mu.Lock()
+	login, ok := i.codes[r.PostFormValue("code")]
+	delete(i.codes, r.PostFormValue("code"))
+	i.mu.Unlock()
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" || encode(sum[:]) != login.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+	claims := map[string]any{"aud": "beekeeper-web", "nonce": login.nonce}
+	for name, value := range login.claims {
+		claims[name] = value
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token": "opaque",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     i.token(i.key, claims),
+	})
+}
+
+// token signs a JWT with the key. Its claims default to a valid bearer
+// token for the API; claims set to nil are left out.
+func (i *issuer) token(key *rsa.PrivateKey, claims map[string]any) string {
+	i.t.Helper()
+	now := time.Now()
+	payload := map[string]any{
+		"iss":   i.URL,
+		"aud":   "beekeeper-api",
+		"sub":   "kc-ana",
+		"email": "ana@example.com",
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}
+	for name, value := range claims {
+		if value == nil {
+			delete(payload, name)
+			continue
+		}
+		payload[name] = value
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		i.t.Fatal(err)
+	}
+	signed := encode([]byte(`{"alg":"RS256","kid":"test","typ":"JWT"}`)) + "." + encode(body)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		i.t.Fatal(err)
+	}
+	return signed + "." + encode(signature)
+}
+
+// authorize logs in at the issuer the login redirect points to, as the user
+// of the claims, and returns the code and state to call back with
+func (i *issuer) authorize(location string, claims map[string]any) (code, state string) {
+	i.t.Helper()
+	redirect, err := url.Parse(location)
+	if err != nil {
+		i.t.Fatal(err)
+	}
+	query := redirect.Query()
+	if !strings.HasPrefix(location, i.URL+"/authorize?") || query.Get("code_challenge_method") != "S256" || query.Get("client_id") != "beekeeper-web" {
+		i.t.Fatalf("login redirected to %s", location)
+	}
+	code, _, err = auth.NewToken()
+	if err != nil {
+		i.t.Fatal(err)
+	}
+	i.mu.Lock()
+	i.codes[code] = authorization{challenge: query.Get("code_challenge"), nonce: query.Get("nonce"), claims: claims}
+	i.mu.Unlock()
+	return code, query.Get("state")
+}
+
+// newOIDCServer builds the router with logins at a fake issuer. Bearer
+// tokens are issued for beekeeper-api, ID tokens for beekeeper-web.
+func newOIDCServer(t *testing.T, configure func(cfg *config.Config)) (*testServer, *issuer, *auth.OIDC) {
+	t.Helper()
+	iss := newIssuer(t)
+	cfg := testConfig(t)
+	cfg.OIDCIssuer = iss.URL
+	cfg.OIDCClientID = "beekeeper-web"
+	cfg.OIDCAudience = "beekeeper-api"
+	if configure != nil {
+		configure(cfg)
+	}
+	db := openTestDB(t, cfg)
+	provider, err := auth.NewOIDC(context.Background(), cfg, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus := events.NewBus(cfg.EventBufferSize)
+	router := newRouter(cfg, db, bus, services.NewGORM(db), backup.NewManager(cfg, db), provider)
+	return &testServer{t: t, db: db, router: router}, iss, provider
+}
+
+func TestOIDCBearer(t *testing.T) {
+	s, iss, _ := newOIDCServer(t, nil)
+	other := newKey(t)
+	valid := strings.Split(iss.token(iss.key, nil), ".")
+	claims, _ := json.Marshal(map[string]any{"iss": iss.URL, "aud": "beekeeper-api", "sub": "kc-eve", "email": "ana@example.com", "exp": time.Now().Add(time.Hour).Unix()})
+	tampered := valid[0] + "." + encode(claims) + "." + valid[2]
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"valid", iss.token(iss.key, nil), http.StatusOK},
+		{"bad signature", iss.token(other, nil), http.StatusUnauthorized},
+		{"other issuer", iss.token(iss.key, map[string]any{"iss": "https://evil.example.com"}), http.StatusUnauthorized},
+		// ID tokens for the web client are no API bearer tokens
+		{"other audience", iss.token(iss.key, map[string]any{"aud": "beekeeper-web"}), http.StatusUnauthorized},
+		{"expired", iss.token(iss.key, map[string]any{"exp": time.Now().Add(-time.Minute).Unix()}), http.StatusUnauthorized},
+		{"tampered", tampered, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.request(http.MethodGet, "/api/hives", nil, tt.token)
+			if rec.Code != tt.status {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status == http.StatusUnauthorized {
+				hasError("Invalid bearer token")(t, s, rec.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestOIDCLogin(t *testing.T) {
+	s, iss, _ := newOIDCServer(t, nil)
+	ana := map[string]any{"sub": "kc-ana", "email": "ana@example.com", "name": "Ana"}
+
+	// start begins a login and returns where the issuer was asked to send
+	// the browser back to and the cookie binding the login to the browser
+	start := func() (string, *http.Cookie) {
+		t.Helper()
+		rec := s.request(http.MethodGet, "/api/auth/login", nil, "")
+		if rec.Code != http.StatusFound {
+			t.Fatalf("got status %d, want 302: %s", rec.Code, rec.Body)
+		}
+		for _, cookie := range rec.Result().Cookies() {
+			if cookie.Name == "beekeeper_login_state" {
+				if !cookie.HttpOnly || cookie.Path != "/api/auth" || cookie.SameSite != http.SameSiteLaxMode {
+					t.Errorf("got cookie %s", cookie)
+				}
+				return rec.Header().Get("Location"), cookie
+			}
+		}
+		t.Fatal("got no state cookie")
+		return "", nil
+	}
+	// callback returns to the API from the issuer with the browser's cookie
+	callback := func(code, state string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		t.Helper()
+		path := "/api/auth/callback?" + url.Values{"code": {code}, "state": {state}}.Encode()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		s.router.ServeHTTP(rec, req)
+		if err := checkDocumented(http.MethodGet, path, rec.Code, rec.Header().Get("Content-Type"), rec.Body.Bytes()); err != nil {
+			t.Errorf("GET %s: response does not match docs/swagger.json: %v", path, err)
+		}
+		return rec
+	}
+	expect := func(rec *httptest.ResponseRecorder, status int, message string) {
+		t.Helper()
+		if rec.Code != status {
+			t.Fatalf("got status %d, want %d: %s", rec.Code, status, rec.Body)
+		}
+		hasError(message)(t, s, rec.Body.Bytes())
+	}
+
+	location, cookie := start()
+	code, state := iss.authorize(location, ana)
+	rec := callback(code, state, cookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200: %s", rec.Code, rec.Body)
+	}
+	loggedIn := decode[login.LoginResponse](t, rec.Body.Bytes())
+	if loggedIn.User.Email != "ana@example.com" || loggedIn.User.Name != "Ana" || loggedIn.Token == "" {
+		t.Errorf("got %+v", loggedIn)
+	}
+	if cleared := rec.Result().Cookies(); len(cleared) != 1 || cleared[0].Name != cookie.Name || cleared[0].MaxAge >= 0 {
+		t.Errorf("got cookies %v, want the state cookie cleared", cleared)
+	}
+	s.token = loggedIn.Token
+	s.do(http.MethodGet, "/api/hives", nil, http.StatusOK, nil)
+	s.token = ""
+
+	// A login can only be finished once
+	expect(callback(code, state, cookie), http.StatusBadRequest, "Unknown or expired login, please start again")
+
+	// A login can only be finished in the browser that started it, so no one
+	// can log a victim into their own account with a link to the callback
+	location, _ = start()
+	code, state = iss.authorize(location, ana)
+	expect(callback(code, state, nil), http.StatusBadRequest, "Login was not started in this browser, please start again")
+	_, victim := start()
+	expect(callback(code, state, victim), http.StatusBadRequest, "Login was not started in this browser, please start again")
+
+	// The code of one login does not finish another, as its PKCE verifier
+	// does not match
+	location, _ = start()
+	code, _ = iss.authorize(location, ana)
+	location, cookie = start()
+	_, state = iss.authorize(location, ana)
+	expect(callback(code, state, cookie), http.StatusUnauthorized, "Could not exchange authorization code")
+
+	// ID tokens must carry the nonce of the login
+	location, cookie = start()
+	code, state = iss.authorize(location, map[string]any{"sub": "kc-ana", "email": "ana@example.com"})
+	iss.mu.Lock()
+	forged := iss.codes[code]
+	forged.nonce = "replayed"
+	iss.codes[code] = forged
+	iss.mu.Unlock()
+	expect(callback(code, state, cookie), http.StatusUnauthorized, "Invalid ID token")
+
+	// The issuer's errors are passed on
+	rec = s.request(http.MethodGet, "/api/auth/callback?error=access_denied", nil, "")
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("got status %d for a denied login, want 401: %s", rec.Code, rec.Body)
+	}
+}
+
+// TestOIDCPendingLogins starts logins without finishing them and expects new
+// ones to be refused once too many are in progress
+func TestOIDCPendingLogins(t *testing.T) {
+	s, _, _ := newOIDCServer(t, nil)
+	for n := 0; ; n++ {
+		rec := s.request(http.MethodGet, "/api/auth/login", nil, "")
+		if rec.Code == http.StatusFound {
+			if n == 1000 {
+				t.Fatal("got more than 1000 logins in progress")
+			}
+			continue
+		}
+		if rec.Code != http.StatusServiceUnavailable || n != 1000 {
+			t.Fatalf("got status %d after %d logins, want 503 after 1000: %s", rec.Code, n, rec.Body)
+		}
+		hasError("Too many logins in progress, please try again later")(t, s, rec.Body.Bytes())
+		return
+	}
+}
+
+func TestOIDCUsers(t *testing.T) {
+	unverified := false
+	benSubject := "kc-ben"
+	tests := []struct {
+		name      string
+		roleClaim string
+		// existing is a local user created before the tokens are verified
+		existing *models.User
+		// others are local users created besides it
+		others []models.User
+		tokens []map[string]any
+		// want is the user after the last token, or nil for an error
+		want *models.User
+		// same is whether every token maps to the same user
+		same bool
+	}{
+		{
+			name:   "new user",
+			tokens: []map[string]any{{"sub": "kc-1", "email": "Ana@Example.com", "name": "Ana"}},
+			want:   &models.User{Name: "Ana", Email: "ana@example.com", Role: models.RoleViewer},
+		},
+		{
+			name:   "name from username",
+			tokens: []map[string]any{{"sub": "kc-1", "email": "ana@example.com", "preferred_username": "ana"}},
+			want:   &models.User{Name: "ana", Email: "ana@example.com", Role: models.RoleViewer},
+		},
+		{
+			name:   "name from email",
+			tokens: []map[string]any{{"sub": "kc-1", "email": "ana@example.com"}},
+			want:   &models.User{Name: "ana@example.com", Email: "ana@example.com", Role: models.RoleViewer},
+		},
+		{
+			name:   "found by subject after the email changed",
+			tokens: []map[string]any{{"sub": "kc-1", "email": "ana@example.com"}, {"sub": "kc-1", "email": "ana@example.org"}},
+			want:   &models.User{Name: "ana@example.com", Email: "ana@example.com", Role: models.RoleViewer},
+			same:   true,
+		},
+		{
+			name:     "linked by verified email",
+			existing: &models.User{Name: "Ben", Email: "ben@example.com", Role: models.RoleOwner},
+			tokens:   []map[string]any{{"sub": "kc-2", "email": "BEN@example.com", "email_verified": true}},
+			want:     &models.User{Name: "Ben", Email: "ben@example.com", Role: models.RoleOwner},
+		},
+		{
+			name:     "not linked by unverified email",
+			existing: &models.User{Name: "Ben", Email: "ben@example.com", Role: models.RoleOwner},
+			tokens:   []map[string]any{{"sub": "kc-2", "email": "ben@example.com", "email_verified": &unverified}},
+		},
+		{
+			name:     "not linked without email_verified",
+			existing: &models.User{Name: "Ben", Email: "ben@example.com", Role: models.RoleOwner},
+			tokens:   []map[string]any{{"sub": "kc-2", "email": "ben@example.com"}},
+		},
+		{
+			name:     "not linked to an account of another login",
+			existing: &models.User{Name: "Ben", Email: "ben@example.com", Role: models.RoleOwner, OIDCSubject: &benSubject},
+			tokens:   []map[string]any{{"sub": "kc-2", "email": "ben@example.com", "email_verified": true}},
+		},
+		{
+			name:     "not linked when several accounts use the email",
+			existing: &models.User{Name: "Ben", Email: "ben@example.com", Role: models.RoleOwner},
+			others:   []models.User{{Name: "Ben", Email: "Ben@Example.com", Role: models.RoleViewer, CalendarTokenHash: "other"}},
+			tokens:   []map[string]any{{"sub": "kc-2", "email": "ben@example.com", "email_verified": true}},
+		},
+		{
+			name:   "no email",
+			tokens: []map[string]any{{"sub": "kc-3", "email": nil}},
+		},
+		{
+			name:      "most privileged role of the claim",
+			roleClaim: "realm_access.roles",
+			tokens:    []map[string]any{{"sub": "kc-1", "email": "ana@example.com", "realm_access": map[string]any{"roles": []string{"offline_access", "viewer", "editor"}}}},
+			want:      &models.User{Name: "ana@example.com", Email: "ana@example.com", Role: models.RoleEditor},
+		},
+		{
+			name:      "role follows the claim",
+			roleClaim: "roles",
+			tokens: []map[string]any{
+				{"sub": "kc-1", "email": "ana@example.com", "roles": "owner"},
+				{"sub": "kc-1", "email": "ana@example.com", "roles": "none"},
+			},
+			want: &models.User{Name: "ana@example.com", Email: "ana@example.com", Role: models.RoleNone},
+			same: true,
+		},
+		{
+			name:      "default role without a known role",
+			roleClaim: "realm_access.roles",
+			tokens:    []map[string]any{{"sub": "kc-1", "email": "ana@example.com", "realm_access": "admin"}},
+			want:      &models.User{Name: "ana@example.com", Email: "ana@example.com", Role: models.RoleViewer},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, iss, provider := newOIDCServer(t, func(cfg *config.Config) { cfg.OIDCRoleClaim = tt.roleClaim })
+			var existing models.User
+			if tt.existing != nil {
+				existing = *tt.existing
+				existing.CalendarTokenHash = "existing"
+				if err := s.db.Create(&existing).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, other := range tt.others {
+				if err := s.db.Create(&other).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var user *models.User
+			var err error
+			for n, claims := range tt.tokens {
+				var previous *models.User
+				previous, user = user, nil
+				user, err = provider.VerifyBearer(context.Background(), iss.token(iss.key, claims))
+				if tt.same && n > 0 && err == nil && user.ID != previous.ID {
+					t.Errorf("token %d mapped to user %d, want %d", n, user.ID, previous.ID)
+				}
+			}
+			if tt.want == nil {
+				if err == nil {
+					t.Fatalf("got user %+v, want an error", user)
+				}
+				// The existing account keeps its subject, or lack of one
+				if tt.existing != nil {
+					var stored models.User
+					if err := s.db.First(&stored, existing.ID).Error; err != nil {
+						t.Fatal(err)
+					}
+					if (stored.OIDCSubject == nil) != (tt.existing.OIDCSubject == nil) || stored.OIDCSubject != nil && *stored.OIDCSubject != *tt.existing.OIDCSubject {
+						t.Errorf("got subject %v, want %v", stored.OIDCSubject, tt.existing.OIDCSubject)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.Name != tt.want.Name || user.Email != tt.want.Email || user.Role != tt.want.Role {
+				t.Errorf("got %q <%s> as %s, want %q <%s> as %s", user.Name, user.Email, user.Role, tt.want.Name, tt.want.Email, tt.want.Role)
+			}
+			if tt.existing != nil && user.ID != existing.ID {
+				t.Errorf("got user %d, want the existing user %d", user.ID, existing.ID)
+			}
+			// The subject is stored, so the user is found by it from now on
+			var stored models.User
+			if err := s.db.First(&stored, user.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			sub := tt.tokens[len(tt.tokens)-1]["sub"]
+			if stored.OIDCSubject == nil || *stored.OIDCSubject != sub {
+				t.Errorf("got subject %v, want %v", stored.OIDCSubject, sub)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"gorm.io/gorm"
+
+	"beekeeper-api/auth"
+	"beekeeper-api/backup"
+	"beekeeper-api/config"
+	_ "beekeeper-api/docs" // Import generated docs
+	"beekeeper-api/events"
+	"beekeeper-api/features/admin"
+	"beekeeper-api/features/apiaries"
+	"beekeeper-api/features/calendar"
+	"beekeeper-api/features/dashboard"
+	"beekeeper-api/features/equipment"
+	"beekeeper-api/features/export"
+	"beekeeper-api/features/harvests"
+	"beekeeper-api/features/hives"
+	"beekeeper-api/features/importer"
+	"beekeeper-api/features/incidents"
+	"beekeeper-api/features/login"
+	"beekeeper-api/features/logs"
+	"beekeeper-api/features/notifications"
+	"beekeeper-api/features/reports"
+	"beekeeper-api/features/stream"
+	"beekeeper-api/features/tags"
+	"beekeeper-api/features/tasks"
+	"beekeeper-api/features/team"
+	"beekeeper-api/features/users"
+	"beekeeper-api/features/webhooks"
+	"beekeeper-api/services"
+)
+
+// newRouter creates the Gin router serving the API and its documentation.
+// oidcProvider may be nil if OIDC login is not configured.
+func newRouter(cfg *config.Config, db *gorm.DB, bus *events.Bus, svc *services.Services, backups *backup.Manager, oidcProvider *auth.OIDC) *gin.Engine {
+	// Create a new Gin router
+	router := gin.Default()
+
+	//Cors
+	router.Use(cors.Default())
+
+	// API base path
+	api := router.Group("/api")
+	api.Use(auth.Middleware(db, oidcProvider, cfg.AuthRequired,
+		"GET /api/tasks/calendar.ics",
+		"POST /api/invitations/accept",
+		"GET /api/auth/login",
+		"GET /api/auth/callback",
+	))
+
+	// Register feature-specific routes
+	login.RegisterRoutes(api, db, oidcProvider)
+	apiaries.RegisterRoutes(api, db)
+	hives.RegisterRoutes(api, db, svc, bus)
+	logs.RegisterRoutes(api, db, svc, bus)
+	tasks.RegisterRoutes(api, db, svc, bus)
+	calendar.RegisterRoutes(api, db, bus)
+	incidents.RegisterRoutes(api, db, bus)
+	harvests.RegisterRoutes(api, db)
+	equipment.RegisterRoutes(api, db)
+	users.RegisterRoutes(api, db)
+	notifications.RegisterRoutes(api, db)
+	webhooks.RegisterRoutes(api, db)
+	stream.RegisterRoutes(api, db, bus)
+	export.RegisterRoutes(api, db)
+	importer.RegisterRoutes(api, db)
+	reports.RegisterRoutes(api, db)
+	dashboard.RegisterRoutes(api, db)
+	tags.RegisterRoutes(api, db)
+	team.RegisterRoutes(api, db, bus)
+	admin.RegisterRoutes(api, db, backups)
+
+	// Add Swagger endpoint
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	return router
+}
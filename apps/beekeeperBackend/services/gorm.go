@@ -0,0 +1,479 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"beekeeper-api/access"
+	"beekeeper-api/database"
+	"beekeeper-api/models"
+	"beekeeper-api/tagging"
+)
+
+// NewGORM creates the services storing hives, logs and tasks in a database
+func NewGORM(db *gorm.DB) *Services {
+	return &Services{
+		Hives: &gormHives{db: db},
+		Logs:  &gormLogs{db: db},
+		Tasks: &gormTasks{db: db},
+	}
+}
+
+// FindOrCreateHive finds a hive by its name or creates it if it doesn't exist,
+// using the given database handle or transaction. This implements the "lazy
+// creation" logic described in the ADR for everything written to a hive.
+//
+// The hive is inserted first, skipping the insert if it exists, and read
+// afterwards. Concurrent requests for the same new hive thus never fail on
+// its unique name, and on SQLite the transaction takes the write lock before
+// reading, where waiting for it works.
+func FindOrCreateHive(db *gorm.DB, hiveName int) (models.Hive, error) {
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Hive{HiveName: hiveName}).Error; err != nil {
+		return models.Hive{}, err
+	}
+	var hive models.Hive
+	if err := database.ForUpdate(db).First(&hive, "hive_name = ?", hiveName).Error; err != nil {
+		return models.Hive{}, err
+	}
+	return hive, nil
+}
+
+// notFound turns GORM's missing record error into ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// hiveExists reports whether a hive with a name exists
+func hiveExists(tx *gorm.DB, name int) (bool, error) {
+	var count int64
+	err := tx.Model(&models.Hive{}).Where("hive_name = ?", name).Count(&count).Error
+	return count > 0, err
+}
+
+// visible restricts a query on rows belonging to hives to the hives of the
+// viewer, if any
+func visible(db *gorm.DB, query *gorm.DB, viewer *Viewer, column string) *gorm.DB {
+	if viewer == nil {
+		return query
+	}
+	return query.Where(column+" IN (?)", access.Hives(db, viewer.UserID, viewer.Perm))
+}
+
+// replaceTags sets the tags of an entry: names replace its tags if not nil,
+// hashtags in the content are added
+func replaceTags(tx *gorm.DB, model any, names []string, content string) error {
+	if names == nil && content == "" {
+		return nil
+	}
+	labels, err := tagging.Resolve(tx, tagging.Extract(content), names)
+	if err != nil {
+		return err
+	}
+	if names != nil {
+		return tx.Model(model).Association("Tags").Replace(labels)
+	}
+	return tx.Model(model).Association("Tags").Append(labels)
+}
+
+// --- Hives ---
+
+type gormHives struct {
+	db *gorm.DB
+}
+
+func (s *gormHives) FindOrCreate(name int) (models.Hive, error) {
+	var hive models.Hive
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		hive, err = FindOrCreateHive(tx, name)
+		return err
+	})
+	return hive, err
+}
+
+func (s *gormHives) apiaryExists(tx *gorm.DB, id uint) (bool, error) {
+	var count int64
+	err := tx.Model(&models.Apiary{}).Where("id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+func (s *gormHives) Create(input HiveInput) (models.Hive, error) {
+	hive := models.Hive{HiveName: input.HiveName, ApiaryID: input.ApiaryID}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if input.ApiaryID != nil {
+			exists, err := s.apiaryExists(tx, *input.ApiaryID)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return ErrApiaryNotFound
+			}
+		}
+		// Inserting right away, rather than looking for the name first, lets
+		// concurrent requests for the same name fail with ErrConflict too
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&hive)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrConflict
+		}
+		labels, err := tagging.Resolve(tx, input.Tags)
+		if err != nil {
+			return err
+		}
+		if len(labels) == 0 {
+			hive.Tags = labels
+			return nil
+		}
+		return tx.Model(&hive).Association("Tags").Append(labels)
+	})
+	return hive, err
+}
+
+func (s *gormHives) List(opts ListOptions) ([]models.Hive, error) {
+	hives := []models.Hive{}
+	query := tagging.Filter(s.db.Preload("Tags"), "hive_tags", "hive_id", opts.Tags)
+	query = visible(s.db, query, opts.Viewer, "hive_name")
+	return hives, query.Find(&hives).Error
+}
+
+func (s *gormHives) Get(name int) (models.Hive, error) {
+	var hive models.Hive
+	err := s.db.Preload("Logs").Preload("Tasks").Preload("Tags").First(&hive, "hive_name = ?", name).Error
+	return hive, notFound(err)
+}
+
+func (s *gormHives) Update(name int, input HiveInput) (models.Hive, error) {
+	var hive models.Hive
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&hive, "hive_name = ?", name).Error; err != nil {
+			return notFound(err)
+		}
+
+		updates := map[string]any{}
+		if input.HiveName != 0 && input.HiveName != name {
+			if taken, err := hiveExists(tx, input.HiveName); err != nil || taken {
+				if err == nil {
+					err = ErrConflict
+				}
+				return err
+			}
+			updates["hive_name"] = input.HiveName
+		}
+		if input.ApiaryID != nil {
+			if *input.ApiaryID == 0 {
+				updates["apiary_id"] = nil
+			} else {
+				exists, err := s.apiaryExists(tx, *input.ApiaryID)
+				if err != nil {
+					return err
+				}
+				if !exists {
+					return ErrApiaryNotFound
+				}
+				updates["apiary_id"] = *input.ApiaryID
+			}
+		}
+		if err := tx.Model(&hive).Updates(updates).Error; err != nil {
+			return err
+		}
+		if _, renamed := updates["hive_name"]; renamed {
+			if err := moveEntries(tx, name, input.HiveName); err != nil {
+				return err
+			}
+		}
+
+		if input.Tags != nil {
+			labels, err := tagging.Resolve(tx, input.Tags)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&hive).Association("Tags").Replace(labels); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&hive).Association("Tags").Find(&hive.Tags)
+	})
+	return hive, err
+}
+
+// hiveColumns lists the columns naming the hive rows belong to
+var hiveColumns = []struct{ table, column string }{
+	{"logs", "hive_id"},
+	{"tasks", "hive_id"},
+	{"telemetries", "hive_id"},
+	{"alerts", "hive_id"},
+	{"incidents", "hive_id"},
+	{"harvests", "hive_id"},
+	{"equipment_moves", "from_hive_id"},
+	{"equipment_moves", "to_hive_id"},
+}
+
+// moveEntries moves everything recorded for a renamed hive to its new name.
+// The foreign keys of logs, tasks, readings and alerts already cascade the
+// rename where they are enforced; the other tables have none.
+func moveEntries(tx *gorm.DB, from, to int) error {
+	for _, c := range hiveColumns {
+		if err := tx.Table(c.table).Where(c.column+" = ?", from).Update(c.column, to).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *gormHives) Delete(name int) error {
+	result := s.db.Delete(&models.Hive{}, "hive_name = ?", name)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// --- Logs ---
+
+type gormLogs struct {
+	db *gorm.DB
+}
+
+func (s *gormLogs) Create(input LogInput) (models.Log, error) {
+	var log models.Log
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := FindOrCreateHive(tx, input.HiveID); err != nil {
+			return err
+		}
+
+		labels, err := tagging.Resolve(tx, tagging.Extract(input.Content), input.Tags)
+		if err != nil {
+			return err
+		}
+
+		log = models.Log{
+			HiveID:  input.HiveID,
+			Content: input.Content,
+			Tags:    labels,
+		}
+		return tx.Create(&log).Error
+	})
+	return log, err
+}
+
+func (s *gormLogs) List(opts ListOptions) ([]models.Log, error) {
+	logs := []models.Log{}
+	query := tagging.Filter(s.db.Preload("Weather").Preload("Tags"), "log_tags", "log_id", opts.Tags)
+	query = visible(s.db, query, opts.Viewer, "hive_id")
+	return logs, query.Find(&logs).Error
+}
+
+func (s *gormLogs) Last(opts ListOptions) (models.Log, error) {
+	var log models.Log
+	query := visible(s.db, s.db.Preload("Weather").Preload("Tags"), opts.Viewer, "hive_id")
+	err := query.Order("created_at desc").First(&log).Error
+	return log, notFound(err)
+}
+
+func (s *gormLogs) Get(id uint) (models.Log, error) {
+	var log models.Log
+	err := s.db.Preload("Weather").Preload("Tags").First(&log, id).Error
+	return log, notFound(err)
+}
+
+func (s *gormLogs) Update(id uint, input LogInput) (models.Log, error) {
+	var log models.Log
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&log, id).Error; err != nil {
+			return notFound(err)
+		}
+
+		updates := map[string]any{}
+		if input.Content != "" {
+			updates["content"] = input.Content
+		}
+		if input.HiveID != 0 && input.HiveID != log.HiveID {
+			if _, err := FindOrCreateHive(tx, input.HiveID); err != nil {
+				return err
+			}
+			updates["hive_id"] = input.HiveID
+		}
+		if err := tx.Model(&log).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := replaceTags(tx, &log, input.Tags, input.Content); err != nil {
+			return err
+		}
+		// Reloaded to return what was stored, e.g. timestamps as precise as
+		// the database keeps them
+		return tx.Preload("Tags").First(&log, id).Error
+	})
+	return log, err
+}
+
+func (s *gormLogs) Delete(id uint) (models.Log, error) {
+	var log models.Log
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&log, id).Error; err != nil {
+			return notFound(err)
+		}
+		result := tx.Select("Tags").Delete(&log)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return result.Error
+	})
+	return log, err
+}
+
+// --- Tasks ---
+
+type gormTasks struct {
+	db *gorm.DB
+}
+
+// checkAssignee makes sure a user exists and may work on the tasks of a hive
+func (s *gormTasks) checkAssignee(tx *gorm.DB, userID uint, hiveName int) error {
+	var assignee models.User
+	if tx.First(&assignee, userID).Error != nil {
+		return ErrInvalidAssignee
+	}
+	role, err := access.HiveRole(tx, &assignee, hiveName)
+	if err != nil {
+		return err
+	}
+	if !access.Can(role, access.TasksWrite) {
+		return ErrInvalidAssignee
+	}
+	return nil
+}
+
+func (s *gormTasks) Create(input TaskInput) (models.Task, error) {
+	var task models.Task
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := FindOrCreateHive(tx, input.HiveID); err != nil {
+			return err
+		}
+
+		if input.AssigneeID != nil {
+			if err := s.checkAssignee(tx, *input.AssigneeID, input.HiveID); err != nil {
+				return err
+			}
+		}
+
+		labels, err := tagging.Resolve(tx, tagging.Extract(input.Content), input.Tags)
+		if err != nil {
+			return err
+		}
+
+		task = models.Task{
+			HiveID:     input.HiveID,
+			Content:    input.Content,
+			Priority:   input.Priority,
+			DueAt:      input.DueAt,
+			Tags:       labels,
+			AssigneeID: input.AssigneeID,
+		}
+		return tx.Create(&task).Error
+	})
+	return task, err
+}
+
+func (s *gormTasks) List(opts ListOptions) ([]models.Task, error) {
+	tasks := []models.Task{}
+	query := tagging.Filter(s.db.Preload("Tags"), "task_tags", "task_id", opts.Tags)
+	query = visible(s.db, query, opts.Viewer, "hive_id")
+	if opts.AssigneeID != nil {
+		query = query.Where("assignee_id = ?", *opts.AssigneeID)
+	}
+	return tasks, query.Find(&tasks).Error
+}
+
+func (s *gormTasks) Last(opts ListOptions) (models.Task, error) {
+	var task models.Task
+	query := visible(s.db, s.db.Preload("Tags"), opts.Viewer, "hive_id")
+	err := query.Order("created_at desc").First(&task).Error
+	return task, notFound(err)
+}
+
+func (s *gormTasks) Get(id uint) (models.Task, error) {
+	var task models.Task
+	err := s.db.Preload("Tags").First(&task, id).Error
+	return task, notFound(err)
+}
+
+func (s *gormTasks) Update(id uint, input TaskInput) (models.Task, bool, error) {
+	var task models.Task
+	completed := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&task, id).Error; err != nil {
+			return notFound(err)
+		}
+
+		updates := map[string]any{}
+		if input.Content != "" {
+			updates["content"] = input.Content
+		}
+		if input.Priority != "" {
+			updates["priority"] = input.Priority
+		}
+		if input.DueAt != nil {
+			updates["due_at"] = input.DueAt
+		}
+		if input.HiveID != 0 && input.HiveID != task.HiveID {
+			if _, err := FindOrCreateHive(tx, input.HiveID); err != nil {
+				return err
+			}
+			updates["hive_id"] = input.HiveID
+		}
+		if input.AssigneeID != nil {
+			hiveID := task.HiveID
+			if input.HiveID != 0 {
+				hiveID = input.HiveID
+			}
+			if err := s.checkAssignee(tx, *input.AssigneeID, hiveID); err != nil {
+				return err
+			}
+			updates["assignee_id"] = *input.AssigneeID
+		}
+		// Completing a task stamps it once; reopening it clears the stamp
+		if input.Completed != nil && *input.Completed != (task.CompletedAt != nil) {
+			var completedAt *time.Time
+			if *input.Completed {
+				now := time.Now()
+				completedAt = &now
+				completed = true
+			}
+			updates["completed_at"] = completedAt
+		}
+		if err := tx.Model(&task).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := replaceTags(tx, &task, input.Tags, input.Content); err != nil {
+			return err
+		}
+		return tx.Preload("Tags").First(&task, id).Error
+	})
+	return task, completed, err
+}
+
+func (s *gormTasks) Delete(id uint) (models.Task, error) {
+	var task models.Task
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&task, id).Error; err != nil {
+			return notFound(err)
+		}
+		result := tx.Select("Tags").Delete(&task)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return result.Error
+	})
+	return task, err
+}
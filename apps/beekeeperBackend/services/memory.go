@@ -0,0 +1,458 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"beekeeper-api/models"
+	"beekeeper-api/tagging"
+)
+
+// NewMemory creates services keeping hives, logs and tasks in memory, for
+// testing handlers without a database. It knows no users, apiaries or roles:
+// assignees are not checked, moving a hive into an apiary fails with
+// ErrApiaryNotFound and lists ignore the viewer.
+func NewMemory() *Services {
+	store := &memoryStore{
+		hives: map[int]*models.Hive{},
+		logs:  map[uint]*models.Log{},
+		tasks: map[uint]*models.Task{},
+		tags:  map[string]models.Tag{},
+		ids:   map[string]uint{},
+	}
+	return &Services{
+		Hives: &memoryHives{store},
+		Logs:  &memoryLogs{store},
+		Tasks: &memoryTasks{store},
+	}
+}
+
+// memoryStore holds the records of all memory services, which share one
+// lock like a database
+type memoryStore struct {
+	mu    sync.Mutex
+	hives map[int]*models.Hive
+	logs  map[uint]*models.Log
+	tasks map[uint]*models.Task
+	tags  map[string]models.Tag
+	// ids are the last IDs given out per table
+	ids map[string]uint
+}
+
+func (m *memoryStore) id(table string) uint {
+	m.ids[table]++
+	return m.ids[table]
+}
+
+// findOrCreateHive is FindOrCreateHive with the lock held
+func (m *memoryStore) findOrCreateHive(name int) models.Hive {
+	if hive, ok := m.hives[name]; ok {
+		return *hive
+	}
+	now := time.Now()
+	hive := &models.Hive{ID: m.id("hives"), HiveName: name, CreatedAt: now, UpdatedAt: now}
+	m.hives[name] = hive
+	return *hive
+}
+
+// resolve works like tagging.Resolve
+func (m *memoryStore) resolve(names ...[]string) []models.Tag {
+	seen := map[string]bool{}
+	labels := []models.Tag{}
+	for _, list := range names {
+		for _, name := range list {
+			if name = tagging.Normalize(name); name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			tag, ok := m.tags[name]
+			if !ok {
+				tag = models.Tag{ID: m.id("tags"), Name: name, CreatedAt: time.Now()}
+				m.tags[name] = tag
+			}
+			labels = append(labels, tag)
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+	return labels
+}
+
+// retag sets the tags of an entry like replaceTags
+func (m *memoryStore) retag(current []models.Tag, names []string, content string) []models.Tag {
+	labels := m.resolve(tagging.Extract(content), names)
+	if names == nil {
+		labels = m.resolve(tagNames(current), tagNames(labels))
+	}
+	return labels
+}
+
+// tagged reports whether an entry carries all of the given tags
+func tagged(labels []models.Tag, names []string) bool {
+	have := map[string]bool{}
+	for _, tag := range labels {
+		have[tag.Name] = true
+	}
+	for _, name := range names {
+		if !have[name] {
+			return false
+		}
+	}
+	return true
+}
+
+func tagNames(labels []models.Tag) []string {
+	names := make([]string, 0, len(labels))
+	for _, tag := range labels {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+// --- Hives ---
+
+type memoryHives struct {
+	*memoryStore
+}
+
+func (s *memoryHives) FindOrCreate(name int) (models.Hive, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.findOrCreateHive(name), nil
+}
+
+func (s *memoryHives) Create(input HiveInput) (models.Hive, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if input.ApiaryID != nil {
+		return models.Hive{}, ErrApiaryNotFound
+	}
+	if _, ok := s.hives[input.HiveName]; ok {
+		return models.Hive{}, ErrConflict
+	}
+	now := time.Now()
+	hive := &models.Hive{ID: s.id("hives"), HiveName: input.HiveName, Tags: s.resolve(input.Tags), CreatedAt: now, UpdatedAt: now}
+	s.hives[hive.HiveName] = hive
+	return *hive, nil
+}
+
+func (s *memoryHives) List(opts ListOptions) ([]models.Hive, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hives := []models.Hive{}
+	for _, hive := range s.hives {
+		if tagged(hive.Tags, opts.Tags) {
+			hives = append(hives, *hive)
+		}
+	}
+	sort.Slice(hives, func(i, j int) bool { return hives[i].ID < hives[j].ID })
+	return hives, nil
+}
+
+func (s *memoryHives) Get(name int) (models.Hive, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.hives[name]
+	if !ok {
+		return models.Hive{}, ErrNotFound
+	}
+	hive := *stored
+	hive.Logs = []models.Log{}
+	for _, log := range sortedLogs(s.logs) {
+		if log.HiveID == name {
+			hive.Logs = append(hive.Logs, log)
+		}
+	}
+	hive.Tasks = []models.Task{}
+	for _, task := range sortedTasks(s.tasks) {
+		if task.HiveID == name {
+			hive.Tasks = append(hive.Tasks, task)
+		}
+	}
+	return hive, nil
+}
+
+func (s *memoryHives) Update(name int, input HiveInput) (models.Hive, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hive, ok := s.hives[name]
+	if !ok {
+		return models.Hive{}, ErrNotFound
+	}
+	if input.ApiaryID != nil && *input.ApiaryID != 0 {
+		return models.Hive{}, ErrApiaryNotFound
+	}
+	if input.HiveName != 0 && input.HiveName != name {
+		if _, taken := s.hives[input.HiveName]; taken {
+			return models.Hive{}, ErrConflict
+		}
+		delete(s.hives, name)
+		hive.HiveName = input.HiveName
+		s.hives[hive.HiveName] = hive
+		// Logs and tasks move along, like the foreign keys cascade the rename
+		for _, log := range s.logs {
+			if log.HiveID == name {
+				log.HiveID = input.HiveName
+			}
+		}
+		for _, task := range s.tasks {
+			if task.HiveID == name {
+				task.HiveID = input.HiveName
+			}
+		}
+	}
+	if input.ApiaryID != nil {
+		hive.ApiaryID = nil
+	}
+	if input.Tags != nil {
+		hive.Tags = s.resolve(input.Tags)
+	}
+	hive.UpdatedAt = time.Now()
+	return *hive, nil
+}
+
+func (s *memoryHives) Delete(name int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.hives[name]; !ok {
+		return ErrNotFound
+	}
+	delete(s.hives, name)
+	// Logs and tasks are deleted with their hive, like the foreign keys do
+	for id, log := range s.logs {
+		if log.HiveID == name {
+			delete(s.logs, id)
+		}
+	}
+	for id, task := range s.tasks {
+		if task.HiveID == name {
+			delete(s.tasks, id)
+		}
+	}
+	return nil
+}
+
+// --- Logs ---
+
+type memoryLogs struct {
+	*memoryStore
+}
+
+func (s *memoryLogs) Create(input LogInput) (models.Log, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.findOrCreateHive(input.HiveID)
+	now := time.Now()
+	log := &models.Log{
+		ID:        s.id("logs"),
+		HiveID:    input.HiveID,
+		Content:   input.Content,
+		Tags:      s.resolve(tagging.Extract(input.Content), input.Tags),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.logs[log.ID] = log
+	return *log, nil
+}
+
+func (s *memoryLogs) List(opts ListOptions) ([]models.Log, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	logs := []models.Log{}
+	for _, log := range sortedLogs(s.logs) {
+		if tagged(log.Tags, opts.Tags) {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (s *memoryLogs) Last(opts ListOptions) (models.Log, error) {
+	logs, _ := s.List(ListOptions{})
+	if len(logs) == 0 {
+		return models.Log{}, ErrNotFound
+	}
+	last := logs[0]
+	for _, log := range logs[1:] {
+		if !log.CreatedAt.Before(last.CreatedAt) {
+			last = log
+		}
+	}
+	return last, nil
+}
+
+func (s *memoryLogs) Get(id uint) (models.Log, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	log, ok := s.logs[id]
+	if !ok {
+		return models.Log{}, ErrNotFound
+	}
+	return *log, nil
+}
+
+func (s *memoryLogs) Update(id uint, input LogInput) (models.Log, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	log, ok := s.logs[id]
+	if !ok {
+		return models.Log{}, ErrNotFound
+	}
+	if input.Content != "" {
+		log.Content = input.Content
+	}
+	if input.HiveID != 0 {
+		s.findOrCreateHive(input.HiveID)
+		log.HiveID = input.HiveID
+	}
+	log.Tags = s.retag(log.Tags, input.Tags, input.Content)
+	log.UpdatedAt = time.Now()
+	return *log, nil
+}
+
+func (s *memoryLogs) Delete(id uint) (models.Log, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	log, ok := s.logs[id]
+	if !ok {
+		return models.Log{}, ErrNotFound
+	}
+	delete(s.logs, id)
+	return *log, nil
+}
+
+func sortedLogs(logs map[uint]*models.Log) []models.Log {
+	sorted := make([]models.Log, 0, len(logs))
+	for _, log := range logs {
+		sorted = append(sorted, *log)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return sorted
+}
+
+// --- Tasks ---
+
+type memoryTasks struct {
+	*memoryStore
+}
+
+func (s *memoryTasks) Create(input TaskInput) (models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.findOrCreateHive(input.HiveID)
+	priority := input.Priority
+	if priority == "" {
+		priority = models.PriorityNormal
+	}
+	now := time.Now()
+	task := &models.Task{
+		ID:         s.id("tasks"),
+		HiveID:     input.HiveID,
+		Content:    input.Content,
+		Priority:   priority,
+		DueAt:      input.DueAt,
+		AssigneeID: input.AssigneeID,
+		Tags:       s.resolve(tagging.Extract(input.Content), input.Tags),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	s.tasks[task.ID] = task
+	return *task, nil
+}
+
+func (s *memoryTasks) List(opts ListOptions) ([]models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tasks := []models.Task{}
+	for _, task := range sortedTasks(s.tasks) {
+		if !tagged(task.Tags, opts.Tags) {
+			continue
+		}
+		if opts.AssigneeID != nil && (task.AssigneeID == nil || *task.AssigneeID != *opts.AssigneeID) {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+func (s *memoryTasks) Last(opts ListOptions) (models.Task, error) {
+	tasks, _ := s.List(ListOptions{})
+	if len(tasks) == 0 {
+		return models.Task{}, ErrNotFound
+	}
+	last := tasks[0]
+	for _, task := range tasks[1:] {
+		if !task.CreatedAt.Before(last.CreatedAt) {
+			last = task
+		}
+	}
+	return last, nil
+}
+
+func (s *memoryTasks) Get(id uint) (models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tasks[id]
+	if !ok {
+		return models.Task{}, ErrNotFound
+	}
+	return *task, nil
+}
+
+func (s *memoryTasks) Update(id uint, input TaskInput) (models.Task, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tasks[id]
+	if !ok {
+		return models.Task{}, false, ErrNotFound
+	}
+	if input.Content != "" {
+		task.Content = input.Content
+	}
+	if input.Priority != "" {
+		task.Priority = input.Priority
+	}
+	if input.DueAt != nil {
+		task.DueAt = input.DueAt
+	}
+	if input.HiveID != 0 {
+		s.findOrCreateHive(input.HiveID)
+		task.HiveID = input.HiveID
+	}
+	if input.AssigneeID != nil {
+		task.AssigneeID = input.AssigneeID
+	}
+	completed := false
+	if input.Completed != nil && *input.Completed != (task.CompletedAt != nil) {
+		task.CompletedAt = nil
+		if *input.Completed {
+			now := time.Now()
+			task.CompletedAt = &now
+			completed = true
+		}
+	}
+	task.Tags = s.retag(task.Tags, input.Tags, input.Content)
+	task.UpdatedAt = time.Now()
+	return *task, completed, nil
+}
+
+func (s *memoryTasks) Delete(id uint) (models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tasks[id]
+	if !ok {
+		return models.Task{}, ErrNotFound
+	}
+	delete(s.tasks, id)
+	return *task, nil
+}
+
+func sortedTasks(tasks map[uint]*models.Task) []models.Task {
+	sorted := make([]models.Task, 0, len(tasks))
+	for _, task := range tasks {
+		sorted = append(sorted, *task)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return sorted
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"beekeeper-api/access"
+	"beekeeper-api/auth"
+	"beekeeper-api/models"
+)
+
+// ErrNotFound is returned for hives, logs and tasks that do not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict is returned when a hive is created or renamed to a name that is
+// taken
+var ErrConflict = errors.New("hive already exists")
+
+// ErrApiaryNotFound is returned when a hive is put into an unknown apiary
+var ErrApiaryNotFound = errors.New("apiary not found")
+
+// ErrInvalidAssignee is returned when a task is assigned to an unknown user
+// or one without access to its hive
+var ErrInvalidAssignee = errors.New("invalid assignee")
+
+// Services are the hive, log and task rules shared by all features, such as
+// creating a hive the first time something is written for it.
+type Services struct {
+	Hives HiveService
+	Logs  LogService
+	Tasks TaskService
+}
+
+// HiveService manages hives, which are addressed by their name
+type HiveService interface {
+	// FindOrCreate returns the hive with a name, creating it if needed
+	FindOrCreate(name int) (models.Hive, error)
+	Create(input HiveInput) (models.Hive, error)
+	List(opts ListOptions) ([]models.Hive, error)
+	// Get returns a hive with its logs, tasks and tags
+	Get(name int) (models.Hive, error)
+	Update(name int, input HiveInput) (models.Hive, error)
+	Delete(name int) error
+}
+
+// LogService manages log entries
+type LogService interface {
+	// Create adds a log entry, creating its hive if needed
+	Create(input LogInput) (models.Log, error)
+	List(opts ListOptions) ([]models.Log, error)
+	// Last returns the most recently created log entry
+	Last(opts ListOptions) (models.Log, error)
+	Get(id uint) (models.Log, error)
+	Update(id uint, input LogInput) (models.Log, error)
+	// Delete removes a log entry and returns it
+	Delete(id uint) (models.Log, error)
+}
+
+// TaskService manages tasks
+type TaskService interface {
+	// Create adds a task, creating its hive if needed
+	Create(input TaskInput) (models.Task, error)
+	List(opts ListOptions) ([]models.Task, error)
+	// Last returns the most recently created task
+	Last(opts ListOptions) (models.Task, error)
+	Get(id uint) (models.Task, error)
+	// Update changes a task and reports whether this completed it
+	Update(id uint, input TaskInput) (models.Task, bool, error)
+	// Delete removes a task and returns it
+	Delete(id uint) (models.Task, error)
+}
+
+// HiveInput is a new hive or changes to one. On updates zero values are left
+// unchanged, an ApiaryID of 0 removes the hive from its apiary and Tags, if
+// not nil, replace the hive's tags.
+type HiveInput struct {
+	HiveName int
+	ApiaryID *uint
+	Tags     []string
+}
+
+// LogInput is a new log entry or changes to one. On updates zero values are
+// left unchanged and Tags, if not nil, replace the entry's tags. Hashtags in
+// the content are always added.
+type LogInput struct {
+	HiveID  int
+	Content string
+	Tags    []string
+}
+
+// TaskInput is a new task or changes to one, like LogInput. Completed marks
+// an existing task as done or open again.
+type TaskInput struct {
+	HiveID     int
+	Content    string
+	Priority   string
+	DueAt      *time.Time
+	AssigneeID *uint
+	Completed  *bool
+	Tags       []string
+}
+
+// ListOptions filter lists of hives, logs and tasks
+type ListOptions struct {
+	// Tags only lists entries carrying all of these tags
+	Tags []string
+	// AssigneeID only lists tasks assigned to this user
+	AssigneeID *uint
+	// Viewer only lists entries of hives the viewer holds a permission for;
+	// nil lists all
+	Viewer *Viewer
+}
+
+// Viewer is a user seeing a list with a permission
+type Viewer struct {
+	UserID uint
+	Perm   access.Permission
+}
+
+// ViewerFrom returns the current user of a request as viewer holding perm,
+// nil for anonymous requests, which are not restricted.
+func ViewerFrom(c *gin.Context, perm access.Permission) *Viewer {
+	user, ok := auth.CurrentUser(c)
+	if !ok {
+		return nil
+	}
+	return &Viewer{UserID: user.ID, Perm: perm}
+}
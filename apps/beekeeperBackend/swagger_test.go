@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// The API documentation in docs/swagger.json is the golden file responses are
+// checked against: every status a handler returns must be documented for its
+// route, and every body must match the documented schema without properties
+// the documentation does not know.
+
+type swaggerDoc struct {
+	BasePath string `json:"basePath"`
+	Paths    map[string]map[string]struct {
+		Responses map[string]struct {
+			Schema *schema `json:"schema"`
+		} `json:"responses"`
+	} `json:"paths"`
+	Definitions map[string]*schema `json:"definitions"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Items                *schema            `json:"items"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties *schema            `json:"additionalProperties"`
+	AllOf                []*schema          `json:"allOf"`
+}
+
+var (
+	swaggerOnce sync.Once
+	swagger     swaggerDoc
+	swaggerErr  error
+)
+
+func loadSwagger() (*swaggerDoc, error) {
+	swaggerOnce.Do(func() {
+		data, err := os.ReadFile("docs/swagger.json")
+		if err != nil {
+			swaggerErr = err
+			return
+		}
+		swaggerErr = json.Unmarshal(data, &swagger)
+	})
+	return &swagger, swaggerErr
+}
+
+// route finds the documented route of a request path, preferring literal
+// segments such as /logs/last over parameters such as /logs/{id}
+func (d *swaggerDoc) route(path string) (string, bool) {
+	path, _, _ = strings.Cut(path, "?")
+	path = strings.TrimPrefix(path, d.BasePath)
+	segments := strings.Split(path, "/")
+
+	best, bestParams := "", math.MaxInt
+	for route := range d.Paths {
+		parts := strings.Split(route, "/")
+		if len(parts) != len(segments) {
+			continue
+		}
+		params, match := 0, true
+		for i, part := range parts {
+			if strings.HasPrefix(part, "{") {
+				params++
+			} else if part != segments[i] {
+				match = false
+				break
+			}
+		}
+		if match && params < bestParams {
+			best, bestParams = route, params
+		}
+	}
+	return best, best != ""
+}
+
+// checkDocumented checks a response against the API documentation. Only
+// JSON bodies are validated against the schema; downloads such as PDF reports
+// and event streams are only checked for a documented status.
+func checkDocumented(method, path string, status int, contentType string, body []byte) error {
+	doc, err := loadSwagger()
+	if err != nil {
+		return err
+	}
+	route, ok := doc.route(path)
+	if !ok {
+		return fmt.Errorf("no documented route")
+	}
+	operation, ok := doc.Paths[route][strings.ToLower(method)]
+	if !ok {
+		return fmt.Errorf("%s %s is not documented", method, route)
+	}
+	response, ok := operation.Responses[strconv.Itoa(status)]
+	if !ok {
+		return fmt.Errorf("status %d is not documented for %s %s", status, method, route)
+	}
+	if response.Schema == nil {
+		// Redirects carry a short HTML link for browsers that do not follow them
+		if len(body) > 0 && (status < 300 || status >= 400) {
+			return fmt.Errorf("status %d is documented without a body", status)
+		}
+		return nil
+	}
+
+	// Documents such as iCalendar feeds are described as strings
+	if response.Schema.Type == "string" && !json.Valid(body) {
+		return nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return fmt.Errorf("body is not JSON: %v", err)
+	}
+	return doc.validate(value, response.Schema, "body")
+}
+
+// validate checks a decoded JSON value against a schema. Swagger 2 cannot
+// mark properties as nullable, so null is accepted everywhere.
+func (d *swaggerDoc) validate(value any, s *schema, at string) error {
+	if value == nil || s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/definitions/")
+		def, ok := d.Definitions[name]
+		if !ok {
+			return fmt.Errorf("%s: unknown definition %s", at, name)
+		}
+		return d.validate(value, def, at)
+	}
+	for _, part := range s.AllOf {
+		if err := d.validate(value, part, at); err != nil {
+			return err
+		}
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: got %T, want an object", at, value)
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			property, documented := s.Properties[key]
+			if !documented {
+				property = s.AdditionalProperties
+			}
+			if property == nil {
+				if s.Properties == nil {
+					continue
+				}
+				return fmt.Errorf("%s: undocumented property %q", at, key)
+			}
+			if err := d.validate(object[key], property, at+"."+key); err != nil {
+				return err
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: got %T, want an array", at, value)
+		}
+		for i, item := range items {
+			if err := d.validate(item, s.Items, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: got %T, want a string", at, value)
+		}
+	case "integer":
+		if number, ok := value.(float64); !ok || number != math.Trunc(number) {
+			return fmt.Errorf("%s: got %v, want an integer", at, value)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: got %T, want a number", at, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: got %T, want a boolean", at, value)
+		}
+	}
+	return nil
+}
+
+// TestSwaggerDocumentsRoutes makes sure every hive, log and task route is in
+// the documentation, so no response escapes the checks above
+func TestSwaggerDocumentsRoutes(t *testing.T) {
+	doc, err := loadSwagger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newMemoryServer(t)
+	for _, r := range s.router.Routes() {
+		if !strings.HasPrefix(r.Path, "/api/hives") && !strings.HasPrefix(r.Path, "/api/logs") && !strings.HasPrefix(r.Path, "/api/tasks") {
+			continue
+		}
+		route := strings.TrimPrefix(r.Path, doc.BasePath)
+		route = strings.ReplaceAll(route, ":id", "{id}")
+		if _, ok := doc.Paths[route][strings.ToLower(r.Method)]; !ok {
+			t.Errorf("%s %s is not documented", r.Method, r.Path)
+		}
+	}
+}
+
+// TestSwaggerValidation makes sure the checks above catch mismatches
+func TestSwaggerValidation(t *testing.T) {
+	const jsonType = "application/json; charset=utf-8"
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		ctype  string
+		body   string
+		ok     bool
+	}{
+		{"hive", "GET", "/api/hives/1", 200, jsonType, `{"id":1,"hive_name":1,"apiary_id":null,"tags":[{"id":1,"name":"nuc"}]}`, true},
+		{"list", "GET", "/api/logs", 200, jsonType, `[{"id":1,"hive_id":1,"content":"ok"}]`, true},
+		{"error", "GET", "/api/tasks/1", 404, jsonType, `{"error":"Task not found"}`, true},
+		{"no content", "DELETE", "/api/hives/1", 204, jsonType, ``, true},
+		{"literal route", "GET", "/api/logs/last", 200, jsonType, `{"id":1}`, true},
+		{"undocumented property", "GET", "/api/hives/1", 200, jsonType, `{"id":1,"secret":"x"}`, false},
+		{"wrong type", "GET", "/api/hives/1", 200, jsonType, `{"hive_name":"one"}`, false},
+		{"fraction", "GET", "/api/hives/1", 200, jsonType, `{"hive_name":1.5}`, false},
+		{"nested", "GET", "/api/hives/1", 200, jsonType, `{"logs":[{"hive_id":"x"}]}`, false},
+		{"object for array", "GET", "/api/logs", 200, jsonType, `{"id":1}`, false},
+		{"undocumented status", "GET", "/api/hives", 418, jsonType, `{"error":"teapot"}`, false},
+		{"undocumented method", "PUT", "/api/hives/1", 200, jsonType, `{}`, false},
+		{"body without schema", "DELETE", "/api/hives/1", 204, jsonType, `{}`, false},
+		{"download", "GET", "/api/hives/1/report.pdf", 200, "application/pdf", `%PDF-1.3`, true},
+		{"undocumented download status", "GET", "/api/hives/1/report.pdf", 418, "application/pdf", `%PDF-1.3`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDocumented(tt.method, tt.path, tt.status, tt.ctype, []byte(tt.body))
+			if (err == nil) != tt.ok {
+				t.Errorf("got error %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
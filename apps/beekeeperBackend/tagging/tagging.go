@@ -0,0 +1,92 @@
+package tagging
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"beekeeper-api/models"
+)
+
+// Hashtags are written as "#winter-prep" or dictated as "hashtag winter-prep".
+var hashtagPattern = regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}_&/#])(?:#|hash\s?tag\s+)([\p{L}\p{N}][\p{L}\p{N}_-]*)`)
+
+var invalidChars = regexp.MustCompile(`[^\p{L}\p{N}_-]+`)
+
+// Normalize turns a label into its stored form: lower case, with runs of
+// spaces and punctuation replaced by a single dash.
+func Normalize(name string) string {
+	name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), "#")))
+	return strings.Trim(invalidChars.ReplaceAllString(name, "-"), "-_")
+}
+
+// Extract returns the hashtags mentioned in an entry's content.
+func Extract(content string) []string {
+	var names []string
+	for _, match := range hashtagPattern.FindAllStringSubmatch(content, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// Resolve finds or creates the tags for the given names, skipping empty and
+// duplicate names. The result is sorted by name.
+func Resolve(tx *gorm.DB, names ...[]string) ([]models.Tag, error) {
+	seen := map[string]bool{}
+	var unique []string
+	for _, list := range names {
+		for _, name := range list {
+			if name = Normalize(name); name != "" && !seen[name] {
+				seen[name] = true
+				unique = append(unique, name)
+			}
+		}
+	}
+	tags := []models.Tag{}
+	if len(unique) == 0 {
+		return tags, nil
+	}
+	sort.Strings(unique)
+
+	for _, name := range unique {
+		tags = append(tags, models.Tag{Name: name})
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+		return nil, err
+	}
+	// Tags that already existed were skipped by the insert and have no ID yet
+	if err := tx.Where("name IN ?", unique).Order("name").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// TagHashtags adds the tags of the hashtags in an entry's content to the
+// entry, a log or task, as when it is written through the API.
+func TagHashtags(tx *gorm.DB, model any, content string) error {
+	labels, err := Resolve(tx, Extract(content))
+	if err != nil || len(labels) == 0 {
+		return err
+	}
+	return tx.Model(model).Association("Tags").Append(labels)
+}
+
+// Filter restricts a query to entries carrying all of the given tags.
+// joinTable and column name the many-to-many table and its foreign key, e.g.
+// "log_tags" and "log_id".
+func Filter(query *gorm.DB, joinTable, column string, names []string) *gorm.DB {
+	if len(names) == 0 {
+		return query
+	}
+	sub := query.Session(&gorm.Session{NewDB: true}).
+		Table(joinTable).
+		Select(joinTable+"."+column).
+		Joins("JOIN tags ON tags.id = "+joinTable+".tag_id").
+		Where("tags.name IN ?", names).
+		Group(joinTable+"."+column).
+		Having("COUNT(DISTINCT tags.id) = ?", len(names))
+	return query.Where("id IN (?)", sub)
+}
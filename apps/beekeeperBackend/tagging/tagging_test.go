@@ -0,0 +1,60 @@
+package tagging
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"none", "Calm colony, eight frames of brood", nil},
+		{"written", "Queen missing #requeen", []string{"requeen"}},
+		{"dictated", "Queen missing hashtag requeen", []string{"requeen"}},
+		{"dictated as two words", "Queen missing hash tag requeen", []string{"requeen"}},
+		{"dictated in capitals", "Queen missing Hashtag Requeen", []string{"Requeen"}},
+		{"hashtag without a word", "Ends with hashtag, #", nil},
+		{"at the start", "#requeen soon", []string{"requeen"}},
+		{"followed by punctuation", "Needs a new queen (#requeen), then #feed.", []string{"requeen", "feed"}},
+		{"with dashes and underscores", "#winter-prep and #mite_count", []string{"winter-prep", "mite_count"}},
+		{"case kept for Normalize", "#Requeen #REQUEEN", []string{"Requeen", "REQUEEN"}},
+		{"duplicates kept for Resolve", "#requeen then hashtag requeen", []string{"requeen", "requeen"}},
+		{"not a word", "# requeen and #-requeen", nil},
+		{"inside a word", "issue#12 and a&#39;b", nil},
+		{"in a link", "see https://example.com/hives#requeen", nil},
+		{"repeated sign", "##requeen", nil},
+		{"letters beyond ASCII", "#Königin #пасіка", []string{"Königin", "пасіка"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Extract(tt.content); !slices.Equal(got, tt.want) {
+				t.Errorf("Extract(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"requeen", "requeen"},
+		{"#Requeen", "requeen"},
+		{"  REQUEEN ", "requeen"},
+		{"Winter Prep", "winter-prep"},
+		{"winter, prep!", "winter-prep"},
+		{"-mite_count_", "mite_count"},
+		{"Königin", "königin"},
+		{"!!!", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.name); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
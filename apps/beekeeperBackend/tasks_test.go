@@ -0,0 +1,326 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"beekeeper-api/models"
+)
+
+func TestTasks(t *testing.T) {
+	createTasks := func(s *testServer) {
+		s.do(http.MethodPost, "/api/tasks", map[string]any{"hiveID": 1, "content": "Feed #syrup"}, http.StatusCreated, nil)
+		s.do(http.MethodPost, "/api/tasks", map[string]any{"hiveID": 2, "content": "Requeen", "priority": "high", "tags": []string{"queen", "syrup"}}, http.StatusCreated, nil)
+		s.do(http.MethodPost, "/api/tasks", map[string]any{"hiveID": 1, "content": "Add super"}, http.StatusCreated, nil)
+	}
+	// assign creates an editor, assigns the second task to them and signs in
+	// as an owner
+	assign := func(s *testServer) {
+		createTasks(s)
+		editor, _ := s.user(models.RoleEditor)
+		s.signIn(models.RoleOwner)
+		if err := s.db.Model(&models.Task{}).Where("id = ?", 2).Update("assignee_id", editor.ID).Error; err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	due := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	runCases(t, []apiCase{
+		{
+			name:   "create for a new hive",
+			method: http.MethodPost, path: "/api/tasks",
+			body:   map[string]any{"hiveID": 5, "content": "Treat for #varroa", "dueAt": due, "tags": []string{"Autumn"}},
+			status: http.StatusCreated,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				task := decode[models.Task](t, body)
+				if task.ID == 0 || task.HiveID != 5 || task.DueAt == nil || !task.DueAt.Equal(due) || task.CompletedAt != nil {
+					t.Errorf("got %+v", task)
+				}
+				if got, want := tagNames(task.Tags), []string{"autumn", "varroa"}; !reflect.DeepEqual(got, want) {
+					t.Errorf("got tags %v, want %v", got, want)
+				}
+				s.do(http.MethodGet, "/api/hives/5", nil, http.StatusOK, nil)
+			},
+		},
+		{
+			name:   "create defaults to normal priority",
+			method: http.MethodPost, path: "/api/tasks",
+			body:   map[string]any{"hiveID": 5, "content": "Feed"},
+			status: http.StatusCreated,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				var task models.Task
+				s.do(http.MethodGet, "/api/tasks/1", nil, http.StatusOK, &task)
+				if task.Priority != models.PriorityNormal {
+					t.Errorf("got priority %q, want %q", task.Priority, models.PriorityNormal)
+				}
+			},
+		},
+		{
+			name:   "create with unknown priority",
+			method: http.MethodPost, path: "/api/tasks",
+			body:   map[string]any{"hiveID": 5, "content": "Feed", "priority": "urgent"},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "create without content",
+			method: http.MethodPost, path: "/api/tasks",
+			body:   map[string]any{"hiveID": 5},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "create with malformed due date",
+			method: http.MethodPost, path: "/api/tasks",
+			body:   map[string]any{"hiveID": 5, "content": "Feed", "dueAt": "tomorrow"},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "create with assignee",
+			method: http.MethodPost, path: "/api/tasks",
+			body: map[string]any{"hiveID": 5, "content": "Feed", "assigneeID": 1},
+			setup: func(s *testServer) {
+				s.user(models.RoleEditor)
+				s.signIn(models.RoleOwner)
+			},
+			status: http.StatusCreated,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				if task := decode[models.Task](t, body); task.AssigneeID == nil || *task.AssigneeID != 1 {
+					t.Errorf("got assignee %v, want 1", task.AssigneeID)
+				}
+			},
+			dbOnly: true,
+		},
+		{
+			name:   "create with unknown assignee",
+			method: http.MethodPost, path: "/api/tasks",
+			body:   map[string]any{"hiveID": 5, "content": "Feed", "assigneeID": 99},
+			status: http.StatusBadRequest,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				hasError("Assignee not found or has no access to this hive")(t, s, body)
+				// The hive created for the task is rolled back with it
+				s.do(http.MethodGet, "/api/hives/5", nil, http.StatusNotFound, nil)
+			},
+			dbOnly: true,
+		},
+		{
+			name:   "create with assignee who may not write",
+			method: http.MethodPost, path: "/api/tasks",
+			body: map[string]any{"hiveID": 5, "content": "Feed", "assigneeID": 1},
+			setup: func(s *testServer) {
+				s.user(models.RoleViewer)
+				s.signIn(models.RoleOwner)
+			},
+			status: http.StatusBadRequest,
+			dbOnly: true,
+		},
+		{
+			name:   "list",
+			setup:  createTasks,
+			method: http.MethodGet, path: "/api/tasks",
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				if tasks := decode[[]models.Task](t, body); len(tasks) != 3 {
+					t.Errorf("got %d tasks, want 3", len(tasks))
+				}
+			},
+		},
+		{
+			name:   "list by tags",
+			setup:  createTasks,
+			method: http.MethodGet, path: "/api/tasks?tag=syrup",
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				if tasks := decode[[]models.Task](t, body); len(tasks) != 2 {
+					t.Errorf("got %d tasks, want 2", len(tasks))
+				}
+			},
+		},
+		{
+			name:   "list by assignee",
+			setup:  assign,
+			method: http.MethodGet, path: "/api/tasks?assignee_id=1",
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				tasks := decode[[]models.Task](t, body)
+				if len(tasks) != 1 || tasks[0].ID != 2 {
+					t.Errorf("got %+v, want task 2", tasks)
+				}
+			},
+			dbOnly: true,
+		},
+		{
+			name:   "list by invalid assignee",
+			method: http.MethodGet, path: "/api/tasks?assignee_id=me",
+			status: http.StatusBadRequest,
+			check:  hasError("Invalid assignee_id"),
+		},
+		{
+			name:   "get",
+			setup:  createTasks,
+			method: http.MethodGet, path: "/api/tasks/2",
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				task := decode[models.Task](t, body)
+				if task.Content != "Requeen" || task.Priority != models.PriorityHigh || len(task.Tags) != 2 {
+					t.Errorf("got %+v", task)
+				}
+			},
+		},
+		{
+			name:   "get missing",
+			method: http.MethodGet, path: "/api/tasks/99",
+			status: http.StatusNotFound,
+			check:  hasError("Task not found"),
+		},
+		{
+			name:   "get with invalid ID",
+			method: http.MethodGet, path: "/api/tasks/1.5",
+			status: http.StatusBadRequest,
+			check:  hasError("Invalid ID"),
+		},
+		{
+			name:   "last",
+			setup:  createTasks,
+			method: http.MethodGet, path: "/api/tasks/last",
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				if task := decode[models.Task](t, body); task.Content != "Add super" {
+					t.Errorf("got %+v, want the last task", task)
+				}
+			},
+		},
+		{
+			name:   "last without tasks",
+			method: http.MethodGet, path: "/api/tasks/last",
+			status: http.StatusNotFound,
+			check:  hasError("No tasks found"),
+		},
+		{
+			name:   "complete",
+			setup:  createTasks,
+			method: http.MethodPut, path: "/api/tasks/1",
+			body:   map[string]any{"completed": true, "priority": "low"},
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				task := decode[models.Task](t, body)
+				if task.CompletedAt == nil || task.Priority != models.PriorityLow || task.Content != "Feed #syrup" {
+					t.Fatalf("got %+v", task)
+				}
+				// Completing it again keeps the first stamp
+				var again models.Task
+				s.do(http.MethodPut, "/api/tasks/1", map[string]any{"completed": true}, http.StatusOK, &again)
+				if again.CompletedAt == nil || !again.CompletedAt.Equal(*task.CompletedAt) {
+					t.Errorf("got completed at %v, want %v", again.CompletedAt, task.CompletedAt)
+				}
+			},
+		},
+		{
+			name: "reopen",
+			setup: func(s *testServer) {
+				createTasks(s)
+				s.do(http.MethodPut, "/api/tasks/1", map[string]any{"completed": true}, http.StatusOK, nil)
+			},
+			method: http.MethodPut, path: "/api/tasks/1",
+			body:   map[string]any{"completed": false},
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				if task := decode[models.Task](t, body); task.CompletedAt != nil {
+					t.Errorf("got completed at %v, want none", task.CompletedAt)
+				}
+			},
+		},
+		{
+			name:   "update content and tags",
+			setup:  createTasks,
+			method: http.MethodPut, path: "/api/tasks/2",
+			body:   map[string]any{"content": "Requeen with #buckfast", "tags": []string{"queen"}},
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				task := decode[models.Task](t, body)
+				if got, want := tagNames(task.Tags), []string{"buckfast", "queen"}; !reflect.DeepEqual(got, want) {
+					t.Errorf("got tags %v, want %v", got, want)
+				}
+				if task.Priority != models.PriorityHigh {
+					t.Errorf("got priority %q, want it kept", task.Priority)
+				}
+			},
+		},
+		{
+			name:   "update moves to a new hive",
+			setup:  createTasks,
+			method: http.MethodPut, path: "/api/tasks/2",
+			body:   map[string]any{"hiveID": 9},
+			status: http.StatusOK,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				var hive models.Hive
+				s.do(http.MethodGet, "/api/hives/9", nil, http.StatusOK, &hive)
+				if len(hive.Tasks) != 1 || hive.Tasks[0].ID != 2 {
+					t.Errorf("got %+v", hive.Tasks)
+				}
+			},
+		},
+		{
+			name:   "update with unknown priority",
+			setup:  createTasks,
+			method: http.MethodPut, path: "/api/tasks/1",
+			body:   map[string]any{"priority": "urgent"},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "update missing",
+			method: http.MethodPut, path: "/api/tasks/99",
+			body:   map[string]any{"completed": true},
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "update missing with invalid input",
+			method: http.MethodPut, path: "/api/tasks/99",
+			body:   `{"hiveID":"one"}`,
+			status: http.StatusNotFound,
+			check:  hasError("Task not found"),
+		},
+		{
+			name:   "update with invalid ID",
+			method: http.MethodPut, path: "/api/tasks/abc",
+			body:   map[string]any{"completed": true},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "delete",
+			setup:  createTasks,
+			method: http.MethodDelete, path: "/api/tasks/2",
+			status: http.StatusNoContent,
+			check: func(t *testing.T, s *testServer, body []byte) {
+				s.do(http.MethodGet, "/api/tasks/2", nil, http.StatusNotFound, nil)
+				var tasks []models.Task
+				s.do(http.MethodGet, "/api/tasks", nil, http.StatusOK, &tasks)
+				if len(tasks) != 2 {
+					t.Errorf("got %d tasks, want 2", len(tasks))
+				}
+			},
+		},
+		{
+			name:   "delete as inspector",
+			setup:  createTasks,
+			method: http.MethodDelete, path: "/api/tasks/2",
+			token: func(s *testServer) string {
+				_, token := s.user(models.RoleInspector)
+				return token
+			},
+			status: http.StatusForbidden,
+			dbOnly: true,
+		},
+		{
+			name:   "delete missing",
+			method: http.MethodDelete, path: "/api/tasks/99",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "delete with invalid ID",
+			method: http.MethodDelete, path: fmt.Sprintf("/api/tasks/%d0", uint64(1<<63)),
+			status: http.StatusBadRequest,
+		},
+	})
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"beekeeper-api/features/team"
+	"beekeeper-api/models"
+)
+
+// TestTeamTasks assigns tasks of hive 1, in a members only apiary Ana works
+// in, and of hive 2 outside of apiaries, and checks everyone's task lists,
+// the workload and that tasks cannot go to Ben, who is not in the team
+func TestTeamTasks(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.signIn(models.RoleOwner)
+	ownerToken := s.token
+	apiary := s.createMembersOnlyApiary()
+	s.do(http.MethodPost, "/api/hives", map[string]any{"hiveName": 1, "apiaryID": apiary}, http.StatusCreated, nil)
+	s.do(http.MethodPost, "/api/hives", map[string]any{"hiveName": 2}, http.StatusCreated, nil)
+
+	// person creates a user without a global role under the name
+	person := func(name string) (models.User, string) {
+		user, token := s.user(models.RoleNone)
+		if err := s.db.Model(&user).Update("name", name).Error; err != nil {
+			t.Fatal(err)
+		}
+		user.Name = name
+		return user, token
+	}
+	ana, anaToken := person("Ana")
+	ben, _ := person("Ben")
+	s.do(http.MethodPost, fmt.Sprintf("/api/apiaries/%d/members", apiary), map[string]any{"userID": ana.ID, "role": models.RoleEditor}, http.StatusCreated, nil)
+
+	// task creates a task and returns its ID
+	task := func(hive int, content string, assignee *uint, due *time.Time) uint {
+		var created models.Task
+		s.do(http.MethodPost, "/api/tasks", map[string]any{"hiveID": hive, "content": content, "assigneeID": assignee, "dueAt": due}, http.StatusCreated, &created)
+		return created.ID
+	}
+	yesterday := time.Now().Add(-24 * time.Hour)
+	overdue := task(1, "Treat against varroa", &ana.ID, &yesterday)
+	open := task(1, "Add a super", &ana.ID, nil)
+	completed := task(1, "Mark the queen", &ana.ID, nil)
+	s.do(http.MethodPut, fmt.Sprintf("/api/tasks/%d", completed), map[string]any{"hiveID": 1, "content": "Mark the queen", "completed": true}, http.StatusOK, nil)
+	unassigned := task(1, "Clean the bottom board", nil, nil)
+	ownTask := task(2, "Order jars", &owner.ID, nil)
+
+	t.Run("my tasks", func(t *testing.T) {
+		// myTasks returns the IDs of the tasks listed at the path
+		myTasks := func(path, token string) []uint {
+			var ids []uint
+			for _, task := range decode[[]models.Task](t, s.request(http.MethodGet, path, nil, token).Body.Bytes()) {
+				ids = append(ids, task.ID)
+			}
+			return ids
+		}
+		// Tasks due first, then the others by creation
+		if got, want := myTasks("/api/me/tasks", anaToken), []uint{overdue, open}; !slices.Equal(got, want) {
+			t.Errorf("got Ana's tasks %v, want %v", got, want)
+		}
+		if got, want := myTasks("/api/me/tasks?status=all", anaToken), []uint{overdue, open, completed}; !slices.Equal(got, want) {
+			t.Errorf("got all of Ana's tasks %v, want %v", got, want)
+		}
+		if got, want := myTasks("/api/me/tasks", ownerToken), []uint{ownTask}; !slices.Equal(got, want) {
+			t.Errorf("got the owner's tasks %v, want %v", got, want)
+		}
+	})
+
+	t.Run("workload", func(t *testing.T) {
+		counts := func(workload team.Workload) map[string]team.TaskCounts {
+			people := make(map[string]team.TaskCounts)
+			for _, person := range workload.People {
+				people[person.Name] = person.TaskCounts
+			}
+			return people
+		}
+		var workload team.Workload
+		s.do(http.MethodGet, "/api/team/workload", nil, http.StatusOK, &workload)
+		want := map[string]team.TaskCounts{
+			"Ana":            {Open: 2, Overdue: 1, CompletedThisWeek: 1},
+			"Ben":            {},
+			models.RoleOwner: {Open: 1},
+		}
+		if got := counts(workload); !maps.Equal(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+		if workload.Unassigned != (team.TaskCounts{Open: 1}) {
+			t.Errorf("got %+v unassigned, want one open task", workload.Unassigned)
+		}
+
+		// Only the team and the owner work on the apiary's hives
+		s.do(http.MethodGet, fmt.Sprintf("/api/team/workload?apiary_id=%d", apiary), nil, http.StatusOK, &workload)
+		want = map[string]team.TaskCounts{
+			"Ana":            {Open: 2, Overdue: 1, CompletedThisWeek: 1},
+			models.RoleOwner: {},
+		}
+		if got := counts(workload); !maps.Equal(got, want) {
+			t.Errorf("got %+v in the apiary, want %+v", got, want)
+		}
+	})
+
+	t.Run("assign outside the team", func(t *testing.T) {
+		path := fmt.Sprintf("/api/tasks/%d/assignee", unassigned)
+		rec := s.request(http.MethodPut, path, map[string]any{"userID": ben.ID}, ownerToken)
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusForbidden, rec.Body)
+		}
+		hasError("Assignee has no access to this hive")(t, s, rec.Body.Bytes())
+		s.do(http.MethodPut, path, map[string]any{"userID": 999}, http.StatusBadRequest, nil)
+		s.do(http.MethodPut, path, map[string]any{"userID": ana.ID}, http.StatusOK, nil)
+	})
+
+	t.Run("reassign outside the team", func(t *testing.T) {
+		var result team.ReassignResult
+		s.do(http.MethodPost, "/api/tasks/reassign", map[string]any{"fromUserID": ana.ID, "toUserID": ben.ID}, http.StatusOK, &result)
+		slices.Sort(result.Skipped)
+		if len(result.Reassigned) != 0 || !slices.Equal(result.Skipped, []uint{overdue, open, unassigned}) {
+			t.Errorf("got %+v, want Ana's open tasks skipped", result)
+		}
+		var tasks []models.Task
+		if err := s.db.Where("assignee_id = ?", ben.ID).Find(&tasks).Error; err != nil {
+			t.Fatal(err)
+		}
+		if len(tasks) != 0 {
+			t.Errorf("got %d tasks assigned to Ben, want none", len(tasks))
+		}
+		s.do(http.MethodPost, "/api/tasks/reassign", map[string]any{"fromUserID": ana.ID, "toUserID": 999}, http.StatusBadRequest, nil)
+	})
+}
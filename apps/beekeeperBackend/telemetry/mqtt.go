@@ -0,0 +1,131 @@
+package telemetry
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"gorm.io/gorm"
+
+	"beekeeper-api/config"
+	"beekeeper-api/models"
+	"beekeeper-api/services"
+)
+
+// Bridge subscribes to the configured MQTT topics and stores every sensor
+// reading it receives as hive telemetry.
+type Bridge struct {
+	db       *gorm.DB
+	client   mqtt.Client
+	patterns []topicPattern
+	handlers []func(models.Telemetry)
+}
+
+// NewBridge creates an MQTT ingestion bridge from the configuration. It does not
+// connect until Start is called.
+func NewBridge(cfg *config.Config, db *gorm.DB) (*Bridge, error) {
+	if len(cfg.MQTTTopics) == 0 {
+		return nil, errors.New("no MQTT topics configured")
+	}
+
+	b := &Bridge{db: db}
+	for _, filter := range cfg.MQTTTopics {
+		pattern, err := parsePattern(filter)
+		if err != nil {
+			return nil, err
+		}
+		b.patterns = append(b.patterns, pattern)
+	}
+
+	// Paho retries the initial connection and reconnects after a lost
+	// connection with exponential backoff capped at MaxReconnectInterval.
+	opts := mqtt.NewClientOptions().
+		AddBroker(cfg.MQTTBrokerURL).
+		SetClientID(cfg.MQTTClientID).
+		SetUsername(cfg.MQTTUsername).
+		SetPassword(cfg.MQTTPassword).
+		SetCleanSession(true).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(5 * time.Second).
+		SetMaxReconnectInterval(cfg.MQTTMaxReconnectGap).
+		SetOnConnectHandler(b.onConnect).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			log.Printf("MQTT connection lost: %v", err)
+		}).
+		SetReconnectingHandler(func(_ mqtt.Client, _ *mqtt.ClientOptions) {
+			log.Println("MQTT reconnecting...")
+		})
+	b.client = mqtt.NewClient(opts)
+
+	return b, nil
+}
+
+// OnReading registers a function that is called with every stored reading.
+// It must be called before Start.
+func (b *Bridge) OnReading(handler func(models.Telemetry)) {
+	b.handlers = append(b.handlers, handler)
+}
+
+// Start connects to the broker in the background. Subscriptions are
+// (re-)established every time the connection comes up.
+func (b *Bridge) Start() {
+	b.client.Connect()
+}
+
+// Stop disconnects from the broker, giving in-flight messages a moment to finish.
+func (b *Bridge) Stop() {
+	b.client.Disconnect(250)
+}
+
+func (b *Bridge) onConnect(client mqtt.Client) {
+	log.Println("MQTT connected, subscribing to telemetry topics")
+	for _, pattern := range b.patterns {
+		token := client.Subscribe(pattern.filter, 1, func(_ mqtt.Client, msg mqtt.Message) {
+			b.handleMessage(pattern, msg.Topic(), msg.Payload())
+		})
+		if token.Wait() && token.Error() != nil {
+			log.Printf("MQTT subscribe to %q failed: %v", pattern.filter, token.Error())
+		}
+	}
+}
+
+func (b *Bridge) handleMessage(pattern topicPattern, topic string, payload []byte) {
+	hiveID, metric, ok := pattern.match(topic)
+	if !ok {
+		log.Printf("MQTT topic %q does not map to a hive, ignoring", topic)
+		return
+	}
+
+	value, recordedAt, err := parsePayload(payload, time.Now().UTC())
+	if err != nil {
+		log.Printf("MQTT message on %q ignored: %v", topic, err)
+		return
+	}
+
+	reading := models.Telemetry{
+		HiveID:     hiveID,
+		Metric:     metric,
+		Value:      value,
+		Topic:      topic,
+		RecordedAt: recordedAt,
+	}
+	if err := b.store(&reading); err != nil {
+		log.Printf("Failed to store telemetry from %q: %v", topic, err)
+		return
+	}
+	for _, handler := range b.handlers {
+		handler(reading)
+	}
+}
+
+// store saves a reading, lazily creating the hive like log and task creation does.
+func (b *Bridge) store(reading *models.Telemetry) error {
+	return b.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := services.FindOrCreateHive(tx, reading.HiveID); err != nil {
+			return err
+		}
+		return tx.Create(reading).Error
+	})
+}
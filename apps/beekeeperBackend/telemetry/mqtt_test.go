@@ -0,0 +1,211 @@
+package telemetry
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"beekeeper-api/config"
+	"beekeeper-api/database"
+	"beekeeper-api/migrate"
+	"beekeeper-api/models"
+)
+
+var databases atomic.Int64
+
+// openDB creates a migrated in-memory database
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	cfg := config.New()
+	cfg.DBDriver = "sqlite"
+	cfg.DatabaseURL = fmt.Sprintf("file:telemetry%d?mode=memory&cache=shared", databases.Add(1))
+	cfg.DBMaxOpenConns = 1
+	cfg.DBMaxIdleConns = 1
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db.Logger = logger.Discard
+
+	migrator, err := migrate.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// startBroker runs an in-process MQTT broker accepting everyone on address,
+// e.g. "127.0.0.1:0" for any free port, and returns the address it listens on
+// and a function stopping it
+func startBroker(t *testing.T, address string) (*mochi.Server, string, func()) {
+	t.Helper()
+	server := mochi.New(&mochi.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err := server.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatal(err)
+	}
+	tcp := listeners.NewTCP(listeners.Config{ID: "tcp", Address: address})
+	if err := server.AddListener(tcp); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Serve(); err != nil {
+		t.Fatal(err)
+	}
+	stop := sync.OnceFunc(func() { server.Close() })
+	t.Cleanup(stop)
+	return server, tcp.Address(), stop
+}
+
+// startBridge connects a bridge to the broker and returns the readings it stores
+func startBridge(t *testing.T, db *gorm.DB, address string) <-chan models.Telemetry {
+	t.Helper()
+	cfg := config.New()
+	cfg.MQTTBrokerURL = "tcp://" + address
+	cfg.MQTTTopics = []string{"apiary/+/hive/+/weight", "hive/+/temperature"}
+	cfg.MQTTClientID = "beekeeper-test"
+	cfg.MQTTMaxReconnectGap = 250 * time.Millisecond
+	bridge, err := NewBridge(cfg, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readings := make(chan models.Telemetry, 16)
+	bridge.OnReading(func(reading models.Telemetry) { readings <- reading })
+	bridge.Start()
+	t.Cleanup(bridge.Stop)
+	return readings
+}
+
+// waitSubscribed waits until the bridge has subscribed to a topic
+func waitSubscribed(t *testing.T, server *mochi.Server, topic string) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for len(server.Topics.Subscribers(topic).Subscriptions) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("bridge did not subscribe to %s", topic)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// publish sends a message with QoS 1
+func publish(t *testing.T, server *mochi.Server, topic, payload string) {
+	t.Helper()
+	if err := server.Publish(topic, []byte(payload), false, 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// next waits for the next stored reading
+func next(t *testing.T, readings <-chan models.Telemetry) models.Telemetry {
+	t.Helper()
+	select {
+	case reading := <-readings:
+		return reading
+	case <-time.After(10 * time.Second):
+		t.Fatal("no reading was stored")
+		return models.Telemetry{}
+	}
+}
+
+func TestBridgeStoresReadings(t *testing.T) {
+	db := openDB(t)
+	server, address, _ := startBroker(t, "127.0.0.1:0")
+	readings := startBridge(t, db, address)
+	waitSubscribed(t, server, "apiary/home/hive/12/weight")
+	waitSubscribed(t, server, "hive/12/temperature")
+
+	publish(t, server, "apiary/home/hive/12/weight", "42.7")
+	weight := next(t, readings)
+	if weight.HiveID != 12 || weight.Metric != "weight" || weight.Value != 42.7 || weight.Topic != "apiary/home/hive/12/weight" {
+		t.Errorf("got %+v", weight)
+	}
+
+	// Messages that cannot be read are skipped, not stored
+	publish(t, server, "hive/12/temperature", "NaN")
+	publish(t, server, "hive/12/temperature", "warm")
+	publish(t, server, "hive/12/temperature", `{"value": 34.5, "timestamp": "2024-05-01T11:30:00Z"}`)
+	temperature := next(t, readings)
+	if temperature.Metric != "temperature" || temperature.Value != 34.5 || !temperature.RecordedAt.Equal(time.Date(2024, 5, 1, 11, 30, 0, 0, time.UTC)) {
+		t.Errorf("got %+v", temperature)
+	}
+
+	// Offsets are stored in UTC, so the reading falls inside windows queried
+	// in UTC, as the anomaly detectors do
+	publish(t, server, "hive/12/temperature", `{"value": 34.6, "timestamp": "2024-05-01T13:45:00+02:00"}`)
+	offset := next(t, readings)
+	recorded := time.Date(2024, 5, 1, 11, 45, 0, 0, time.UTC)
+	if !offset.RecordedAt.Equal(recorded) || offset.RecordedAt.Location() != time.UTC {
+		t.Errorf("got reading at %v, want %v", offset.RecordedAt, recorded)
+	}
+	var inWindow int64
+	err := db.Model(&models.Telemetry{}).
+		Where("hive_id = ? AND recorded_at >= ? AND recorded_at <= ?", 12, recorded.Add(-5*time.Minute), recorded.Add(5*time.Minute)).
+		Count(&inWindow).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inWindow != 1 {
+		t.Errorf("got %d readings between 11:40 and 11:50 UTC, want the one sent at 13:45+02:00", inWindow)
+	}
+
+	var stored int64
+	if err := db.Model(&models.Telemetry{}).Where("hive_id = ?", 12).Count(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored != 3 {
+		t.Errorf("got %d readings stored, want 3", stored)
+	}
+	// The hive is created with the first reading, once
+	var hives int64
+	if err := db.Model(&models.Hive{}).Where("hive_name = ?", 12).Count(&hives).Error; err != nil {
+		t.Fatal(err)
+	}
+	if hives != 1 {
+		t.Errorf("got %d hives, want 1", hives)
+	}
+}
+
+func TestBridgeReconnects(t *testing.T) {
+	db := openDB(t)
+	server, address, stop := startBroker(t, "127.0.0.1:0")
+	readings := startBridge(t, db, address)
+	waitSubscribed(t, server, "hive/3/temperature")
+	publish(t, server, "hive/3/temperature", "34")
+	next(t, readings)
+
+	// While the broker is down, reconnection attempts back off up to the
+	// configured gap. Once it is back, the bridge reconnects within that gap
+	// and subscribes again, as a restarted broker has forgotten it.
+	stop()
+	time.Sleep(2 * time.Second)
+	restarted := time.Now()
+	server, _, _ = startBroker(t, address)
+	waitSubscribed(t, server, "hive/3/temperature")
+	if waited := time.Since(restarted); waited > time.Second {
+		t.Errorf("reconnecting took %v, want at most about the 250ms backoff cap", waited)
+	}
+	publish(t, server, "hive/3/temperature", "35")
+	if reading := next(t, readings); reading.Value != 35 {
+		t.Errorf("got %+v after reconnecting", reading)
+	}
+}
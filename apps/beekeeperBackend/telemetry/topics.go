@@ -0,0 +1,114 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// topicPattern is an MQTT subscription filter such as "apiary/+/hive/+/weight".
+// The "+" wildcard following a literal "hive" segment carries the hive number,
+// and the last segment of the received topic is used as the metric name, so a
+// pattern needs a non-empty segment after the hive number.
+type topicPattern struct {
+	filter   string
+	segments []string
+	hiveIdx  int
+}
+
+func parsePattern(filter string) (topicPattern, error) {
+	segments := strings.Split(filter, "/")
+	hiveIdx := -1
+	for i, segment := range segments {
+		if segment == "#" && i != len(segments)-1 {
+			return topicPattern{}, fmt.Errorf("topic pattern %q: '#' must be the last segment", filter)
+		}
+		if segment == "+" && i > 0 && segments[i-1] == "hive" {
+			hiveIdx = i
+		}
+	}
+	if hiveIdx < 0 {
+		return topicPattern{}, fmt.Errorf("topic pattern %q: no 'hive/+' segment to take the hive number from", filter)
+	}
+	if hiveIdx == len(segments)-1 || segments[len(segments)-1] == "" {
+		return topicPattern{}, fmt.Errorf("topic pattern %q: no segment after the hive number to take the metric from", filter)
+	}
+	return topicPattern{filter: filter, segments: segments, hiveIdx: hiveIdx}, nil
+}
+
+// match reports whether topic matches the pattern and extracts the hive number and metric.
+// Hive numbers start at 1, and the metric must not be empty.
+func (p topicPattern) match(topic string) (hiveID int, metric string, ok bool) {
+	segments := strings.Split(topic, "/")
+	multiLevel := false
+	for i, segment := range p.segments {
+		if segment == "#" {
+			multiLevel = true
+			break
+		}
+		if i >= len(segments) || (segment != "+" && segment != segments[i]) {
+			return 0, "", false
+		}
+	}
+	if !multiLevel && len(segments) != len(p.segments) {
+		return 0, "", false
+	}
+	if len(segments)-1 <= p.hiveIdx {
+		return 0, "", false
+	}
+
+	hiveID, err := strconv.Atoi(segments[p.hiveIdx])
+	if err != nil || hiveID < 1 {
+		return 0, "", false
+	}
+	metric = segments[len(segments)-1]
+	if metric == "" {
+		return 0, "", false
+	}
+	return hiveID, metric, true
+}
+
+// parsePayload reads a sensor value from either a bare number ("42.7") or a JSON
+// object like {"value": 42.7, "timestamp": "2024-01-15T10:30:00Z"}. The timestamp
+// may also be given in unix seconds; when it is missing, receivedAt is used.
+// Timestamps are returned in UTC.
+// Bare values such as "NaN" or "Inf" are rejected, JSON has no way to express
+// them.
+func parsePayload(payload []byte, receivedAt time.Time) (float64, time.Time, error) {
+	raw := strings.TrimSpace(string(payload))
+	if value, err := strconv.ParseFloat(raw, 64); err == nil {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return 0, time.Time{}, fmt.Errorf("value %q is not a finite number", raw)
+		}
+		return value, receivedAt, nil
+	}
+
+	var body struct {
+		Value     *float64        `json:"value"`
+		Timestamp json.RawMessage `json:"timestamp"`
+	}
+	if err := json.Unmarshal([]byte(raw), &body); err != nil {
+		return 0, time.Time{}, fmt.Errorf("unrecognised payload %q", raw)
+	}
+	if body.Value == nil {
+		return 0, time.Time{}, errors.New("payload has no value")
+	}
+	if len(body.Timestamp) == 0 || string(body.Timestamp) == "null" {
+		return *body.Value, receivedAt, nil
+	}
+
+	var unix float64
+	if err := json.Unmarshal(body.Timestamp, &unix); err == nil {
+		return *body.Value, time.Unix(0, int64(unix*float64(time.Second))).UTC(), nil
+	}
+	var at time.Time
+	if err := json.Unmarshal(body.Timestamp, &at); err != nil {
+		return 0, time.Time{}, fmt.Errorf("invalid timestamp %s", body.Timestamp)
+	}
+	// Stored times compare as text in SQLite, so they all have to be in UTC
+	return *body.Value, at.UTC(), nil
+}
@@ -0,0 +1,118 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		filter  string
+		hiveIdx int
+		ok      bool
+	}{
+		{"apiary/+/hive/+/weight", 3, true},
+		{"hive/+/#", 1, true},
+		{"sensors/hive/+/+", 2, true},
+		{"apiary/+/weight", 0, false},
+		{"hive/12/weight", 0, false},
+		{"+/hive/+/#/weight", 0, false},
+		// No segment left for the metric
+		{"apiary/+/hive/+", 0, false},
+		{"hive/+/", 0, false},
+		{"hive/+/weight/", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			pattern, err := parsePattern(tt.filter)
+			if (err == nil) != tt.ok {
+				t.Fatalf("got error %v, want ok=%v", err, tt.ok)
+			}
+			if tt.ok && pattern.hiveIdx != tt.hiveIdx {
+				t.Errorf("got hive segment %d, want %d", pattern.hiveIdx, tt.hiveIdx)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		filter string
+		topic  string
+		hiveID int
+		metric string
+		ok     bool
+	}{
+		{"apiary/+/hive/+/weight", "apiary/home/hive/12/weight", 12, "weight", true},
+		{"apiary/+/hive/+/weight", "apiary/home/hive/12/temperature", 0, "", false},
+		{"apiary/+/hive/+/weight", "apiary/home/hive/12/weight/raw", 0, "", false},
+		{"apiary/+/hive/+/weight", "apiary/home/hive/twelve/weight", 0, "", false},
+		{"apiary/+/hive/+/weight", "apiary/home/hive", 0, "", false},
+		{"hive/+/#", "hive/7/temperature", 7, "temperature", true},
+		{"hive/+/#", "hive/7/brood/temperature", 7, "temperature", true},
+		// The hive number is no metric
+		{"hive/+/#", "hive/7", 0, "", false},
+		{"hive/+/#", "scale/7/weight", 0, "", false},
+		// Hive numbers start at 1
+		{"hive/+/#", "hive/0/weight", 0, "", false},
+		{"hive/+/#", "hive/-3/weight", 0, "", false},
+		{"apiary/+/hive/+/weight", "apiary/home/hive/-1/weight", 0, "", false},
+		// Nor is an empty segment
+		{"hive/+/#", "hive/7/weight/", 0, "", false},
+		{"hive/+/+", "hive/7/", 0, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.topic, func(t *testing.T) {
+			pattern, err := parsePattern(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hiveID, metric, ok := pattern.match(tt.topic)
+			if ok != tt.ok || hiveID != tt.hiveID || metric != tt.metric {
+				t.Errorf("got %d, %q, %v, want %d, %q, %v", hiveID, metric, ok, tt.hiveID, tt.metric, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParsePayload(t *testing.T) {
+	received := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	recorded := time.Date(2024, 5, 1, 11, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		payload string
+		value   float64
+		at      time.Time
+		ok      bool
+	}{
+		{"bare number", " 42.7\n", 42.7, received, true},
+		{"negative", "-3", -3, received, true},
+		{"object", `{"value": 42.7}`, 42.7, received, true},
+		{"null timestamp", `{"value": 42.7, "timestamp": null}`, 42.7, received, true},
+		{"RFC 3339 timestamp", `{"value": 42.7, "timestamp": "2024-05-01T11:30:00Z"}`, 42.7, recorded, true},
+		{"RFC 3339 timestamp with offset", `{"value": 42.7, "timestamp": "2024-05-01T13:30:00+02:00"}`, 42.7, recorded, true},
+		{"unix timestamp", `{"value": 0, "timestamp": 1714563000}`, 0, recorded, true},
+		{"missing value", `{"timestamp": 1714563000}`, 0, time.Time{}, false},
+		{"invalid timestamp", `{"value": 1, "timestamp": "yesterday"}`, 0, time.Time{}, false},
+		{"text", "heavy", 0, time.Time{}, false},
+		{"empty", "", 0, time.Time{}, false},
+		{"NaN", "NaN", 0, time.Time{}, false},
+		{"infinity", "+Inf", 0, time.Time{}, false},
+		{"out of range", "1e400", 0, time.Time{}, false},
+		{"JSON out of range", `{"value": 1e400}`, 0, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, at, err := parsePayload([]byte(tt.payload), received)
+			if (err == nil) != tt.ok {
+				t.Fatalf("got error %v, want ok=%v", err, tt.ok)
+			}
+			if value != tt.value || !at.Equal(tt.at) {
+				t.Errorf("got %v at %v, want %v at %v", value, at, tt.value, tt.at)
+			}
+			if tt.ok && at.Location() != time.UTC {
+				t.Errorf("got %v, want it in UTC", at)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Conditions is the weather at one place and time.
+type Conditions struct {
+	TemperatureC    float64
+	WindSpeedKmh    float64
+	CloudCoverPct   float64
+	PrecipitationMm float64
+	ObservedAt      time.Time
+}
+
+// Provider looks up the weather for a location at a point in time.
+type Provider interface {
+	Name() string
+	Conditions(ctx context.Context, lat, lon float64, at time.Time) (Conditions, error)
+}
+
+// Stub is an offline provider that always reports the same conditions, for
+// development and for installations without internet access.
+type Stub struct {
+	Fixed Conditions
+}
+
+func (s Stub) Name() string { return "stub" }
+
+func (s Stub) Conditions(_ context.Context, _, _ float64, at time.Time) (Conditions, error) {
+	c := s.Fixed
+	c.ObservedAt = at.UTC().Truncate(time.Hour)
+	return c, nil
+}
+
+// OpenMeteo queries an Open-Meteo compatible API for hourly conditions.
+// BaseURL can point at a self-hosted instance or a local test server.
+type OpenMeteo struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+func (o OpenMeteo) Name() string { return "open-meteo" }
+
+type openMeteoResponse struct {
+	Hourly struct {
+		Time          []string   `json:"time"`
+		Temperature   []*float64 `json:"temperature_2m"`
+		WindSpeed     []*float64 `json:"wind_speed_10m"`
+		CloudCover    []*float64 `json:"cloud_cover"`
+		Precipitation []*float64 `json:"precipitation"`
+	} `json:"hourly"`
+	Reason string `json:"reason"`
+}
+
+func (o OpenMeteo) Conditions(ctx context.Context, lat, lon float64, at time.Time) (Conditions, error) {
+	hour := at.UTC().Truncate(time.Hour)
+	query := url.Values{}
+	query.Set("latitude", strconv.FormatFloat(lat, 'f', 4, 64))
+	query.Set("longitude", strconv.FormatFloat(lon, 'f', 4, 64))
+	query.Set("hourly", "temperature_2m,wind_speed_10m,cloud_cover,precipitation")
+	query.Set("wind_speed_unit", "kmh")
+	query.Set("timezone", "GMT")
+	query.Set("start_hour", hour.Format("2006-01-02T15:04"))
+	query.Set("end_hour", hour.Format("2006-01-02T15:04"))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(o.BaseURL, "/")+"/v1/forecast?"+query.Encode(), nil)
+	if err != nil {
+		return Conditions{}, err
+	}
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return Conditions{}, err
+	}
+	defer resp.Body.Close()
+
+	var body openMeteoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Conditions{}, fmt.Errorf("decoding weather response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Conditions{}, fmt.Errorf("weather API returned %s: %s", resp.Status, body.Reason)
+	}
+
+	h := body.Hourly
+	if len(h.Time) == 0 || len(h.Temperature) == 0 || len(h.WindSpeed) == 0 || len(h.CloudCover) == 0 || len(h.Precipitation) == 0 {
+		return Conditions{}, errors.New("weather API returned no hourly data")
+	}
+	if h.Temperature[0] == nil || h.WindSpeed[0] == nil || h.CloudCover[0] == nil || h.Precipitation[0] == nil {
+		return Conditions{}, errors.New("weather API has no data for that hour")
+	}
+	observedAt, err := time.Parse("2006-01-02T15:04", h.Time[0])
+	if err != nil {
+		observedAt = hour
+	}
+	return Conditions{
+		TemperatureC:    *h.Temperature[0],
+		WindSpeedKmh:    *h.WindSpeed[0],
+		CloudCoverPct:   *h.CloudCover[0],
+		PrecipitationMm: *h.Precipitation[0],
+		ObservedAt:      observedAt,
+	}, nil
+}
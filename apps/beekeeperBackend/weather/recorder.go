@@ -0,0 +1,95 @@
+package weather
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+
+	"beekeeper-api/config"
+	"beekeeper-api/events"
+	"beekeeper-api/models"
+)
+
+// Recorder attaches the weather at the hive's apiary to every new log entry.
+// Lookups run in the background so creating a log never waits for the weather
+// API; hives that are not placed in an apiary get no weather.
+type Recorder struct {
+	db       *gorm.DB
+	bus      *events.Bus
+	provider Provider
+	timeout  time.Duration
+}
+
+// NewProvider returns the weather provider selected in the configuration, or
+// nil when weather lookups are disabled.
+func NewProvider(cfg *config.Config) (Provider, error) {
+	switch cfg.WeatherProvider {
+	case "", "none":
+		return nil, nil
+	case "stub":
+		return Stub{Fixed: Conditions{TemperatureC: 20, WindSpeedKmh: 5, CloudCoverPct: 25}}, nil
+	case "open-meteo":
+		return OpenMeteo{BaseURL: cfg.WeatherBaseURL, Client: &http.Client{Timeout: cfg.WeatherTimeout}}, nil
+	default:
+		return nil, fmt.Errorf("unknown weather provider %q", cfg.WeatherProvider)
+	}
+}
+
+// NewRecorder creates a recorder looking up weather with the given provider.
+func NewRecorder(cfg *config.Config, db *gorm.DB, bus *events.Bus, provider Provider) *Recorder {
+	return &Recorder{db: db, bus: bus, provider: provider, timeout: cfg.WeatherTimeout}
+}
+
+// Start looks up the weather for every log entry created from now on, each
+// in its own goroutine.
+func (r *Recorder) Start() {
+	r.bus.Handle(func(event events.Event) {
+		if event.Type != events.LogCreated {
+			return
+		}
+		if entry, ok := event.Data.(models.Log); ok {
+			go r.Record(entry)
+		}
+	})
+}
+
+// Record looks up and stores the weather for a log entry.
+func (r *Recorder) Record(entry models.Log) {
+	var hive models.Hive
+	if err := r.db.First(&hive, "hive_name = ?", entry.HiveID).Error; err != nil || hive.ApiaryID == nil {
+		return
+	}
+	var apiary models.Apiary
+	if err := r.db.First(&apiary, *hive.ApiaryID).Error; err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	conditions, err := r.provider.Conditions(ctx, apiary.Latitude, apiary.Longitude, entry.CreatedAt)
+	if err != nil {
+		log.Printf("Weather lookup for log %d failed: %v", entry.ID, err)
+		return
+	}
+
+	weather := models.LogWeather{
+		LogID:           entry.ID,
+		Provider:        r.provider.Name(),
+		TemperatureC:    conditions.TemperatureC,
+		WindSpeedKmh:    conditions.WindSpeedKmh,
+		CloudCoverPct:   conditions.CloudCoverPct,
+		PrecipitationMm: conditions.PrecipitationMm,
+		ObservedAt:      conditions.ObservedAt,
+	}
+	if err := r.db.Create(&weather).Error; err != nil {
+		log.Printf("Failed to store weather for log %d: %v", entry.ID, err)
+		return
+	}
+
+	entry.Weather = &weather
+	r.bus.Publish(events.LogUpdated, entry.HiveID, entry)
+}
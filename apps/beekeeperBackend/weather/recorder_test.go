@@ -0,0 +1,87 @@
+package weather
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"beekeeper-api/config"
+	"beekeeper-api/database"
+	"beekeeper-api/events"
+	"beekeeper-api/migrate"
+	"beekeeper-api/models"
+)
+
+var databases atomic.Int64
+
+// openDB creates a migrated in-memory database
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	cfg := config.New()
+	cfg.DBDriver = "sqlite"
+	cfg.DatabaseURL = fmt.Sprintf("file:weather%d?mode=memory&cache=shared", databases.Add(1))
+	cfg.DBMaxOpenConns = 1
+	cfg.DBMaxIdleConns = 1
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db.Logger = logger.Discard
+
+	migrator, err := migrate.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// TestRecorderRecordsEveryLog creates a burst of log entries, more than any
+// subscriber buffer holds, and expects the weather for each of them.
+func TestRecorderRecordsEveryLog(t *testing.T) {
+	const burst = 500
+	db := openDB(t)
+	apiary := models.Apiary{Name: "Orchard", Latitude: 48.4, Longitude: 11.7}
+	if err := db.Create(&apiary).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.Hive{HiveName: 1, ApiaryID: &apiary.ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+	entries := make([]models.Log, burst)
+	for i := range entries {
+		entries[i] = models.Log{HiveID: 1, Content: "Inspected"}
+	}
+	if err := db.Create(&entries).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	bus := events.NewBus(10)
+	NewRecorder(config.New(), db, bus, Stub{Fixed: Conditions{TemperatureC: 20}}).Start()
+	for _, entry := range entries {
+		bus.Publish(events.LogCreated, entry.HiveID, entry)
+	}
+
+	var recorded int64
+	deadline := time.Now().Add(10 * time.Second)
+	for recorded < burst && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		if err := db.Model(&models.LogWeather{}).Count(&recorded).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if recorded != burst {
+		t.Errorf("got weather for %d logs, want %d", recorded, burst)
+	}
+}